@@ -102,22 +102,34 @@ func GetInfo(ctx context.Context) Info {
 	return internal.GetActivityInfo(ctx)
 }
 
-// GetLogger returns a logger that can be used in activity
+// GetLogger returns a logger that can be used in activity. It is the worker's logger, tagged with the activity ID and
+// type, and the type, ID and run ID of the workflow execution that scheduled the activity.
 func GetLogger(ctx context.Context) *zap.Logger {
 	return internal.GetActivityLogger(ctx)
 }
 
-// GetMetricsScope returns a metrics scope that can be used in activity
+// GetMetricsScope returns a metrics scope that can be used in activity. It is the worker's metrics scope, tagged with
+// the domain and task list of the activity, and the activity and workflow types.
 func GetMetricsScope(ctx context.Context) tally.Scope {
 	return internal.GetActivityMetricsScope(ctx)
 }
 
+// GetHostSpecificTaskList returns the task list unique to the host executing this activity. Return it to the
+// workflow and use it as ActivityOptions.TaskList to run follow-up activities on the same host.
+// The worker must be created with the EnableHostSpecificTaskList option to poll this task list.
+func GetHostSpecificTaskList(ctx context.Context) string {
+	return internal.GetActivityHostSpecificTaskList(ctx)
+}
+
 // RecordHeartbeat sends heartbeat for the currently executing activity
 // If the activity is either cancelled (or) workflow/activity doesn't exist then we would cancel
 // the context with error context.Canceled.
 //
 // details - the details that you provided here can be seen in the workflow when it receives TimeoutError, you
-// can check error with TimeoutType()/Details().
+// can check error with TimeoutType()/Details(). They are encoded with the DataConverter of the worker.
+//
+// It is safe to call RecordHeartbeat on every iteration of a loop: calls are batched, and only the latest details of
+// a batch are sent to the server, see worker.Options.HeartbeatThrottleRatio.
 func RecordHeartbeat(ctx context.Context, details ...interface{}) {
 	internal.RecordActivityHeartbeat(ctx, details...)
 }
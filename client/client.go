@@ -30,6 +30,11 @@ package client
 import (
 	"context"
 	"errors"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
 
 	"go.uber.org/cadence"
 	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
@@ -53,6 +58,10 @@ const (
 	QueryTypeQueryTypes string = internal.QueryTypeQueryTypes
 )
 
+// ErrDecisionTaskNotStarted is returned when StartWorkflowOptions.WaitForDecisionTaskStarted is set and no worker
+// picked up the first decision task of the started workflow in time.
+var ErrDecisionTaskNotStarted = internal.ErrDecisionTaskNotStarted
+
 type (
 	// Options are optional parameters for Client creation.
 	Options = internal.ClientOptions
@@ -60,6 +69,24 @@ type (
 	// FeatureFlags define which breaking changes can be enabled for client
 	FeatureFlags = internal.FeatureFlags
 
+	// ServiceWrapper wraps the workflow service used by a client or worker with middleware.
+	ServiceWrapper = internal.ServiceWrapper
+
+	// ActiveClusterResolver returns the service to use for requests to a cluster, see Options.ActiveClusterResolver.
+	ActiveClusterResolver = internal.ActiveClusterResolver
+
+	// ServiceClient is a client of the Cadence frontend service created by NewServiceClient.
+	ServiceClient = internal.ServiceClient
+
+	// ServiceClientOption configures a ServiceClient created by NewServiceClient.
+	ServiceClientOption = internal.ServiceClientOption
+
+	// PeerResolver returns the host:port addresses of the Cadence frontend hosts, see WithPeerResolver.
+	PeerResolver = internal.PeerResolver
+
+	// LoadBalancing is the strategy a ServiceClient uses to pick the frontend host of each call.
+	LoadBalancing = internal.LoadBalancing
+
 	// StartWorkflowOptions configuration parameters for starting a workflow execution.
 	StartWorkflowOptions = internal.StartWorkflowOptions
 
@@ -69,6 +96,9 @@ type (
 	// WorkflowRun represents a started non child workflow
 	WorkflowRun = internal.WorkflowRun
 
+	// WorkflowRunGetOptions are the options for WorkflowRun.GetWithOptions.
+	WorkflowRunGetOptions = internal.WorkflowRunGetOptions
+
 	// WorkflowIDReusePolicy defines workflow ID reuse behavior.
 	WorkflowIDReusePolicy = internal.WorkflowIDReusePolicy
 
@@ -78,6 +108,12 @@ type (
 	// QueryWorkflowWithOptionsResponse defines the response to QueryWorkflowWithOptions
 	QueryWorkflowWithOptionsResponse = internal.QueryWorkflowWithOptionsResponse
 
+	// TaskListBacklogOptions are optional parameters of GetTaskListBacklog.
+	TaskListBacklogOptions = internal.TaskListBacklogOptions
+
+	// TaskListBacklog is the estimated backlog of a task list returned by GetTaskListBacklog.
+	TaskListBacklog = internal.TaskListBacklog
+
 	// ParentClosePolicy defines the behavior performed on a child workflow when its parent is closed
 	ParentClosePolicy = internal.ParentClosePolicy
 
@@ -88,6 +124,8 @@ type (
 
 	// Client is the client for starting and getting information about a workflow executions as well as
 	// completing activities asynchronously.
+	// The deadline and cancellation of the context.Context passed to each method apply to the whole call, including
+	// the retries of transient service errors. The timeout of a single attempt is derived from that deadline.
 	Client interface {
 		// StartWorkflow starts a workflow execution
 		// The user can use this to start using a function or workflow type name.
@@ -342,8 +380,9 @@ type (
 		// target workflow execution that this query will be send to. If runID is not specified (empty string), server will
 		// use the currently running execution of that workflowID. The queryType specifies the type of query you want to
 		// run. By default, cadence supports "__stack_trace" as a standard query type, which will return string value
-		// representing the call stack of the target workflow. The target workflow could also setup different query handler
-		// to handle custom query types.
+		// representing the call stack of the target workflow. "__query_types" lists the query types the workflow supports
+		// and "__open_sessions" returns the sessions currently open in it. The target workflow could also setup different
+		// query handler to handle custom query types.
 		// See comments at workflow.SetQueryHandler(ctx Context, queryType string, handler interface{}) for more details
 		// on how to setup query handler within the target workflow.
 		// - workflowID is required.
@@ -391,6 +430,15 @@ type (
 		//  - EntityNotExistError
 		DescribeTaskList(ctx context.Context, tasklist string, tasklistType s.TaskListType) (*s.DescribeTaskListResponse, error)
 
+		// GetTaskListBacklog estimates the backlog of the decision and activity task lists of the name, and optionally
+		// the number of its open workflows, e.g. for autoscalers to scale workers on demand. See TaskListBacklogOptions
+		// to also emit them as gauges.
+		// The errors it can return:
+		//  - BadRequestError
+		//  - InternalServiceError
+		//  - EntityNotExistError
+		GetTaskListBacklog(ctx context.Context, tasklist string, options *TaskListBacklogOptions) (*TaskListBacklog, error)
+
 		// RefreshWorkflowTasks refreshes all the tasks of a given workflow.
 		// - workflow ID of the workflow.
 		// - runID can be default(empty string). if empty string then it will pick the running execution of that workflow ID.
@@ -458,6 +506,13 @@ const (
 	ParentClosePolicyAbandon = internal.ParentClosePolicyAbandon
 )
 
+const (
+	// LoadBalancingRoundRobin sends calls to the available hosts in turn.
+	LoadBalancingRoundRobin = internal.LoadBalancingRoundRobin
+	// LoadBalancingLeastPending sends calls to the available host with the fewest calls in flight.
+	LoadBalancingLeastPending = internal.LoadBalancingLeastPending
+)
+
 // NewClient creates an instance of a workflow client
 func NewClient(service workflowserviceclient.Interface, domain string, options *Options) Client {
 	return internal.NewClient(service, domain, options)
@@ -468,6 +523,73 @@ func NewDomainClient(service workflowserviceclient.Interface, options *Options)
 	return internal.NewDomainClient(service, options)
 }
 
+// NewServiceClient creates a TChannel client of the Cadence frontend service reachable at the given host:port
+// addresses, or at the addresses returned by the resolver set with WithPeerResolver, in which case hosts must be
+// empty. Pass it to NewClient, NewDomainClient and worker.New. Calls are spread across the hosts as set with
+// WithLoadBalancing and failed connections are re-established in the background. Close the client once it is no
+// longer used.
+func NewServiceClient(hosts []string, opts ...ServiceClientOption) (ServiceClient, error) {
+	return internal.NewServiceClient(hosts, opts...)
+}
+
+// WithServiceName sets the name of the Cadence frontend service to call. Defaults to "cadence-frontend".
+func WithServiceName(name string) ServiceClientOption {
+	return internal.WithServiceName(name)
+}
+
+// WithCallerName sets the name the client identifies itself with to the service. Defaults to "cadence-client".
+func WithCallerName(name string) ServiceClientOption {
+	return internal.WithCallerName(name)
+}
+
+// WithServiceClientLogger sets the logger of the underlying transport and the health check. Defaults to no logging.
+func WithServiceClientLogger(logger *zap.Logger) ServiceClientOption {
+	return internal.WithServiceClientLogger(logger)
+}
+
+// WithServiceClientTracer sets the tracer of the underlying transport. Defaults to the global tracer.
+func WithServiceClientTracer(tracer opentracing.Tracer) ServiceClientOption {
+	return internal.WithServiceClientTracer(tracer)
+}
+
+// WithServiceClientMetricsScope sets the scope the health check reports failed checks, lost connectivity and
+// reconnects to. Defaults to no metrics.
+func WithServiceClientMetricsScope(scope tally.Scope) ServiceClientOption {
+	return internal.WithServiceClientMetricsScope(scope)
+}
+
+// WithHealthCheck pings the service every interval and re-dials the hosts once failureThreshold consecutive pings
+// failed to reach it, so that clients and workers recover from network failures without being restarted.
+// A failureThreshold of 0 defaults to 3. Defaults to no health checks.
+func WithHealthCheck(interval time.Duration, failureThreshold int) ServiceClientOption {
+	return internal.WithHealthCheck(interval, failureThreshold)
+}
+
+// NewDNSPeerResolver creates a PeerResolver that looks up the addresses of the host of hostPort, e.g.
+// "cadence-frontend.example.com:7933", and returns them along with its port.
+func NewDNSPeerResolver(hostPort string) (PeerResolver, error) {
+	return internal.NewDNSPeerResolver(hostPort)
+}
+
+// WithPeerResolver discovers the frontend hosts with resolver instead of using a static list, and refreshes them
+// every refreshInterval. The hosts stay unchanged while the resolver fails. A refreshInterval of 0 only resolves
+// them when connecting.
+func WithPeerResolver(resolver PeerResolver, refreshInterval time.Duration) ServiceClientOption {
+	return internal.WithPeerResolver(resolver, refreshInterval)
+}
+
+// WithLoadBalancing sets the strategy to spread the calls across the frontend hosts.
+// Defaults to LoadBalancingRoundRobin.
+func WithLoadBalancing(loadBalancing LoadBalancing) ServiceClientOption {
+	return internal.WithLoadBalancing(loadBalancing)
+}
+
+// WithConnectivityCallback sets a function that the health check calls with false when the connectivity to the
+// service is lost and with true once it is restored. It must not block.
+func WithConnectivityCallback(fn func(connected bool)) ServiceClientOption {
+	return internal.WithConnectivityCallback(fn)
+}
+
 // make sure if new methods are added to internal.Client they are also added to public Client.
 var _ Client = internal.Client(nil)
 var _ internal.Client = Client(nil)
@@ -554,3 +676,29 @@ func IsWorkflowError(err error) bool {
 func WithCancelReason(reason string) CancelOption {
 	return internal.WithCancelReason(reason)
 }
+
+// WithRPCHeaders returns a context which adds the given headers to every service call made with it, e.g.
+//
+//	ctx = client.WithRPCHeaders(ctx, map[string]string{"routing-key": "tenant-a"})
+//	run, err := c.ExecuteWorkflow(ctx, options, workflowFn)
+//
+// Headers given here override Options.Headers with the same name.
+func WithRPCHeaders(ctx context.Context, headers map[string]string) context.Context {
+	return internal.WithRPCHeaders(ctx, headers)
+}
+
+// WithDomain returns a context which makes the Client calls made with it operate on the given domain instead
+// of the domain the client was created with, so that tools working across domains can share one client:
+//
+//	run, err := c.ExecuteWorkflow(client.WithDomain(ctx, "other-domain"), options, workflowFn)
+//
+// Calls that take a domain explicitly, like CompleteActivityByID, are not affected.
+func WithDomain(ctx context.Context, domain string) context.Context {
+	return internal.WithDomain(ctx, domain)
+}
+
+// GetWorkflowBuildIDs returns the build IDs of the workers which completed the decision tasks of a workflow execution,
+// in the order in which they first processed it, see worker.Options.BuildID. The latest run is used if runID is empty.
+func GetWorkflowBuildIDs(ctx context.Context, c Client, workflowID, runID string) ([]string, error) {
+	return internal.GetWorkflowBuildIDs(ctx, c, workflowID, runID)
+}
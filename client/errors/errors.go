@@ -0,0 +1,121 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package errors exposes the errors returned by the Cadence service as Go types, so that callers
+// can inspect them with errors.As (or the Is* helpers below) instead of matching error strings:
+//
+//	_, err := c.StartWorkflow(ctx, options, "MyWorkflow")
+//	var alreadyStarted *errors.WorkflowExecutionAlreadyStartedError
+//	if stderrors.As(err, &alreadyStarted) {
+//		// the workflow is already running, with run ID alreadyStarted.GetRunId()
+//	}
+//
+// The same types are returned regardless of whether the client talks to the service over thrift
+// or gRPC.
+package errors
+
+import (
+	"errors"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal"
+)
+
+type (
+	// EntityNotExistsError is returned when the domain, workflow execution or another entity
+	// referenced by a request does not exist.
+	EntityNotExistsError = shared.EntityNotExistsError
+
+	// WorkflowExecutionAlreadyStartedError is returned when starting a workflow whose ID is
+	// already in use, as allowed by the workflow ID reuse policy.
+	WorkflowExecutionAlreadyStartedError = shared.WorkflowExecutionAlreadyStartedError
+
+	// BadRequestError is returned when a request is invalid, e.g. because of a missing field.
+	BadRequestError = shared.BadRequestError
+
+	// DomainNotActiveError is returned when a request is sent to a cluster in which the domain
+	// is not active. GetActiveCluster reports the cluster to use instead.
+	DomainNotActiveError = shared.DomainNotActiveError
+
+	// ServiceBusyError is returned when the service is overloaded or the caller is rate limited.
+	// The request may be retried after a delay.
+	ServiceBusyError = shared.ServiceBusyError
+
+	// CompatibilityError is returned when the service does not support this version of the client
+	// library. SupportedVersions reports the client feature versions it accepts. It wraps the
+	// ClientVersionNotSupportedError returned by the service.
+	CompatibilityError = internal.CompatibilityError
+)
+
+// IsEntityNotExistsError returns true if err is or wraps an EntityNotExistsError.
+func IsEntityNotExistsError(err error) bool {
+	var target *EntityNotExistsError
+	return errors.As(err, &target)
+}
+
+// IsWorkflowExecutionAlreadyStartedError returns true if err is or wraps a WorkflowExecutionAlreadyStartedError.
+func IsWorkflowExecutionAlreadyStartedError(err error) bool {
+	var target *WorkflowExecutionAlreadyStartedError
+	return errors.As(err, &target)
+}
+
+// IsBadRequestError returns true if err is or wraps a BadRequestError.
+func IsBadRequestError(err error) bool {
+	var target *BadRequestError
+	return errors.As(err, &target)
+}
+
+// IsDomainNotActiveError returns true if err is or wraps a DomainNotActiveError.
+func IsDomainNotActiveError(err error) bool {
+	var target *DomainNotActiveError
+	return errors.As(err, &target)
+}
+
+// ActiveCluster returns the name of the cluster in which the domain is active, if err is or wraps a
+// DomainNotActiveError that names it. Requests can be retried against that cluster.
+func ActiveCluster(err error) (string, bool) {
+	var target *DomainNotActiveError
+	if errors.As(err, &target) && target.ActiveCluster != "" {
+		return target.ActiveCluster, true
+	}
+	return "", false
+}
+
+// ExistingRunID returns the run ID of the execution that is already running, if err is or wraps a
+// WorkflowExecutionAlreadyStartedError that reports it.
+func ExistingRunID(err error) (string, bool) {
+	var target *WorkflowExecutionAlreadyStartedError
+	if errors.As(err, &target) && target.GetRunId() != "" {
+		return target.GetRunId(), true
+	}
+	return "", false
+}
+
+// IsServiceBusyError returns true if err is or wraps a ServiceBusyError.
+func IsServiceBusyError(err error) bool {
+	var target *ServiceBusyError
+	return errors.As(err, &target)
+}
+
+// IsCompatibilityError returns true if err is or wraps a CompatibilityError.
+func IsCompatibilityError(err error) bool {
+	var target *CompatibilityError
+	return errors.As(err, &target)
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestErrorShapes(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		is   func(error) bool
+		as   func(error) bool
+	}{
+		{
+			name: "EntityNotExists",
+			err:  &shared.EntityNotExistsError{Message: "workflow not found"},
+			is:   IsEntityNotExistsError,
+			as: func(err error) bool {
+				var target *EntityNotExistsError
+				return errors.As(err, &target) && target.Message == "workflow not found"
+			},
+		},
+		{
+			name: "WorkflowExecutionAlreadyStarted",
+			err:  &shared.WorkflowExecutionAlreadyStartedError{Message: common.StringPtr("already started"), RunId: common.StringPtr("rid")},
+			is:   IsWorkflowExecutionAlreadyStartedError,
+			as: func(err error) bool {
+				var target *WorkflowExecutionAlreadyStartedError
+				return errors.As(err, &target) && target.GetRunId() == "rid"
+			},
+		},
+		{
+			name: "BadRequest",
+			err:  &shared.BadRequestError{Message: "missing workflow ID"},
+			is:   IsBadRequestError,
+			as: func(err error) bool {
+				var target *BadRequestError
+				return errors.As(err, &target) && target.Message == "missing workflow ID"
+			},
+		},
+		{
+			name: "DomainNotActive",
+			err:  &shared.DomainNotActiveError{Message: "not active", DomainName: "domain", CurrentCluster: "east", ActiveCluster: "west"},
+			is:   IsDomainNotActiveError,
+			as: func(err error) bool {
+				var target *DomainNotActiveError
+				return errors.As(err, &target) && target.ActiveCluster == "west"
+			},
+		},
+		{
+			name: "ServiceBusy",
+			err:  &shared.ServiceBusyError{Message: "rate limited"},
+			is:   IsServiceBusyError,
+			as: func(err error) bool {
+				var target *ServiceBusyError
+				return errors.As(err, &target) && target.Message == "rate limited"
+			},
+		},
+		{
+			name: "Compatibility",
+			err:  &CompatibilityError{SupportedVersions: ">=1.0.0"},
+			is:   IsCompatibilityError,
+			as: func(err error) bool {
+				var target *CompatibilityError
+				return errors.As(err, &target) && target.SupportedVersions == ">=1.0.0"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, err := range map[string]error{
+				"direct":  tt.err,
+				"wrapped": fmt.Errorf("start workflow: %w", tt.err),
+			} {
+				assert.True(t, tt.is(err), name)
+				assert.True(t, tt.as(err), name)
+			}
+			for _, other := range tests {
+				if other.name != tt.name {
+					assert.False(t, other.is(tt.err), "%v is not %v", tt.name, other.name)
+				}
+			}
+			assert.False(t, tt.is(errors.New(tt.err.Error())), "matched by message")
+			assert.False(t, tt.is(nil))
+		})
+	}
+}
+
+func TestActiveCluster(t *testing.T) {
+	cluster, ok := ActiveCluster(fmt.Errorf("signal: %w", &shared.DomainNotActiveError{ActiveCluster: "west"}))
+	assert.True(t, ok)
+	assert.Equal(t, "west", cluster)
+
+	_, ok = ActiveCluster(&shared.DomainNotActiveError{})
+	assert.False(t, ok)
+	_, ok = ActiveCluster(&shared.BadRequestError{})
+	assert.False(t, ok)
+}
+
+func TestExistingRunID(t *testing.T) {
+	runID, ok := ExistingRunID(fmt.Errorf("start: %w", &shared.WorkflowExecutionAlreadyStartedError{RunId: common.StringPtr("rid")}))
+	assert.True(t, ok)
+	assert.Equal(t, "rid", runID)
+
+	_, ok = ExistingRunID(&shared.WorkflowExecutionAlreadyStartedError{})
+	assert.False(t, ok)
+	_, ok = ExistingRunID(&shared.EntityNotExistsError{})
+	assert.False(t, ok)
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	workflowPackagePath = "go.uber.org/cadence/workflow"
+	ignoreDirective     = "workflowcheck:ignore"
+)
+
+// timeFunctions are the functions of the time package which read the wall clock or block on it
+var timeFunctions = map[string]string{
+	"Now":       "use workflow.Now",
+	"Since":     "use workflow.Now",
+	"Until":     "use workflow.Now",
+	"Sleep":     "use workflow.Sleep",
+	"After":     "use workflow.NewTimer",
+	"AfterFunc": "use workflow.NewTimer",
+	"NewTimer":  "use workflow.NewTimer",
+	"Tick":      "use workflow.NewTimer",
+	"NewTicker": "use workflow.NewTimer",
+}
+
+// randPackages are the packages generating random values, which differ when the workflow is replayed
+var randPackages = map[string]bool{
+	"math/rand":    true,
+	"math/rand/v2": true,
+	"crypto/rand":  true,
+}
+
+type (
+	// issue is a non-deterministic construct found in a workflow function
+	issue struct {
+		pos     token.Position
+		message string
+	}
+
+	checker struct {
+		fset    *token.FileSet
+		info    *types.Info
+		file    *ast.File
+		imports map[string]string // local name of the imports of the file to their path
+		ignored map[int]bool      // lines with an ignore directive
+		issues  []issue
+	}
+)
+
+func (i issue) String() string {
+	return fmt.Sprintf("%v: %v", i.pos, i.message)
+}
+
+// checkFiles reports the non-deterministic constructs used by the workflow functions of the files, that is the
+// functions and function literals whose first parameter is a workflow.Context.
+// info may be partial, for example when imports could not be type checked, in which case the checks needing
+// type information are skipped for the expressions whose type is unknown.
+func checkFiles(fset *token.FileSet, files []*ast.File, info *types.Info) []issue {
+	var issues []issue
+	for _, file := range files {
+		c := &checker{
+			fset:    fset,
+			info:    info,
+			file:    file,
+			imports: fileImports(file),
+			ignored: ignoredLines(fset, file),
+		}
+		c.checkFile()
+		issues = append(issues, c.issues...)
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].pos.Filename != issues[j].pos.Filename {
+			return issues[i].pos.Filename < issues[j].pos.Filename
+		}
+		return issues[i].pos.Offset < issues[j].pos.Offset
+	})
+	return issues
+}
+
+func (c *checker) checkFile() {
+	ast.Inspect(c.file, func(n ast.Node) bool {
+		switch fn := n.(type) {
+		case *ast.FuncDecl:
+			if fn.Body != nil && c.isWorkflowFunc(fn.Type) {
+				c.checkBody(fn.Body)
+				return false
+			}
+		case *ast.FuncLit:
+			if c.isWorkflowFunc(fn.Type) {
+				c.checkBody(fn.Body)
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// checkBody reports the issues of a workflow function body, including the function literals it contains
+// as they run as part of the workflow, e.g. when started with workflow.Go.
+func (c *checker) checkBody(body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.GoStmt:
+			c.report(node, "go statement in workflow function, use workflow.Go")
+		case *ast.SelectStmt:
+			c.report(node, "select statement in workflow function, use workflow.Selector")
+		case *ast.ChanType:
+			c.report(node, "native channel in workflow function, use workflow.Channel")
+		case *ast.SendStmt:
+			c.report(node, "native channel send in workflow function, use workflow.Channel")
+		case *ast.UnaryExpr:
+			if node.Op == token.ARROW {
+				c.report(node, "native channel receive in workflow function, use workflow.Channel")
+			}
+		case *ast.RangeStmt:
+			if c.isMap(node.X) {
+				c.report(node, "iteration over a map in workflow function is randomized, iterate over sorted keys")
+			}
+		case *ast.SelectorExpr:
+			c.checkSelector(node)
+		}
+		return true
+	})
+}
+
+func (c *checker) checkSelector(sel *ast.SelectorExpr) {
+	pkgPath, ok := c.packagePath(sel.X)
+	if !ok {
+		return
+	}
+	switch {
+	case pkgPath == "time":
+		if advice, ok := timeFunctions[sel.Sel.Name]; ok {
+			c.report(sel, fmt.Sprintf("time.%v in workflow function, %v", sel.Sel.Name, advice))
+		}
+	case randPackages[pkgPath]:
+		c.report(sel, fmt.Sprintf("%v.%v in workflow function, use workflow.SideEffect", pkgPath, sel.Sel.Name))
+	}
+}
+
+// isWorkflowFunc returns true if the first parameter of the function is a workflow.Context
+func (c *checker) isWorkflowFunc(fn *ast.FuncType) bool {
+	if fn.Params == nil || len(fn.Params.List) == 0 {
+		return false
+	}
+	sel, ok := fn.Params.List[0].Type.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Context" {
+		return false
+	}
+	pkgPath, ok := c.packagePath(sel.X)
+	return ok && pkgPath == workflowPackagePath
+}
+
+// packagePath returns the path of the package expr refers to, if it is an imported package name
+func (c *checker) packagePath(expr ast.Expr) (string, bool) {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	if c.info != nil {
+		if obj, ok := c.info.Uses[ident]; ok && obj != nil {
+			pkgName, ok := obj.(*types.PkgName)
+			if !ok {
+				// a variable or type shadowing the import
+				return "", false
+			}
+			return pkgName.Imported().Path(), true
+		}
+	}
+	if ident.Obj != nil {
+		// a local declaration shadowing the import
+		return "", false
+	}
+	pkgPath, ok := c.imports[ident.Name]
+	return pkgPath, ok
+}
+
+func (c *checker) isMap(expr ast.Expr) bool {
+	if c.info == nil {
+		return false
+	}
+	t := c.info.TypeOf(expr)
+	if t == nil {
+		return false
+	}
+	_, ok := t.Underlying().(*types.Map)
+	return ok
+}
+
+func (c *checker) report(n ast.Node, message string) {
+	pos := c.fset.Position(n.Pos())
+	if c.ignored[pos.Line] {
+		return
+	}
+	c.issues = append(c.issues, issue{pos: pos, message: message})
+}
+
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string, len(file.Imports))
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path.Base(importPath)
+		if strings.HasPrefix(name, "v") && strings.Contains(importPath, "/") {
+			if _, err := strconv.Atoi(name[1:]); err == nil {
+				// major version suffix, e.g. math/rand/v2
+				name = path.Base(path.Dir(importPath))
+			}
+		}
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = importPath
+	}
+	return imports
+}
+
+// ignoredLines returns the lines of the file with a workflowcheck:ignore comment
+func ignoredLines(fset *token.FileSet, file *ast.File) map[int]bool {
+	lines := make(map[int]bool)
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			if strings.Contains(comment.Text, ignoreDirective) {
+				lines[fset.Position(comment.Pos()).Line] = true
+			}
+		}
+	}
+	return lines
+}
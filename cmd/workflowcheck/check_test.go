@@ -0,0 +1,127 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSource = `package sample
+
+import (
+	"math/rand"
+	"time"
+
+	"go.uber.org/cadence/workflow"
+)
+
+func Workflow(ctx workflow.Context, input map[string]int) error {
+	go func() {}()
+	ch := make(chan int)
+	ch <- 1
+	<-ch
+	select {}
+	_ = time.Now()
+	time.Sleep(time.Second)
+	_ = rand.Intn(10)
+	for range input {
+	}
+	for range []int{1} {
+	}
+	_ = time.Now() // workflowcheck:ignore
+	workflow.Go(ctx, func(ctx workflow.Context) {
+		_ = time.Now()
+	})
+	return nil
+}
+
+func activity(input map[string]int) {
+	go func() {}()
+	_ = time.Now()
+	for range input {
+	}
+}
+
+func shadowed(ctx workflow.Context) {
+	time := struct{ Now func() int }{}
+	_ = time.Now()
+}
+
+var literal = func(ctx workflow.Context) {
+	_ = time.Now()
+}
+`
+
+func TestCheckFiles(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "sample.go", testSource, parser.ParseComments)
+	require.NoError(t, err)
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	config := types.Config{Importer: importer.Default(), Error: func(error) {}}
+	_, _ = config.Check("sample", fset, []*ast.File{file}, info)
+
+	var reported []string
+	for _, i := range checkFiles(fset, []*ast.File{file}, info) {
+		reported = append(reported, fmt.Sprintf("%v: %v", i.pos.Line, i.message))
+	}
+	assert.Equal(t, []string{
+		"11: go statement in workflow function, use workflow.Go",
+		"12: native channel in workflow function, use workflow.Channel",
+		"13: native channel send in workflow function, use workflow.Channel",
+		"14: native channel receive in workflow function, use workflow.Channel",
+		"15: select statement in workflow function, use workflow.Selector",
+		"16: time.Now in workflow function, use workflow.Now",
+		"17: time.Sleep in workflow function, use workflow.Sleep",
+		"18: math/rand.Intn in workflow function, use workflow.SideEffect",
+		"19: iteration over a map in workflow function is randomized, iterate over sorted keys",
+		"25: time.Now in workflow function, use workflow.Now",
+		"43: time.Now in workflow function, use workflow.Now",
+	}, reported)
+}
+
+func TestFileImports(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "imports.go", `package sample
+
+import (
+	"math/rand/v2"
+	wf "go.uber.org/cadence/workflow"
+	"time"
+)
+`, parser.ImportsOnly)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"rand": "math/rand/v2",
+		"wf":   "go.uber.org/cadence/workflow",
+		"time": "time",
+	}, fileImports(file))
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Command workflowcheck reports the constructs which make workflow code non-deterministic, to catch them in CI
+// instead of with non-deterministic errors in production.
+//
+// Workflow functions are the functions and function literals whose first parameter is a workflow.Context.
+// Within them, workflowcheck flags go statements, native channels and select statements, the time functions
+// reading or waiting on the wall clock, random number generators and iteration over maps.
+// Lines with a "workflowcheck:ignore" comment are not reported.
+//
+// Usage:
+//
+//	workflowcheck [packages]
+//
+// Packages are given in the go list format and default to "./...". workflowcheck exits with status 1 if any issue
+// is found.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// listedPackage is the subset of the go list output used by workflowcheck
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	GoFiles    []string
+}
+
+func main() {
+	patterns := os.Args[1:]
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	issues, err := run(patterns)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "workflowcheck:", err)
+		os.Exit(2)
+	}
+	for _, i := range issues {
+		fmt.Println(i)
+	}
+	if len(issues) > 0 {
+		os.Exit(1)
+	}
+}
+
+func run(patterns []string) ([]issue, error) {
+	packages, err := listPackages(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []issue
+	for _, pkg := range packages {
+		pkgIssues, err := checkPackage(pkg)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, pkgIssues...)
+	}
+	return issues, nil
+}
+
+func listPackages(patterns []string) ([]listedPackage, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", append([]string{"list", "-json"}, patterns...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go list failed: %v: %s", err, stderr.String())
+	}
+
+	var packages []listedPackage
+	decoder := json.NewDecoder(&stdout)
+	for {
+		var pkg listedPackage
+		if err := decoder.Decode(&pkg); err == io.EOF {
+			return packages, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode go list output: %v", err)
+		}
+		packages = append(packages, pkg)
+	}
+}
+
+func checkPackage(pkg listedPackage) ([]issue, error) {
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 0, len(pkg.GoFiles))
+	for _, name := range pkg.GoFiles {
+		file, err := parser.ParseFile(fset, filepath.Join(pkg.Dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	// Type information is only needed to find the maps iterated over, so type errors, like imports which cannot be
+	// loaded from export data, are ignored and the expressions affected by them are left without types.
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	config := types.Config{
+		Importer: importer.Default(),
+		Error:    func(error) {},
+	}
+	_, _ = config.Check(pkg.ImportPath, fset, files, info)
+	return checkFiles(fset, files, info), nil
+}
@@ -42,9 +42,56 @@ type (
 	// Cadence support using different DataConverters for different activity/childWorkflow in same workflow.
 	//   2. Activity/Workflow worker that run these activity/childWorkflow, through worker.Options.
 	DataConverter = internal.DataConverter
+
+	// KeyProvider provides the keys used by an encrypted data converter, see NewEncryptedDataConverter.
+	// Keys are AES keys of 16, 24 or 32 bytes, identified by a key ID stored in the encrypted payloads.
+	KeyProvider = internal.KeyProvider
 )
 
 // GetDefaultDataConverter return default data converter used by Cadence worker
 func GetDefaultDataConverter() DataConverter {
 	return internal.DefaultDataConverter
 }
+
+// NewProtoDataConverter creates a data converter which encodes values with binary protobuf when all of them are
+// proto messages generated with gogo/protobuf. Other values are encoded by the default data converter. Messages
+// generated with golang/protobuf v1.4 or later are not supported.
+// Values are decoded into pointers to proto messages, or pointers to pointers to proto messages. The encoded data
+// carries no marker of its encoding, so it must be decoded into the same proto message types it was encoded from.
+func NewProtoDataConverter() DataConverter {
+	return internal.NewProtoDataConverter()
+}
+
+// NewEncryptedDataConverter creates a data converter which encrypts the payloads produced by dataConverter
+// with AES-GCM, using the current key of the key provider. The key ID is stored in the payload, so payloads
+// encrypted with previous keys can still be decrypted after the current key is rotated, as long as the
+// key provider returns them.
+// The default data converter is used when dataConverter is nil.
+//
+// Workers and clients must use the same key provider. Payloads which were not produced by an encrypted
+// data converter are rejected, except for empty ones. Payloads are base64 encoded text.
+func NewEncryptedDataConverter(dataConverter DataConverter, keys KeyProvider) DataConverter {
+	return internal.NewEncryptedDataConverter(dataConverter, keys)
+}
+
+// NewStaticKeyProvider creates a key provider from a fixed set of keys, indexed by key ID.
+// New payloads are encrypted with the key of currentKeyID. To rotate keys, add the new key to the set and make
+// it current, keeping the previous keys for as long as payloads encrypted with them are in use.
+func NewStaticKeyProvider(currentKeyID string, keys map[string][]byte) (KeyProvider, error) {
+	return internal.NewStaticKeyProvider(currentKeyID, keys)
+}
+
+// EncodeArgs encodes values the way the framework encodes the arguments of activities, child workflows,
+// signals and queries. The default data converter is used when dc is nil.
+func EncodeArgs(dc DataConverter, args ...interface{}) ([]byte, error) {
+	return internal.EncodeArgs(dc, args...)
+}
+
+// DecodeArgs decodes data produced by EncodeArgs, or by the framework, into value pointers.
+// A nil value pointer skips the corresponding value with the default data converter, so only some of the values
+// can be decoded. Other data converters may not support it.
+// cadence.ErrNoData is returned when there is no data to decode.
+// The default data converter is used when dc is nil.
+func DecodeArgs(dc DataConverter, data []byte, valuePtrs ...interface{}) error {
+	return internal.DecodeArgs(dc, data, valuePtrs...)
+}
@@ -21,6 +21,8 @@
 package cadence
 
 import (
+	"errors"
+
 	"go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal"
 	"go.uber.org/cadence/workflow"
@@ -33,8 +35,15 @@ type (
 	// CanceledError returned when operation was canceled.
 	CanceledError = internal.CanceledError
 
+	// NonRetryableError wraps an error returned from workflow and activity implementations which must not be retried,
+	// regardless of the RetryPolicy.
+	NonRetryableError = internal.NonRetryableError
+
 	// NonDeterministicError is returned when a workflow's replay was non-deterministic, and it could not be resumed safely.
 	NonDeterministicError = internal.NonDeterministicError
+
+	// NotRegisteredError is returned when a workflow or activity type is not registered with a worker.
+	NotRegisteredError = internal.NotRegisteredError
 )
 
 // ErrNoData is returned when trying to extract strong typed data while there is no data available.
@@ -52,6 +61,13 @@ func NewCanceledError(details ...interface{}) *CanceledError {
 	return internal.NewCanceledError(details...)
 }
 
+// NewNonRetryableError creates NonRetryableError instance wrapping err.
+// Return this error from activity or child workflow for permanent failures, e.g. validation errors, which should
+// not be retried by the RetryPolicy. It may also be wrapped in another error, e.g. with fmt.Errorf("...: %w", err).
+func NewNonRetryableError(err error) *NonRetryableError {
+	return internal.NewNonRetryableError(err)
+}
+
 // IsCustomError return if the err is a CustomError
 func IsCustomError(err error) bool {
 	_, ok := err.(*CustomError)
@@ -76,6 +92,12 @@ func IsGenericError(err error) bool {
 	return ok
 }
 
+// IsNonRetryableError return if the err is or wraps a NonRetryableError
+func IsNonRetryableError(err error) bool {
+	var nonRetryableErr *NonRetryableError
+	return errors.As(err, &nonRetryableErr)
+}
+
 // IsTimeoutError return if the err is a TimeoutError
 func IsTimeoutError(err error) bool {
 	_, ok := err.(*workflow.TimeoutError)
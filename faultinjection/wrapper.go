@@ -0,0 +1,46 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package faultinjection provides a workflow service wrapper injecting faults into RPCs, for validating retry
+// policies and worker resiliency in integration tests. It must not be used in production.
+package faultinjection
+
+import (
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	internal "go.uber.org/cadence/internal/common/faultinjection"
+)
+
+type (
+	// Rule describes the faults injected into the calls of a set of RPCs.
+	Rule = internal.Rule
+
+	// Config is the configuration of a fault injection wrapper.
+	Config = internal.Config
+)
+
+// NewFaultInjectionWrapper creates a client wrapper that injects latency, errors and lost responses into requests
+// to cadence server according to config. It can be passed to worker.Options.ServiceWrapper and client.Options.ServiceWrapper:
+//
+//	ServiceWrapper: func(service workflowserviceclient.Interface) workflowserviceclient.Interface {
+//		return faultinjection.NewFaultInjectionWrapper(service, config)
+//	}
+func NewFaultInjectionWrapper(service workflowserviceclient.Interface, config Config) workflowserviceclient.Interface {
+	return internal.NewWorkflowServiceWrapper(service, config)
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package history provides helpers to inspect workflow histories, e.g. as returned by Client.GetWorkflowHistory or
+// loaded with worker.HistoryFromJSON, as the building block for debugging tools.
+package history
+
+import (
+	"io"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	internal "go.uber.org/cadence/internal/common/history"
+)
+
+type (
+	// FormatOptions configure how Format renders a history.
+	FormatOptions = internal.FormatOptions
+
+	// DiffOptions configure how Diff compares histories.
+	DiffOptions = internal.DiffOptions
+
+	// Divergence is the first difference found by Diff between two histories. Its String method describes both
+	// diverging events.
+	Divergence = internal.Divergence
+)
+
+// Format writes history to w as a readable timeline, one event per line, in the form:
+//
+//	5  +12ms  ActivityTaskScheduled  activityId=0 activityType=Greet taskList=tl input="\"world\"" ...
+//	6  +20ms  ActivityTaskStarted    scheduledEventId=5(ActivityTaskScheduled) identity=worker-1 attempt=0
+//	7  +1.5s  ActivityTaskCompleted  result="\"hello world\"" scheduledEventId=5(ActivityTaskScheduled) ...
+//
+// Timestamps are relative to the first event, payloads are previewed and references to other events, e.g. from
+// the completion of an activity to its scheduling, are annotated with the type of the referenced event.
+func Format(w io.Writer, history *shared.History, options *FormatOptions) error {
+	return internal.Format(w, history, options)
+}
+
+// Diff compares the histories of two runs of the same workflow type event by event, and returns the first event
+// which differs by its type or by its attributes, or nil if both runs took the same path through the workflow code:
+//
+//	if divergence := history.Diff(historyA, historyB, nil); divergence != nil {
+//		fmt.Println(divergence)
+//	}
+//
+// Attributes which are specific to a run, such as run IDs, timestamps, worker identities and task lists, are not
+// compared. Payloads are only compared when DiffOptions.ComparePayloads is set.
+func Diff(a, b *shared.History, options *DiffOptions) *Divergence {
+	return internal.Diff(a, b, options)
+}
+
+// FilterEvents returns the events of the given types, in the order of the history.
+func FilterEvents(events []*shared.HistoryEvent, eventTypes ...shared.EventType) []*shared.HistoryEvent {
+	return internal.FilterEvents(events, eventTypes...)
+}
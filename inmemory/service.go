@@ -0,0 +1,42 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package inmemory provides an in-process fake of the workflow service, to run workers end-to-end in integration
+// tests without a Cadence cluster. It must not be used in production.
+package inmemory
+
+import (
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	internal "go.uber.org/cadence/internal/common/inmemory"
+)
+
+// NewWorkflowService creates a workflow service keeping all state in memory. It can be shared by workers and
+// clients in place of a service connected to a Cadence cluster:
+//
+//	service := inmemory.NewWorkflowService()
+//	w := worker.New(service, "domain", "tasklist", worker.Options{})
+//	c := client.NewClient(service, "domain", nil)
+//
+// Workflows can start and complete activities and timers, receive signals and cancellation requests, and be
+// terminated. All domains are reported as registered. Timeouts, retry policies, child workflows, queries and
+// visibility APIs are not supported: unsupported requests fail with a *shared.BadRequestError.
+func NewWorkflowService() workflowserviceclient.Interface {
+	return internal.NewWorkflowService()
+}
@@ -38,6 +38,14 @@ type (
 	// Use workflow.IsReplaying(ctx) to filter out duplicated calls.
 	WorkflowInterceptor = internal.WorkflowInterceptor
 
+	// WorkflowMarkerInterceptor is an optional interface a WorkflowInterceptor implements to also intercept
+	// workflow.RecordMarker. WorkflowInterceptorBase implements it by forwarding to the next link.
+	WorkflowMarkerInterceptor = internal.WorkflowMarkerInterceptor
+
+	// WorkflowMemoInterceptor is an optional interface a WorkflowInterceptor implements to also intercept
+	// workflow.UpsertMemo. WorkflowInterceptorBase implements it by forwarding to the next link.
+	WorkflowMemoInterceptor = internal.WorkflowMemoInterceptor
+
 	// WorkflowInterceptorBase is a noop implementation of WorkflowInterceptor that just forwards requests
 	// to the next link in an interceptor chain. To be used as base implementation of interceptors.
 	WorkflowInterceptorBase = internal.WorkflowInterceptorBase
@@ -37,10 +37,12 @@ import (
 //go:generate mockery --name ServiceInvoker --inpackage --with-expecter --case snake --filename service_invoker_mock.go --boilerplate-file ../LICENSE
 
 type (
-	// RegistryActivityInfo
+	// RegistryActivityInfo describes an activity registered on a worker or replayer.
 	RegistryActivityInfo interface {
 		ActivityType() ActivityType
 		GetFunction() interface{}
+		// GetOptions returns the options the activity was registered with.
+		GetOptions() RegisterActivityOptions
 	}
 
 	// ActivityType identifies a activity type.
@@ -76,6 +78,22 @@ type (
 		// This option has no effect when explicit Name is provided.
 		EnableShortName               bool
 		DisableAlreadyRegisteredCheck bool
+		// Additional activity type names that are executed by this activity, for example the previous Go function
+		// path of an activity function that was moved or renamed. Activity tasks already scheduled under any of these
+		// names are executed by this activity. Workflows keep scheduling the activity under its registered name.
+		// This option has no effect when registering a structure.
+		Aliases []string
+		// Maximum number of concurrent executions of this activity type on the worker. Activity tasks received
+		// above this limit wait for a running execution of the same type to finish, so that a single heavy
+		// activity type cannot occupy the whole worker. When registering a structure the limit applies to each
+		// of its activities separately.
+		// When the worker is started with such a limit registered, polled tasks are dispatched through a queue
+		// of WorkerOptions.ActivityTaskDispatchQueueSize tasks: tasks above the limit wait in the queue without
+		// holding one of the WorkerOptions.MaxConcurrentActivityExecutionSize execution slots, and tasks of other
+		// types are executed in the meantime. Limits of activities registered after the worker is started are
+		// enforced while holding an execution slot.
+		// Default: 0, no limit other than WorkerOptions.MaxConcurrentActivityExecutionSize
+		MaxConcurrentExecutionSize int
 		// Automatically send heartbeats for this activity at an interval that is less than the HeartbeatTimeout.
 		// This option has no effect if the activity is executed with a HeartbeatTimeout of 0.
 		// Default: false
@@ -224,6 +242,13 @@ func GetActivityInfo(ctx context.Context) ActivityInfo {
 	}
 }
 
+// GetActivityHostSpecificTaskList returns the host-specific task list of the worker executing the current activity.
+// Activities scheduled on it are only executed by this host, given the worker enabled EnableHostSpecificTaskList.
+func GetActivityHostSpecificTaskList(ctx context.Context) string {
+	env := getActivityEnv(ctx)
+	return GetHostSpecificTaskList(env.taskList)
+}
+
 // HasHeartbeatDetails checks if there is heartbeat details from last attempt.
 func HasHeartbeatDetails(ctx context.Context) bool {
 	env := getActivityEnv(ctx)
@@ -315,6 +340,47 @@ type ServiceInvoker interface {
 	SignalWorkflow(ctx context.Context, domain, workflowID, runID, signalName string, signalInput []byte) error
 }
 
+// calculateActivityDeadline returns the earlier of the schedule-to-close deadline of this attempt
+// and the start-to-close deadline of the task.
+func calculateActivityDeadline(task *shared.PollForActivityTaskResponse) time.Time {
+	scheduled := time.Unix(0, task.GetScheduledTimestampOfThisAttempt())
+	started := time.Unix(0, task.GetStartedTimestamp())
+	scheduleToCloseDeadline := scheduled.Add(time.Duration(task.GetScheduleToCloseTimeoutSeconds()) * time.Second)
+	startToCloseDeadline := started.Add(time.Duration(task.GetStartToCloseTimeoutSeconds()) * time.Second)
+	// Minimum of the two deadlines.
+	if scheduleToCloseDeadline.Before(startToCloseDeadline) {
+		return scheduleToCloseDeadline
+	}
+	return startToCloseDeadline
+}
+
+// activityTaskExpiryMargin is how far past its deadline a task has to be before the worker skips it,
+// so that a task close to its deadline is still given to the activity rather than dropped.
+const activityTaskExpiryMargin = time.Second
+
+// isActivityTaskExpired reports whether the server has already timed out the task by the time the
+// worker gets to process it, e.g. because it sat in a local buffer or the poller was starved. Any work
+// done for such a task would be discarded.
+//
+// waited is the time the task spent in the worker since the poll returned, measured on the local
+// clock. It is only ever added to differences of server timestamps, so clock skew between the worker
+// and the server cannot make a task look expired. Tasks without timestamps are never considered
+// expired, and zero timeouts are not checked. The schedule-to-start timeout is not part of the poll
+// response; the server enforces it before handing the task out, when started is recorded.
+func isActivityTaskExpired(task *shared.PollForActivityTaskResponse, waited time.Duration) bool {
+	scheduled := task.GetScheduledTimestampOfThisAttempt()
+	started := task.GetStartedTimestamp()
+	if scheduled == 0 || started == 0 {
+		return false
+	}
+	if timeout := task.GetScheduleToCloseTimeoutSeconds(); timeout > 0 &&
+		time.Duration(started-scheduled)+waited > time.Duration(timeout)*time.Second+activityTaskExpiryMargin {
+		return true
+	}
+	return task.GetStartToCloseTimeoutSeconds() > 0 &&
+		waited > time.Duration(task.GetStartToCloseTimeoutSeconds())*time.Second+activityTaskExpiryMargin
+}
+
 // WithActivityTask adds activity specific information into context.
 // Use this method to unit test activity implementations that use context extractor methodshared.
 func WithActivityTask(
@@ -329,20 +395,10 @@ func WithActivityTask(
 	contextPropagators []ContextPropagator,
 	tracer opentracing.Tracer,
 ) context.Context {
-	var deadline time.Time
 	scheduled := time.Unix(0, task.GetScheduledTimestampOfThisAttempt())
 	started := time.Unix(0, task.GetStartedTimestamp())
-	scheduleToCloseTimeout := time.Duration(task.GetScheduleToCloseTimeoutSeconds()) * time.Second
-	startToCloseTimeout := time.Duration(task.GetStartToCloseTimeoutSeconds()) * time.Second
 	heartbeatTimeout := time.Duration(task.GetHeartbeatTimeoutSeconds()) * time.Second
-	scheduleToCloseDeadline := scheduled.Add(scheduleToCloseTimeout)
-	startToCloseDeadline := started.Add(startToCloseTimeout)
-	// Minimum of the two deadlines.
-	if scheduleToCloseDeadline.Before(startToCloseDeadline) {
-		deadline = scheduleToCloseDeadline
-	} else {
-		deadline = startToCloseDeadline
-	}
+	deadline := calculateActivityDeadline(task)
 
 	// keep in sync with local activity logger tags
 	logger = logger.With(
@@ -23,11 +23,11 @@ package internal
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/jonboulle/clockwork"
 	"github.com/opentracing/opentracing-go"
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
 
 	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
@@ -54,6 +54,9 @@ type (
 		tracer             opentracing.Tracer
 		featureFlags       FeatureFlags
 		activityTracker    debug.ActivityTracker
+		payloadThreshold   int
+		hbThrottleRatio    float64
+		maxHbThrottle      time.Duration
 	}
 )
 
@@ -94,6 +97,9 @@ func newActivityTaskHandlerWithCustomProvider(
 		tracer:             params.Tracer,
 		featureFlags:       params.FeatureFlags,
 		activityTracker:    params.WorkerStats.ActivityTracker,
+		payloadThreshold:   params.LargePayloadWarningThreshold,
+		hbThrottleRatio:    params.HeartbeatThrottleRatio,
+		maxHbThrottle:      params.MaxHeartbeatThrottleInterval,
 	}
 }
 
@@ -112,23 +118,33 @@ func (ath *activityTaskHandlerImpl) Execute(taskList string, t *s.PollForActivit
 	}
 	canCtx, cancel := context.WithCancel(rootCtx)
 	defer cancel()
+	cancelRequested := atomic.NewBool(false)
+	requestCancel := func() {
+		cancelRequested.Store(true)
+		cancel()
+	}
 
 	workflowType := t.WorkflowType.GetName()
 	activityType := t.ActivityType.GetName()
-	invoker := newServiceInvoker(t.TaskToken, ath.identity, ath.service, cancel, t.GetHeartbeatTimeoutSeconds(), ath.workerStopCh, ath.featureFlags, ath.logger, workflowType, activityType)
+	invoker := newServiceInvoker(t.TaskToken, ath.identity, ath.service, requestCancel, t.GetHeartbeatTimeoutSeconds(), ath.hbThrottleRatio, ath.maxHbThrottle, ath.workerStopCh, ath.featureFlags, ath.logger, workflowType, activityType)
 	defer func() {
 		_, activityCompleted := result.(*s.RespondActivityTaskCompletedRequest)
 		invoker.Close(!activityCompleted) // flush buffered heartbeat if activity was not successfully completed.
 	}()
 
 	metricsScope := getMetricsScopeForActivity(ath.metricsScope, workflowType, activityType)
+	if taskList != ath.taskListName {
+		// polled from one of WorkerOptions.AdditionalActivityTaskLists, the worker's scope is tagged with its own
+		metricsScope = ath.metricsScope.GetTaggedScope(tagWorkflowType, workflowType, tagActivityType, activityType, tagTaskList, taskList)
+	}
 	ctx := WithActivityTask(canCtx, t, taskList, invoker, ath.logger, metricsScope, ath.dataConverter, ath.workerStopCh, ath.contextPropagators, ath.tracer)
 
 	activityImplementation := ath.getActivity(activityType)
 	if activityImplementation == nil {
-		// Couldn't find the activity implementation.
-		supported := strings.Join(ath.getRegisteredActivityNames(), ", ")
-		return nil, fmt.Errorf("unable to find activityType=%v. Supported types: [%v]", activityType, supported)
+		// Couldn't find the activity implementation, fail the activity so it is retried according to its retry policy
+		// instead of waiting for it to time out.
+		err := &NotRegisteredError{TypeName: activityType, IsActivity: true, SupportedTypes: ath.getRegisteredActivityNames()}
+		return convertActivityResultToRespondRequest(ath.identity, t.TaskToken, nil, err, ath.dataConverter, ath.featureFlags), nil
 	}
 
 	// panic handler
@@ -144,7 +160,7 @@ func (ath *activityTaskHandlerImpl) Execute(taskList string, t *s.PollForActivit
 				zap.String(tagPanicStack, st))
 			metricsScope.Counter(metrics.ActivityTaskPanicCounter).Inc(1)
 			panicErr := newPanicError(p, st)
-			result, err = convertActivityResultToRespondRequest(ath.identity, t.TaskToken, nil, panicErr, ath.dataConverter), nil
+			result, err = convertActivityResultToRespondRequest(ath.identity, t.TaskToken, nil, panicErr, ath.dataConverter, ath.featureFlags), nil
 		}
 	}()
 
@@ -172,6 +188,11 @@ func (ath *activityTaskHandlerImpl) Execute(taskList string, t *s.PollForActivit
 		ActivityType: activityType,
 	}
 	defer ath.activityTracker.Start(activityInfo).Stop()
+	payloadLogger := ath.logger.With(
+		zap.String(tagWorkflowID, t.WorkflowExecution.GetWorkflowId()),
+		zap.String(tagRunID, t.WorkflowExecution.GetRunId()),
+		zap.String(tagActivityType, activityType))
+	recordPayloadSize(metricsScope, payloadLogger, ath.payloadThreshold, metrics.ActivityInputSize, t.Input)
 	output, err := activityImplementation.Execute(ctx, t.Input)
 
 	dlCancelFunc()
@@ -191,7 +212,11 @@ func (ath *activityTaskHandlerImpl) Execute(taskList string, t *s.PollForActivit
 			zap.Error(err),
 		)
 	}
-	return convertActivityResultToRespondRequest(ath.identity, t.TaskToken, output, err, ath.dataConverter), nil
+	if err == nil {
+		recordPayloadSize(metricsScope, payloadLogger, ath.payloadThreshold, metrics.ActivityResultSize, output)
+	}
+	err = activityCancellationResult(canCtx, cancelRequested.Load(), err)
+	return convertActivityResultToRespondRequest(ath.identity, t.TaskToken, output, err, ath.dataConverter, ath.featureFlags), nil
 }
 
 func (ath *activityTaskHandlerImpl) getActivity(name string) activity {
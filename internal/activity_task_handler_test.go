@@ -24,6 +24,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -36,10 +37,15 @@ import (
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
 	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
 	s "go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common"
+	"go.uber.org/cadence/internal/common/metrics"
 )
 
 func TestActivityTaskHandler_Execute_deadline(t *testing.T) {
@@ -150,6 +156,43 @@ func TestActivityTaskHandler_Execute_worker_stop(t *testing.T) {
 	assert.NotNil(t, r)
 }
 
+func TestActivityTaskHandler_Execute_NotRegistered(t *testing.T) {
+	registry := newRegistry()
+	registry.RegisterActivityWithOptions(func() error { return nil }, RegisterActivityOptions{Name: "registered"})
+	mockCtrl := gomock.NewController(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	wep := workerExecutionParameters{
+		WorkerOptions: WorkerOptions{
+			Logger:        testlogger.NewZap(t),
+			DataConverter: getDefaultDataConverter(),
+		},
+		UserContext:       ctx,
+		UserContextCancel: cancel,
+	}
+	activityHandler := newActivityTaskHandler(workflowservicetest.NewMockClient(mockCtrl), wep, registry)
+	now := time.Now()
+	pats := &s.PollForActivityTaskResponse{
+		TaskToken:                       []byte("token"),
+		WorkflowExecution:               &s.WorkflowExecution{WorkflowId: common.StringPtr("wID"), RunId: common.StringPtr("rID")},
+		ActivityType:                    &s.ActivityType{Name: common.StringPtr("unregistered")},
+		ActivityId:                      common.StringPtr(uuid.New()),
+		ScheduledTimestamp:              common.Int64Ptr(now.UnixNano()),
+		ScheduledTimestampOfThisAttempt: common.Int64Ptr(now.UnixNano()),
+		ScheduleToCloseTimeoutSeconds:   common.Int32Ptr(1),
+		StartedTimestamp:                common.Int64Ptr(now.UnixNano()),
+		StartToCloseTimeoutSeconds:      common.Int32Ptr(1),
+		WorkflowType:                    &s.WorkflowType{Name: common.StringPtr("wType")},
+		WorkflowDomain:                  common.StringPtr("domain"),
+	}
+
+	r, err := activityHandler.Execute(tasklist, pats)
+	require.NoError(t, err)
+	request, ok := r.(*s.RespondActivityTaskFailedRequest)
+	require.True(t, ok, "unregistered activity must be failed, got %T", r)
+	require.Equal(t, errReasonGeneric, request.GetReason())
+	require.Contains(t, string(request.Details), "unable to find activityType=unregistered. Supported types: [registered")
+}
+
 func TestActivityTaskHandler_Execute_with_propagators(t *testing.T) {
 	logger := testlogger.NewZap(t)
 
@@ -312,6 +355,244 @@ func TestActivityTaskHandler_Execute_with_auto_heartbeat(t *testing.T) {
 	require.True(t, ok, "response is not of type *s.RespondActivityTaskCompletedRequest but of type %T", res)
 }
 
+func TestActivityTaskPoller_ProcessTask_with_concurrency_limit(t *testing.T) {
+	logger := testlogger.NewZap(t)
+	registry := newRegistry()
+
+	registry.RegisterActivityWithOptions(
+		func(ctx context.Context) error {
+			return nil
+		},
+		RegisterActivityOptions{Name: "limited", MaxConcurrentExecutionSize: 1},
+	)
+
+	mockCtrl := gomock.NewController(t)
+	mockService := workflowservicetest.NewMockClient(mockCtrl)
+	wep := workerExecutionParameters{
+		WorkerOptions: WorkerOptions{
+			Logger:        logger,
+			DataConverter: getDefaultDataConverter(),
+		},
+	}
+	ensureRequiredParams(&wep)
+	activityHandler := newActivityTaskHandler(mockService, wep, registry)
+	poller := newActivityTaskPoller(activityHandler, mockService, "domain", wep)
+	newTask := func(startToClose int32) *s.PollForActivityTaskResponse {
+		now := time.Now()
+		return &s.PollForActivityTaskResponse{
+			TaskToken: []byte("token"),
+			WorkflowExecution: &s.WorkflowExecution{
+				WorkflowId: common.StringPtr("wID"),
+				RunId:      common.StringPtr("rID")},
+			ActivityType:                    &s.ActivityType{Name: common.StringPtr("limited")},
+			ActivityId:                      common.StringPtr(uuid.New()),
+			ScheduledTimestamp:              common.Int64Ptr(now.UnixNano()),
+			ScheduledTimestampOfThisAttempt: common.Int64Ptr(now.UnixNano()),
+			ScheduleToCloseTimeoutSeconds:   common.Int32Ptr(startToClose),
+			StartedTimestamp:                common.Int64Ptr(now.UnixNano()),
+			StartToCloseTimeoutSeconds:      common.Int32Ptr(startToClose),
+			WorkflowType: &s.WorkflowType{
+				Name: common.StringPtr("wType"),
+			},
+			WorkflowDomain: common.StringPtr("domain"),
+		}
+	}
+
+	// another execution holds the only slot
+	slots := registry.getActivityTypeSlots("limited")
+	require.NotNil(t, slots)
+	slots <- struct{}{}
+
+	// the task cannot acquire the slot before its deadline
+	err := poller.ProcessTask(&activityTask{task: newTask(1)})
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// the slot acquired when the task was dispatched is not waited for
+	mockService.EXPECT().RespondActivityTaskCompleted(gomock.Any(), gomock.Any(), callOptions()...).Return(nil)
+	require.NoError(t, poller.ProcessTask(&activityTask{task: newTask(1), slotHeld: true}))
+
+	<-slots
+	mockService.EXPECT().RespondActivityTaskCompleted(gomock.Any(), gomock.Any(), callOptions()...).Return(nil)
+	require.NoError(t, poller.ProcessTask(&activityTask{task: newTask(1)}))
+	assert.Empty(t, slots)
+}
+
+func TestActivityTaskHandler_Execute_activity_info(t *testing.T) {
+	logger := testlogger.NewZap(t)
+	registry := newRegistry()
+
+	var info ActivityInfo
+	registry.RegisterActivityWithOptions(
+		func(ctx context.Context) error {
+			info = GetActivityInfo(ctx)
+			return nil
+		},
+		RegisterActivityOptions{Name: "info"},
+	)
+
+	mockCtrl := gomock.NewController(t)
+	mockService := workflowservicetest.NewMockClient(mockCtrl)
+	wep := workerExecutionParameters{
+		WorkerOptions: WorkerOptions{
+			Logger:        logger,
+			DataConverter: getDefaultDataConverter(),
+		},
+	}
+	ensureRequiredParams(&wep)
+	activityHandler := newActivityTaskHandler(mockService, wep, registry)
+
+	scheduled := time.Now().Add(-time.Second).Round(0)
+	started := time.Now().Round(0)
+	pats := &s.PollForActivityTaskResponse{
+		TaskToken: []byte("token"),
+		WorkflowExecution: &s.WorkflowExecution{
+			WorkflowId: common.StringPtr("wID"),
+			RunId:      common.StringPtr("rID")},
+		ActivityType:                    &s.ActivityType{Name: common.StringPtr("info")},
+		ActivityId:                      common.StringPtr("aID"),
+		Attempt:                         common.Int32Ptr(2),
+		ScheduledTimestamp:              common.Int64Ptr(scheduled.UnixNano()),
+		ScheduledTimestampOfThisAttempt: common.Int64Ptr(scheduled.UnixNano()),
+		ScheduleToCloseTimeoutSeconds:   common.Int32Ptr(10),
+		StartedTimestamp:                common.Int64Ptr(started.UnixNano()),
+		StartToCloseTimeoutSeconds:      common.Int32Ptr(5),
+		HeartbeatTimeoutSeconds:         common.Int32Ptr(3),
+		WorkflowType: &s.WorkflowType{
+			Name: common.StringPtr("wType"),
+		},
+		WorkflowDomain: common.StringPtr("domain"),
+	}
+	_, err := activityHandler.Execute(tasklist, pats)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("token"), info.TaskToken)
+	assert.Equal(t, "aID", info.ActivityID)
+	assert.Equal(t, "info", info.ActivityType.Name)
+	assert.Equal(t, WorkflowExecution{ID: "wID", RunID: "rID"}, info.WorkflowExecution)
+	assert.Equal(t, "wType", info.WorkflowType.Name)
+	assert.Equal(t, "domain", info.WorkflowDomain)
+	assert.Equal(t, tasklist, info.TaskList)
+	assert.Equal(t, int32(2), info.Attempt)
+	assert.Equal(t, 3*time.Second, info.HeartbeatTimeout)
+	assert.True(t, scheduled.Equal(info.ScheduledTimestamp))
+	assert.True(t, started.Equal(info.StartedTimestamp))
+	// the earlier of start-to-close and schedule-to-close applies
+	assert.True(t, started.Add(5*time.Second).Equal(info.Deadline))
+}
+
+func TestActivityTaskHandler_Execute_metrics_scope(t *testing.T) {
+	registry := newRegistry()
+	registry.RegisterActivityWithOptions(
+		func(ctx context.Context) error {
+			GetActivityMetricsScope(ctx).Counter("custom-counter").Inc(1)
+			return nil
+		},
+		RegisterActivityOptions{Name: "count"},
+	)
+
+	scope := tally.NewTestScope("", nil)
+	mockCtrl := gomock.NewController(t)
+	mockService := workflowservicetest.NewMockClient(mockCtrl)
+	wep := workerExecutionParameters{
+		TaskList: tasklist,
+		WorkerOptions: WorkerOptions{
+			Logger:        testlogger.NewZap(t),
+			MetricsScope:  scope,
+			DataConverter: getDefaultDataConverter(),
+		},
+	}
+	ensureRequiredParams(&wep)
+	activityHandler := newActivityTaskHandler(mockService, wep, registry)
+
+	now := time.Now()
+	pats := &s.PollForActivityTaskResponse{
+		TaskToken: []byte("token"),
+		WorkflowExecution: &s.WorkflowExecution{
+			WorkflowId: common.StringPtr("wID"),
+			RunId:      common.StringPtr("rID")},
+		ActivityType:                    &s.ActivityType{Name: common.StringPtr("count")},
+		ActivityId:                      common.StringPtr("aID"),
+		ScheduledTimestamp:              common.Int64Ptr(now.UnixNano()),
+		ScheduledTimestampOfThisAttempt: common.Int64Ptr(now.UnixNano()),
+		ScheduleToCloseTimeoutSeconds:   common.Int32Ptr(1),
+		StartedTimestamp:                common.Int64Ptr(now.UnixNano()),
+		StartToCloseTimeoutSeconds:      common.Int32Ptr(1),
+		WorkflowType:                    &s.WorkflowType{Name: common.StringPtr("wType")},
+		WorkflowDomain:                  common.StringPtr("domain"),
+	}
+	_, err := activityHandler.Execute(tasklist, pats)
+	require.NoError(t, err)
+	// a task polled from an additional task list
+	_, err = activityHandler.Execute("gpu", pats)
+	require.NoError(t, err)
+
+	taskLists := map[string]int64{}
+	for _, counter := range scope.Snapshot().Counters() {
+		if counter.Name() != "custom-counter" {
+			continue
+		}
+		assert.Equal(t, "count", counter.Tags()[tagActivityType])
+		assert.Equal(t, "wType", counter.Tags()[tagWorkflowType])
+		taskLists[counter.Tags()[tagTaskList]] += counter.Value()
+	}
+	assert.Equal(t, map[string]int64{"": 1, "gpu": 1}, taskLists)
+}
+
+func TestActivityTaskHandler_Execute_context_deadline(t *testing.T) {
+	logger := testlogger.NewZap(t)
+	registry := newRegistry()
+
+	var ctxDeadline, infoDeadline time.Time
+	var ctxErr error
+	registry.RegisterActivityWithOptions(
+		func(ctx context.Context) error {
+			ctxDeadline, _ = ctx.Deadline()
+			infoDeadline = GetActivityInfo(ctx).Deadline
+			// a context-aware client stops as soon as the activity timed out
+			<-ctx.Done()
+			ctxErr = ctx.Err()
+			return ctxErr
+		},
+		RegisterActivityOptions{Name: "context-aware"},
+	)
+
+	mockCtrl := gomock.NewController(t)
+	mockService := workflowservicetest.NewMockClient(mockCtrl)
+	wep := workerExecutionParameters{
+		WorkerOptions: WorkerOptions{
+			Logger:        logger,
+			DataConverter: getDefaultDataConverter(),
+		},
+	}
+	ensureRequiredParams(&wep)
+	activityHandler := newActivityTaskHandler(mockService, wep, registry)
+
+	now := time.Now()
+	pats := &s.PollForActivityTaskResponse{
+		TaskToken: []byte("token"),
+		WorkflowExecution: &s.WorkflowExecution{
+			WorkflowId: common.StringPtr("wID"),
+			RunId:      common.StringPtr("rID")},
+		ActivityType:                    &s.ActivityType{Name: common.StringPtr("context-aware")},
+		ActivityId:                      common.StringPtr(uuid.New()),
+		ScheduledTimestamp:              common.Int64Ptr(now.UnixNano()),
+		ScheduledTimestampOfThisAttempt: common.Int64Ptr(now.UnixNano()),
+		ScheduleToCloseTimeoutSeconds:   common.Int32Ptr(10),
+		StartedTimestamp:                common.Int64Ptr(now.UnixNano()),
+		StartToCloseTimeoutSeconds:      common.Int32Ptr(1),
+		WorkflowType: &s.WorkflowType{
+			Name: common.StringPtr("wType"),
+		},
+		WorkflowDomain: common.StringPtr("domain"),
+	}
+	r, err := activityHandler.Execute(tasklist, pats)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Nil(t, r)
+	assert.Equal(t, context.DeadlineExceeded, ctxErr)
+	assert.True(t, infoDeadline.Equal(ctxDeadline))
+	assert.WithinDuration(t, now.Add(time.Second), ctxDeadline, time.Millisecond)
+}
+
 func activityWithWorkerStop(ctx context.Context) error {
 	fmt.Println("Executing Activity with worker stop")
 	workerStopCh := GetWorkerStopChannel(ctx)
@@ -352,3 +633,71 @@ func (f failingContextPropagator) InjectFromWorkflow(ctx Context, writer HeaderW
 func (f failingContextPropagator) ExtractToWorkflow(ctx Context, reader HeaderReader) (Context, error) {
 	return nil, f.err
 }
+
+func TestActivityTaskHandler_Execute_records_payload_sizes(t *testing.T) {
+	core, observed := observer.New(zapcore.WarnLevel)
+	logger := zap.New(core)
+
+	now := time.Now()
+
+	registry := newRegistry()
+	err := registry.registerActivityFunction(func(ctx context.Context, in string) (string, error) {
+		return in + in, nil
+	}, RegisterActivityOptions{Name: "echoTwice"})
+	require.NoError(t, err)
+
+	scope := tally.NewTestScope("", nil)
+	mockCtrl := gomock.NewController(t)
+	mockService := workflowservicetest.NewMockClient(mockCtrl)
+	wep := workerExecutionParameters{
+		WorkerOptions: WorkerOptions{
+			Logger:                       logger,
+			MetricsScope:                 scope,
+			DataConverter:                getDefaultDataConverter(),
+			Tracer:                       opentracing.NoopTracer{},
+			LargePayloadWarningThreshold: 100,
+		},
+	}
+	ensureRequiredParams(&wep)
+	input, err := encodeArg(getDefaultDataConverter(), strings.Repeat("a", 60))
+	require.NoError(t, err)
+
+	activityHandler := newActivityTaskHandler(mockService, wep, registry)
+	pats := &s.PollForActivityTaskResponse{
+		TaskToken: []byte("token"),
+		WorkflowExecution: &s.WorkflowExecution{
+			WorkflowId: common.StringPtr("wID"),
+			RunId:      common.StringPtr("rID")},
+		ActivityType:                    &s.ActivityType{Name: common.StringPtr("echoTwice")},
+		ActivityId:                      common.StringPtr(uuid.New()),
+		ScheduledTimestamp:              common.Int64Ptr(now.UnixNano()),
+		ScheduledTimestampOfThisAttempt: common.Int64Ptr(now.UnixNano()),
+		ScheduleToCloseTimeoutSeconds:   common.Int32Ptr(1),
+		StartedTimestamp:                common.Int64Ptr(now.UnixNano()),
+		StartToCloseTimeoutSeconds:      common.Int32Ptr(1),
+		WorkflowType: &s.WorkflowType{
+			Name: common.StringPtr("wType"),
+		},
+		WorkflowDomain: common.StringPtr("domain"),
+		Input:          input,
+	}
+	r, err := activityHandler.Execute(tasklist, pats)
+	require.NoError(t, err)
+	response := r.(*s.RespondActivityTaskCompletedRequest)
+
+	recorded := map[string]bool{}
+	for _, h := range scope.Snapshot().Histograms() {
+		recorded[h.Name()] = true
+		assert.Equal(t, "echoTwice", h.Tags()[tagActivityType])
+	}
+	assert.True(t, recorded[metrics.ActivityInputSize])
+	assert.True(t, recorded[metrics.ActivityResultSize])
+
+	// only the result is above the threshold
+	warnings := observed.FilterMessage("Payload size exceeds threshold.").All()
+	require.Len(t, warnings, 1)
+	fields := warnings[0].ContextMap()
+	assert.Equal(t, metrics.ActivityResultSize, fields["PayloadType"])
+	assert.Equal(t, int64(len(response.Result)), fields["PayloadSize"])
+	assert.Equal(t, "wID", fields[tagWorkflowID])
+}
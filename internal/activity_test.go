@@ -23,6 +23,7 @@ package internal
 import (
 	"context"
 	"testing"
+	"time"
 
 	"go.uber.org/cadence/internal/common/testlogger"
 
@@ -66,7 +67,7 @@ func (s *activityTestSuite) TearDownTest() {
 
 func (s *activityTestSuite) TestActivityHeartbeat() {
 	ctx, cancel := context.WithCancel(context.Background())
-	invoker := newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 1, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
+	invoker := newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 1, 0, 0, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
 	ctx = context.WithValue(ctx, activityEnvContextKey, &activityEnvironment{serviceInvoker: invoker})
 
 	s.service.EXPECT().RecordActivityTaskHeartbeat(gomock.Any(), gomock.Any(), callOptions()...).
@@ -77,7 +78,7 @@ func (s *activityTestSuite) TestActivityHeartbeat() {
 
 func (s *activityTestSuite) TestActivityHeartbeat_InternalError() {
 	ctx, cancel := context.WithCancel(context.Background())
-	invoker := newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 1, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
+	invoker := newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 1, 0, 0, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
 	ctx = context.WithValue(ctx, activityEnvContextKey, &activityEnvironment{
 		serviceInvoker: invoker,
 		logger:         getTestLogger(s.T())})
@@ -93,7 +94,7 @@ func (s *activityTestSuite) TestActivityHeartbeat_InternalError() {
 
 func (s *activityTestSuite) TestActivityHeartbeat_CancelRequested() {
 	ctx, cancel := context.WithCancel(context.Background())
-	invoker := newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 1, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
+	invoker := newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 1, 0, 0, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
 	ctx = context.WithValue(ctx, activityEnvContextKey, &activityEnvironment{
 		serviceInvoker: invoker,
 		logger:         getTestLogger(s.T())})
@@ -108,7 +109,7 @@ func (s *activityTestSuite) TestActivityHeartbeat_CancelRequested() {
 
 func (s *activityTestSuite) TestActivityHeartbeat_EntityNotExist() {
 	ctx, cancel := context.WithCancel(context.Background())
-	invoker := newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 1, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
+	invoker := newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 1, 0, 0, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
 	ctx = context.WithValue(ctx, activityEnvContextKey, &activityEnvironment{
 		serviceInvoker: invoker,
 		logger:         getTestLogger(s.T())})
@@ -123,7 +124,7 @@ func (s *activityTestSuite) TestActivityHeartbeat_EntityNotExist() {
 
 func (s *activityTestSuite) TestActivityHeartbeat_SuppressContinousInvokes() {
 	ctx, cancel := context.WithCancel(context.Background())
-	invoker := newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 2, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
+	invoker := newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 2, 0, 0, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
 	ctx = context.WithValue(ctx, activityEnvContextKey, &activityEnvironment{
 		serviceInvoker: invoker,
 		logger:         getTestLogger(s.T())})
@@ -138,7 +139,7 @@ func (s *activityTestSuite) TestActivityHeartbeat_SuppressContinousInvokes() {
 
 	// No HB timeout configured.
 	service2 := workflowservicetest.NewMockClient(s.mockCtrl)
-	invoker2 := newServiceInvoker([]byte("task-token"), "identity", service2, cancel, 0, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
+	invoker2 := newServiceInvoker([]byte("task-token"), "identity", service2, cancel, 0, 0, 0, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
 	ctx = context.WithValue(ctx, activityEnvContextKey, &activityEnvironment{
 		serviceInvoker: invoker2,
 		logger:         getTestLogger(s.T())})
@@ -151,7 +152,7 @@ func (s *activityTestSuite) TestActivityHeartbeat_SuppressContinousInvokes() {
 	// simulate batch picks before expiry.
 	waitCh := make(chan struct{})
 	service3 := workflowservicetest.NewMockClient(s.mockCtrl)
-	invoker3 := newServiceInvoker([]byte("task-token"), "identity", service3, cancel, 2, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
+	invoker3 := newServiceInvoker([]byte("task-token"), "identity", service3, cancel, 2, 0, 0, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
 	ctx = context.WithValue(ctx, activityEnvContextKey, &activityEnvironment{
 		serviceInvoker: invoker3,
 		logger:         getTestLogger(s.T())})
@@ -181,7 +182,7 @@ func (s *activityTestSuite) TestActivityHeartbeat_SuppressContinousInvokes() {
 	// simulate batch picks before expiry, with out any progress specified.
 	waitCh2 := make(chan struct{})
 	service4 := workflowservicetest.NewMockClient(s.mockCtrl)
-	invoker4 := newServiceInvoker([]byte("task-token"), "identity", service4, cancel, 2, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
+	invoker4 := newServiceInvoker([]byte("task-token"), "identity", service4, cancel, 2, 0, 0, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
 	ctx = context.WithValue(ctx, activityEnvContextKey, &activityEnvironment{
 		serviceInvoker: invoker4,
 		logger:         getTestLogger(s.T())})
@@ -202,10 +203,77 @@ func (s *activityTestSuite) TestActivityHeartbeat_SuppressContinousInvokes() {
 	invoker4.Close(false)
 }
 
+func (s *activityTestSuite) TestActivityHeartbeat_MaxHeartbeatThrottle() {
+	ctx, cancel := context.WithCancel(context.Background())
+	// batches last 80ms instead of 80% of the one hour heartbeat timeout
+	invoker := newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 3600, 0, 80*time.Millisecond, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
+	ctx = context.WithValue(ctx, activityEnvContextKey, &activityEnvironment{
+		serviceInvoker: invoker,
+		logger:         getTestLogger(s.T())})
+
+	waitCh := make(chan struct{})
+	s.service.EXPECT().RecordActivityTaskHeartbeat(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.RecordActivityTaskHeartbeatResponse{}, nil).Times(1)
+	s.service.EXPECT().RecordActivityTaskHeartbeat(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.RecordActivityTaskHeartbeatResponse{}, nil).
+		Do(func(ctx context.Context, request *shared.RecordActivityTaskHeartbeatRequest, opts ...yarpc.CallOption) {
+			var progress int
+			require.NoError(s.T(), newEncodedValues(request.Details, nil).Get(&progress))
+			require.Equal(s.T(), 99, progress)
+			waitCh <- struct{}{}
+		}).Times(1)
+
+	for i := 0; i < 100; i++ {
+		RecordActivityHeartbeat(ctx, i)
+	}
+	select {
+	case <-waitCh:
+	case <-time.After(10 * time.Second):
+		s.Fail("batched heartbeat was not reported")
+	}
+	invoker.Close(false)
+}
+
+func (s *activityTestSuite) TestActivityHeartbeat_HeartbeatThrottleRatio() {
+	_, cancel := context.WithCancel(context.Background())
+	// batches last 10% of the one hour heartbeat timeout, capped to 80ms
+	invoker := newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 3600, 0.1, 80*time.Millisecond, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
+	s.Equal(80*time.Millisecond, s.batchDuration(invoker))
+	invoker.Close(false)
+
+	// batches last 10% of the ten seconds heartbeat timeout
+	invoker = newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 10, 0.1, 0, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
+	s.Equal(time.Second, s.batchDuration(invoker))
+	invoker.Close(false)
+
+	// batches shorter than a second are not truncated to zero
+	invoker = newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 1, 0.1, 0, make(chan struct{}), FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
+	s.Equal(100*time.Millisecond, s.batchDuration(invoker))
+	invoker.Close(false)
+}
+
+// batchDuration returns how long the invoker batches heartbeats following a first heartbeat.
+func (s *activityTestSuite) batchDuration(invoker ServiceInvoker) time.Duration {
+	ctx := context.WithValue(context.Background(), activityEnvContextKey, &activityEnvironment{
+		serviceInvoker: invoker,
+		logger:         getTestLogger(s.T())})
+	heartbeats := make(chan time.Time, 2)
+	s.service.EXPECT().RecordActivityTaskHeartbeat(gomock.Any(), gomock.Any(), callOptions()...).
+		Return(&shared.RecordActivityTaskHeartbeatResponse{}, nil).
+		Do(func(context.Context, *shared.RecordActivityTaskHeartbeatRequest, ...yarpc.CallOption) {
+			heartbeats <- time.Now()
+		}).Times(2)
+
+	RecordActivityHeartbeat(ctx, "first")
+	RecordActivityHeartbeat(ctx, "batched")
+	first, batched := <-heartbeats, <-heartbeats
+	return batched.Sub(first).Round(20 * time.Millisecond)
+}
+
 func (s *activityTestSuite) TestActivityHeartbeat_WorkerStop() {
 	ctx, cancel := context.WithCancel(context.Background())
 	workerStopChannel := make(chan struct{})
-	invoker := newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 5, workerStopChannel, FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
+	invoker := newServiceInvoker([]byte("task-token"), "identity", s.service, cancel, 5, 0, 0, workerStopChannel, FeatureFlags{}, s.logger, testWorkflowType, testActivityType)
 	ctx = context.WithValue(ctx, activityEnvContextKey, &activityEnvironment{serviceInvoker: invoker})
 
 	heartBeatDetail := "testDetails"
@@ -35,6 +35,8 @@ import (
 	"go.uber.org/cadence/internal/common/auth"
 	"go.uber.org/cadence/internal/common/isolationgroup"
 	"go.uber.org/cadence/internal/common/metrics"
+	"go.uber.org/cadence/internal/common/redirect"
+	"go.uber.org/cadence/internal/common/rpcheaders"
 )
 
 const (
@@ -77,6 +79,8 @@ type (
 
 	// Client is the client for starting and getting information about a workflow executions as well as
 	// completing activities asynchronously.
+	// The deadline and cancellation of the context.Context passed to each method apply to the whole call, including
+	// the retries of transient service errors. The timeout of a single attempt is derived from that deadline.
 	Client interface {
 		// StartWorkflow starts a workflow execution
 		// The user can use this to start using a function or workflow type name.
@@ -328,8 +332,9 @@ type (
 		// target workflow execution that this query will be send to. If runID is not specified (empty string), server will
 		// use the currently running execution of that workflowID. The queryType specifies the type of query you want to
 		// run. By default, cadence supports "__stack_trace" as a standard query type, which will return string value
-		// representing the call stack of the target workflow. The target workflow could also setup different query handler
-		// to handle custom query types.
+		// representing the call stack of the target workflow. "__query_types" lists the query types the workflow supports
+		// and "__open_sessions" returns the sessions currently open in it. The target workflow could also setup different
+		// query handler to handle custom query types.
 		// See comments at workflow.SetQueryHandler(ctx Context, queryType string, handler interface{}) for more details
 		// on how to setup query handler within the target workflow.
 		// - workflowID is required.
@@ -375,6 +380,15 @@ type (
 		//  - EntityNotExistError
 		DescribeTaskList(ctx context.Context, tasklist string, tasklistType s.TaskListType) (*s.DescribeTaskListResponse, error)
 
+		// GetTaskListBacklog estimates the backlog of the decision and activity task lists of the name, and optionally
+		// the number of its open workflows, e.g. for autoscalers to scale workers on demand. See TaskListBacklogOptions
+		// to also emit them as gauges.
+		// The errors it can return:
+		//  - BadRequestError
+		//  - InternalServiceError
+		//  - EntityNotExistError
+		GetTaskListBacklog(ctx context.Context, tasklist string, options *TaskListBacklogOptions) (*TaskListBacklog, error)
+
 		// RefreshWorkflowTasks refreshes all the tasks of a given workflow.
 		// - workflow ID of the workflow.
 		// - runID can be default(empty string). if empty string then it will pick the running execution of that workflow ID.
@@ -386,18 +400,49 @@ type (
 		RefreshWorkflowTasks(ctx context.Context, workflowID, runID string) error
 	}
 
-	// ClientOptions are optional parameters for Client creation.
+	// ClientOptions are optional parameters for Client creation. They apply to every call made by the client,
+	// per-call parameters are set by StartWorkflowOptions and the other options of the Client methods.
 	ClientOptions struct {
-		MetricsScope       tally.Scope
-		Identity           string
-		IsolationGroup     string
-		DataConverter      DataConverter
-		Tracer             opentracing.Tracer
+		// MetricsScope receives the metrics of the client, tagged with its domain. default: no metrics.
+		MetricsScope tally.Scope
+		// Identity is sent to the server with the calls made by the client. default: see SetWorkerIdentityFunc.
+		Identity string
+		// IsolationGroup is sent to the server with the calls made by the client, see WorkerOptions.IsolationGroup.
+		IsolationGroup string
+		// DataConverter encodes workflow arguments, signals and queries and decodes their results.
+		// default: JSON encoding.
+		DataConverter DataConverter
+		// Tracer starts a span for the workflows started by the client, which is propagated to them.
+		Tracer opentracing.Tracer
+		// ContextPropagators propagate values from the context of the client calls to the started workflows.
 		ContextPropagators []ContextPropagator
-		FeatureFlags       FeatureFlags
-		Authorization      auth.AuthorizationProvider
+		// FeatureFlags enable breaking changes of the client, see FeatureFlags.
+		FeatureFlags FeatureFlags
+		// Authorization adds an authorization token to every outgoing service call.
+		Authorization auth.AuthorizationProvider
+		// ServiceWrapper wraps the service used by the client, see WorkerOptions.ServiceWrapper.
+		ServiceWrapper ServiceWrapper
+		// Headers are attached to every outgoing service call made by the client, e.g. a routing key or caller
+		// name for proxies in front of cadence. Use WithRPCHeaders to add or override headers for a single call.
+		// Headers with an empty name or the reserved cadence- prefix are not sent. The same mechanism attaches
+		// WorkerOptions.Headers to the calls of workers.
+		Headers map[string]string
+		// ActiveClusterResolver is an optional hook for global domains. When a call fails with a
+		// DomainNotActiveError, the client resolves the connection to the active cluster named by the error and
+		// sends the call there once more. If it is nil or returns an error, the DomainNotActiveError is returned
+		// to the caller, whose ActiveCluster field can be used to redirect the request elsewhere.
+		ActiveClusterResolver ActiveClusterResolver
 	}
 
+	// ActiveClusterResolver returns the service to use for requests to the named cluster. Implementations should
+	// cache the returned services, as it is called whenever a request is redirected. The returned service is not
+	// wrapped by ClientOptions.ServiceWrapper.
+	ActiveClusterResolver func(cluster string) (workflowserviceclient.Interface, error)
+
+	// ServiceWrapper wraps a workflow service with middleware, for example request logging, custom metrics or
+	// fault injection. The returned service is used for all RPCs.
+	ServiceWrapper func(service workflowserviceclient.Interface) workflowserviceclient.Interface
+
 	// StartWorkflowOptions configuration parameters for starting a workflow execution.
 	// The current timeout resolution implementation is in seconds and uses math.Ceil(d.Seconds()) as the duration. But is
 	// subjected to change in the future.
@@ -406,6 +451,13 @@ type (
 		// Optional: defaulted to a uuid.
 		ID string
 
+		// RequestID - Idempotency key of the start request. Retries of the same start call, including retries the
+		// client performs on transient errors, reuse it so that the server does not create duplicate executions.
+		// Set it explicitly to make starts retried by the caller idempotent as well. WorkflowExecution.Deduplicated
+		// reports whether an earlier attempt had already started the execution.
+		// Optional: defaulted to a uuid.
+		RequestID string
+
 		// TaskList - The decisions of the workflow are scheduled on this queue.
 		// This is also the default task list on which activities are scheduled. The workflow author can choose
 		// to override this using activity options.
@@ -471,6 +523,22 @@ type (
 		// This will only be used and override DelayStart and JitterStart if provided in the first run
 		// Optional: defaulted to Unix epoch time
 		FirstRunAt time.Time
+
+		// WaitForDecisionTaskStarted - When set, StartWorkflow and ExecuteWorkflow block after the workflow was started
+		// until a worker has picked up its first decision task, giving request/response style callers confidence that
+		// the workflow is being processed. If that does not happen within the duration, or the workflow closes first,
+		// ErrDecisionTaskNotStarted is returned; StartWorkflow returns it along with the started execution.
+		// Combine it with DelayStart, JitterStart or FirstRunAt only if the duration covers the delay.
+		// Ignored by StartWorkflowAsync and SignalWithStartWorkflow.
+		// Optional: defaulted to 0, which does not wait.
+		WaitForDecisionTaskStarted time.Duration
+
+		// TreatAlreadyStartedAsSuccess - When set, StartWorkflow returns the execution that is already running with the
+		// same ID instead of a WorkflowExecutionAlreadyStartedError, so idempotent starters don't need to describe it.
+		// Without it, the RunID of that execution is available from the error's GetRunId.
+		// Ignored by StartWorkflowAsync.
+		// Optional: defaulted to false.
+		TreatAlreadyStartedAsSuccess bool
 	}
 
 	// RetryPolicy defines the retry policy.
@@ -505,8 +573,11 @@ type (
 		// Non-Retriable errors. This is optional. Cadence server will stop retry if error reason matches this list.
 		// Error reason for custom error is specified when your activity/workflow return cadence.NewCustomError(reason).
 		// Error reason for panic error is "cadenceInternal:Panic".
-		// Error reason for any other error is "cadenceInternal:Generic".
+		// Error reason for any other error is "cadenceInternal:Generic", or "cadenceInternal:Wrapped" for errors wrapping
+		// other errors if FeatureFlags.WrappedErrorEncodingEnabled is set.
 		// Error reason for timeouts is: "cadenceInternal:Timeout TIMEOUT_TYPE". TIMEOUT_TYPE could be START_TO_CLOSE or HEARTBEAT.
+		// Errors created by NewNonRetryableError(), or wrapping it, are never retried: their reason "cadence:NonRetryable"
+		// is always added to this list.
 		// Note, cancellation is not a failure, so it won't be retried.
 		NonRetriableErrorReasons []string
 	}
@@ -578,11 +649,19 @@ func getFeatureFlags(options *ClientOptions) FeatureFlags {
 		return FeatureFlags{
 			WorkflowExecutionAlreadyCompletedErrorEnabled: options.FeatureFlags.WorkflowExecutionAlreadyCompletedErrorEnabled,
 			PollerAutoScalerEnabled:                       options.FeatureFlags.PollerAutoScalerEnabled,
+			WrappedErrorEncodingEnabled:                   options.FeatureFlags.WrappedErrorEncodingEnabled,
 		}
 	}
 	return FeatureFlags{}
 }
 
+func getHeaders(options *ClientOptions) map[string]string {
+	if options == nil {
+		return nil
+	}
+	return options.Headers
+}
+
 // NewClient creates an instance of a workflow client
 func NewClient(service workflowserviceclient.Interface, domain string, options *ClientOptions) Client {
 	var identity string
@@ -613,12 +692,20 @@ func NewClient(service workflowserviceclient.Interface, domain string, options *
 	} else {
 		tracer = opentracing.NoopTracer{}
 	}
+	if options != nil && options.ActiveClusterResolver != nil {
+		service = redirect.NewWorkflowServiceWrapper(service, options.ActiveClusterResolver)
+	}
+	if options != nil && options.ServiceWrapper != nil {
+		service = options.ServiceWrapper(service)
+	}
 	if options != nil && options.Authorization != nil {
 		service = auth.NewWorkflowServiceWrapper(service, options.Authorization)
 	}
 	if options != nil && options.IsolationGroup != "" {
 		service = isolationgroup.NewWorkflowServiceWrapper(service, options.IsolationGroup)
 	}
+	service = rpcheaders.NewWorkflowServiceWrapper(service, getHeaders(options))
+	service = newWorkflowServiceVersionWrapper(service)
 	service = metrics.NewWorkflowServiceWrapper(service, metricScope)
 	return &workflowClient{
 		workflowService:    service,
@@ -646,9 +733,17 @@ func NewDomainClient(service workflowserviceclient.Interface, options *ClientOpt
 		metricScope = options.MetricsScope
 	}
 	metricScope = tagScope(metricScope, tagDomain, "domain-client", clientImplHeaderName, clientImplHeaderValue)
+	if options != nil && options.ActiveClusterResolver != nil {
+		service = redirect.NewWorkflowServiceWrapper(service, options.ActiveClusterResolver)
+	}
+	if options != nil && options.ServiceWrapper != nil {
+		service = options.ServiceWrapper(service)
+	}
 	if options != nil && options.Authorization != nil {
 		service = auth.NewWorkflowServiceWrapper(service, options.Authorization)
 	}
+	service = rpcheaders.NewWorkflowServiceWrapper(service, getHeaders(options))
+	service = newWorkflowServiceVersionWrapper(service)
 	service = metrics.NewWorkflowServiceWrapper(service, metricScope)
 	return &domainClient{
 		workflowService: service,
@@ -658,6 +753,20 @@ func NewDomainClient(service workflowserviceclient.Interface, options *ClientOpt
 	}
 }
 
+// WithRPCHeaders returns a context that adds the given headers to the service calls made with it,
+// overriding ClientOptions.Headers with the same name.
+func WithRPCHeaders(ctx context.Context, headers map[string]string) context.Context {
+	return rpcheaders.WithHeaders(ctx, headers)
+}
+
+type domainContextKey struct{}
+
+// WithDomain returns a context that makes the Client calls made with it operate on the given domain
+// instead of the domain the client was created with.
+func WithDomain(ctx context.Context, domain string) context.Context {
+	return context.WithValue(ctx, domainContextKey{}, domain)
+}
+
 func (p WorkflowIDReusePolicy) toThriftPtr() *s.WorkflowIdReusePolicy {
 	var policy s.WorkflowIdReusePolicy
 	switch p {
@@ -33,6 +33,24 @@ type (
 	// Operation to retry
 	Operation func() error
 
+	// ContextOperation is an Operation that receives the context passed to RetryWithContext,
+	// so that a single attempt can be interrupted as well.
+	ContextOperation func(ctx context.Context) error
+
+	// RetryHook is called after a failed attempt that is going to be retried. attempt starts at 1
+	// for the first call of the operation, and next is the delay before the following attempt.
+	RetryHook func(attempt int, err error, next time.Duration)
+
+	// RetryOption configures RetryWithContext.
+	RetryOption func(*retryOptions)
+
+	retryOptions struct {
+		onRetry        RetryHook
+		clock          Clock
+		throttlePolicy RetryPolicy
+		isThrottled    IsRetryable
+	}
+
 	// IsRetryable handler can be used to exclude certain errors during retry
 	IsRetryable func(error) bool
 
@@ -89,20 +107,66 @@ func NewConcurrentRetrier(retryPolicy RetryPolicy) *ConcurrentRetrier {
 	return &ConcurrentRetrier{retrier: retrier}
 }
 
+// WithOnRetry registers a hook that is called before every retry, e.g. to log or emit metrics.
+func WithOnRetry(hook RetryHook) RetryOption {
+	return func(o *retryOptions) {
+		o.onRetry = hook
+	}
+}
+
+// WithClock overrides the clock used to measure the elapsed time against the policy's expiration interval.
+func WithClock(clock Clock) RetryOption {
+	return func(o *retryOptions) {
+		o.clock = clock
+	}
+}
+
+// WithThrottlePolicy uses a separate policy to compute delays after errors matched by isThrottled,
+// e.g. server-busy or rate-limit errors that warrant a longer backoff than other transient failures.
+// Both policies track their attempts independently and retrying stops as soon as either gives up.
+func WithThrottlePolicy(policy RetryPolicy, isThrottled IsRetryable) RetryOption {
+	return func(o *retryOptions) {
+		o.throttlePolicy = policy
+		o.isThrottled = isThrottled
+	}
+}
+
 // Retry function can be used to wrap any call with retry logic using the passed in policy
 func Retry(ctx context.Context, operation Operation, policy RetryPolicy, isRetriable IsRetryable) error {
+	return RetryWithContext(ctx, func(context.Context) error { return operation() }, policy, isRetriable)
+}
+
+// RetryWithContext calls operation until it succeeds, returns a non-retryable error, the policy
+// gives up or ctx is done. The operation is always called at least once, and the error of the
+// last attempt is returned. Waiting between attempts is interrupted as soon as ctx is done.
+func RetryWithContext(ctx context.Context, operation ContextOperation, policy RetryPolicy, isRetriable IsRetryable, opts ...RetryOption) error {
 	var err error
 	var next time.Duration
 
-	r := NewRetrier(policy, SystemClock)
+	options := retryOptions{clock: SystemClock}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	r := NewRetrier(policy, options.clock)
+	var throttleRetrier Retrier
+	if options.throttlePolicy != nil {
+		throttleRetrier = NewRetrier(options.throttlePolicy, options.clock)
+	}
+	attempt := 0
 Retry_Loop:
 	for {
+		attempt++
 		// operation completed successfully.  No need to retry.
-		if err = operation(); err == nil {
+		if err = operation(ctx); err == nil {
 			return nil
 		}
 
-		if next = r.NextBackOff(); next == done {
+		retrier := r
+		if throttleRetrier != nil && options.isThrottled(err) {
+			retrier = throttleRetrier
+		}
+		if next = retrier.NextBackOff(); next == done {
 			return err
 		}
 
@@ -128,6 +192,10 @@ Retry_Loop:
 			return err
 		}
 
+		if options.onRetry != nil {
+			options.onRetry(attempt, err, next)
+		}
+
 		// wait for the next retry period (or context timeout)
 		if ctxDone := ctx.Done(); ctxDone != nil {
 			// we could check if this is longer than context deadline and immediately fail...
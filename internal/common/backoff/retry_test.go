@@ -23,6 +23,7 @@ package backoff
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -115,6 +116,76 @@ func TestRetry(t *testing.T) {
 	}
 }
 
+func TestRetryWithContext_OnRetry(t *testing.T) {
+	t.Parallel()
+
+	type retry struct {
+		attempt int
+		next    time.Duration
+	}
+	var retries []retry
+	policy := NewExponentialRetryPolicy(time.Millisecond)
+	policy.SetJitter(0)
+	policy.SetMaximumAttempts(2)
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	calls := 0
+	err := RetryWithContext(ctx, func(opCtx context.Context) error {
+		calls++
+		assert.Equal(t, "value", opCtx.Value(ctxKey{}))
+		return &someError{}
+	}, policy, func(error) bool { return true }, WithOnRetry(func(attempt int, err error, next time.Duration) {
+		assert.IsType(t, &someError{}, err)
+		retries = append(retries, retry{attempt, next})
+	}))
+	assert.IsType(t, &someError{}, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []retry{{1, time.Millisecond}, {2, 2 * time.Millisecond}}, retries)
+}
+
+func TestRetryWithContext_CanceledWhileWaiting(t *testing.T) {
+	t.Parallel()
+
+	policy := NewExponentialRetryPolicy(time.Minute)
+	policy.SetExpirationInterval(NoInterval)
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Now()
+	err := RetryWithContext(ctx, func(context.Context) error {
+		return &someError{}
+	}, policy, func(error) bool { return true }, WithOnRetry(func(int, error, time.Duration) {
+		cancel()
+	}))
+	assert.IsType(t, &someError{}, err)
+	assert.Less(t, time.Since(start), time.Minute)
+}
+
+func TestRetryWithContext_ThrottlePolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := NewExponentialRetryPolicy(time.Millisecond)
+	policy.SetJitter(0)
+	throttlePolicy := NewExponentialRetryPolicy(100 * time.Millisecond)
+	throttlePolicy.SetJitter(0)
+	throttlePolicy.SetMaximumAttempts(1)
+
+	errs := []error{&someError{}, errThrottled, &someError{}, errThrottled, nil}
+	var delays []time.Duration
+	calls := 0
+	err := RetryWithContext(context.Background(), func(context.Context) error {
+		e := errs[calls]
+		calls++
+		return e
+	}, policy, func(error) bool { return true },
+		WithThrottlePolicy(throttlePolicy, func(err error) bool { return err == errThrottled }),
+		WithOnRetry(func(_ int, _ error, next time.Duration) { delays = append(delays, next) }))
+
+	// the second throttled error exceeds the throttle policy's attempts, regardless of the main policy
+	assert.Equal(t, errThrottled, err)
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, []time.Duration{time.Millisecond, 100 * time.Millisecond, 2 * time.Millisecond}, delays)
+}
+
 func TestConcurrentRetrier(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
@@ -162,6 +233,8 @@ func TestConcurrentRetrier(t *testing.T) {
 	}
 }
 
+var errThrottled = errors.New("throttled")
+
 type someError struct{}
 
 func (e *someError) Error() string {
@@ -50,6 +50,22 @@ func TestExponentialBackoff(t *testing.T) {
 	}
 }
 
+func TestJitter(t *testing.T) {
+	t.Parallel()
+	policy := createPolicy(time.Second)
+	policy.SetJitter(0)
+	r, _ := createRetrier(policy)
+	assert.Equal(t, time.Second, r.NextBackOff())
+	assert.Equal(t, 2*time.Second, r.NextBackOff())
+
+	policy.SetJitter(0.5)
+	for i := 0; i < 10; i++ {
+		next := policy.ComputeNextDelay(0, 2)
+		assert.True(t, next >= 2*time.Second, "NextBackoff too low")
+		assert.True(t, next < 4*time.Second, "NextBackoff too high")
+	}
+}
+
 func TestNumberOfAttempts(t *testing.T) {
 	t.Parallel()
 	policy := createPolicy(time.Second)
@@ -0,0 +1,541 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package faultinjection
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"go.uber.org/yarpc"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+type (
+	// Rule describes the faults injected into the calls of a set of RPCs.
+	Rule struct {
+		// Methods the rule applies to, e.g. "PollForDecisionTask". Empty applies the rule to every method.
+		Methods []string
+		// Latency is added to every matching call before it is sent.
+		Latency time.Duration
+		// ErrorRate is the fraction of matching calls, between 0 and 1, failing with Error without being sent.
+		ErrorRate float64
+		// DropRate is the fraction of matching calls, between 0 and 1, whose response is lost: the request
+		// reaches the service, but the caller gets Error. Use it to validate that retried calls are idempotent.
+		DropRate float64
+		// Error returned by failed and dropped calls.
+		// default: a transient *shared.InternalServiceError
+		Error error
+	}
+
+	// Config is the configuration of a fault injection wrapper.
+	Config struct {
+		Rules []Rule
+	}
+
+	workflowServiceFaultInjectionWrapper struct {
+		service workflowserviceclient.Interface
+		rules   []Rule
+	}
+)
+
+// NewWorkflowServiceWrapper creates a service wrapper injecting the faults described by config into calls to service.
+func NewWorkflowServiceWrapper(service workflowserviceclient.Interface, config Config) workflowserviceclient.Interface {
+	return &workflowServiceFaultInjectionWrapper{
+		service: service,
+		rules:   config.Rules,
+	}
+}
+
+func (r *Rule) matches(method string) bool {
+	if len(r.Methods) == 0 {
+		return true
+	}
+	for _, m := range r.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) err(method string) error {
+	if r.Error != nil {
+		return r.Error
+	}
+	return &shared.InternalServiceError{Message: "fault injected into " + method}
+}
+
+// beforeCall delays the call and decides whether it fails before being sent
+func (w *workflowServiceFaultInjectionWrapper) beforeCall(ctx context.Context, method string) error {
+	for i := range w.rules {
+		rule := &w.rules[i]
+		if !rule.matches(method) {
+			continue
+		}
+		if rule.Latency > 0 {
+			timer := time.NewTimer(rule.Latency)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+		if rule.ErrorRate > 0 && rand.Float64() < rule.ErrorRate {
+			return rule.err(method)
+		}
+	}
+	return nil
+}
+
+// afterCall decides whether the response of a successful call is lost
+func (w *workflowServiceFaultInjectionWrapper) afterCall(method string, err error) error {
+	if err != nil {
+		return err
+	}
+	for i := range w.rules {
+		rule := &w.rules[i]
+		if rule.matches(method) && rule.DropRate > 0 && rand.Float64() < rule.DropRate {
+			return rule.err(method)
+		}
+	}
+	return nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) CountWorkflowExecutions(ctx context.Context, request *shared.CountWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.CountWorkflowExecutionsResponse, error) {
+	if err := w.beforeCall(ctx, "CountWorkflowExecutions"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.CountWorkflowExecutions(ctx, request, opts...)
+	if err = w.afterCall("CountWorkflowExecutions", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) DeprecateDomain(ctx context.Context, request *shared.DeprecateDomainRequest, opts ...yarpc.CallOption) error {
+	if err := w.beforeCall(ctx, "DeprecateDomain"); err != nil {
+		return err
+	}
+	return w.afterCall("DeprecateDomain", w.service.DeprecateDomain(ctx, request, opts...))
+}
+
+func (w *workflowServiceFaultInjectionWrapper) DescribeDomain(ctx context.Context, request *shared.DescribeDomainRequest, opts ...yarpc.CallOption) (*shared.DescribeDomainResponse, error) {
+	if err := w.beforeCall(ctx, "DescribeDomain"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.DescribeDomain(ctx, request, opts...)
+	if err = w.afterCall("DescribeDomain", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) DescribeTaskList(ctx context.Context, request *shared.DescribeTaskListRequest, opts ...yarpc.CallOption) (*shared.DescribeTaskListResponse, error) {
+	if err := w.beforeCall(ctx, "DescribeTaskList"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.DescribeTaskList(ctx, request, opts...)
+	if err = w.afterCall("DescribeTaskList", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) DescribeWorkflowExecution(ctx context.Context, request *shared.DescribeWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.DescribeWorkflowExecutionResponse, error) {
+	if err := w.beforeCall(ctx, "DescribeWorkflowExecution"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.DescribeWorkflowExecution(ctx, request, opts...)
+	if err = w.afterCall("DescribeWorkflowExecution", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) GetClusterInfo(ctx context.Context, opts ...yarpc.CallOption) (*shared.ClusterInfo, error) {
+	if err := w.beforeCall(ctx, "GetClusterInfo"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.GetClusterInfo(ctx, opts...)
+	if err = w.afterCall("GetClusterInfo", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) GetSearchAttributes(ctx context.Context, opts ...yarpc.CallOption) (*shared.GetSearchAttributesResponse, error) {
+	if err := w.beforeCall(ctx, "GetSearchAttributes"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.GetSearchAttributes(ctx, opts...)
+	if err = w.afterCall("GetSearchAttributes", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) GetTaskListsByDomain(ctx context.Context, request *shared.GetTaskListsByDomainRequest, opts ...yarpc.CallOption) (*shared.GetTaskListsByDomainResponse, error) {
+	if err := w.beforeCall(ctx, "GetTaskListsByDomain"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.GetTaskListsByDomain(ctx, request, opts...)
+	if err = w.afterCall("GetTaskListsByDomain", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) GetWorkflowExecutionHistory(ctx context.Context, request *shared.GetWorkflowExecutionHistoryRequest, opts ...yarpc.CallOption) (*shared.GetWorkflowExecutionHistoryResponse, error) {
+	if err := w.beforeCall(ctx, "GetWorkflowExecutionHistory"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.GetWorkflowExecutionHistory(ctx, request, opts...)
+	if err = w.afterCall("GetWorkflowExecutionHistory", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) ListArchivedWorkflowExecutions(ctx context.Context, request *shared.ListArchivedWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListArchivedWorkflowExecutionsResponse, error) {
+	if err := w.beforeCall(ctx, "ListArchivedWorkflowExecutions"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.ListArchivedWorkflowExecutions(ctx, request, opts...)
+	if err = w.afterCall("ListArchivedWorkflowExecutions", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) ListClosedWorkflowExecutions(ctx context.Context, request *shared.ListClosedWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListClosedWorkflowExecutionsResponse, error) {
+	if err := w.beforeCall(ctx, "ListClosedWorkflowExecutions"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.ListClosedWorkflowExecutions(ctx, request, opts...)
+	if err = w.afterCall("ListClosedWorkflowExecutions", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) ListDomains(ctx context.Context, request *shared.ListDomainsRequest, opts ...yarpc.CallOption) (*shared.ListDomainsResponse, error) {
+	if err := w.beforeCall(ctx, "ListDomains"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.ListDomains(ctx, request, opts...)
+	if err = w.afterCall("ListDomains", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) ListOpenWorkflowExecutions(ctx context.Context, request *shared.ListOpenWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListOpenWorkflowExecutionsResponse, error) {
+	if err := w.beforeCall(ctx, "ListOpenWorkflowExecutions"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.ListOpenWorkflowExecutions(ctx, request, opts...)
+	if err = w.afterCall("ListOpenWorkflowExecutions", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) ListTaskListPartitions(ctx context.Context, request *shared.ListTaskListPartitionsRequest, opts ...yarpc.CallOption) (*shared.ListTaskListPartitionsResponse, error) {
+	if err := w.beforeCall(ctx, "ListTaskListPartitions"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.ListTaskListPartitions(ctx, request, opts...)
+	if err = w.afterCall("ListTaskListPartitions", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) ListWorkflowExecutions(ctx context.Context, request *shared.ListWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+	if err := w.beforeCall(ctx, "ListWorkflowExecutions"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.ListWorkflowExecutions(ctx, request, opts...)
+	if err = w.afterCall("ListWorkflowExecutions", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) PollForActivityTask(ctx context.Context, request *shared.PollForActivityTaskRequest, opts ...yarpc.CallOption) (*shared.PollForActivityTaskResponse, error) {
+	if err := w.beforeCall(ctx, "PollForActivityTask"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.PollForActivityTask(ctx, request, opts...)
+	if err = w.afterCall("PollForActivityTask", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) PollForDecisionTask(ctx context.Context, request *shared.PollForDecisionTaskRequest, opts ...yarpc.CallOption) (*shared.PollForDecisionTaskResponse, error) {
+	if err := w.beforeCall(ctx, "PollForDecisionTask"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.PollForDecisionTask(ctx, request, opts...)
+	if err = w.afterCall("PollForDecisionTask", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) QueryWorkflow(ctx context.Context, request *shared.QueryWorkflowRequest, opts ...yarpc.CallOption) (*shared.QueryWorkflowResponse, error) {
+	if err := w.beforeCall(ctx, "QueryWorkflow"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.QueryWorkflow(ctx, request, opts...)
+	if err = w.afterCall("QueryWorkflow", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RecordActivityTaskHeartbeat(ctx context.Context, request *shared.RecordActivityTaskHeartbeatRequest, opts ...yarpc.CallOption) (*shared.RecordActivityTaskHeartbeatResponse, error) {
+	if err := w.beforeCall(ctx, "RecordActivityTaskHeartbeat"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.RecordActivityTaskHeartbeat(ctx, request, opts...)
+	if err = w.afterCall("RecordActivityTaskHeartbeat", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RecordActivityTaskHeartbeatByID(ctx context.Context, request *shared.RecordActivityTaskHeartbeatByIDRequest, opts ...yarpc.CallOption) (*shared.RecordActivityTaskHeartbeatResponse, error) {
+	if err := w.beforeCall(ctx, "RecordActivityTaskHeartbeatByID"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.RecordActivityTaskHeartbeatByID(ctx, request, opts...)
+	if err = w.afterCall("RecordActivityTaskHeartbeatByID", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RefreshWorkflowTasks(ctx context.Context, request *shared.RefreshWorkflowTasksRequest, opts ...yarpc.CallOption) error {
+	if err := w.beforeCall(ctx, "RefreshWorkflowTasks"); err != nil {
+		return err
+	}
+	return w.afterCall("RefreshWorkflowTasks", w.service.RefreshWorkflowTasks(ctx, request, opts...))
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RegisterDomain(ctx context.Context, request *shared.RegisterDomainRequest, opts ...yarpc.CallOption) error {
+	if err := w.beforeCall(ctx, "RegisterDomain"); err != nil {
+		return err
+	}
+	return w.afterCall("RegisterDomain", w.service.RegisterDomain(ctx, request, opts...))
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RequestCancelWorkflowExecution(ctx context.Context, request *shared.RequestCancelWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	if err := w.beforeCall(ctx, "RequestCancelWorkflowExecution"); err != nil {
+		return err
+	}
+	return w.afterCall("RequestCancelWorkflowExecution", w.service.RequestCancelWorkflowExecution(ctx, request, opts...))
+}
+
+func (w *workflowServiceFaultInjectionWrapper) ResetStickyTaskList(ctx context.Context, request *shared.ResetStickyTaskListRequest, opts ...yarpc.CallOption) (*shared.ResetStickyTaskListResponse, error) {
+	if err := w.beforeCall(ctx, "ResetStickyTaskList"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.ResetStickyTaskList(ctx, request, opts...)
+	if err = w.afterCall("ResetStickyTaskList", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) ResetWorkflowExecution(ctx context.Context, request *shared.ResetWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.ResetWorkflowExecutionResponse, error) {
+	if err := w.beforeCall(ctx, "ResetWorkflowExecution"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.ResetWorkflowExecution(ctx, request, opts...)
+	if err = w.afterCall("ResetWorkflowExecution", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RespondActivityTaskCanceled(ctx context.Context, request *shared.RespondActivityTaskCanceledRequest, opts ...yarpc.CallOption) error {
+	if err := w.beforeCall(ctx, "RespondActivityTaskCanceled"); err != nil {
+		return err
+	}
+	return w.afterCall("RespondActivityTaskCanceled", w.service.RespondActivityTaskCanceled(ctx, request, opts...))
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RespondActivityTaskCanceledByID(ctx context.Context, request *shared.RespondActivityTaskCanceledByIDRequest, opts ...yarpc.CallOption) error {
+	if err := w.beforeCall(ctx, "RespondActivityTaskCanceledByID"); err != nil {
+		return err
+	}
+	return w.afterCall("RespondActivityTaskCanceledByID", w.service.RespondActivityTaskCanceledByID(ctx, request, opts...))
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RespondActivityTaskCompleted(ctx context.Context, request *shared.RespondActivityTaskCompletedRequest, opts ...yarpc.CallOption) error {
+	if err := w.beforeCall(ctx, "RespondActivityTaskCompleted"); err != nil {
+		return err
+	}
+	return w.afterCall("RespondActivityTaskCompleted", w.service.RespondActivityTaskCompleted(ctx, request, opts...))
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RespondActivityTaskCompletedByID(ctx context.Context, request *shared.RespondActivityTaskCompletedByIDRequest, opts ...yarpc.CallOption) error {
+	if err := w.beforeCall(ctx, "RespondActivityTaskCompletedByID"); err != nil {
+		return err
+	}
+	return w.afterCall("RespondActivityTaskCompletedByID", w.service.RespondActivityTaskCompletedByID(ctx, request, opts...))
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RespondActivityTaskFailed(ctx context.Context, request *shared.RespondActivityTaskFailedRequest, opts ...yarpc.CallOption) error {
+	if err := w.beforeCall(ctx, "RespondActivityTaskFailed"); err != nil {
+		return err
+	}
+	return w.afterCall("RespondActivityTaskFailed", w.service.RespondActivityTaskFailed(ctx, request, opts...))
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RespondActivityTaskFailedByID(ctx context.Context, request *shared.RespondActivityTaskFailedByIDRequest, opts ...yarpc.CallOption) error {
+	if err := w.beforeCall(ctx, "RespondActivityTaskFailedByID"); err != nil {
+		return err
+	}
+	return w.afterCall("RespondActivityTaskFailedByID", w.service.RespondActivityTaskFailedByID(ctx, request, opts...))
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RespondDecisionTaskCompleted(ctx context.Context, request *shared.RespondDecisionTaskCompletedRequest, opts ...yarpc.CallOption) (*shared.RespondDecisionTaskCompletedResponse, error) {
+	if err := w.beforeCall(ctx, "RespondDecisionTaskCompleted"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.RespondDecisionTaskCompleted(ctx, request, opts...)
+	if err = w.afterCall("RespondDecisionTaskCompleted", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RespondDecisionTaskFailed(ctx context.Context, request *shared.RespondDecisionTaskFailedRequest, opts ...yarpc.CallOption) error {
+	if err := w.beforeCall(ctx, "RespondDecisionTaskFailed"); err != nil {
+		return err
+	}
+	return w.afterCall("RespondDecisionTaskFailed", w.service.RespondDecisionTaskFailed(ctx, request, opts...))
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RespondQueryTaskCompleted(ctx context.Context, request *shared.RespondQueryTaskCompletedRequest, opts ...yarpc.CallOption) error {
+	if err := w.beforeCall(ctx, "RespondQueryTaskCompleted"); err != nil {
+		return err
+	}
+	return w.afterCall("RespondQueryTaskCompleted", w.service.RespondQueryTaskCompleted(ctx, request, opts...))
+}
+
+func (w *workflowServiceFaultInjectionWrapper) RestartWorkflowExecution(ctx context.Context, request *shared.RestartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.RestartWorkflowExecutionResponse, error) {
+	if err := w.beforeCall(ctx, "RestartWorkflowExecution"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.RestartWorkflowExecution(ctx, request, opts...)
+	if err = w.afterCall("RestartWorkflowExecution", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) ScanWorkflowExecutions(ctx context.Context, request *shared.ListWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+	if err := w.beforeCall(ctx, "ScanWorkflowExecutions"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.ScanWorkflowExecutions(ctx, request, opts...)
+	if err = w.afterCall("ScanWorkflowExecutions", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) SignalWithStartWorkflowExecution(ctx context.Context, request *shared.SignalWithStartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionResponse, error) {
+	if err := w.beforeCall(ctx, "SignalWithStartWorkflowExecution"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.SignalWithStartWorkflowExecution(ctx, request, opts...)
+	if err = w.afterCall("SignalWithStartWorkflowExecution", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) SignalWithStartWorkflowExecutionAsync(ctx context.Context, request *shared.SignalWithStartWorkflowExecutionAsyncRequest, opts ...yarpc.CallOption) (*shared.SignalWithStartWorkflowExecutionAsyncResponse, error) {
+	if err := w.beforeCall(ctx, "SignalWithStartWorkflowExecutionAsync"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.SignalWithStartWorkflowExecutionAsync(ctx, request, opts...)
+	if err = w.afterCall("SignalWithStartWorkflowExecutionAsync", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) SignalWorkflowExecution(ctx context.Context, request *shared.SignalWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	if err := w.beforeCall(ctx, "SignalWorkflowExecution"); err != nil {
+		return err
+	}
+	return w.afterCall("SignalWorkflowExecution", w.service.SignalWorkflowExecution(ctx, request, opts...))
+}
+
+func (w *workflowServiceFaultInjectionWrapper) StartWorkflowExecution(ctx context.Context, request *shared.StartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionResponse, error) {
+	if err := w.beforeCall(ctx, "StartWorkflowExecution"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.StartWorkflowExecution(ctx, request, opts...)
+	if err = w.afterCall("StartWorkflowExecution", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) StartWorkflowExecutionAsync(ctx context.Context, request *shared.StartWorkflowExecutionAsyncRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionAsyncResponse, error) {
+	if err := w.beforeCall(ctx, "StartWorkflowExecutionAsync"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.StartWorkflowExecutionAsync(ctx, request, opts...)
+	if err = w.afterCall("StartWorkflowExecutionAsync", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w *workflowServiceFaultInjectionWrapper) TerminateWorkflowExecution(ctx context.Context, request *shared.TerminateWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	if err := w.beforeCall(ctx, "TerminateWorkflowExecution"); err != nil {
+		return err
+	}
+	return w.afterCall("TerminateWorkflowExecution", w.service.TerminateWorkflowExecution(ctx, request, opts...))
+}
+
+func (w *workflowServiceFaultInjectionWrapper) UpdateDomain(ctx context.Context, request *shared.UpdateDomainRequest, opts ...yarpc.CallOption) (*shared.UpdateDomainResponse, error) {
+	if err := w.beforeCall(ctx, "UpdateDomain"); err != nil {
+		return nil, err
+	}
+	result, err := w.service.UpdateDomain(ctx, request, opts...)
+	if err = w.afterCall("UpdateDomain", err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package faultinjection
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+func TestServiceWrapper(t *testing.T) {
+	t.Run("error is returned without calling the service", func(t *testing.T) {
+		service := workflowservicetest.NewMockClient(gomock.NewController(t))
+		wrapper := NewWorkflowServiceWrapper(service, Config{Rules: []Rule{
+			{Methods: []string{"PollForDecisionTask"}, ErrorRate: 1},
+		}})
+
+		_, err := wrapper.PollForDecisionTask(context.Background(), &shared.PollForDecisionTaskRequest{})
+		assert.IsType(t, &shared.InternalServiceError{}, err)
+	})
+	t.Run("other methods are not affected", func(t *testing.T) {
+		service := workflowservicetest.NewMockClient(gomock.NewController(t))
+		wrapper := NewWorkflowServiceWrapper(service, Config{Rules: []Rule{
+			{Methods: []string{"PollForDecisionTask"}, ErrorRate: 1},
+		}})
+		response := &shared.PollForActivityTaskResponse{}
+		service.EXPECT().PollForActivityTask(gomock.Any(), gomock.Any()).Return(response, nil)
+
+		result, err := wrapper.PollForActivityTask(context.Background(), &shared.PollForActivityTaskRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, response, result)
+	})
+	t.Run("dropped response after calling the service", func(t *testing.T) {
+		service := workflowservicetest.NewMockClient(gomock.NewController(t))
+		injected := errors.New("injected")
+		wrapper := NewWorkflowServiceWrapper(service, Config{Rules: []Rule{
+			{DropRate: 1, Error: injected},
+		}})
+		service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any()).Return(&shared.StartWorkflowExecutionResponse{}, nil)
+		service.EXPECT().SignalWorkflowExecution(gomock.Any(), gomock.Any()).Return(nil)
+
+		result, err := wrapper.StartWorkflowExecution(context.Background(), &shared.StartWorkflowExecutionRequest{})
+		assert.Equal(t, injected, err)
+		assert.Nil(t, result)
+		assert.Equal(t, injected, wrapper.SignalWorkflowExecution(context.Background(), &shared.SignalWorkflowExecutionRequest{}))
+	})
+	t.Run("service errors are passed through", func(t *testing.T) {
+		service := workflowservicetest.NewMockClient(gomock.NewController(t))
+		wrapper := NewWorkflowServiceWrapper(service, Config{Rules: []Rule{{DropRate: 1}}})
+		serviceErr := &shared.EntityNotExistsError{}
+		service.EXPECT().GetSearchAttributes(gomock.Any()).Return(nil, serviceErr)
+
+		_, err := wrapper.GetSearchAttributes(context.Background())
+		assert.Equal(t, serviceErr, err)
+	})
+	t.Run("latency", func(t *testing.T) {
+		service := workflowservicetest.NewMockClient(gomock.NewController(t))
+		wrapper := NewWorkflowServiceWrapper(service, Config{Rules: []Rule{{Latency: 50 * time.Millisecond}}})
+		service.EXPECT().DescribeDomain(gomock.Any(), gomock.Any()).Return(&shared.DescribeDomainResponse{}, nil)
+
+		start := time.Now()
+		_, err := wrapper.DescribeDomain(context.Background(), &shared.DescribeDomainRequest{})
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+	t.Run("latency respects the context", func(t *testing.T) {
+		service := workflowservicetest.NewMockClient(gomock.NewController(t))
+		wrapper := NewWorkflowServiceWrapper(service, Config{Rules: []Rule{{Latency: time.Minute}}})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := wrapper.DescribeDomain(ctx, &shared.DescribeDomainRequest{})
+		assert.Equal(t, context.DeadlineExceeded, err)
+	})
+}
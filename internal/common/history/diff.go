@@ -0,0 +1,159 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package history
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+)
+
+// runSpecificFields are the attributes which differ between runs taking the same path through the workflow code,
+// e.g. because they identify the run or the worker, or describe how the run was started, and are thus ignored
+// by Diff.
+var runSpecificFields = map[string]struct{}{
+	"identity":                        {},
+	"requestId":                       {},
+	"binaryChecksum":                  {},
+	"taskList":                        {},
+	"header":                          {},
+	"workflowId":                      {},
+	"workflowExecution":               {},
+	"parentWorkflowExecution":         {},
+	"parentInitiatedEventId":          {},
+	"originalExecutionRunId":          {},
+	"firstExecutionRunId":             {},
+	"continuedExecutionRunId":         {},
+	"newExecutionRunId":               {},
+	"prevAutoResetPoints":             {},
+	"firstScheduledTimeNano":          {},
+	"attempt":                         {},
+	"firstDecisionTaskBackoffSeconds": {},
+	"initiator":                       {},
+	"continuedFailureReason":          {},
+	"cronSchedule":                    {},
+	"partitionConfig":                 {},
+}
+
+type (
+	// DiffOptions configure how Diff compares histories.
+	DiffOptions struct {
+		// ComparePayloads also compares the payloads of the events (inputs, results, details...). By default only
+		// the path taken through the workflow code is compared, as runs usually differ by their inputs.
+		ComparePayloads bool
+	}
+
+	// Divergence is the first difference found by Diff between two histories.
+	Divergence struct {
+		// Index of the diverging events in both histories.
+		Index int
+		// A and B are the diverging events of both histories, or nil if the history has no more events.
+		A, B *s.HistoryEvent
+		// Fields are the names of the differing attributes when both events have the same type.
+		Fields []string
+	}
+)
+
+// Diff compares the histories of two runs of the same workflow type event by event, and returns the first event
+// which differs by its type or by its attributes, or nil if both histories are the same. Attributes which are
+// specific to a run, such as run IDs, timestamps, attempts, worker identities and task lists, are not compared.
+func Diff(a, b *s.History, options *DiffOptions) *Divergence {
+	if options == nil {
+		options = &DiffOptions{}
+	}
+	eventsA, eventsB := a.GetEvents(), b.GetEvents()
+	for i := 0; i < len(eventsA) || i < len(eventsB); i++ {
+		if i >= len(eventsA) {
+			return &Divergence{Index: i, B: eventsB[i]}
+		}
+		if i >= len(eventsB) {
+			return &Divergence{Index: i, A: eventsA[i]}
+		}
+		eventA, eventB := eventsA[i], eventsB[i]
+		if eventA.GetEventType() != eventB.GetEventType() {
+			return &Divergence{Index: i, A: eventA, B: eventB}
+		}
+		if fields := diffAttributes(eventA, eventB, options); len(fields) > 0 {
+			return &Divergence{Index: i, A: eventA, B: eventB, Fields: fields}
+		}
+	}
+	return nil
+}
+
+// diffAttributes returns the names of the attributes which differ between two events of the same type.
+func diffAttributes(a, b *s.HistoryEvent, options *DiffOptions) []string {
+	attributesA, attributesB := getAttributes(a), getAttributes(b)
+	if !attributesA.IsValid() || !attributesB.IsValid() {
+		return nil
+	}
+	var fields []string
+	for i := 0; i < attributesA.NumField(); i++ {
+		name := fieldName(attributesA.Type().Field(i))
+		fieldA, fieldB := attributesA.Field(i), attributesB.Field(i)
+		if _, ok := runSpecificFields[name]; ok || strings.HasSuffix(name, "Timestamp") {
+			continue
+		}
+		if !options.ComparePayloads && isPayloadType(fieldA.Type()) {
+			continue
+		}
+		if !reflect.DeepEqual(fieldA.Interface(), fieldB.Interface()) {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// isPayloadType reports whether values of t only hold payloads: byte slices, maps of them, e.g. the fields of
+// a memo, or structs made of them.
+func isPayloadType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	case reflect.Map, reflect.Ptr:
+		return isPayloadType(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !isPayloadType(t.Field(i).Type) {
+				return false
+			}
+		}
+		return t.NumField() > 0
+	}
+	return false
+}
+
+// String describes the divergence with both events rendered as by Format.
+func (d *Divergence) String() string {
+	f := formatter{previewLength: defaultPayloadPreviewLength}
+	describe := func(event *s.HistoryEvent) string {
+		if event == nil {
+			return "<no more events>"
+		}
+		return fmt.Sprintf("%d %v %s", event.GetEventId(), event.GetEventType(), f.formatAttributes(event))
+	}
+	var differing string
+	if len(d.Fields) > 0 {
+		differing = fmt.Sprintf(" in %s", strings.Join(d.Fields, ", "))
+	}
+	return fmt.Sprintf("histories diverge at event index %d%s:\n- %s\n+ %s", d.Index, differing, describe(d.A), describe(d.B))
+}
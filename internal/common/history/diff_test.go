@@ -0,0 +1,118 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package history
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestDiff(t *testing.T) {
+	t.Run("same path", func(t *testing.T) {
+		b := testHistory()
+		for _, event := range b.Events {
+			event.Timestamp = common.Int64Ptr(event.GetTimestamp() + int64(time.Hour))
+		}
+		b.Events[2].ActivityTaskStartedEventAttributes.Identity = common.StringPtr("worker 2")
+		b.Events[1].ActivityTaskScheduledEventAttributes.TaskList = &s.TaskList{Name: common.StringPtr("sticky")}
+		assert.Nil(t, Diff(testHistory(), b, nil))
+	})
+	t.Run("different attributes", func(t *testing.T) {
+		b := testHistory()
+		b.Events[1].ActivityTaskScheduledEventAttributes.ActivityType = &s.ActivityType{Name: common.StringPtr("Greet2")}
+		divergence := Diff(testHistory(), b, nil)
+		require.NotNil(t, divergence)
+		assert.Equal(t, 1, divergence.Index)
+		assert.Equal(t, []string{"activityType"}, divergence.Fields)
+		input := `input="` + strings.Repeat("x", 64) + `"...<70 bytes>`
+		assert.Equal(t, "histories diverge at event index 1 in activityType:\n"+
+			"- 2 ActivityTaskScheduled activityId=0 activityType=Greet taskList={name=tl kind=NORMAL} "+input+"\n"+
+			"+ 2 ActivityTaskScheduled activityId=0 activityType=Greet2 taskList={name=tl kind=NORMAL} "+input,
+			divergence.String())
+	})
+	t.Run("different event types", func(t *testing.T) {
+		b := testHistory()
+		b.Events[3] = newEvent(4, 2*time.Second, s.EventTypeActivityTaskFailed, func(e *s.HistoryEvent) {
+			e.ActivityTaskFailedEventAttributes = &s.ActivityTaskFailedEventAttributes{
+				Reason:           common.StringPtr("cadenceInternal:Generic"),
+				ScheduledEventId: common.Int64Ptr(2),
+				StartedEventId:   common.Int64Ptr(3),
+			}
+		})
+		divergence := Diff(testHistory(), b, nil)
+		require.NotNil(t, divergence)
+		assert.Equal(t, 3, divergence.Index)
+		assert.Equal(t, s.EventTypeActivityTaskCompleted, divergence.A.GetEventType())
+		assert.Equal(t, s.EventTypeActivityTaskFailed, divergence.B.GetEventType())
+		assert.Empty(t, divergence.Fields)
+	})
+	t.Run("shorter history", func(t *testing.T) {
+		b := testHistory()
+		b.Events = b.Events[:2]
+		divergence := Diff(testHistory(), b, nil)
+		require.NotNil(t, divergence)
+		assert.Equal(t, 2, divergence.Index)
+		assert.Nil(t, divergence.B)
+		assert.Contains(t, divergence.String(), "+ <no more events>")
+	})
+	t.Run("payloads", func(t *testing.T) {
+		b := testHistory()
+		b.Events[0].WorkflowExecutionStartedEventAttributes.Input = []byte(`"cadence"`)
+		assert.Nil(t, Diff(testHistory(), b, nil))
+		divergence := Diff(testHistory(), b, &DiffOptions{ComparePayloads: true})
+		require.NotNil(t, divergence)
+		assert.Equal(t, []string{"input"}, divergence.Fields)
+	})
+	t.Run("started events of different runs", func(t *testing.T) {
+		started := func(runID string, attempt int32, initiator s.ContinueAsNewInitiator, scheduled time.Time) *s.History {
+			h := testHistory()
+			attributes := h.Events[0].WorkflowExecutionStartedEventAttributes
+			attributes.ParentInitiatedEventId = common.Int64Ptr(int64(attempt) + 5)
+			attributes.ContinuedExecutionRunId = common.StringPtr(runID + "-previous")
+			attributes.OriginalExecutionRunId = common.StringPtr(runID)
+			attributes.FirstExecutionRunId = common.StringPtr("first-" + runID)
+			attributes.Identity = common.StringPtr("client " + runID)
+			attributes.Attempt = common.Int32Ptr(attempt)
+			attributes.FirstScheduledTimeNano = common.Int64Ptr(scheduled.UnixNano())
+			attributes.FirstDecisionTaskBackoffSeconds = common.Int32Ptr(attempt * 10)
+			attributes.Initiator = initiator.Ptr()
+			attributes.ContinuedFailureReason = common.StringPtr("reason " + runID)
+			attributes.CronSchedule = common.StringPtr("*/" + runID + " * * * *")
+			attributes.Memo = &s.Memo{Fields: map[string][]byte{"run": []byte(runID)}}
+			attributes.SearchAttributes = &s.SearchAttributes{IndexedFields: map[string][]byte{"CustomKeywordField": []byte(runID)}}
+			return h
+		}
+		a := started("1", 0, s.ContinueAsNewInitiatorDecider, time.Unix(1700000000, 0))
+		b := started("2", 3, s.ContinueAsNewInitiatorRetryPolicy, time.Unix(1700003600, 0))
+		assert.Nil(t, Diff(a, b, nil))
+		divergence := Diff(a, b, &DiffOptions{ComparePayloads: true})
+		require.NotNil(t, divergence)
+		assert.Equal(t, 0, divergence.Index)
+		assert.Equal(t, []string{"memo", "searchAttributes"}, divergence.Fields)
+	})
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package history renders workflow histories as readable timelines and compares them, for debugging tools built on
+// top of the client.
+package history
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+)
+
+const defaultPayloadPreviewLength = 64
+
+// FormatOptions configure how Format renders a history.
+type FormatOptions struct {
+	// EventTypes restricts the rendered events to the given types. default: all events.
+	// References to events which are not rendered are still annotated with their event type.
+	EventTypes []s.EventType
+	// PayloadPreviewLength is the number of bytes of each payload (inputs, results, details...) which is rendered.
+	// Longer payloads are truncated, binary payloads are only rendered by their size.
+	// default: 64, set it to a negative value to only render the size of all payloads.
+	PayloadPreviewLength int
+}
+
+// FilterEvents returns the events of the given types, in the order of the history.
+func FilterEvents(events []*s.HistoryEvent, eventTypes ...s.EventType) []*s.HistoryEvent {
+	types := make(map[s.EventType]struct{}, len(eventTypes))
+	for _, eventType := range eventTypes {
+		types[eventType] = struct{}{}
+	}
+	var filtered []*s.HistoryEvent
+	for _, event := range events {
+		if _, ok := types[event.GetEventType()]; ok {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// Format writes history to w as a timeline, one event per line:
+//
+//	5  +12ms  ActivityTaskScheduled  activityId=0 activityType=Greet taskList=tl input="\"world\"" ...
+//	6  +20ms  ActivityTaskStarted    scheduledEventId=5(ActivityTaskScheduled) identity=worker-1 attempt=0
+//	7  +1.5s  ActivityTaskCompleted  result="\"hello world\"" scheduledEventId=5(ActivityTaskScheduled) ...
+//
+// Timestamps are relative to the first event of the history, and references to other events are annotated with
+// the type of the referenced event.
+func Format(w io.Writer, history *s.History, options *FormatOptions) error {
+	if options == nil {
+		options = &FormatOptions{}
+	}
+	events := history.GetEvents()
+	f := formatter{
+		eventTypes:    make(map[int64]s.EventType, len(events)),
+		previewLength: options.PayloadPreviewLength,
+	}
+	if f.previewLength == 0 {
+		f.previewLength = defaultPayloadPreviewLength
+	}
+	for _, event := range events {
+		f.eventTypes[event.GetEventId()] = event.GetEventType()
+	}
+	if len(options.EventTypes) > 0 {
+		events = FilterEvents(events, options.EventTypes...)
+	}
+
+	var start int64
+	if first := history.GetEvents(); len(first) > 0 {
+		start = first[0].GetTimestamp()
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, event := range events {
+		elapsed := time.Duration(event.GetTimestamp() - start).Round(time.Millisecond)
+		if _, err := fmt.Fprintf(tw, "%d\t+%v\t%v\t%s\n",
+			event.GetEventId(), elapsed, event.GetEventType(), f.formatAttributes(event)); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+type formatter struct {
+	eventTypes    map[int64]s.EventType
+	previewLength int
+}
+
+// formatAttributes renders the non-empty fields of the attributes of event.
+func (f *formatter) formatAttributes(event *s.HistoryEvent) string {
+	if attributes := getAttributes(event); attributes.IsValid() {
+		return strings.Join(f.formatFields(attributes, true), " ")
+	}
+	return ""
+}
+
+// getAttributes returns the attributes struct set on event, or the zero Value if there is none.
+func getAttributes(event *s.HistoryEvent) reflect.Value {
+	v := reflect.ValueOf(event).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if strings.HasSuffix(v.Type().Field(i).Name, "EventAttributes") && !field.IsNil() {
+			return field.Elem()
+		}
+	}
+	return reflect.Value{}
+}
+
+// formatFields renders the non-empty fields of a thrift struct as name=value pairs.
+func (f *formatter) formatFields(v reflect.Value, topLevel bool) []string {
+	var fields []string
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if isEmpty(field) {
+			continue
+		}
+		name := fieldName(v.Type().Field(i))
+		value := f.formatValue(field)
+		if topLevel && strings.HasSuffix(name, "EventId") && field.Kind() == reflect.Ptr {
+			if eventType, ok := f.eventTypes[field.Elem().Int()]; ok {
+				value = fmt.Sprintf("%s(%v)", value, eventType)
+			}
+		}
+		fields = append(fields, name+"="+value)
+	}
+	return fields
+}
+
+func (f *formatter) formatValue(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return "nil"
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		return f.formatPayload(v.Bytes())
+	}
+	if stringer, ok := v.Interface().(fmt.Stringer); ok && v.Kind() == reflect.Ptr && v.Elem().Kind() != reflect.Struct {
+		// enums
+		return stringer.String()
+	}
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return formatString(v.String())
+	case reflect.Struct:
+		fields := f.formatFields(v, false)
+		if name := v.FieldByName("Name"); len(fields) == 1 && name.IsValid() && !isEmpty(name) {
+			// e.g. the WorkflowType, ActivityType and TaskList of an event
+			return formatString(name.Elem().String())
+		}
+		return "{" + strings.Join(fields, " ") + "}"
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]string, v.Len())
+		for _, key := range v.MapKeys() {
+			k := fmt.Sprint(key.Interface())
+			keys = append(keys, k)
+			values[k] = f.formatValue(v.MapIndex(key))
+		}
+		sort.Strings(keys)
+		for i, key := range keys {
+			keys[i] = formatString(key) + "=" + values[key]
+		}
+		return "{" + strings.Join(keys, " ") + "}"
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = f.formatValue(v.Index(i))
+		}
+		return "[" + strings.Join(items, " ") + "]"
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// formatPayload renders a preview of data, cut to the preview length.
+func (f *formatter) formatPayload(data []byte) string {
+	if f.previewLength < 0 || !utf8.Valid(data) {
+		return fmt.Sprintf("<%d bytes>", len(data))
+	}
+	if len(data) <= f.previewLength {
+		return strconv.Quote(string(data))
+	}
+	cut := f.previewLength
+	for cut > 0 && !utf8.RuneStart(data[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s...<%d bytes>", strconv.Quote(string(data[:cut])), len(data))
+}
+
+// formatString quotes s only when needed to keep the name=value pairs readable.
+func formatString(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// fieldName returns the name of the field in the JSON histories, e.g. activityId for the ActivityId field.
+func fieldName(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		if name := strings.Split(tag, ",")[0]; name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// isEmpty reports whether v is unset, including structs without any set field, e.g. an empty Header.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return true
+		}
+		if elem := v.Elem(); elem.Kind() == reflect.Struct {
+			for i := 0; i < elem.NumField(); i++ {
+				if !isEmpty(elem.Field(i)) {
+					return false
+				}
+			}
+			return true
+		}
+		return false
+	case reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func newEvent(id int64, elapsed time.Duration, eventType s.EventType, setAttributes func(*s.HistoryEvent)) *s.HistoryEvent {
+	event := &s.HistoryEvent{
+		EventId:   common.Int64Ptr(id),
+		Timestamp: common.Int64Ptr(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(elapsed).UnixNano()),
+		EventType: eventType.Ptr(),
+	}
+	setAttributes(event)
+	return event
+}
+
+func testHistory() *s.History {
+	return &s.History{Events: []*s.HistoryEvent{
+		newEvent(1, 0, s.EventTypeWorkflowExecutionStarted, func(e *s.HistoryEvent) {
+			e.WorkflowExecutionStartedEventAttributes = &s.WorkflowExecutionStartedEventAttributes{
+				WorkflowType: &s.WorkflowType{Name: common.StringPtr("GreetingWorkflow")},
+				TaskList:     &s.TaskList{Name: common.StringPtr("tl")},
+				Input:        []byte(`"world"`),
+				Header:       &s.Header{Fields: map[string][]byte{"b": []byte("2"), "a": []byte("1")}},
+			}
+		}),
+		newEvent(2, time.Millisecond, s.EventTypeActivityTaskScheduled, func(e *s.HistoryEvent) {
+			e.ActivityTaskScheduledEventAttributes = &s.ActivityTaskScheduledEventAttributes{
+				ActivityId:   common.StringPtr("0"),
+				ActivityType: &s.ActivityType{Name: common.StringPtr("Greet")},
+				TaskList:     &s.TaskList{Name: common.StringPtr("tl"), Kind: s.TaskListKindNormal.Ptr()},
+				Input:        []byte(strings.Repeat("x", 70)),
+			}
+		}),
+		newEvent(3, 1500*time.Millisecond, s.EventTypeActivityTaskStarted, func(e *s.HistoryEvent) {
+			e.ActivityTaskStartedEventAttributes = &s.ActivityTaskStartedEventAttributes{
+				ScheduledEventId: common.Int64Ptr(2),
+				Identity:         common.StringPtr("worker 1"),
+				Attempt:          common.Int32Ptr(0),
+			}
+		}),
+		newEvent(4, 2*time.Second, s.EventTypeActivityTaskCompleted, func(e *s.HistoryEvent) {
+			e.ActivityTaskCompletedEventAttributes = &s.ActivityTaskCompletedEventAttributes{
+				Result:           []byte{0xff, 0x00},
+				ScheduledEventId: common.Int64Ptr(2),
+				StartedEventId:   common.Int64Ptr(3),
+			}
+		}),
+	}}
+}
+
+func TestFormat(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Format(&buf, testHistory(), nil))
+	assert.Equal(t, strings.Join([]string{
+		`1  +0s    WorkflowExecutionStarted  workflowType=GreetingWorkflow taskList=tl input="\"world\"" header={fields={a="1" b="2"}}`,
+		`2  +1ms   ActivityTaskScheduled     activityId=0 activityType=Greet taskList={name=tl kind=NORMAL} input="` + strings.Repeat("x", 64) + `"...<70 bytes>`,
+		`3  +1.5s  ActivityTaskStarted       scheduledEventId=2(ActivityTaskScheduled) identity="worker 1" attempt=0`,
+		`4  +2s    ActivityTaskCompleted     result=<2 bytes> scheduledEventId=2(ActivityTaskScheduled) startedEventId=3(ActivityTaskStarted)`,
+		``,
+	}, "\n"), buf.String())
+}
+
+func TestFormat_Options(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Format(&buf, testHistory(), &FormatOptions{
+		EventTypes:           []s.EventType{s.EventTypeWorkflowExecutionStarted, s.EventTypeActivityTaskCompleted},
+		PayloadPreviewLength: -1,
+	}))
+	assert.Equal(t, strings.Join([]string{
+		`1  +0s  WorkflowExecutionStarted  workflowType=GreetingWorkflow taskList=tl input=<7 bytes> header={fields={a=<1 bytes> b=<1 bytes>}}`,
+		`4  +2s  ActivityTaskCompleted     result=<2 bytes> scheduledEventId=2(ActivityTaskScheduled) startedEventId=3(ActivityTaskStarted)`,
+		``,
+	}, "\n"), buf.String())
+}
+
+func TestFormat_Testdata(t *testing.T) {
+	files, err := filepath.Glob("../../testdata/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			var history s.History
+			require.NoError(t, json.Unmarshal(data, &history.Events))
+
+			var buf bytes.Buffer
+			require.NoError(t, Format(&buf, &history, nil))
+			assert.Equal(t, len(history.Events), strings.Count(buf.String(), "\n"))
+		})
+	}
+}
+
+func TestFormat_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Format(&buf, &s.History{}, nil))
+	assert.Empty(t, buf.String())
+}
+
+func TestFilterEvents(t *testing.T) {
+	events := FilterEvents(testHistory().Events, s.EventTypeActivityTaskStarted, s.EventTypeActivityTaskCompleted)
+	require.Len(t, events, 2)
+	assert.Equal(t, int64(3), events[0].GetEventId())
+	assert.Equal(t, int64(4), events[1].GetEventId())
+	assert.Empty(t, FilterEvents(testHistory().Events))
+}
@@ -0,0 +1,929 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package inmemory implements a workflow service keeping all state in process memory. It supports the RPCs used by
+// workers and clients to start workflows, process decision and activity tasks, and read their history, so that
+// workflows can run end-to-end in integration tests without a Cadence cluster.
+package inmemory
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+	"go.uber.org/yarpc"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+// decisionRetryDelay is the delay before a failed decision task is dispatched again.
+const decisionRetryDelay = time.Second
+
+var supportedDecisions = map[shared.DecisionType]struct{}{
+	shared.DecisionTypeScheduleActivityTask:           {},
+	shared.DecisionTypeRequestCancelActivityTask:      {},
+	shared.DecisionTypeStartTimer:                     {},
+	shared.DecisionTypeCancelTimer:                    {},
+	shared.DecisionTypeRecordMarker:                   {},
+	shared.DecisionTypeUpsertWorkflowSearchAttributes: {},
+	shared.DecisionTypeCompleteWorkflowExecution:      {},
+	shared.DecisionTypeFailWorkflowExecution:          {},
+	shared.DecisionTypeCancelWorkflowExecution:        {},
+}
+
+type (
+	service struct {
+		unimplementedService
+
+		sync.Mutex
+		executions    map[executionKey]*execution
+		currentRuns   map[workflowKey]string
+		decisionTasks map[taskListKey][]*execution
+		activityTasks map[taskListKey][]*activityTask
+		// changed is closed and replaced on every state change, to wake up long polls.
+		changed chan struct{}
+	}
+
+	workflowKey struct {
+		domain     string
+		workflowID string
+	}
+
+	executionKey struct {
+		domain     string
+		workflowID string
+		runID      string
+	}
+
+	taskListKey struct {
+		domain   string
+		taskList string
+	}
+
+	execution struct {
+		key             executionKey
+		requestID       string
+		workflowType    *shared.WorkflowType
+		taskList        string
+		decisionTimeout int32
+		history         []*shared.HistoryEvent
+		// buffered records the events received while a decision task is running, they are appended to the
+		// history once the decision task is completed or failed.
+		buffered            []func()
+		closed              bool
+		decisionScheduledID int64
+		decisionStartedID   int64
+		previousStartedID   int64
+		activities          map[int64]*activityTask
+		timers              map[string]*timer
+	}
+
+	activityTask struct {
+		execution       *execution
+		scheduledID     int64
+		started         bool
+		identity        *string
+		cancelRequested bool
+		attributes      *shared.ActivityTaskScheduledEventAttributes
+	}
+
+	timer struct {
+		startedID int64
+		timer     *time.Timer
+	}
+
+	taskToken struct {
+		Domain     string `json:"domain"`
+		WorkflowID string `json:"workflowId"`
+		RunID      string `json:"runId"`
+		ScheduleID int64  `json:"scheduleId"`
+	}
+)
+
+// NewWorkflowService creates an in-memory workflow service. Every domain is reported as registered.
+// Timeouts, retry policies, sticky task lists, child workflows and queries are not supported.
+func NewWorkflowService() workflowserviceclient.Interface {
+	return &service{
+		executions:    make(map[executionKey]*execution),
+		currentRuns:   make(map[workflowKey]string),
+		decisionTasks: make(map[taskListKey][]*execution),
+		activityTasks: make(map[taskListKey][]*activityTask),
+		changed:       make(chan struct{}),
+	}
+}
+
+func (s *service) DescribeDomain(ctx context.Context, request *shared.DescribeDomainRequest, opts ...yarpc.CallOption) (*shared.DescribeDomainResponse, error) {
+	return &shared.DescribeDomainResponse{
+		DomainInfo: &shared.DomainInfo{
+			Name:   common.StringPtr(request.GetName()),
+			Status: shared.DomainStatusRegistered.Ptr(),
+			UUID:   common.StringPtr(request.GetUUID()),
+		},
+		Configuration:            &shared.DomainConfiguration{WorkflowExecutionRetentionPeriodInDays: common.Int32Ptr(1)},
+		ReplicationConfiguration: &shared.DomainReplicationConfiguration{},
+		IsGlobalDomain:           common.BoolPtr(false),
+	}, nil
+}
+
+func (s *service) StartWorkflowExecution(ctx context.Context, request *shared.StartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionResponse, error) {
+	if request.GetWorkflowId() == "" || request.GetWorkflowType().GetName() == "" || request.GetTaskList().GetName() == "" {
+		return nil, &shared.BadRequestError{Message: "workflow ID, workflow type and task list are required"}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	wfKey := workflowKey{domain: request.GetDomain(), workflowID: request.GetWorkflowId()}
+	if runID, ok := s.currentRuns[wfKey]; ok {
+		current := s.executions[executionKey{domain: wfKey.domain, workflowID: wfKey.workflowID, runID: runID}]
+		if request.GetRequestId() != "" && current.requestID == request.GetRequestId() {
+			return &shared.StartWorkflowExecutionResponse{RunId: common.StringPtr(runID)}, nil
+		}
+		if !current.closed {
+			return nil, &shared.WorkflowExecutionAlreadyStartedError{
+				Message:        common.StringPtr("workflow execution is already running"),
+				StartRequestId: common.StringPtr(current.requestID),
+				RunId:          common.StringPtr(runID),
+			}
+		}
+	}
+
+	runID := uuid.New()
+	e := &execution{
+		key:             executionKey{domain: wfKey.domain, workflowID: wfKey.workflowID, runID: runID},
+		requestID:       request.GetRequestId(),
+		workflowType:    request.WorkflowType,
+		taskList:        request.TaskList.GetName(),
+		decisionTimeout: request.GetTaskStartToCloseTimeoutSeconds(),
+		activities:      make(map[int64]*activityTask),
+		timers:          make(map[string]*timer),
+	}
+	s.executions[e.key] = e
+	s.currentRuns[wfKey] = runID
+
+	s.addEventLocked(e, &shared.HistoryEvent{
+		EventType: shared.EventTypeWorkflowExecutionStarted.Ptr(),
+		WorkflowExecutionStartedEventAttributes: &shared.WorkflowExecutionStartedEventAttributes{
+			WorkflowType:                        request.WorkflowType,
+			TaskList:                            request.TaskList,
+			Input:                               request.Input,
+			ExecutionStartToCloseTimeoutSeconds: request.ExecutionStartToCloseTimeoutSeconds,
+			TaskStartToCloseTimeoutSeconds:      request.TaskStartToCloseTimeoutSeconds,
+			OriginalExecutionRunId:              common.StringPtr(runID),
+			FirstExecutionRunId:                 common.StringPtr(runID),
+			Identity:                            request.Identity,
+			RetryPolicy:                         request.RetryPolicy,
+			Attempt:                             common.Int32Ptr(0),
+			CronSchedule:                        request.CronSchedule,
+			Memo:                                request.Memo,
+			SearchAttributes:                    request.SearchAttributes,
+			Header:                              request.Header,
+			RequestId:                           request.RequestId,
+		},
+	})
+	s.scheduleDecisionLocked(e)
+	return &shared.StartWorkflowExecutionResponse{RunId: common.StringPtr(runID)}, nil
+}
+
+func (s *service) SignalWorkflowExecution(ctx context.Context, request *shared.SignalWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	s.Lock()
+	defer s.Unlock()
+
+	e, err := s.openExecutionLocked(request.GetDomain(), request.WorkflowExecution)
+	if err != nil {
+		return err
+	}
+	s.addEventLocked(e, &shared.HistoryEvent{
+		EventType: shared.EventTypeWorkflowExecutionSignaled.Ptr(),
+		WorkflowExecutionSignaledEventAttributes: &shared.WorkflowExecutionSignaledEventAttributes{
+			SignalName: request.SignalName,
+			Input:      request.Input,
+			Identity:   request.Identity,
+		},
+	})
+	s.scheduleDecisionLocked(e)
+	return nil
+}
+
+func (s *service) RequestCancelWorkflowExecution(ctx context.Context, request *shared.RequestCancelWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	s.Lock()
+	defer s.Unlock()
+
+	e, err := s.openExecutionLocked(request.GetDomain(), request.WorkflowExecution)
+	if err != nil {
+		return err
+	}
+	s.addEventLocked(e, &shared.HistoryEvent{
+		EventType: shared.EventTypeWorkflowExecutionCancelRequested.Ptr(),
+		WorkflowExecutionCancelRequestedEventAttributes: &shared.WorkflowExecutionCancelRequestedEventAttributes{
+			Cause:    request.Cause,
+			Identity: request.Identity,
+		},
+	})
+	s.scheduleDecisionLocked(e)
+	return nil
+}
+
+func (s *service) TerminateWorkflowExecution(ctx context.Context, request *shared.TerminateWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	s.Lock()
+	defer s.Unlock()
+
+	e, err := s.openExecutionLocked(request.GetDomain(), request.WorkflowExecution)
+	if err != nil {
+		return err
+	}
+	// the running decision task, if any, can no longer be completed
+	e.decisionScheduledID, e.decisionStartedID = 0, 0
+	s.flushBufferedEventsLocked(e)
+	s.appendEventLocked(e, &shared.HistoryEvent{
+		EventType: shared.EventTypeWorkflowExecutionTerminated.Ptr(),
+		WorkflowExecutionTerminatedEventAttributes: &shared.WorkflowExecutionTerminatedEventAttributes{
+			Reason:   request.Reason,
+			Details:  request.Details,
+			Identity: request.Identity,
+		},
+	})
+	s.closeLocked(e)
+	return nil
+}
+
+func (s *service) GetWorkflowExecutionHistory(ctx context.Context, request *shared.GetWorkflowExecutionHistoryRequest, opts ...yarpc.CallOption) (*shared.GetWorkflowExecutionHistoryResponse, error) {
+	closeEventOnly := request.GetHistoryEventFilterType() == shared.HistoryEventFilterTypeCloseEvent
+	var e *execution
+	var err error
+	ready := func() bool {
+		e, err = s.executionLocked(request.GetDomain(), request.Execution)
+		return err != nil || !closeEventOnly || !request.GetWaitForNewEvent() || e.closed
+	}
+	if !s.waitLocked(ctx, ready) {
+		return nil, ctx.Err()
+	}
+	defer s.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	events := e.history
+	if closeEventOnly {
+		events = nil
+		if e.closed {
+			events = e.history[len(e.history)-1:]
+		}
+	}
+	return &shared.GetWorkflowExecutionHistoryResponse{
+		History: &shared.History{Events: append([]*shared.HistoryEvent(nil), events...)},
+	}, nil
+}
+
+func (s *service) PollForDecisionTask(ctx context.Context, request *shared.PollForDecisionTaskRequest, opts ...yarpc.CallOption) (*shared.PollForDecisionTaskResponse, error) {
+	key := taskListKey{domain: request.GetDomain(), taskList: request.GetTaskList().GetName()}
+	ready := func() bool {
+		tasks := s.decisionTasks[key]
+		for len(tasks) > 0 && tasks[0].decisionScheduledID == 0 {
+			tasks = tasks[1:]
+		}
+		s.decisionTasks[key] = tasks
+		return len(tasks) > 0
+	}
+	if !s.waitLocked(ctx, ready) {
+		return &shared.PollForDecisionTaskResponse{}, nil
+	}
+	defer s.Unlock()
+
+	e := s.decisionTasks[key][0]
+	s.decisionTasks[key] = s.decisionTasks[key][1:]
+	e.decisionStartedID = s.appendEventLocked(e, &shared.HistoryEvent{
+		EventType: shared.EventTypeDecisionTaskStarted.Ptr(),
+		DecisionTaskStartedEventAttributes: &shared.DecisionTaskStartedEventAttributes{
+			ScheduledEventId: common.Int64Ptr(e.decisionScheduledID),
+			Identity:         request.Identity,
+		},
+	})
+	return &shared.PollForDecisionTaskResponse{
+		TaskToken:                 encodeTaskToken(e.key, e.decisionScheduledID),
+		WorkflowExecution:         e.workflowExecution(),
+		WorkflowType:              e.workflowType,
+		PreviousStartedEventId:    common.Int64Ptr(e.previousStartedID),
+		StartedEventId:            common.Int64Ptr(e.decisionStartedID),
+		Attempt:                   common.Int64Ptr(0),
+		History:                   &shared.History{Events: append([]*shared.HistoryEvent(nil), e.history...)},
+		WorkflowExecutionTaskList: &shared.TaskList{Name: common.StringPtr(e.taskList)},
+		ScheduledTimestamp:        e.history[e.decisionScheduledID-1].Timestamp,
+		StartedTimestamp:          e.history[e.decisionStartedID-1].Timestamp,
+		NextEventId:               common.Int64Ptr(int64(len(e.history)) + 1),
+	}, nil
+}
+
+func (s *service) RespondDecisionTaskCompleted(ctx context.Context, request *shared.RespondDecisionTaskCompletedRequest, opts ...yarpc.CallOption) (*shared.RespondDecisionTaskCompletedResponse, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	e, err := s.runningDecisionLocked(request.TaskToken)
+	if err != nil {
+		return nil, err
+	}
+	for _, decision := range request.Decisions {
+		if _, ok := supportedDecisions[decision.GetDecisionType()]; !ok {
+			return nil, &shared.BadRequestError{
+				Message: decision.GetDecisionType().String() + " decision is not supported by the in-memory service",
+			}
+		}
+	}
+	if len(e.buffered) > 0 && hasCloseDecision(request.Decisions) {
+		// like the server, fail the decision task so that the workflow can handle the new events first
+		s.failDecisionLocked(e, shared.DecisionTaskFailedCauseUnhandledDecision, nil, request.Identity)
+		return &shared.RespondDecisionTaskCompletedResponse{}, nil
+	}
+
+	completedID := s.appendEventLocked(e, &shared.HistoryEvent{
+		EventType: shared.EventTypeDecisionTaskCompleted.Ptr(),
+		DecisionTaskCompletedEventAttributes: &shared.DecisionTaskCompletedEventAttributes{
+			ExecutionContext: request.ExecutionContext,
+			ScheduledEventId: common.Int64Ptr(e.decisionScheduledID),
+			StartedEventId:   common.Int64Ptr(e.decisionStartedID),
+			Identity:         request.Identity,
+			BinaryChecksum:   request.BinaryChecksum,
+		},
+	})
+	e.previousStartedID = e.decisionStartedID
+	e.decisionScheduledID, e.decisionStartedID = 0, 0
+
+	scheduleDecision := request.GetForceCreateNewDecisionTask()
+	for _, decision := range request.Decisions {
+		scheduleDecision = s.applyDecisionLocked(e, completedID, decision) || scheduleDecision
+	}
+	scheduleDecision = scheduleDecision || len(e.buffered) > 0
+	s.flushBufferedEventsLocked(e)
+	if scheduleDecision {
+		s.scheduleDecisionLocked(e)
+	}
+	return &shared.RespondDecisionTaskCompletedResponse{}, nil
+}
+
+func (s *service) RespondDecisionTaskFailed(ctx context.Context, request *shared.RespondDecisionTaskFailedRequest, opts ...yarpc.CallOption) error {
+	s.Lock()
+	defer s.Unlock()
+
+	e, err := s.runningDecisionLocked(request.TaskToken)
+	if err != nil {
+		return err
+	}
+	s.failDecisionLocked(e, request.GetCause(), request.Details, request.Identity)
+	return nil
+}
+
+func (s *service) ResetStickyTaskList(ctx context.Context, request *shared.ResetStickyTaskListRequest, opts ...yarpc.CallOption) (*shared.ResetStickyTaskListResponse, error) {
+	// decision tasks are always dispatched to the normal task list with the full history
+	return &shared.ResetStickyTaskListResponse{}, nil
+}
+
+func (s *service) PollForActivityTask(ctx context.Context, request *shared.PollForActivityTaskRequest, opts ...yarpc.CallOption) (*shared.PollForActivityTaskResponse, error) {
+	key := taskListKey{domain: request.GetDomain(), taskList: request.GetTaskList().GetName()}
+	ready := func() bool {
+		tasks := s.activityTasks[key]
+		for len(tasks) > 0 && tasks[0].execution.activities[tasks[0].scheduledID] != tasks[0] {
+			tasks = tasks[1:]
+		}
+		s.activityTasks[key] = tasks
+		return len(tasks) > 0
+	}
+	if !s.waitLocked(ctx, ready) {
+		return &shared.PollForActivityTaskResponse{}, nil
+	}
+	defer s.Unlock()
+
+	task := s.activityTasks[key][0]
+	s.activityTasks[key] = s.activityTasks[key][1:]
+	// like the server, the started event is only recorded along with the completion of the activity
+	task.started = true
+	task.identity = request.Identity
+	e := task.execution
+	scheduledTimestamp := e.history[task.scheduledID-1].Timestamp
+	return &shared.PollForActivityTaskResponse{
+		TaskToken:                       encodeTaskToken(e.key, task.scheduledID),
+		WorkflowExecution:               e.workflowExecution(),
+		ActivityId:                      task.attributes.ActivityId,
+		ActivityType:                    task.attributes.ActivityType,
+		Input:                           task.attributes.Input,
+		ScheduledTimestamp:              scheduledTimestamp,
+		ScheduleToCloseTimeoutSeconds:   task.attributes.ScheduleToCloseTimeoutSeconds,
+		StartedTimestamp:                common.Int64Ptr(time.Now().UnixNano()),
+		StartToCloseTimeoutSeconds:      task.attributes.StartToCloseTimeoutSeconds,
+		HeartbeatTimeoutSeconds:         task.attributes.HeartbeatTimeoutSeconds,
+		Attempt:                         common.Int32Ptr(0),
+		ScheduledTimestampOfThisAttempt: scheduledTimestamp,
+		WorkflowType:                    e.workflowType,
+		WorkflowDomain:                  common.StringPtr(e.key.domain),
+		Header:                          task.attributes.Header,
+	}, nil
+}
+
+func (s *service) RecordActivityTaskHeartbeat(ctx context.Context, request *shared.RecordActivityTaskHeartbeatRequest, opts ...yarpc.CallOption) (*shared.RecordActivityTaskHeartbeatResponse, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	task, err := s.runningActivityLocked(request.TaskToken)
+	if err != nil {
+		return nil, err
+	}
+	return &shared.RecordActivityTaskHeartbeatResponse{CancelRequested: common.BoolPtr(task.cancelRequested)}, nil
+}
+
+func (s *service) RespondActivityTaskCompleted(ctx context.Context, request *shared.RespondActivityTaskCompletedRequest, opts ...yarpc.CallOption) error {
+	s.Lock()
+	defer s.Unlock()
+
+	task, err := s.runningActivityLocked(request.TaskToken)
+	if err != nil {
+		return err
+	}
+	s.closeActivityLocked(task, func(startedID int64) *shared.HistoryEvent {
+		return &shared.HistoryEvent{
+			EventType: shared.EventTypeActivityTaskCompleted.Ptr(),
+			ActivityTaskCompletedEventAttributes: &shared.ActivityTaskCompletedEventAttributes{
+				Result:           request.Result,
+				ScheduledEventId: common.Int64Ptr(task.scheduledID),
+				StartedEventId:   common.Int64Ptr(startedID),
+				Identity:         request.Identity,
+			},
+		}
+	})
+	return nil
+}
+
+func (s *service) RespondActivityTaskFailed(ctx context.Context, request *shared.RespondActivityTaskFailedRequest, opts ...yarpc.CallOption) error {
+	s.Lock()
+	defer s.Unlock()
+
+	task, err := s.runningActivityLocked(request.TaskToken)
+	if err != nil {
+		return err
+	}
+	s.closeActivityLocked(task, func(startedID int64) *shared.HistoryEvent {
+		return &shared.HistoryEvent{
+			EventType: shared.EventTypeActivityTaskFailed.Ptr(),
+			ActivityTaskFailedEventAttributes: &shared.ActivityTaskFailedEventAttributes{
+				Reason:           request.Reason,
+				Details:          request.Details,
+				ScheduledEventId: common.Int64Ptr(task.scheduledID),
+				StartedEventId:   common.Int64Ptr(startedID),
+				Identity:         request.Identity,
+			},
+		}
+	})
+	return nil
+}
+
+func (s *service) RespondActivityTaskCanceled(ctx context.Context, request *shared.RespondActivityTaskCanceledRequest, opts ...yarpc.CallOption) error {
+	s.Lock()
+	defer s.Unlock()
+
+	task, err := s.runningActivityLocked(request.TaskToken)
+	if err != nil {
+		return err
+	}
+	s.closeActivityLocked(task, func(startedID int64) *shared.HistoryEvent {
+		return &shared.HistoryEvent{
+			EventType: shared.EventTypeActivityTaskCanceled.Ptr(),
+			ActivityTaskCanceledEventAttributes: &shared.ActivityTaskCanceledEventAttributes{
+				Details:          request.Details,
+				ScheduledEventId: common.Int64Ptr(task.scheduledID),
+				StartedEventId:   common.Int64Ptr(startedID),
+				Identity:         request.Identity,
+			},
+		}
+	})
+	return nil
+}
+
+// applyDecisionLocked records the events of a decision, it returns true if they require a new decision task.
+func (s *service) applyDecisionLocked(e *execution, completedID int64, decision *shared.Decision) bool {
+	switch decision.GetDecisionType() {
+	case shared.DecisionTypeScheduleActivityTask:
+		attributes := decision.ScheduleActivityTaskDecisionAttributes
+		taskList := attributes.GetTaskList().GetName()
+		if taskList == "" {
+			taskList = e.taskList
+		}
+		scheduled := &shared.ActivityTaskScheduledEventAttributes{
+			ActivityId:                    attributes.ActivityId,
+			ActivityType:                  attributes.ActivityType,
+			Domain:                        common.StringPtr(e.key.domain),
+			TaskList:                      &shared.TaskList{Name: common.StringPtr(taskList)},
+			Input:                         attributes.Input,
+			ScheduleToCloseTimeoutSeconds: attributes.ScheduleToCloseTimeoutSeconds,
+			ScheduleToStartTimeoutSeconds: attributes.ScheduleToStartTimeoutSeconds,
+			StartToCloseTimeoutSeconds:    attributes.StartToCloseTimeoutSeconds,
+			HeartbeatTimeoutSeconds:       attributes.HeartbeatTimeoutSeconds,
+			DecisionTaskCompletedEventId:  common.Int64Ptr(completedID),
+			RetryPolicy:                   attributes.RetryPolicy,
+			Header:                        attributes.Header,
+		}
+		task := &activityTask{execution: e, attributes: scheduled}
+		task.scheduledID = s.appendEventLocked(e, &shared.HistoryEvent{
+			EventType:                            shared.EventTypeActivityTaskScheduled.Ptr(),
+			ActivityTaskScheduledEventAttributes: scheduled,
+		})
+		e.activities[task.scheduledID] = task
+		key := taskListKey{domain: e.key.domain, taskList: taskList}
+		s.activityTasks[key] = append(s.activityTasks[key], task)
+		return false
+
+	case shared.DecisionTypeRequestCancelActivityTask:
+		activityID := decision.RequestCancelActivityTaskDecisionAttributes.GetActivityId()
+		var task *activityTask
+		for _, t := range e.activities {
+			if t.attributes.GetActivityId() == activityID {
+				task = t
+			}
+		}
+		if task == nil {
+			s.appendEventLocked(e, &shared.HistoryEvent{
+				EventType: shared.EventTypeRequestCancelActivityTaskFailed.Ptr(),
+				RequestCancelActivityTaskFailedEventAttributes: &shared.RequestCancelActivityTaskFailedEventAttributes{
+					ActivityId:                   common.StringPtr(activityID),
+					Cause:                        common.StringPtr("ACTIVITY_ID_UNKNOWN"),
+					DecisionTaskCompletedEventId: common.Int64Ptr(completedID),
+				},
+			})
+			return true
+		}
+		cancelRequestedID := s.appendEventLocked(e, &shared.HistoryEvent{
+			EventType: shared.EventTypeActivityTaskCancelRequested.Ptr(),
+			ActivityTaskCancelRequestedEventAttributes: &shared.ActivityTaskCancelRequestedEventAttributes{
+				ActivityId:                   common.StringPtr(activityID),
+				DecisionTaskCompletedEventId: common.Int64Ptr(completedID),
+			},
+		})
+		if task.started {
+			// the activity learns about the cancellation from its next heartbeat
+			task.cancelRequested = true
+			return false
+		}
+		delete(e.activities, task.scheduledID)
+		s.appendEventLocked(e, &shared.HistoryEvent{
+			EventType: shared.EventTypeActivityTaskCanceled.Ptr(),
+			ActivityTaskCanceledEventAttributes: &shared.ActivityTaskCanceledEventAttributes{
+				LatestCancelRequestedEventId: common.Int64Ptr(cancelRequestedID),
+				ScheduledEventId:             common.Int64Ptr(task.scheduledID),
+				StartedEventId:               common.Int64Ptr(0),
+			},
+		})
+		return true
+
+	case shared.DecisionTypeStartTimer:
+		attributes := decision.StartTimerDecisionAttributes
+		timerID := attributes.GetTimerId()
+		startedID := s.appendEventLocked(e, &shared.HistoryEvent{
+			EventType: shared.EventTypeTimerStarted.Ptr(),
+			TimerStartedEventAttributes: &shared.TimerStartedEventAttributes{
+				TimerId:                      attributes.TimerId,
+				StartToFireTimeoutSeconds:    attributes.StartToFireTimeoutSeconds,
+				DecisionTaskCompletedEventId: common.Int64Ptr(completedID),
+			},
+		})
+		fireAfter := time.Duration(attributes.GetStartToFireTimeoutSeconds()) * time.Second
+		e.timers[timerID] = &timer{
+			startedID: startedID,
+			timer:     time.AfterFunc(fireAfter, func() { s.fireTimer(e, timerID, startedID) }),
+		}
+		return false
+
+	case shared.DecisionTypeCancelTimer:
+		timerID := decision.CancelTimerDecisionAttributes.GetTimerId()
+		t, ok := e.timers[timerID]
+		if !ok {
+			s.appendEventLocked(e, &shared.HistoryEvent{
+				EventType: shared.EventTypeCancelTimerFailed.Ptr(),
+				CancelTimerFailedEventAttributes: &shared.CancelTimerFailedEventAttributes{
+					TimerId:                      common.StringPtr(timerID),
+					Cause:                        common.StringPtr("TIMER_ID_UNKNOWN"),
+					DecisionTaskCompletedEventId: common.Int64Ptr(completedID),
+				},
+			})
+			return true
+		}
+		t.timer.Stop()
+		delete(e.timers, timerID)
+		s.appendEventLocked(e, &shared.HistoryEvent{
+			EventType: shared.EventTypeTimerCanceled.Ptr(),
+			TimerCanceledEventAttributes: &shared.TimerCanceledEventAttributes{
+				TimerId:                      common.StringPtr(timerID),
+				StartedEventId:               common.Int64Ptr(t.startedID),
+				DecisionTaskCompletedEventId: common.Int64Ptr(completedID),
+			},
+		})
+		return false
+
+	case shared.DecisionTypeRecordMarker:
+		attributes := decision.RecordMarkerDecisionAttributes
+		s.appendEventLocked(e, &shared.HistoryEvent{
+			EventType: shared.EventTypeMarkerRecorded.Ptr(),
+			MarkerRecordedEventAttributes: &shared.MarkerRecordedEventAttributes{
+				MarkerName:                   attributes.MarkerName,
+				Details:                      attributes.Details,
+				DecisionTaskCompletedEventId: common.Int64Ptr(completedID),
+				Header:                       attributes.Header,
+			},
+		})
+		return false
+
+	case shared.DecisionTypeUpsertWorkflowSearchAttributes:
+		s.appendEventLocked(e, &shared.HistoryEvent{
+			EventType: shared.EventTypeUpsertWorkflowSearchAttributes.Ptr(),
+			UpsertWorkflowSearchAttributesEventAttributes: &shared.UpsertWorkflowSearchAttributesEventAttributes{
+				DecisionTaskCompletedEventId: common.Int64Ptr(completedID),
+				SearchAttributes:             decision.UpsertWorkflowSearchAttributesDecisionAttributes.SearchAttributes,
+			},
+		})
+		return false
+
+	case shared.DecisionTypeCompleteWorkflowExecution:
+		s.appendEventLocked(e, &shared.HistoryEvent{
+			EventType: shared.EventTypeWorkflowExecutionCompleted.Ptr(),
+			WorkflowExecutionCompletedEventAttributes: &shared.WorkflowExecutionCompletedEventAttributes{
+				Result:                       decision.CompleteWorkflowExecutionDecisionAttributes.Result,
+				DecisionTaskCompletedEventId: common.Int64Ptr(completedID),
+			},
+		})
+		s.closeLocked(e)
+		return false
+
+	case shared.DecisionTypeFailWorkflowExecution:
+		attributes := decision.FailWorkflowExecutionDecisionAttributes
+		s.appendEventLocked(e, &shared.HistoryEvent{
+			EventType: shared.EventTypeWorkflowExecutionFailed.Ptr(),
+			WorkflowExecutionFailedEventAttributes: &shared.WorkflowExecutionFailedEventAttributes{
+				Reason:                       attributes.Reason,
+				Details:                      attributes.Details,
+				DecisionTaskCompletedEventId: common.Int64Ptr(completedID),
+			},
+		})
+		s.closeLocked(e)
+		return false
+
+	case shared.DecisionTypeCancelWorkflowExecution:
+		s.appendEventLocked(e, &shared.HistoryEvent{
+			EventType: shared.EventTypeWorkflowExecutionCanceled.Ptr(),
+			WorkflowExecutionCanceledEventAttributes: &shared.WorkflowExecutionCanceledEventAttributes{
+				Details:                      decision.CancelWorkflowExecutionDecisionAttributes.Details,
+				DecisionTaskCompletedEventId: common.Int64Ptr(completedID),
+			},
+		})
+		s.closeLocked(e)
+		return false
+	}
+	return false
+}
+
+func (s *service) fireTimer(e *execution, timerID string, startedID int64) {
+	s.Lock()
+	defer s.Unlock()
+
+	if t, ok := e.timers[timerID]; !ok || t.startedID != startedID || e.closed {
+		return
+	}
+	delete(e.timers, timerID)
+	s.addEventLocked(e, &shared.HistoryEvent{
+		EventType: shared.EventTypeTimerFired.Ptr(),
+		TimerFiredEventAttributes: &shared.TimerFiredEventAttributes{
+			TimerId:        common.StringPtr(timerID),
+			StartedEventId: common.Int64Ptr(startedID),
+		},
+	})
+	s.scheduleDecisionLocked(e)
+}
+
+func (s *service) failDecisionLocked(e *execution, cause shared.DecisionTaskFailedCause, details []byte, identity *string) {
+	s.appendEventLocked(e, &shared.HistoryEvent{
+		EventType: shared.EventTypeDecisionTaskFailed.Ptr(),
+		DecisionTaskFailedEventAttributes: &shared.DecisionTaskFailedEventAttributes{
+			ScheduledEventId: common.Int64Ptr(e.decisionScheduledID),
+			StartedEventId:   common.Int64Ptr(e.decisionStartedID),
+			Cause:            cause.Ptr(),
+			Details:          details,
+			Identity:         identity,
+		},
+	})
+	e.decisionScheduledID, e.decisionStartedID = 0, 0
+	s.flushBufferedEventsLocked(e)
+	time.AfterFunc(decisionRetryDelay, func() {
+		s.Lock()
+		defer s.Unlock()
+		s.scheduleDecisionLocked(e)
+	})
+}
+
+// closeActivityLocked records the started event of task followed by the event created by closeEvent.
+func (s *service) closeActivityLocked(task *activityTask, closeEvent func(startedID int64) *shared.HistoryEvent) {
+	e := task.execution
+	delete(e.activities, task.scheduledID)
+	s.recordLocked(e, func() {
+		startedID := s.appendEventLocked(e, &shared.HistoryEvent{
+			EventType: shared.EventTypeActivityTaskStarted.Ptr(),
+			ActivityTaskStartedEventAttributes: &shared.ActivityTaskStartedEventAttributes{
+				ScheduledEventId: common.Int64Ptr(task.scheduledID),
+				Identity:         task.identity,
+				Attempt:          common.Int32Ptr(0),
+			},
+		})
+		s.appendEventLocked(e, closeEvent(startedID))
+	})
+	s.scheduleDecisionLocked(e)
+}
+
+// scheduleDecisionLocked dispatches a decision task for the new events of e, unless one is already pending.
+func (s *service) scheduleDecisionLocked(e *execution) {
+	if e.closed || e.decisionScheduledID != 0 {
+		// a running decision task is followed by a new one once it completes with buffered events
+		return
+	}
+	e.decisionScheduledID = s.appendEventLocked(e, &shared.HistoryEvent{
+		EventType: shared.EventTypeDecisionTaskScheduled.Ptr(),
+		DecisionTaskScheduledEventAttributes: &shared.DecisionTaskScheduledEventAttributes{
+			TaskList:                   &shared.TaskList{Name: common.StringPtr(e.taskList)},
+			StartToCloseTimeoutSeconds: common.Int32Ptr(e.decisionTimeout),
+			Attempt:                    common.Int64Ptr(0),
+		},
+	})
+	key := taskListKey{domain: e.key.domain, taskList: e.taskList}
+	s.decisionTasks[key] = append(s.decisionTasks[key], e)
+}
+
+func (s *service) closeLocked(e *execution) {
+	e.closed = true
+	e.decisionScheduledID, e.decisionStartedID = 0, 0
+	for _, t := range e.timers {
+		t.timer.Stop()
+	}
+	e.timers = make(map[string]*timer)
+	e.activities = make(map[int64]*activityTask)
+	s.notifyLocked()
+}
+
+// addEventLocked appends event to the history of e, once the running decision task completes if there is one.
+func (s *service) addEventLocked(e *execution, event *shared.HistoryEvent) {
+	s.recordLocked(e, func() { s.appendEventLocked(e, event) })
+}
+
+// recordLocked calls record, which appends events to the history of e, right away or, while a decision task is
+// running, once it completes: the events of a decision task must directly follow its started event.
+func (s *service) recordLocked(e *execution, record func()) {
+	if e.decisionStartedID != 0 {
+		e.buffered = append(e.buffered, record)
+		return
+	}
+	record()
+}
+
+func (s *service) appendEventLocked(e *execution, event *shared.HistoryEvent) int64 {
+	event.EventId = common.Int64Ptr(int64(len(e.history)) + 1)
+	event.Timestamp = common.Int64Ptr(time.Now().UnixNano())
+	event.Version = common.Int64Ptr(0)
+	event.TaskId = common.Int64Ptr(0)
+	e.history = append(e.history, event)
+	s.notifyLocked()
+	return event.GetEventId()
+}
+
+func (s *service) flushBufferedEventsLocked(e *execution) {
+	for _, record := range e.buffered {
+		record()
+	}
+	e.buffered = nil
+}
+
+func (s *service) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// waitLocked acquires the lock and waits until ready, called with the lock held, returns true. It returns true with
+// the lock held, or false with the lock released once ctx is done.
+func (s *service) waitLocked(ctx context.Context, ready func() bool) bool {
+	s.Lock()
+	for !ready() {
+		changed := s.changed
+		s.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return false
+		}
+		s.Lock()
+	}
+	return true
+}
+
+func (s *service) executionLocked(domain string, workflowExecution *shared.WorkflowExecution) (*execution, error) {
+	runID := workflowExecution.GetRunId()
+	if runID == "" {
+		runID = s.currentRuns[workflowKey{domain: domain, workflowID: workflowExecution.GetWorkflowId()}]
+	}
+	e, ok := s.executions[executionKey{domain: domain, workflowID: workflowExecution.GetWorkflowId(), runID: runID}]
+	if !ok {
+		return nil, &shared.EntityNotExistsError{Message: "workflow execution not found"}
+	}
+	return e, nil
+}
+
+func (s *service) openExecutionLocked(domain string, workflowExecution *shared.WorkflowExecution) (*execution, error) {
+	e, err := s.executionLocked(domain, workflowExecution)
+	if err != nil {
+		return nil, err
+	}
+	if e.closed {
+		return nil, &shared.EntityNotExistsError{Message: "workflow execution already completed"}
+	}
+	return e, nil
+}
+
+func (s *service) runningDecisionLocked(token []byte) (*execution, error) {
+	t, err := decodeTaskToken(token)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.openExecutionLocked(t.Domain, &shared.WorkflowExecution{WorkflowId: &t.WorkflowID, RunId: &t.RunID})
+	if err != nil {
+		return nil, err
+	}
+	if e.decisionStartedID == 0 || e.decisionScheduledID != t.ScheduleID {
+		return nil, &shared.EntityNotExistsError{Message: "decision task not found"}
+	}
+	return e, nil
+}
+
+func (s *service) runningActivityLocked(token []byte) (*activityTask, error) {
+	t, err := decodeTaskToken(token)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.openExecutionLocked(t.Domain, &shared.WorkflowExecution{WorkflowId: &t.WorkflowID, RunId: &t.RunID})
+	if err != nil {
+		return nil, err
+	}
+	task, ok := e.activities[t.ScheduleID]
+	if !ok {
+		return nil, &shared.EntityNotExistsError{Message: "activity task not found"}
+	}
+	return task, nil
+}
+
+func (e *execution) workflowExecution() *shared.WorkflowExecution {
+	return &shared.WorkflowExecution{
+		WorkflowId: common.StringPtr(e.key.workflowID),
+		RunId:      common.StringPtr(e.key.runID),
+	}
+}
+
+func hasCloseDecision(decisions []*shared.Decision) bool {
+	for _, decision := range decisions {
+		switch decision.GetDecisionType() {
+		case shared.DecisionTypeCompleteWorkflowExecution,
+			shared.DecisionTypeFailWorkflowExecution,
+			shared.DecisionTypeCancelWorkflowExecution:
+			return true
+		}
+	}
+	return false
+}
+
+func encodeTaskToken(key executionKey, scheduleID int64) []byte {
+	// marshaling a struct of strings and integers cannot fail
+	token, _ := json.Marshal(taskToken{
+		Domain:     key.domain,
+		WorkflowID: key.workflowID,
+		RunID:      key.runID,
+		ScheduleID: scheduleID,
+	})
+	return token
+}
+
+func decodeTaskToken(token []byte) (taskToken, error) {
+	var t taskToken
+	if err := json.Unmarshal(token, &t); err != nil {
+		return t, &shared.BadRequestError{Message: "invalid task token"}
+	}
+	return t, nil
+}
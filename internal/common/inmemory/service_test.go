@@ -0,0 +1,216 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package inmemory
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal"
+	"go.uber.org/cadence/internal/common"
+	"go.uber.org/cadence/internal/common/testlogger"
+)
+
+const (
+	testDomain   = "test-domain"
+	testTaskList = "test-tasklist"
+)
+
+func greetingActivity(name string) (string, error) {
+	return "hello " + name, nil
+}
+
+func greetingWorkflow(ctx internal.Context, name string) (string, error) {
+	ctx = internal.WithActivityOptions(ctx, internal.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+	var greeting string
+	if err := internal.ExecuteActivity(ctx, greetingActivity, name).Get(ctx, &greeting); err != nil {
+		return "", err
+	}
+	if err := internal.Sleep(ctx, time.Second); err != nil {
+		return "", err
+	}
+	var punctuation string
+	internal.GetSignalChannel(ctx, "punctuation").Receive(ctx, &punctuation)
+	return greeting + punctuation, nil
+}
+
+func failingActivity() error {
+	return internal.NewCustomError("activity-failure")
+}
+
+func failingWorkflow(ctx internal.Context) error {
+	ctx = internal.WithActivityOptions(ctx, internal.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+	return internal.ExecuteActivity(ctx, failingActivity).Get(ctx, nil)
+}
+
+func newTestWorker(t *testing.T) internal.Client {
+	service := NewWorkflowService()
+	worker, err := internal.NewWorker(service, testDomain, testTaskList, internal.WorkerOptions{
+		Logger: testlogger.NewZap(t),
+	})
+	require.NoError(t, err)
+	worker.RegisterWorkflow(greetingWorkflow)
+	worker.RegisterWorkflow(failingWorkflow)
+	worker.RegisterActivity(greetingActivity)
+	worker.RegisterActivity(failingActivity)
+	require.NoError(t, worker.Start())
+	t.Cleanup(worker.Stop)
+	return internal.NewClient(service, testDomain, nil)
+}
+
+func TestService_RunsWorkflowEndToEnd(t *testing.T) {
+	client := newTestWorker(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	run, err := client.ExecuteWorkflow(ctx, internal.StartWorkflowOptions{
+		TaskList:                        testTaskList,
+		ExecutionStartToCloseTimeout:    time.Minute,
+		DecisionTaskStartToCloseTimeout: time.Minute,
+	}, greetingWorkflow, "cadence")
+	require.NoError(t, err)
+	require.NoError(t, client.SignalWorkflow(ctx, run.GetID(), "", "punctuation", "!"))
+
+	var result string
+	require.NoError(t, run.Get(ctx, &result))
+	assert.Equal(t, "hello cadence!", result)
+
+	var eventTypes []shared.EventType
+	iter := client.GetWorkflowHistory(ctx, run.GetID(), run.GetRunID(), false, shared.HistoryEventFilterTypeAllEvent)
+	for iter.HasNext() {
+		event, err := iter.Next()
+		require.NoError(t, err)
+		eventTypes = append(eventTypes, event.GetEventType())
+	}
+	assert.Equal(t, shared.EventTypeWorkflowExecutionStarted, eventTypes[0])
+	assert.Contains(t, eventTypes, shared.EventTypeActivityTaskCompleted)
+	assert.Contains(t, eventTypes, shared.EventTypeTimerFired)
+	assert.Contains(t, eventTypes, shared.EventTypeWorkflowExecutionSignaled)
+	assert.Equal(t, shared.EventTypeWorkflowExecutionCompleted, eventTypes[len(eventTypes)-1])
+}
+
+func TestService_ActivityFailure(t *testing.T) {
+	client := newTestWorker(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	run, err := client.ExecuteWorkflow(ctx, internal.StartWorkflowOptions{
+		TaskList:                        testTaskList,
+		ExecutionStartToCloseTimeout:    time.Minute,
+		DecisionTaskStartToCloseTimeout: time.Minute,
+	}, failingWorkflow)
+	require.NoError(t, err)
+
+	err = run.Get(ctx, nil)
+	var customErr *internal.CustomError
+	require.ErrorAs(t, err, &customErr)
+	assert.Equal(t, "activity-failure", customErr.Reason())
+}
+
+func TestService_TerminateWorkflow(t *testing.T) {
+	client := newTestWorker(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	run, err := client.ExecuteWorkflow(ctx, internal.StartWorkflowOptions{
+		TaskList:                        testTaskList,
+		ExecutionStartToCloseTimeout:    time.Minute,
+		DecisionTaskStartToCloseTimeout: time.Minute,
+	}, greetingWorkflow, "cadence")
+	require.NoError(t, err)
+	require.NoError(t, client.TerminateWorkflow(ctx, run.GetID(), run.GetRunID(), "test", nil))
+
+	err = run.Get(ctx, nil)
+	var terminatedErr *internal.TerminatedError
+	assert.ErrorAs(t, err, &terminatedErr)
+
+	err = client.SignalWorkflow(ctx, run.GetID(), "", "punctuation", "!")
+	var notExistsErr *shared.EntityNotExistsError
+	assert.ErrorAs(t, err, &notExistsErr)
+}
+
+func TestService_StartWorkflowExecution(t *testing.T) {
+	service := NewWorkflowService()
+	request := &shared.StartWorkflowExecutionRequest{
+		Domain:       common.StringPtr(testDomain),
+		WorkflowId:   common.StringPtr("workflow-id"),
+		WorkflowType: &shared.WorkflowType{Name: common.StringPtr("workflow-type")},
+		TaskList:     &shared.TaskList{Name: common.StringPtr(testTaskList)},
+		RequestId:    common.StringPtr("request-id"),
+	}
+
+	response, err := service.StartWorkflowExecution(context.Background(), request)
+	require.NoError(t, err)
+
+	t.Run("same request", func(t *testing.T) {
+		retried, err := service.StartWorkflowExecution(context.Background(), request)
+		require.NoError(t, err)
+		assert.Equal(t, response.GetRunId(), retried.GetRunId())
+	})
+	t.Run("already started", func(t *testing.T) {
+		duplicate := *request
+		duplicate.RequestId = common.StringPtr("other-request-id")
+		_, err := service.StartWorkflowExecution(context.Background(), &duplicate)
+		var startedErr *shared.WorkflowExecutionAlreadyStartedError
+		require.ErrorAs(t, err, &startedErr)
+		assert.Equal(t, response.GetRunId(), startedErr.GetRunId())
+		assert.Equal(t, "request-id", startedErr.GetStartRequestId())
+	})
+	t.Run("missing workflow ID", func(t *testing.T) {
+		invalid := *request
+		invalid.WorkflowId = nil
+		_, err := service.StartWorkflowExecution(context.Background(), &invalid)
+		assert.IsType(t, &shared.BadRequestError{}, err)
+	})
+}
+
+func TestService_UnsupportedRequest(t *testing.T) {
+	service := NewWorkflowService()
+	_, err := service.ListOpenWorkflowExecutions(context.Background(), &shared.ListOpenWorkflowExecutionsRequest{})
+	var badRequestErr *shared.BadRequestError
+	require.ErrorAs(t, err, &badRequestErr)
+	assert.True(t, strings.HasPrefix(badRequestErr.Message, "ListOpenWorkflowExecutions"))
+}
+
+func TestService_PollTimesOut(t *testing.T) {
+	service := NewWorkflowService()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	response, err := service.PollForDecisionTask(ctx, &shared.PollForDecisionTaskRequest{
+		Domain:   common.StringPtr(testDomain),
+		TaskList: &shared.TaskList{Name: common.StringPtr(testTaskList)},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, response.TaskToken)
+}
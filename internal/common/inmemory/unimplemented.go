@@ -0,0 +1,209 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package inmemory
+
+import (
+	"context"
+
+	"go.uber.org/yarpc"
+
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+// unimplementedService fails every call with a BadRequestError. The in-memory service embeds it so that only
+// the RPCs needed to run workers end-to-end have to be implemented.
+type unimplementedService struct{}
+
+func unimplemented(method string) error {
+	return &shared.BadRequestError{Message: method + " is not supported by the in-memory service"}
+}
+
+func (unimplementedService) CountWorkflowExecutions(ctx context.Context, request *shared.CountWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.CountWorkflowExecutionsResponse, error) {
+	return nil, unimplemented("CountWorkflowExecutions")
+}
+
+func (unimplementedService) DeprecateDomain(ctx context.Context, request *shared.DeprecateDomainRequest, opts ...yarpc.CallOption) error {
+	return unimplemented("DeprecateDomain")
+}
+
+func (unimplementedService) DescribeDomain(ctx context.Context, request *shared.DescribeDomainRequest, opts ...yarpc.CallOption) (*shared.DescribeDomainResponse, error) {
+	return nil, unimplemented("DescribeDomain")
+}
+
+func (unimplementedService) DescribeTaskList(ctx context.Context, request *shared.DescribeTaskListRequest, opts ...yarpc.CallOption) (*shared.DescribeTaskListResponse, error) {
+	return nil, unimplemented("DescribeTaskList")
+}
+
+func (unimplementedService) DescribeWorkflowExecution(ctx context.Context, request *shared.DescribeWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.DescribeWorkflowExecutionResponse, error) {
+	return nil, unimplemented("DescribeWorkflowExecution")
+}
+
+func (unimplementedService) GetClusterInfo(ctx context.Context, opts ...yarpc.CallOption) (*shared.ClusterInfo, error) {
+	return nil, unimplemented("GetClusterInfo")
+}
+
+func (unimplementedService) GetSearchAttributes(ctx context.Context, opts ...yarpc.CallOption) (*shared.GetSearchAttributesResponse, error) {
+	return nil, unimplemented("GetSearchAttributes")
+}
+
+func (unimplementedService) GetTaskListsByDomain(ctx context.Context, request *shared.GetTaskListsByDomainRequest, opts ...yarpc.CallOption) (*shared.GetTaskListsByDomainResponse, error) {
+	return nil, unimplemented("GetTaskListsByDomain")
+}
+
+func (unimplementedService) GetWorkflowExecutionHistory(ctx context.Context, request *shared.GetWorkflowExecutionHistoryRequest, opts ...yarpc.CallOption) (*shared.GetWorkflowExecutionHistoryResponse, error) {
+	return nil, unimplemented("GetWorkflowExecutionHistory")
+}
+
+func (unimplementedService) ListArchivedWorkflowExecutions(ctx context.Context, request *shared.ListArchivedWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListArchivedWorkflowExecutionsResponse, error) {
+	return nil, unimplemented("ListArchivedWorkflowExecutions")
+}
+
+func (unimplementedService) ListClosedWorkflowExecutions(ctx context.Context, request *shared.ListClosedWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListClosedWorkflowExecutionsResponse, error) {
+	return nil, unimplemented("ListClosedWorkflowExecutions")
+}
+
+func (unimplementedService) ListDomains(ctx context.Context, request *shared.ListDomainsRequest, opts ...yarpc.CallOption) (*shared.ListDomainsResponse, error) {
+	return nil, unimplemented("ListDomains")
+}
+
+func (unimplementedService) ListOpenWorkflowExecutions(ctx context.Context, request *shared.ListOpenWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListOpenWorkflowExecutionsResponse, error) {
+	return nil, unimplemented("ListOpenWorkflowExecutions")
+}
+
+func (unimplementedService) ListTaskListPartitions(ctx context.Context, request *shared.ListTaskListPartitionsRequest, opts ...yarpc.CallOption) (*shared.ListTaskListPartitionsResponse, error) {
+	return nil, unimplemented("ListTaskListPartitions")
+}
+
+func (unimplementedService) ListWorkflowExecutions(ctx context.Context, request *shared.ListWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+	return nil, unimplemented("ListWorkflowExecutions")
+}
+
+func (unimplementedService) PollForActivityTask(ctx context.Context, request *shared.PollForActivityTaskRequest, opts ...yarpc.CallOption) (*shared.PollForActivityTaskResponse, error) {
+	return nil, unimplemented("PollForActivityTask")
+}
+
+func (unimplementedService) PollForDecisionTask(ctx context.Context, request *shared.PollForDecisionTaskRequest, opts ...yarpc.CallOption) (*shared.PollForDecisionTaskResponse, error) {
+	return nil, unimplemented("PollForDecisionTask")
+}
+
+func (unimplementedService) QueryWorkflow(ctx context.Context, request *shared.QueryWorkflowRequest, opts ...yarpc.CallOption) (*shared.QueryWorkflowResponse, error) {
+	return nil, unimplemented("QueryWorkflow")
+}
+
+func (unimplementedService) RecordActivityTaskHeartbeat(ctx context.Context, request *shared.RecordActivityTaskHeartbeatRequest, opts ...yarpc.CallOption) (*shared.RecordActivityTaskHeartbeatResponse, error) {
+	return nil, unimplemented("RecordActivityTaskHeartbeat")
+}
+
+func (unimplementedService) RecordActivityTaskHeartbeatByID(ctx context.Context, request *shared.RecordActivityTaskHeartbeatByIDRequest, opts ...yarpc.CallOption) (*shared.RecordActivityTaskHeartbeatResponse, error) {
+	return nil, unimplemented("RecordActivityTaskHeartbeatByID")
+}
+
+func (unimplementedService) RefreshWorkflowTasks(ctx context.Context, request *shared.RefreshWorkflowTasksRequest, opts ...yarpc.CallOption) error {
+	return unimplemented("RefreshWorkflowTasks")
+}
+
+func (unimplementedService) RegisterDomain(ctx context.Context, request *shared.RegisterDomainRequest, opts ...yarpc.CallOption) error {
+	return unimplemented("RegisterDomain")
+}
+
+func (unimplementedService) RequestCancelWorkflowExecution(ctx context.Context, request *shared.RequestCancelWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	return unimplemented("RequestCancelWorkflowExecution")
+}
+
+func (unimplementedService) ResetStickyTaskList(ctx context.Context, request *shared.ResetStickyTaskListRequest, opts ...yarpc.CallOption) (*shared.ResetStickyTaskListResponse, error) {
+	return nil, unimplemented("ResetStickyTaskList")
+}
+
+func (unimplementedService) ResetWorkflowExecution(ctx context.Context, request *shared.ResetWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.ResetWorkflowExecutionResponse, error) {
+	return nil, unimplemented("ResetWorkflowExecution")
+}
+
+func (unimplementedService) RespondActivityTaskCanceled(ctx context.Context, request *shared.RespondActivityTaskCanceledRequest, opts ...yarpc.CallOption) error {
+	return unimplemented("RespondActivityTaskCanceled")
+}
+
+func (unimplementedService) RespondActivityTaskCanceledByID(ctx context.Context, request *shared.RespondActivityTaskCanceledByIDRequest, opts ...yarpc.CallOption) error {
+	return unimplemented("RespondActivityTaskCanceledByID")
+}
+
+func (unimplementedService) RespondActivityTaskCompleted(ctx context.Context, request *shared.RespondActivityTaskCompletedRequest, opts ...yarpc.CallOption) error {
+	return unimplemented("RespondActivityTaskCompleted")
+}
+
+func (unimplementedService) RespondActivityTaskCompletedByID(ctx context.Context, request *shared.RespondActivityTaskCompletedByIDRequest, opts ...yarpc.CallOption) error {
+	return unimplemented("RespondActivityTaskCompletedByID")
+}
+
+func (unimplementedService) RespondActivityTaskFailed(ctx context.Context, request *shared.RespondActivityTaskFailedRequest, opts ...yarpc.CallOption) error {
+	return unimplemented("RespondActivityTaskFailed")
+}
+
+func (unimplementedService) RespondActivityTaskFailedByID(ctx context.Context, request *shared.RespondActivityTaskFailedByIDRequest, opts ...yarpc.CallOption) error {
+	return unimplemented("RespondActivityTaskFailedByID")
+}
+
+func (unimplementedService) RespondDecisionTaskCompleted(ctx context.Context, request *shared.RespondDecisionTaskCompletedRequest, opts ...yarpc.CallOption) (*shared.RespondDecisionTaskCompletedResponse, error) {
+	return nil, unimplemented("RespondDecisionTaskCompleted")
+}
+
+func (unimplementedService) RespondDecisionTaskFailed(ctx context.Context, request *shared.RespondDecisionTaskFailedRequest, opts ...yarpc.CallOption) error {
+	return unimplemented("RespondDecisionTaskFailed")
+}
+
+func (unimplementedService) RespondQueryTaskCompleted(ctx context.Context, request *shared.RespondQueryTaskCompletedRequest, opts ...yarpc.CallOption) error {
+	return unimplemented("RespondQueryTaskCompleted")
+}
+
+func (unimplementedService) RestartWorkflowExecution(ctx context.Context, request *shared.RestartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.RestartWorkflowExecutionResponse, error) {
+	return nil, unimplemented("RestartWorkflowExecution")
+}
+
+func (unimplementedService) ScanWorkflowExecutions(ctx context.Context, request *shared.ListWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+	return nil, unimplemented("ScanWorkflowExecutions")
+}
+
+func (unimplementedService) SignalWithStartWorkflowExecution(ctx context.Context, request *shared.SignalWithStartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionResponse, error) {
+	return nil, unimplemented("SignalWithStartWorkflowExecution")
+}
+
+func (unimplementedService) SignalWithStartWorkflowExecutionAsync(ctx context.Context, request *shared.SignalWithStartWorkflowExecutionAsyncRequest, opts ...yarpc.CallOption) (*shared.SignalWithStartWorkflowExecutionAsyncResponse, error) {
+	return nil, unimplemented("SignalWithStartWorkflowExecutionAsync")
+}
+
+func (unimplementedService) SignalWorkflowExecution(ctx context.Context, request *shared.SignalWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	return unimplemented("SignalWorkflowExecution")
+}
+
+func (unimplementedService) StartWorkflowExecution(ctx context.Context, request *shared.StartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionResponse, error) {
+	return nil, unimplemented("StartWorkflowExecution")
+}
+
+func (unimplementedService) StartWorkflowExecutionAsync(ctx context.Context, request *shared.StartWorkflowExecutionAsyncRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionAsyncResponse, error) {
+	return nil, unimplemented("StartWorkflowExecutionAsync")
+}
+
+func (unimplementedService) TerminateWorkflowExecution(ctx context.Context, request *shared.TerminateWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	return unimplemented("TerminateWorkflowExecution")
+}
+
+func (unimplementedService) UpdateDomain(ctx context.Context, request *shared.UpdateDomainRequest, opts ...yarpc.CallOption) (*shared.UpdateDomainResponse, error) {
+	return nil, unimplemented("UpdateDomain")
+}
@@ -70,6 +70,7 @@ const (
 	ActivityTaskCompletedCounter                = CadenceMetricsPrefix + "activity-task-completed"
 	ActivityTaskFailedCounter                   = CadenceMetricsPrefix + "activity-task-failed"
 	ActivityTaskCanceledCounter                 = CadenceMetricsPrefix + "activity-task-canceled"
+	ActivityTaskExpiredCounter                  = CadenceMetricsPrefix + "activity-task-expired"
 	ActivityTaskCompletedByIDCounter            = CadenceMetricsPrefix + "activity-task-completed-by-id"
 	ActivityTaskFailedByIDCounter               = CadenceMetricsPrefix + "activity-task-failed-by-id"
 	ActivityTaskCanceledByIDCounter             = CadenceMetricsPrefix + "activity-task-canceled-by-id"
@@ -85,12 +86,28 @@ const (
 	ActivityLocalDispatchFailedCounter          = CadenceMetricsPrefix + "activity-local-dispatch-failed"
 	ActivityLocalDispatchSucceedCounter         = CadenceMetricsPrefix + "activity-local-dispatch-succeed"
 	WorkerPanicCounter                          = CadenceMetricsPrefix + "worker-panic"
+	PollerPanicCounter                          = CadenceMetricsPrefix + "poller-panic"
+	ServiceTransientRetryCounter                = CadenceMetricsPrefix + "service-transient-retry"
+	ServiceThrottledRetryCounter                = CadenceMetricsPrefix + "service-throttled-retry"
+	ServiceHealthCheckFailedCounter             = CadenceMetricsPrefix + "service-health-check-failed"
+	ServiceConnectivityLostCounter              = CadenceMetricsPrefix + "service-connectivity-lost"
+	ServiceReconnectCounter                     = CadenceMetricsPrefix + "service-reconnect"
+	TaskListDecisionBacklogGauge                = CadenceMetricsPrefix + "task-list-decision-backlog"
+	TaskListActivityBacklogGauge                = CadenceMetricsPrefix + "task-list-activity-backlog"
+	TaskListOpenWorkflowsGauge                  = CadenceMetricsPrefix + "task-list-open-workflows"
 
 	UnhandledSignalsCounter = CadenceMetricsPrefix + "unhandled-signals"
 	CorruptedSignalsCounter = CadenceMetricsPrefix + "corrupted-signals"
 
-	WorkerStartCounter = CadenceMetricsPrefix + "worker-start"
-	PollerStartCounter = CadenceMetricsPrefix + "poller-start"
+	WorkflowInputSize  = CadenceMetricsPrefix + "workflow-input-size"
+	WorkflowResultSize = CadenceMetricsPrefix + "workflow-result-size"
+	ActivityInputSize  = CadenceMetricsPrefix + "activity-input-size"
+	ActivityResultSize = CadenceMetricsPrefix + "activity-result-size"
+
+	WorkerStartCounter    = CadenceMetricsPrefix + "worker-start"
+	PollerStartCounter    = CadenceMetricsPrefix + "poller-start"
+	PollToDispatchLatency = CadenceMetricsPrefix + "poll-to-dispatch-latency"
+	TaskDispatchQueueSize = CadenceMetricsPrefix + "task-dispatch-queue-size"
 
 	CadenceRequest        = CadenceMetricsPrefix + "request"
 	CadenceError          = CadenceMetricsPrefix + "error"
@@ -110,6 +127,18 @@ const (
 	ReplaySkippedCounter = CadenceMetricsPrefix + "replay-skipped"
 	ReplayLatency        = CadenceMetricsPrefix + "replay-latency"
 
+	DecisionReplayLatency  = CadenceMetricsPrefix + "decision-replay-latency"  // time spent replaying events in a decision task
+	DecisionReplayedEvents = CadenceMetricsPrefix + "decision-replayed-events" // events replayed in a decision task
+	DecisionHistoryLength  = CadenceMetricsPrefix + "decision-history-length"  // history event count when a decision task is processed
+	DecisionHistorySize    = CadenceMetricsPrefix + "decision-history-size"    // estimated history size in bytes when a decision task is processed
+	DecisionCount          = CadenceMetricsPrefix + "decision-count"           // decisions produced by a decision task
+
+	HistorySoftLimitExceeded = CadenceMetricsPrefix + "history-soft-limit-exceeded"
+
+	WorkflowCoroutines          = CadenceMetricsPrefix + "workflow-coroutines"            // live coroutines after the workflow code blocked
+	WorkflowCoroutineRuns       = CadenceMetricsPrefix + "workflow-coroutine-runs"        // coroutine runs needed until the workflow code blocked
+	WorkflowCoroutineMaxRunTime = CadenceMetricsPrefix + "workflow-coroutine-max-runtime" // longest single coroutine run until the workflow code blocked
+
 	EstimatedHistorySize     = CadenceMetricsPrefix + "estimated-history-size"
 	ServerSideHistorySize    = CadenceMetricsPrefix + "server-side-history-size"
 	ConcurrentTaskQuota      = CadenceMetricsPrefix + "concurrent-task-quota"
@@ -21,6 +21,7 @@
 package metrics
 
 import (
+	"strings"
 	"sync"
 	"time"
 
@@ -204,19 +205,29 @@ func (ts *TaggedScope) GetTaggedScope(keyValueinPairs ...string) tally.Scope {
 		ts.Map = &sync.Map{}
 	}
 
-	key := ""
-	tagsMap := map[string]string{}
+	keyLen := 0
+	for _, s := range keyValueinPairs {
+		keyLen += len(s) + 1
+	}
+	var sb strings.Builder
+	sb.Grow(keyLen)
 	for i := 0; i < len(keyValueinPairs); i += 2 {
-		tagName := keyValueinPairs[i]
-		tagValue := keyValueinPairs[i+1]
-		key = key + tagName + ":" + tagValue + "-" // used to prevent collision of tagValue (map key) for different tagName
-		tagsMap[tagName] = tagValue
+		// separators are used to prevent collision of tagValue (map key) for different tagName
+		sb.WriteString(keyValueinPairs[i])
+		sb.WriteByte(':')
+		sb.WriteString(keyValueinPairs[i+1])
+		sb.WriteByte('-')
 	}
+	key := sb.String()
 
 	taggedScope, ok := ts.Load(key)
 	if !ok {
-		ts.Store(key, ts.Scope.Tagged(tagsMap))
-		taggedScope, _ = ts.Load(key)
+		// the tags map is only needed to create the scope on the first lookup of a key
+		tagsMap := make(map[string]string, len(keyValueinPairs)/2)
+		for i := 0; i < len(keyValueinPairs); i += 2 {
+			tagsMap[keyValueinPairs[i]] = keyValueinPairs[i+1]
+		}
+		taggedScope, _ = ts.LoadOrStore(key, ts.Scope.Tagged(tagsMap))
 	}
 	if taggedScope == nil {
 		panic("metric scope cannot be tagged") // This should never happen
@@ -61,6 +61,21 @@ func Test_Timer(t *testing.T) {
 	require.Equal(t, time.Second, executed.Timers()[0].Value())
 }
 
+func Test_StopwatchFromChildScope(t *testing.T) {
+	t.Parallel()
+	replayed, executed := withScope(t, func(scope tally.Scope) {
+		var stopwatch tally.Stopwatch = scope.SubScope("sub").Tagged(map[string]string{"tag": "value"}).Timer("test-stopwatch").Start()
+		stopwatch.Stop()
+		scope.SubScope("sub").Histogram("test-hist", tally.DefaultBuckets).Start().Stop()
+	})
+	require.Equal(t, 0, len(replayed.Timers()))
+	require.Equal(t, 0, len(replayed.HistogramDurationSamples()))
+	require.Equal(t, 1, len(executed.Timers()))
+	require.Equal(t, "sub.test-stopwatch", executed.Timers()[0].Name())
+	require.Equal(t, map[string]string{"tag": "value"}, executed.Timers()[0].Tags())
+	require.Equal(t, 1, len(executed.HistogramDurationSamples()))
+}
+
 func Test_Histogram(t *testing.T) {
 	t.Parallel()
 	t.Run("values", func(t *testing.T) {
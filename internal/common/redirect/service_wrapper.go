@@ -0,0 +1,405 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package redirect
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/yarpc"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+type workflowServiceRedirectWrapper struct {
+	service workflowserviceclient.Interface
+	resolve func(activeCluster string) (workflowserviceclient.Interface, error)
+}
+
+// NewWorkflowServiceWrapper creates a service that retries a call once against the service returned by
+// resolve when the call fails with a DomainNotActiveError naming the active cluster. The original error is
+// returned if resolve fails or the error does not name an active cluster.
+func NewWorkflowServiceWrapper(
+	service workflowserviceclient.Interface,
+	resolve func(activeCluster string) (workflowserviceclient.Interface, error),
+) workflowserviceclient.Interface {
+	return &workflowServiceRedirectWrapper{
+		service: service,
+		resolve: resolve,
+	}
+}
+
+func (w *workflowServiceRedirectWrapper) redirect(err error) (workflowserviceclient.Interface, bool) {
+	var notActive *shared.DomainNotActiveError
+	if !errors.As(err, &notActive) || notActive.ActiveCluster == "" {
+		return nil, false
+	}
+	service, resolveErr := w.resolve(notActive.ActiveCluster)
+	if resolveErr != nil || service == nil {
+		return nil, false
+	}
+	return service, true
+}
+
+func (w *workflowServiceRedirectWrapper) DeprecateDomain(ctx context.Context, request *shared.DeprecateDomainRequest, opts ...yarpc.CallOption) error {
+	err := w.service.DeprecateDomain(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.DeprecateDomain(ctx, request, opts...)
+	}
+	return err
+}
+
+func (w *workflowServiceRedirectWrapper) ListDomains(ctx context.Context, request *shared.ListDomainsRequest, opts ...yarpc.CallOption) (*shared.ListDomainsResponse, error) {
+	result, err := w.service.ListDomains(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.ListDomains(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) DescribeDomain(ctx context.Context, request *shared.DescribeDomainRequest, opts ...yarpc.CallOption) (*shared.DescribeDomainResponse, error) {
+	result, err := w.service.DescribeDomain(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.DescribeDomain(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) DescribeWorkflowExecution(ctx context.Context, request *shared.DescribeWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.DescribeWorkflowExecutionResponse, error) {
+	result, err := w.service.DescribeWorkflowExecution(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.DescribeWorkflowExecution(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) GetWorkflowExecutionHistory(ctx context.Context, request *shared.GetWorkflowExecutionHistoryRequest, opts ...yarpc.CallOption) (*shared.GetWorkflowExecutionHistoryResponse, error) {
+	result, err := w.service.GetWorkflowExecutionHistory(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.GetWorkflowExecutionHistory(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) ListClosedWorkflowExecutions(ctx context.Context, request *shared.ListClosedWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListClosedWorkflowExecutionsResponse, error) {
+	result, err := w.service.ListClosedWorkflowExecutions(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.ListClosedWorkflowExecutions(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) ListOpenWorkflowExecutions(ctx context.Context, request *shared.ListOpenWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListOpenWorkflowExecutionsResponse, error) {
+	result, err := w.service.ListOpenWorkflowExecutions(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.ListOpenWorkflowExecutions(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) ListWorkflowExecutions(ctx context.Context, request *shared.ListWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+	result, err := w.service.ListWorkflowExecutions(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.ListWorkflowExecutions(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) ListArchivedWorkflowExecutions(ctx context.Context, request *shared.ListArchivedWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListArchivedWorkflowExecutionsResponse, error) {
+	result, err := w.service.ListArchivedWorkflowExecutions(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.ListArchivedWorkflowExecutions(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) ScanWorkflowExecutions(ctx context.Context, request *shared.ListWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+	result, err := w.service.ScanWorkflowExecutions(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.ScanWorkflowExecutions(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) CountWorkflowExecutions(ctx context.Context, request *shared.CountWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.CountWorkflowExecutionsResponse, error) {
+	result, err := w.service.CountWorkflowExecutions(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.CountWorkflowExecutions(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) PollForActivityTask(ctx context.Context, request *shared.PollForActivityTaskRequest, opts ...yarpc.CallOption) (*shared.PollForActivityTaskResponse, error) {
+	result, err := w.service.PollForActivityTask(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.PollForActivityTask(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) PollForDecisionTask(ctx context.Context, request *shared.PollForDecisionTaskRequest, opts ...yarpc.CallOption) (*shared.PollForDecisionTaskResponse, error) {
+	result, err := w.service.PollForDecisionTask(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.PollForDecisionTask(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) RecordActivityTaskHeartbeat(ctx context.Context, request *shared.RecordActivityTaskHeartbeatRequest, opts ...yarpc.CallOption) (*shared.RecordActivityTaskHeartbeatResponse, error) {
+	result, err := w.service.RecordActivityTaskHeartbeat(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RecordActivityTaskHeartbeat(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) RecordActivityTaskHeartbeatByID(ctx context.Context, request *shared.RecordActivityTaskHeartbeatByIDRequest, opts ...yarpc.CallOption) (*shared.RecordActivityTaskHeartbeatResponse, error) {
+	result, err := w.service.RecordActivityTaskHeartbeatByID(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RecordActivityTaskHeartbeatByID(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) RegisterDomain(ctx context.Context, request *shared.RegisterDomainRequest, opts ...yarpc.CallOption) error {
+	err := w.service.RegisterDomain(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RegisterDomain(ctx, request, opts...)
+	}
+	return err
+}
+
+func (w *workflowServiceRedirectWrapper) RequestCancelWorkflowExecution(ctx context.Context, request *shared.RequestCancelWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	err := w.service.RequestCancelWorkflowExecution(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RequestCancelWorkflowExecution(ctx, request, opts...)
+	}
+	return err
+}
+
+func (w *workflowServiceRedirectWrapper) RespondActivityTaskCanceled(ctx context.Context, request *shared.RespondActivityTaskCanceledRequest, opts ...yarpc.CallOption) error {
+	err := w.service.RespondActivityTaskCanceled(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RespondActivityTaskCanceled(ctx, request, opts...)
+	}
+	return err
+}
+
+func (w *workflowServiceRedirectWrapper) RespondActivityTaskCompleted(ctx context.Context, request *shared.RespondActivityTaskCompletedRequest, opts ...yarpc.CallOption) error {
+	err := w.service.RespondActivityTaskCompleted(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RespondActivityTaskCompleted(ctx, request, opts...)
+	}
+	return err
+}
+
+func (w *workflowServiceRedirectWrapper) RespondActivityTaskFailed(ctx context.Context, request *shared.RespondActivityTaskFailedRequest, opts ...yarpc.CallOption) error {
+	err := w.service.RespondActivityTaskFailed(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RespondActivityTaskFailed(ctx, request, opts...)
+	}
+	return err
+}
+
+func (w *workflowServiceRedirectWrapper) RespondActivityTaskCanceledByID(ctx context.Context, request *shared.RespondActivityTaskCanceledByIDRequest, opts ...yarpc.CallOption) error {
+	err := w.service.RespondActivityTaskCanceledByID(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RespondActivityTaskCanceledByID(ctx, request, opts...)
+	}
+	return err
+}
+
+func (w *workflowServiceRedirectWrapper) RespondActivityTaskCompletedByID(ctx context.Context, request *shared.RespondActivityTaskCompletedByIDRequest, opts ...yarpc.CallOption) error {
+	err := w.service.RespondActivityTaskCompletedByID(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RespondActivityTaskCompletedByID(ctx, request, opts...)
+	}
+	return err
+}
+
+func (w *workflowServiceRedirectWrapper) RespondActivityTaskFailedByID(ctx context.Context, request *shared.RespondActivityTaskFailedByIDRequest, opts ...yarpc.CallOption) error {
+	err := w.service.RespondActivityTaskFailedByID(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RespondActivityTaskFailedByID(ctx, request, opts...)
+	}
+	return err
+}
+
+func (w *workflowServiceRedirectWrapper) RespondDecisionTaskCompleted(ctx context.Context, request *shared.RespondDecisionTaskCompletedRequest, opts ...yarpc.CallOption) (*shared.RespondDecisionTaskCompletedResponse, error) {
+	result, err := w.service.RespondDecisionTaskCompleted(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RespondDecisionTaskCompleted(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) RespondDecisionTaskFailed(ctx context.Context, request *shared.RespondDecisionTaskFailedRequest, opts ...yarpc.CallOption) error {
+	err := w.service.RespondDecisionTaskFailed(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RespondDecisionTaskFailed(ctx, request, opts...)
+	}
+	return err
+}
+
+func (w *workflowServiceRedirectWrapper) SignalWorkflowExecution(ctx context.Context, request *shared.SignalWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	err := w.service.SignalWorkflowExecution(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.SignalWorkflowExecution(ctx, request, opts...)
+	}
+	return err
+}
+
+func (w *workflowServiceRedirectWrapper) SignalWithStartWorkflowExecution(ctx context.Context, request *shared.SignalWithStartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionResponse, error) {
+	result, err := w.service.SignalWithStartWorkflowExecution(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.SignalWithStartWorkflowExecution(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) SignalWithStartWorkflowExecutionAsync(ctx context.Context, request *shared.SignalWithStartWorkflowExecutionAsyncRequest, opts ...yarpc.CallOption) (*shared.SignalWithStartWorkflowExecutionAsyncResponse, error) {
+	result, err := w.service.SignalWithStartWorkflowExecutionAsync(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.SignalWithStartWorkflowExecutionAsync(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) StartWorkflowExecution(ctx context.Context, request *shared.StartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionResponse, error) {
+	result, err := w.service.StartWorkflowExecution(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.StartWorkflowExecution(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) StartWorkflowExecutionAsync(ctx context.Context, request *shared.StartWorkflowExecutionAsyncRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionAsyncResponse, error) {
+	result, err := w.service.StartWorkflowExecutionAsync(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.StartWorkflowExecutionAsync(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) TerminateWorkflowExecution(ctx context.Context, request *shared.TerminateWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	err := w.service.TerminateWorkflowExecution(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.TerminateWorkflowExecution(ctx, request, opts...)
+	}
+	return err
+}
+
+func (w *workflowServiceRedirectWrapper) ResetWorkflowExecution(ctx context.Context, request *shared.ResetWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.ResetWorkflowExecutionResponse, error) {
+	result, err := w.service.ResetWorkflowExecution(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.ResetWorkflowExecution(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) UpdateDomain(ctx context.Context, request *shared.UpdateDomainRequest, opts ...yarpc.CallOption) (*shared.UpdateDomainResponse, error) {
+	result, err := w.service.UpdateDomain(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.UpdateDomain(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) QueryWorkflow(ctx context.Context, request *shared.QueryWorkflowRequest, opts ...yarpc.CallOption) (*shared.QueryWorkflowResponse, error) {
+	result, err := w.service.QueryWorkflow(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.QueryWorkflow(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) ResetStickyTaskList(ctx context.Context, request *shared.ResetStickyTaskListRequest, opts ...yarpc.CallOption) (*shared.ResetStickyTaskListResponse, error) {
+	result, err := w.service.ResetStickyTaskList(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.ResetStickyTaskList(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) DescribeTaskList(ctx context.Context, request *shared.DescribeTaskListRequest, opts ...yarpc.CallOption) (*shared.DescribeTaskListResponse, error) {
+	result, err := w.service.DescribeTaskList(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.DescribeTaskList(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) RespondQueryTaskCompleted(ctx context.Context, request *shared.RespondQueryTaskCompletedRequest, opts ...yarpc.CallOption) error {
+	err := w.service.RespondQueryTaskCompleted(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RespondQueryTaskCompleted(ctx, request, opts...)
+	}
+	return err
+}
+
+func (w *workflowServiceRedirectWrapper) GetSearchAttributes(ctx context.Context, opts ...yarpc.CallOption) (*shared.GetSearchAttributesResponse, error) {
+	result, err := w.service.GetSearchAttributes(ctx, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.GetSearchAttributes(ctx, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) ListTaskListPartitions(ctx context.Context, request *shared.ListTaskListPartitionsRequest, opts ...yarpc.CallOption) (*shared.ListTaskListPartitionsResponse, error) {
+	result, err := w.service.ListTaskListPartitions(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.ListTaskListPartitions(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) GetClusterInfo(ctx context.Context, opts ...yarpc.CallOption) (*shared.ClusterInfo, error) {
+	result, err := w.service.GetClusterInfo(ctx, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.GetClusterInfo(ctx, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) GetTaskListsByDomain(ctx context.Context, request *shared.GetTaskListsByDomainRequest, opts ...yarpc.CallOption) (*shared.GetTaskListsByDomainResponse, error) {
+	result, err := w.service.GetTaskListsByDomain(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.GetTaskListsByDomain(ctx, request, opts...)
+	}
+	return result, err
+}
+
+func (w *workflowServiceRedirectWrapper) RefreshWorkflowTasks(ctx context.Context, request *shared.RefreshWorkflowTasksRequest, opts ...yarpc.CallOption) error {
+	err := w.service.RefreshWorkflowTasks(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RefreshWorkflowTasks(ctx, request, opts...)
+	}
+	return err
+}
+
+func (w *workflowServiceRedirectWrapper) RestartWorkflowExecution(ctx context.Context, request *shared.RestartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.RestartWorkflowExecutionResponse, error) {
+	result, err := w.service.RestartWorkflowExecution(ctx, request, opts...)
+	if service, ok := w.redirect(err); ok {
+		return service.RestartWorkflowExecution(ctx, request, opts...)
+	}
+	return result, err
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package redirect
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+func TestRedirect(t *testing.T) {
+	notActive := &shared.DomainNotActiveError{DomainName: "domain", CurrentCluster: "cluster-a", ActiveCluster: "cluster-b"}
+	request := &shared.SignalWorkflowExecutionRequest{}
+
+	t.Run("retries on the active cluster", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		primary := workflowservicetest.NewMockClient(ctrl)
+		active := workflowservicetest.NewMockClient(ctrl)
+		primary.EXPECT().SignalWorkflowExecution(gomock.Any(), request, gomock.Any()).Return(notActive)
+		active.EXPECT().SignalWorkflowExecution(gomock.Any(), request, gomock.Any()).Return(nil)
+
+		var resolved []string
+		sw := NewWorkflowServiceWrapper(primary, func(cluster string) (workflowserviceclient.Interface, error) {
+			resolved = append(resolved, cluster)
+			return active, nil
+		})
+		assert.NoError(t, sw.SignalWorkflowExecution(context.Background(), request))
+		assert.Equal(t, []string{"cluster-b"}, resolved)
+	})
+	t.Run("returns the original error when resolving fails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		primary := workflowservicetest.NewMockClient(ctrl)
+		primary.EXPECT().SignalWorkflowExecution(gomock.Any(), request, gomock.Any()).Return(notActive)
+
+		sw := NewWorkflowServiceWrapper(primary, func(string) (workflowserviceclient.Interface, error) {
+			return nil, errors.New("unknown cluster")
+		})
+		assert.Equal(t, notActive, sw.SignalWorkflowExecution(context.Background(), request))
+	})
+	t.Run("does not redirect other errors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		primary := workflowservicetest.NewMockClient(ctrl)
+		primary.EXPECT().DescribeDomain(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, &shared.EntityNotExistsError{})
+		primary.EXPECT().GetClusterInfo(gomock.Any(), gomock.Any()).Return(nil, &shared.DomainNotActiveError{})
+
+		sw := NewWorkflowServiceWrapper(primary, func(string) (workflowserviceclient.Interface, error) {
+			t.Fatal("unexpected redirect")
+			return nil, nil
+		})
+		_, err := sw.DescribeDomain(context.Background(), &shared.DescribeDomainRequest{})
+		assert.Equal(t, &shared.EntityNotExistsError{}, err)
+		// no active cluster to redirect to
+		_, err = sw.GetClusterInfo(context.Background())
+		assert.Equal(t, &shared.DomainNotActiveError{}, err)
+	})
+}
@@ -0,0 +1,387 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rpcheaders
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/yarpc"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+type (
+	workflowServiceHeadersWrapper struct {
+		service workflowserviceclient.Interface
+		headers map[string]string
+	}
+
+	contextKey struct{}
+)
+
+// ReservedPrefix is the prefix of the headers set by the client itself, like its version and feature flags.
+// Custom headers using it are rejected by Validate and dropped by the wrapper.
+const ReservedPrefix = "cadence-"
+
+// Validate returns an error if a header name is empty or uses ReservedPrefix.
+func Validate(headers map[string]string) error {
+	for name := range headers {
+		if name == "" {
+			return fmt.Errorf("empty header name")
+		}
+		if isReserved(name) {
+			return fmt.Errorf("header %q uses the reserved %v prefix", name, ReservedPrefix)
+		}
+	}
+	return nil
+}
+
+func isReserved(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), ReservedPrefix)
+}
+
+// NewWorkflowServiceWrapper creates a service wrapper which attaches the given headers, along with
+// any headers added to the call context with WithHeaders, to every outgoing request.
+// Headers which do not pass Validate are dropped.
+func NewWorkflowServiceWrapper(service workflowserviceclient.Interface, headers map[string]string) workflowserviceclient.Interface {
+	copied := make(map[string]string, len(headers))
+	for k, v := range headers {
+		copied[k] = v
+	}
+	return &workflowServiceHeadersWrapper{
+		service: service,
+		headers: copied,
+	}
+}
+
+// WithHeaders returns a context carrying headers for the calls made with it. They are merged with
+// headers added by outer contexts and take precedence over the headers configured on the wrapper.
+func WithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	merged := make(map[string]string, len(headers))
+	for k, v := range FromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, contextKey{}, merged)
+}
+
+// FromContext returns the headers added to the context with WithHeaders.
+func FromContext(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	headers, _ := ctx.Value(contextKey{}).(map[string]string)
+	return headers
+}
+
+func (w *workflowServiceHeadersWrapper) callOptions(ctx context.Context) []yarpc.CallOption {
+	return CallOptions(w.headers, FromContext(ctx))
+}
+
+// CallOptions returns the call options adding headers to a request, in a deterministic order, with overrides
+// taking precedence over headers of the same name. Headers which do not pass Validate are dropped.
+func CallOptions(headers, overrides map[string]string) []yarpc.CallOption {
+	keys := make([]string, 0, len(headers)+len(overrides))
+	for k := range headers {
+		if _, ok := overrides[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	opts := make([]yarpc.CallOption, 0, len(keys))
+	for _, k := range keys {
+		if k == "" || isReserved(k) {
+			continue
+		}
+		v, ok := overrides[k]
+		if !ok {
+			v = headers[k]
+		}
+		opts = append(opts, yarpc.WithHeader(k, v))
+	}
+	return opts
+}
+
+func (w *workflowServiceHeadersWrapper) DeprecateDomain(ctx context.Context, request *shared.DeprecateDomainRequest, opts ...yarpc.CallOption) error {
+	opts = append(opts, w.callOptions(ctx)...)
+	return w.service.DeprecateDomain(ctx, request, opts...)
+}
+
+func (w *workflowServiceHeadersWrapper) ListDomains(ctx context.Context, request *shared.ListDomainsRequest, opts ...yarpc.CallOption) (*shared.ListDomainsResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.ListDomains(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) DescribeDomain(ctx context.Context, request *shared.DescribeDomainRequest, opts ...yarpc.CallOption) (*shared.DescribeDomainResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.DescribeDomain(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) DescribeWorkflowExecution(ctx context.Context, request *shared.DescribeWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.DescribeWorkflowExecutionResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.DescribeWorkflowExecution(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) GetWorkflowExecutionHistory(ctx context.Context, request *shared.GetWorkflowExecutionHistoryRequest, opts ...yarpc.CallOption) (*shared.GetWorkflowExecutionHistoryResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.GetWorkflowExecutionHistory(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) ListClosedWorkflowExecutions(ctx context.Context, request *shared.ListClosedWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListClosedWorkflowExecutionsResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.ListClosedWorkflowExecutions(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) ListOpenWorkflowExecutions(ctx context.Context, request *shared.ListOpenWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListOpenWorkflowExecutionsResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.ListOpenWorkflowExecutions(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) ListWorkflowExecutions(ctx context.Context, request *shared.ListWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.ListWorkflowExecutions(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) ListArchivedWorkflowExecutions(ctx context.Context, request *shared.ListArchivedWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListArchivedWorkflowExecutionsResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.ListArchivedWorkflowExecutions(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) ScanWorkflowExecutions(ctx context.Context, request *shared.ListWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.ScanWorkflowExecutions(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) CountWorkflowExecutions(ctx context.Context, request *shared.CountWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.CountWorkflowExecutionsResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.CountWorkflowExecutions(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) PollForActivityTask(ctx context.Context, request *shared.PollForActivityTaskRequest, opts ...yarpc.CallOption) (*shared.PollForActivityTaskResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.PollForActivityTask(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) PollForDecisionTask(ctx context.Context, request *shared.PollForDecisionTaskRequest, opts ...yarpc.CallOption) (*shared.PollForDecisionTaskResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.PollForDecisionTask(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) RecordActivityTaskHeartbeat(ctx context.Context, request *shared.RecordActivityTaskHeartbeatRequest, opts ...yarpc.CallOption) (*shared.RecordActivityTaskHeartbeatResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.RecordActivityTaskHeartbeat(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) RecordActivityTaskHeartbeatByID(ctx context.Context, request *shared.RecordActivityTaskHeartbeatByIDRequest, opts ...yarpc.CallOption) (*shared.RecordActivityTaskHeartbeatResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.RecordActivityTaskHeartbeatByID(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) RegisterDomain(ctx context.Context, request *shared.RegisterDomainRequest, opts ...yarpc.CallOption) error {
+	opts = append(opts, w.callOptions(ctx)...)
+	err := w.service.RegisterDomain(ctx, request, opts...)
+	return err
+}
+
+func (w *workflowServiceHeadersWrapper) RequestCancelWorkflowExecution(ctx context.Context, request *shared.RequestCancelWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	opts = append(opts, w.callOptions(ctx)...)
+	err := w.service.RequestCancelWorkflowExecution(ctx, request, opts...)
+	return err
+}
+
+func (w *workflowServiceHeadersWrapper) RespondActivityTaskCanceled(ctx context.Context, request *shared.RespondActivityTaskCanceledRequest, opts ...yarpc.CallOption) error {
+	opts = append(opts, w.callOptions(ctx)...)
+	err := w.service.RespondActivityTaskCanceled(ctx, request, opts...)
+	return err
+}
+
+func (w *workflowServiceHeadersWrapper) RespondActivityTaskCompleted(ctx context.Context, request *shared.RespondActivityTaskCompletedRequest, opts ...yarpc.CallOption) error {
+	opts = append(opts, w.callOptions(ctx)...)
+	err := w.service.RespondActivityTaskCompleted(ctx, request, opts...)
+	return err
+}
+
+func (w *workflowServiceHeadersWrapper) RespondActivityTaskFailed(ctx context.Context, request *shared.RespondActivityTaskFailedRequest, opts ...yarpc.CallOption) error {
+	opts = append(opts, w.callOptions(ctx)...)
+	err := w.service.RespondActivityTaskFailed(ctx, request, opts...)
+	return err
+}
+
+func (w *workflowServiceHeadersWrapper) RespondActivityTaskCanceledByID(ctx context.Context, request *shared.RespondActivityTaskCanceledByIDRequest, opts ...yarpc.CallOption) error {
+	opts = append(opts, w.callOptions(ctx)...)
+	err := w.service.RespondActivityTaskCanceledByID(ctx, request, opts...)
+	return err
+}
+
+func (w *workflowServiceHeadersWrapper) RespondActivityTaskCompletedByID(ctx context.Context, request *shared.RespondActivityTaskCompletedByIDRequest, opts ...yarpc.CallOption) error {
+	opts = append(opts, w.callOptions(ctx)...)
+	err := w.service.RespondActivityTaskCompletedByID(ctx, request, opts...)
+	return err
+}
+
+func (w *workflowServiceHeadersWrapper) RespondActivityTaskFailedByID(ctx context.Context, request *shared.RespondActivityTaskFailedByIDRequest, opts ...yarpc.CallOption) error {
+	opts = append(opts, w.callOptions(ctx)...)
+	err := w.service.RespondActivityTaskFailedByID(ctx, request, opts...)
+	return err
+}
+
+func (w *workflowServiceHeadersWrapper) RespondDecisionTaskCompleted(ctx context.Context, request *shared.RespondDecisionTaskCompletedRequest, opts ...yarpc.CallOption) (*shared.RespondDecisionTaskCompletedResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	response, err := w.service.RespondDecisionTaskCompleted(ctx, request, opts...)
+	return response, err
+}
+
+func (w *workflowServiceHeadersWrapper) RespondDecisionTaskFailed(ctx context.Context, request *shared.RespondDecisionTaskFailedRequest, opts ...yarpc.CallOption) error {
+	opts = append(opts, w.callOptions(ctx)...)
+	err := w.service.RespondDecisionTaskFailed(ctx, request, opts...)
+	return err
+}
+
+func (w *workflowServiceHeadersWrapper) SignalWorkflowExecution(ctx context.Context, request *shared.SignalWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	opts = append(opts, w.callOptions(ctx)...)
+	err := w.service.SignalWorkflowExecution(ctx, request, opts...)
+	return err
+}
+
+func (w *workflowServiceHeadersWrapper) SignalWithStartWorkflowExecution(ctx context.Context, request *shared.SignalWithStartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.SignalWithStartWorkflowExecution(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) SignalWithStartWorkflowExecutionAsync(ctx context.Context, request *shared.SignalWithStartWorkflowExecutionAsyncRequest, opts ...yarpc.CallOption) (*shared.SignalWithStartWorkflowExecutionAsyncResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.SignalWithStartWorkflowExecutionAsync(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) StartWorkflowExecution(ctx context.Context, request *shared.StartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.StartWorkflowExecution(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) StartWorkflowExecutionAsync(ctx context.Context, request *shared.StartWorkflowExecutionAsyncRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionAsyncResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.StartWorkflowExecutionAsync(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) TerminateWorkflowExecution(ctx context.Context, request *shared.TerminateWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	opts = append(opts, w.callOptions(ctx)...)
+	err := w.service.TerminateWorkflowExecution(ctx, request, opts...)
+	return err
+}
+
+func (w *workflowServiceHeadersWrapper) ResetWorkflowExecution(ctx context.Context, request *shared.ResetWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.ResetWorkflowExecutionResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.ResetWorkflowExecution(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) UpdateDomain(ctx context.Context, request *shared.UpdateDomainRequest, opts ...yarpc.CallOption) (*shared.UpdateDomainResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.UpdateDomain(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) QueryWorkflow(ctx context.Context, request *shared.QueryWorkflowRequest, opts ...yarpc.CallOption) (*shared.QueryWorkflowResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.QueryWorkflow(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) ResetStickyTaskList(ctx context.Context, request *shared.ResetStickyTaskListRequest, opts ...yarpc.CallOption) (*shared.ResetStickyTaskListResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.ResetStickyTaskList(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) DescribeTaskList(ctx context.Context, request *shared.DescribeTaskListRequest, opts ...yarpc.CallOption) (*shared.DescribeTaskListResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.DescribeTaskList(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) RespondQueryTaskCompleted(ctx context.Context, request *shared.RespondQueryTaskCompletedRequest, opts ...yarpc.CallOption) error {
+	opts = append(opts, w.callOptions(ctx)...)
+	err := w.service.RespondQueryTaskCompleted(ctx, request, opts...)
+	return err
+}
+
+func (w *workflowServiceHeadersWrapper) GetSearchAttributes(ctx context.Context, opts ...yarpc.CallOption) (*shared.GetSearchAttributesResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.GetSearchAttributes(ctx, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) ListTaskListPartitions(ctx context.Context, request *shared.ListTaskListPartitionsRequest, opts ...yarpc.CallOption) (*shared.ListTaskListPartitionsResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.ListTaskListPartitions(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) GetClusterInfo(ctx context.Context, opts ...yarpc.CallOption) (*shared.ClusterInfo, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.GetClusterInfo(ctx, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) GetTaskListsByDomain(ctx context.Context, request *shared.GetTaskListsByDomainRequest, opts ...yarpc.CallOption) (*shared.GetTaskListsByDomainResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	result, err := w.service.GetTaskListsByDomain(ctx, request, opts...)
+	return result, err
+}
+
+func (w *workflowServiceHeadersWrapper) RefreshWorkflowTasks(ctx context.Context, request *shared.RefreshWorkflowTasksRequest, opts ...yarpc.CallOption) error {
+	opts = append(opts, w.callOptions(ctx)...)
+	err := w.service.RefreshWorkflowTasks(ctx, request, opts...)
+	return err
+}
+
+func (w *workflowServiceHeadersWrapper) RestartWorkflowExecution(ctx context.Context, request *shared.RestartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.RestartWorkflowExecutionResponse, error) {
+	opts = append(opts, w.callOptions(ctx)...)
+	return w.service.RestartWorkflowExecution(ctx, request, opts...)
+}
@@ -29,10 +29,18 @@ import (
 )
 
 func anyToString(d interface{}) string {
+	return anyToStringWithLimit(d, 0)
+}
+
+// anyToStringWithLimit is anyToString with blobs truncated to maxBlobSize bytes, 0 means no truncation
+func anyToStringWithLimit(d interface{}, maxBlobSize int) string {
 	v := reflect.ValueOf(d)
 	switch v.Kind() {
 	case reflect.Ptr:
-		return anyToString(v.Elem().Interface())
+		if v.IsNil() {
+			return "<nil>"
+		}
+		return anyToStringWithLimit(v.Elem().Interface(), maxBlobSize)
 	case reflect.Struct:
 		var buf bytes.Buffer
 		t := reflect.TypeOf(d)
@@ -42,7 +50,7 @@ func anyToString(d interface{}) string {
 			if f.Kind() == reflect.Invalid {
 				continue
 			}
-			fieldValue := valueToString(f)
+			fieldValue := valueToStringWithLimit(f, maxBlobSize)
 			if len(fieldValue) == 0 {
 				continue
 			}
@@ -59,16 +67,24 @@ func anyToString(d interface{}) string {
 }
 
 func valueToString(v reflect.Value) string {
+	return valueToStringWithLimit(v, 0)
+}
+
+func valueToStringWithLimit(v reflect.Value, maxBlobSize int) string {
 	switch v.Kind() {
 	case reflect.Ptr:
-		return valueToString(v.Elem())
+		return valueToStringWithLimit(v.Elem(), maxBlobSize)
 	case reflect.Struct:
-		return anyToString(v.Interface())
+		return anyToStringWithLimit(v.Interface(), maxBlobSize)
 	case reflect.Invalid:
 		return ""
 	case reflect.Slice:
 		if v.Type().Elem().Kind() == reflect.Uint8 {
-			return fmt.Sprintf("[%v]", string(v.Bytes()))
+			blob := v.Bytes()
+			if maxBlobSize > 0 && len(blob) > maxBlobSize {
+				return fmt.Sprintf("[%v...(%d bytes)]", string(blob[:maxBlobSize]), len(blob))
+			}
+			return fmt.Sprintf("[%v]", string(blob))
 		}
 		return fmt.Sprintf("[len=%d]", v.Len())
 	default:
@@ -78,9 +94,15 @@ func valueToString(v reflect.Value) string {
 
 // HistoryEventToString convert HistoryEvent to string
 func HistoryEventToString(e *s.HistoryEvent) string {
+	return TruncatedHistoryEventToString(e, 0)
+}
+
+// TruncatedHistoryEventToString convert HistoryEvent to string, truncating payloads to maxPayloadSize bytes.
+// A maxPayloadSize of 0 disables the truncation.
+func TruncatedHistoryEventToString(e *s.HistoryEvent, maxPayloadSize int) string {
 	data := getHistoryEventData(e)
 
-	return e.GetEventType().String() + ": " + anyToString(data)
+	return e.GetEventType().String() + ": " + anyToStringWithLimit(data, maxPayloadSize)
 }
 
 func getHistoryEventData(e *s.HistoryEvent) interface{} {
@@ -158,9 +180,15 @@ func getHistoryEventData(e *s.HistoryEvent) interface{} {
 
 // DecisionToString convert Decision to string
 func DecisionToString(d *s.Decision) string {
+	return TruncatedDecisionToString(d, 0)
+}
+
+// TruncatedDecisionToString convert Decision to string, truncating payloads to maxPayloadSize bytes.
+// A maxPayloadSize of 0 disables the truncation.
+func TruncatedDecisionToString(d *s.Decision, maxPayloadSize int) string {
 	data := decisionGetData(d)
 
-	return d.GetDecisionType().String() + ": " + anyToString(data)
+	return d.GetDecisionType().String() + ": " + anyToStringWithLimit(data, maxPayloadSize)
 }
 
 func decisionGetData(d *s.Decision) interface{} {
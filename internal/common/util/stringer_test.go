@@ -42,6 +42,11 @@ func Test_anyToString(t *testing.T) {
 			thingToSerialize: nil,
 			expected:         "<nil>",
 		},
+		{
+			name:             "nil pointer",
+			thingToSerialize: (*struct{ A int })(nil),
+			expected:         "<nil>",
+		},
 		{
 			name:             "int",
 			thingToSerialize: 1,
@@ -335,6 +340,20 @@ func TestDecisionToString(t *testing.T) {
 	require.Equal(t, expected, strVal)
 }
 
+func TestTruncatedDecisionToString(t *testing.T) {
+	decision := &s.Decision{
+		DecisionType: toPtr(s.DecisionTypeScheduleActivityTask),
+		ScheduleActivityTaskDecisionAttributes: &s.ScheduleActivityTaskDecisionAttributes{
+			ActivityId: toPtr("activity-id"),
+			Input:      []byte("0123456789"),
+		},
+	}
+
+	require.Equal(t, "ScheduleActivityTask: (ActivityId:activity-id, Input:[0123...(10 bytes)])", TruncatedDecisionToString(decision, 4))
+	require.Equal(t, "ScheduleActivityTask: (ActivityId:activity-id, Input:[0123456789])", TruncatedDecisionToString(decision, 10))
+	require.Equal(t, DecisionToString(decision), TruncatedDecisionToString(decision, 0))
+}
+
 // This just tests that we pick the right attributes to return
 // the other attributes will be nil
 func Test_decisionGetData(t *testing.T) {
@@ -202,7 +202,7 @@ func WithCancel(parent Context) (ctx Context, cancel CancelFunc) {
 //	err := workflow.ExecuteActivity(ctx, ActivityFoo).Get(ctx, &activityFooResult)
 //	if err != nil && cadence.IsCanceledError(ctx.Err()) {
 //	  // activity failed, and workflow context is canceled
-//	  disconnectedCtx, _ := workflow.newDisconnectedContext(ctx);
+//	  disconnectedCtx, _ := workflow.NewDisconnectedContext(ctx)
 //	  workflow.ExecuteActivity(disconnectedCtx, handleCancellationActivity).Get(disconnectedCtx, nil)
 //	  return err // workflow return CanceledError
 //	}
@@ -211,6 +211,34 @@ func NewDisconnectedContext(parent Context) (ctx Context, cancel CancelFunc) {
 	return c, func() { c.cancel(true, ErrCanceled) }
 }
 
+// WithTimeout returns a copy of parent which is canceled when timeout elapses, when the returned cancel function is
+// called, or when the parent context is canceled, whichever happens first. The timeout is tracked by a durable workflow
+// timer, and once it elapses the Err method of the returned context returns ErrDeadlineExceeded.
+//
+// Canceling this context cancels its timer, so code should call cancel as soon as the operations running in this
+// Context complete:
+//
+//	ctx, cancel := workflow.WithTimeout(ctx, 10*time.Minute)
+//	defer cancel() // cancels the timer if the activity completes before the timeout
+//	err := workflow.ExecuteActivity(ctx, SlowActivity).Get(ctx, nil)
+func WithTimeout(parent Context, timeout time.Duration) (ctx Context, cancel CancelFunc) {
+	c := newCancelCtx(parent)
+	propagateCancel(parent, c)
+	cancel = func() { c.cancel(true, ErrCanceled) }
+	if timeout <= 0 {
+		c.cancel(true, ErrDeadlineExceeded)
+		return c, cancel
+	}
+	// the timer is canceled with the context
+	timer := NewTimer(c, timeout)
+	GoNamed(c, "timeout", func(ctx Context) {
+		if timer.Get(ctx, nil) == nil {
+			c.cancel(true, ErrDeadlineExceeded)
+		}
+	})
+	return c, cancel
+}
+
 // newCancelCtx returns an initialized cancelCtx.
 func newCancelCtx(parent Context) *cancelCtx {
 	return &cancelCtx{
@@ -444,20 +472,6 @@ func (c *cancelCtx) cancel(removeFromParent bool, err error) {
 //		c.timer = nil
 //	}
 // }
-//
-// WithTimeout returns WithDeadline(parent, time.Now().Add(timeout)).
-//
-// Canceling this context releases resources associated with it, so code should
-// call cancel as soon as the operations running in this Context complete:
-//
-// 	func slowOperationWithTimeout(ctx context.Context) (Result, error) {
-// 		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
-// 		defer cancel()  // releases resources if slowOperation completes before timeout elapses
-// 		return slowOperation(ctx)
-// 	}
-// func WithTimeout(parent Context, timeout time.Duration) (Context, CancelFunc) {
-//	return WithDeadline(parent, time.Now().Add(timeout))
-// }
 
 // WithValue returns a copy of parent in which the value associated with key is
 // val.
@@ -0,0 +1,58 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+// DecisionTaskListener is notified of the progress of the workflows processed by a worker, for example to build
+// audit trails or debug consoles. Register it through WorkerOptions.DecisionTaskListener and embed
+// DecisionTaskListenerBase in implementations that are not interested in every callback.
+// Callbacks are invoked synchronously while the decision task is processed, so they must be fast and must not block.
+// They are invoked again when the history of a workflow is replayed, use the isReplay argument to filter out
+// duplicated calls.
+type DecisionTaskListener interface {
+	// OnDecisionTaskStarted is called when the worker starts processing a decision task of the workflow,
+	// before the workflow code runs for it.
+	OnDecisionTaskStarted(info *WorkflowInfo, isReplay bool)
+	// OnActivityScheduled is called when the workflow schedules an activity.
+	OnActivityScheduled(info *WorkflowInfo, activityID string, activityType string, isReplay bool)
+	// OnTimerFired is called when a timer of the workflow fires.
+	OnTimerFired(info *WorkflowInfo, timerID string, isReplay bool)
+	// OnWorkflowCompleted is called when the workflow function returns, err is nil if it completed successfully.
+	// It is also called with a *ContinueAsNewError or a *CanceledError if the workflow continues as new or is canceled.
+	OnWorkflowCompleted(info *WorkflowInfo, result Value, err error, isReplay bool)
+}
+
+var _ DecisionTaskListener = DecisionTaskListenerBase{}
+
+// DecisionTaskListenerBase is a no-op DecisionTaskListener, to be embedded in listeners which only implement a
+// subset of the callbacks.
+type DecisionTaskListenerBase struct{}
+
+// OnDecisionTaskStarted does nothing
+func (DecisionTaskListenerBase) OnDecisionTaskStarted(*WorkflowInfo, bool) {}
+
+// OnActivityScheduled does nothing
+func (DecisionTaskListenerBase) OnActivityScheduled(*WorkflowInfo, string, string, bool) {}
+
+// OnTimerFired does nothing
+func (DecisionTaskListenerBase) OnTimerFired(*WorkflowInfo, string, bool) {}
+
+// OnWorkflowCompleted does nothing
+func (DecisionTaskListenerBase) OnWorkflowCompleted(*WorkflowInfo, Value, error, bool) {}
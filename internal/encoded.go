@@ -78,6 +78,27 @@ func getDefaultDataConverter() DataConverter {
 	return defaultJSONDataConverter
 }
 
+// EncodeArgs encodes values the way the framework encodes the arguments of activities, child workflows,
+// signals and queries. The default data converter is used when dc is nil.
+func EncodeArgs(dc DataConverter, args ...interface{}) ([]byte, error) {
+	return encodeArgs(dc, args)
+}
+
+// DecodeArgs decodes data produced by EncodeArgs, or by the framework, into value pointers, the way results and
+// arguments of activities, child workflows, signals and queries are decoded.
+// A nil value pointer skips the corresponding value with the default data converter, other data converters may
+// not support it. ErrNoData is returned when there is no data to decode.
+// The default data converter is used when dc is nil.
+func DecodeArgs(dc DataConverter, data []byte, valuePtrs ...interface{}) error {
+	if data == nil && len(valuePtrs) > 0 {
+		return ErrNoData
+	}
+	if dc == nil {
+		dc = getDefaultDataConverter()
+	}
+	return dc.FromData(data, valuePtrs...)
+}
+
 func (dc *defaultDataConverter) ToData(r ...interface{}) ([]byte, error) {
 	if len(r) == 1 && util.IsTypeByteSlice(reflect.TypeOf(r[0])) {
 		return r[0].([]byte), nil
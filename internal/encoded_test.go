@@ -128,3 +128,37 @@ func TestDecodeArg(t *testing.T) {
 	require.NoError(t, err)
 	require.Error(t, decodeArg(dc, b, &r))
 }
+
+func TestEncodeDecodeArgs(t *testing.T) {
+	t.Parallel()
+
+	data, err := EncodeArgs(nil, "first", 2, (*testStruct)(nil), &testErrorDetails3)
+	require.NoError(t, err)
+
+	var first string
+	var second int
+	var nilStruct *testStruct
+	var nonNilStruct *testStruct
+	require.NoError(t, DecodeArgs(nil, data, &first, &second, &nilStruct, &nonNilStruct))
+	require.Equal(t, "first", first)
+	require.Equal(t, 2, second)
+	require.Nil(t, nilStruct)
+	require.Equal(t, &testErrorDetails3, nonNilStruct)
+
+	// nil value pointers skip values
+	second = 0
+	require.NoError(t, DecodeArgs(nil, data, nil, &second))
+	require.Equal(t, 2, second)
+	second = 0
+	require.NoError(t, newEncodedValues(data, nil).Get(nil, &second))
+	require.Equal(t, 2, second)
+
+	require.Error(t, DecodeArgs(nil, data, nil, second))
+	require.Equal(t, ErrNoData, DecodeArgs(nil, nil, &first))
+
+	gobData, err := EncodeArgs(&testDataConverter{}, "first", 2)
+	require.NoError(t, err)
+	second = 0
+	require.NoError(t, DecodeArgs(&testDataConverter{}, gobData, &first, &second))
+	require.Equal(t, 2, second)
+}
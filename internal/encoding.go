@@ -63,6 +63,10 @@ func (g jsonEncoding) Unmarshal(data []byte, objs []interface{}) error {
 	dec := json.NewDecoder(bytes.NewBuffer(data))
 	dec.UseNumber()
 	for i, obj := range objs {
+		if obj == nil {
+			// skips the value
+			obj = new(json.RawMessage)
+		}
 		if err := dec.Decode(obj); err != nil {
 			return fmt.Errorf(
 				"unable to decode argument: %d, %v, with json error: %v", i, reflect.TypeOf(obj), err)
@@ -0,0 +1,185 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// encryptedPayloadVersion is the first byte of the payloads produced by the encrypted data converter.
+// The payload layout is: version, key ID length, key ID, nonce, AES-GCM sealed data. Payloads are base64 encoded,
+// so they survive being stored as strings, as MutableSideEffect markers do.
+const encryptedPayloadVersion byte = 1
+
+type (
+	// KeyProvider provides the keys used by the encrypted data converter, see NewEncryptedDataConverter.
+	// Keys are AES keys of 16, 24 or 32 bytes.
+	KeyProvider interface {
+		// CurrentKey returns the ID and the key new payloads are encrypted with.
+		CurrentKey() (keyID string, key []byte, err error)
+		// Key returns the key with the given ID. It is used to decrypt payloads, so keys must remain
+		// available for as long as payloads encrypted with them are part of workflow histories.
+		Key(keyID string) ([]byte, error)
+	}
+
+	encryptedDataConverter struct {
+		dataConverter DataConverter
+		keys          KeyProvider
+	}
+
+	staticKeyProvider struct {
+		currentKeyID string
+		keys         map[string][]byte
+	}
+)
+
+// NewEncryptedDataConverter creates a data converter which encrypts the payloads produced by dataConverter
+// with AES-GCM, using the current key of the key provider. The key ID is stored in the payload, so payloads
+// encrypted with previous keys can still be decrypted after the current key is rotated.
+// The default data converter is used when dataConverter is nil.
+//
+// Payloads which were not produced by an encrypted data converter are rejected, except for empty ones.
+// Encryption uses a random nonce, so encoding the same value twice produces different payloads.
+// Payloads are base64 encoded text, which keeps them intact where they are stored as strings.
+func NewEncryptedDataConverter(dataConverter DataConverter, keys KeyProvider) DataConverter {
+	if keys == nil {
+		panic("keys must not be nil")
+	}
+	if dataConverter == nil {
+		dataConverter = getDefaultDataConverter()
+	}
+	return &encryptedDataConverter{dataConverter: dataConverter, keys: keys}
+}
+
+// NewStaticKeyProvider creates a key provider from a fixed set of keys, indexed by key ID.
+// To rotate keys, add the new key to the set and make it current, keeping the previous keys.
+func NewStaticKeyProvider(currentKeyID string, keys map[string][]byte) (KeyProvider, error) {
+	if _, ok := keys[currentKeyID]; !ok {
+		return nil, fmt.Errorf("current key %q is not in the key set", currentKeyID)
+	}
+	copied := make(map[string][]byte, len(keys))
+	for id, key := range keys {
+		if err := validateEncryptionKey(id, key); err != nil {
+			return nil, err
+		}
+		copied[id] = append([]byte(nil), key...)
+	}
+	return &staticKeyProvider{currentKeyID: currentKeyID, keys: copied}, nil
+}
+
+func (p *staticKeyProvider) CurrentKey() (string, []byte, error) {
+	return p.currentKeyID, p.keys[p.currentKeyID], nil
+}
+
+func (p *staticKeyProvider) Key(keyID string) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", keyID)
+	}
+	return key, nil
+}
+
+func (dc *encryptedDataConverter) ToData(value ...interface{}) ([]byte, error) {
+	data, err := dc.dataConverter.ToData(value...)
+	if err != nil {
+		return nil, err
+	}
+	keyID, key, err := dc.keys.CurrentKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current encryption key: %v", err)
+	}
+	if err := validateEncryptionKey(keyID, key); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, 2+len(keyID)+aead.NonceSize())
+	header = append(header, encryptedPayloadVersion, byte(len(keyID)))
+	header = append(header, keyID...)
+	nonce := header[len(header) : len(header)+aead.NonceSize()]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	header = header[:len(header)+aead.NonceSize()]
+	// the header is authenticated, so the key ID and the version cannot be tampered with
+	sealed := aead.Seal(header, nonce, data, header)
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(len(sealed)))
+	base64.StdEncoding.Encode(encoded, sealed)
+	return encoded, nil
+}
+
+func (dc *encryptedDataConverter) FromData(input []byte, valuePtr ...interface{}) error {
+	if len(input) == 0 {
+		return dc.dataConverter.FromData(input, valuePtr...)
+	}
+	input, err := base64.StdEncoding.DecodeString(string(input))
+	if err != nil || len(input) < 2 || input[0] != encryptedPayloadVersion || len(input) < 2+int(input[1]) {
+		return errors.New("payload is not encrypted or has an unsupported format")
+	}
+	keyIDEnd := 2 + int(input[1])
+	keyID := string(input[2:keyIDEnd])
+	key, err := dc.keys.Key(keyID)
+	if err != nil {
+		return fmt.Errorf("failed to get encryption key %q: %v", keyID, err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	if len(input) < keyIDEnd+aead.NonceSize() {
+		return errors.New("encrypted payload is truncated")
+	}
+	header := input[:keyIDEnd+aead.NonceSize()]
+	data, err := aead.Open(nil, header[keyIDEnd:], input[len(header):], header)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt payload with key %q: %v", keyID, err)
+	}
+	return dc.dataConverter.FromData(data, valuePtr...)
+}
+
+func validateEncryptionKey(keyID string, key []byte) error {
+	if len(keyID) == 0 || len(keyID) > 255 {
+		return fmt.Errorf("key ID %q must be between 1 and 255 bytes", keyID)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	default:
+		return fmt.Errorf("key %q must be 16, 24 or 32 bytes, got %v", keyID, len(key))
+	}
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedDataConverter(t *testing.T) {
+	key1 := bytes.Repeat([]byte{1}, 32)
+	key2 := bytes.Repeat([]byte{2}, 16)
+	keys, err := NewStaticKeyProvider("key1", map[string][]byte{"key1": key1})
+	require.NoError(t, err)
+	dc := NewEncryptedDataConverter(nil, keys)
+
+	t.Run("round trip", func(t *testing.T) {
+		data, err := dc.ToData("secret", 42)
+		require.NoError(t, err)
+		require.NotContains(t, string(data), "secret")
+
+		var s string
+		var n int
+		require.NoError(t, dc.FromData(data, &s, &n))
+		require.Equal(t, "secret", s)
+		require.Equal(t, 42, n)
+
+		again, err := dc.ToData("secret", 42)
+		require.NoError(t, err)
+		require.NotEqual(t, data, again, "nonce must be random")
+
+		// payloads are text, so they survive being stored as a string, as MutableSideEffect does
+		asString, err := json.Marshal(string(data))
+		require.NoError(t, err)
+		var decoded string
+		require.NoError(t, json.Unmarshal(asString, &decoded))
+		require.NoError(t, dc.FromData([]byte(decoded), &s, &n))
+
+		f1 := func(ctx Context, r []byte) string {
+			return "result"
+		}
+		require.Equal(t, "result", testDataConverterFunction(t, dc, f1, new(emptyCtx), []byte("test")))
+	})
+
+	t.Run("key rotation", func(t *testing.T) {
+		old, err := dc.ToData("secret")
+		require.NoError(t, err)
+
+		rotated, err := NewStaticKeyProvider("key2", map[string][]byte{"key1": key1, "key2": key2})
+		require.NoError(t, err)
+		rotatedDC := NewEncryptedDataConverter(nil, rotated)
+		data, err := rotatedDC.ToData("new secret")
+		require.NoError(t, err)
+
+		var s string
+		require.NoError(t, rotatedDC.FromData(old, &s))
+		require.Equal(t, "secret", s)
+		require.NoError(t, rotatedDC.FromData(data, &s))
+		require.Equal(t, "new secret", s)
+		require.EqualError(t, dc.FromData(data, &s), `failed to get encryption key "key2": unknown key "key2"`)
+	})
+
+	t.Run("tampered payloads", func(t *testing.T) {
+		data, err := dc.ToData("secret")
+		require.NoError(t, err)
+		var s string
+
+		tampered := append([]byte(nil), data...)
+		tampered[len(tampered)-1] ^= 1
+		require.Error(t, dc.FromData(tampered, &s))
+
+		// the key ID is authenticated as well
+		both, err := NewStaticKeyProvider("key1", map[string][]byte{"key1": key1, "key0": key1})
+		require.NoError(t, err)
+		sealed, err := base64.StdEncoding.DecodeString(string(data))
+		require.NoError(t, err)
+		sealed[5] = '0'
+		tampered = []byte(base64.StdEncoding.EncodeToString(sealed))
+		require.Error(t, NewEncryptedDataConverter(nil, both).FromData(tampered, &s))
+
+		require.Error(t, dc.FromData(data[:8], &s))
+		require.EqualError(t, dc.FromData([]byte(`"plain"`), &s), "payload is not encrypted or has an unsupported format")
+	})
+
+	t.Run("empty payload", func(t *testing.T) {
+		require.NoError(t, dc.FromData(nil))
+	})
+}
+
+func TestNewStaticKeyProvider(t *testing.T) {
+	_, err := NewStaticKeyProvider("missing", map[string][]byte{"key": make([]byte, 16)})
+	require.EqualError(t, err, `current key "missing" is not in the key set`)
+
+	_, err = NewStaticKeyProvider("key", map[string][]byte{"key": make([]byte, 10)})
+	require.EqualError(t, err, `key "key" must be 16, 24 or 32 bytes, got 10`)
+
+	key := make([]byte, 24)
+	keys, err := NewStaticKeyProvider("key", map[string][]byte{"key": key})
+	require.NoError(t, err)
+	key[0] = 1
+	id, current, err := keys.CurrentKey()
+	require.NoError(t, err)
+	require.Equal(t, "key", id)
+	require.Equal(t, make([]byte, 24), current, "keys must be copied")
+}
@@ -41,14 +41,23 @@ Below are the possible cases that activity could fail:
 	details are before extracting them.
 2) *GenericError:
 	If activity implementation returns errors other than from NewCustomError() API, workflow code would receive *GenericError.
-	Use err.Error() to get the string representation of the actual error.
+	Use err.Error() to get the string representation of the actual error. If FeatureFlags.WrappedErrorEncodingEnabled
+	is set and the returned error wraps other errors (e.g. via fmt.Errorf("...: %w", err)), the chain is preserved:
+	the *GenericError unwraps to the reconstructed causes, so errors.As can find a wrapped *CustomError, *CanceledError,
+	*TimeoutError or *PanicError. Other wrapped errors are reconstructed as *GenericError with the same message, so
+	errors.Is does not match the original error values.
 3) *CanceledError:
 	If activity was canceled, workflow code will receive instance of *CanceledError. When activity cancels itself by
 	returning NewCancelError() it would supply optional details which could be extracted by workflow code.
 4) *TimeoutError:
 	If activity was timed out (several timeout types), workflow code will receive instance of *TimeoutError. The err contains
 	details about what type of timeout it was.
-5) *PanicError:
+5) *NonRetryableError:
+	If activity implementation returns an error created by NewNonRetryableError(), or an error wrapping it, the activity
+	is not retried even if its RetryPolicy would allow it, and workflow code would receive *NonRetryableError wrapping
+	the reconstructed error. Use errors.As to get to the wrapped *CustomError, if any. Its reason is
+	"cadence:NonRetryable", workers prior to this client version receive it as *CustomError.
+6) *PanicError:
 	If activity code panic while executing, cadence activity worker will report it as activity failure to cadence server.
 	The cadence client library will present that failure as *PanicError to workflow code. The err contains a string
 	representation of the panic message and the call stack when panic was happen.
@@ -95,7 +104,14 @@ type (
 
 	// GenericError returned from workflow/workflow when the implementations return errors other than from NewCustomError() API.
 	GenericError struct {
-		err string
+		err   string
+		cause error
+	}
+
+	// NonRetryableError wraps an error returned from workflow and activity implementations which must not be retried,
+	// regardless of the RetryPolicy.
+	NonRetryableError struct {
+		cause error
 	}
 
 	// TimeoutError returned when activity or child workflow timed out.
@@ -173,16 +189,51 @@ type (
 
 	// ContinueAsNewError contains information about how to continue the workflow as new.
 	ContinueAsNewError struct {
-		wfn    interface{}
-		args   []interface{}
-		params *executeWorkflowParams
+		wfn      interface{}
+		args     []interface{}
+		params   *executeWorkflowParams
+		newRunID string
 	}
 
 	// UnknownExternalWorkflowExecutionError can be returned when external workflow doesn't exist
 	UnknownExternalWorkflowExecutionError struct{}
 
+	// NotRegisteredError is returned when a workflow or activity type is not registered with the worker, either
+	// when a task of the type is processed or when the type is deregistered.
+	NotRegisteredError struct {
+		// TypeName is the name of the workflow or activity type.
+		TypeName string
+		// IsActivity is true for activity types and false for workflow types.
+		IsActivity bool
+		// SupportedTypes are the types of the same kind registered with the worker.
+		SupportedTypes []string
+	}
+
+	// CompatibilityError is returned when the server rejects a call because it doesn't support this version of the
+	// client library. It wraps the *shared.ClientVersionNotSupportedError returned by the server.
+	CompatibilityError struct {
+		// ClientImpl and FeatureVersion identify the client as reported back by the server.
+		ClientImpl     string
+		FeatureVersion string
+		// SupportedVersions is the range of client feature versions supported by the server.
+		SupportedVersions string
+		// LibraryVersion is the version of this client library.
+		LibraryVersion string
+
+		cause *shared.ClientVersionNotSupportedError
+	}
+
 	// ErrorDetailsValues is a type alias used hold error details objects.
 	ErrorDetailsValues []interface{}
+
+	// errorChainNode is a single error of a wrapped error chain sent to the server, see getWrappedErrorDetails.
+	// Reason and Details are only set for the cadence errors which end the chain, and for the cause of
+	// a NonRetryableError.
+	errorChainNode struct {
+		Message string
+		Reason  string `json:",omitempty"`
+		Details []byte `json:",omitempty"`
+	}
 )
 
 const (
@@ -190,6 +241,11 @@ const (
 	errReasonGeneric  = "cadenceInternal:Generic"
 	errReasonCanceled = "cadenceInternal:Canceled"
 	errReasonTimeout  = "cadenceInternal:Timeout"
+	errReasonWrapped  = "cadenceInternal:Wrapped"
+
+	// errReasonNonRetryable is not prefixed with cadenceInternal, so that prior client versions, which reject unknown
+	// cadenceInternal reasons, decode it as a CustomError.
+	errReasonNonRetryable = "cadence:NonRetryable"
 )
 
 // ErrNoData is returned when trying to extract strong typed data while there is no data available.
@@ -198,6 +254,10 @@ var ErrNoData = errors.New("no data available")
 // ErrTooManyArg is returned when trying to extract strong typed data with more arguments than available data.
 var ErrTooManyArg = errors.New("too many arguments")
 
+// ErrDecisionTaskNotStarted is returned when StartWorkflowOptions.WaitForDecisionTaskStarted is set and no worker
+// picked up the first decision task of the started workflow in time.
+var ErrDecisionTaskNotStarted = errors.New("first decision task of the workflow was not started in time")
+
 // ErrActivityResultPending is returned from activity's implementation to indicate the activity is not completed when
 // activity method returns. Activity needs to be completed by Client.CompleteActivity() separately. For example, if an
 // activity require human interaction (like approve an expense report), the activity could return activity.ErrResultPending
@@ -220,6 +280,15 @@ func NewCustomError(reason string, details ...interface{}) *CustomError {
 	return &CustomError{reason: reason, details: ErrorDetailsValues(details)}
 }
 
+// NewNonRetryableError creates NonRetryableError instance wrapping err, e.g. for validation errors which will fail
+// again on every attempt. The retry is skipped as well if the returned error wraps it.
+func NewNonRetryableError(err error) *NonRetryableError {
+	if err == nil {
+		panic("NewNonRetryableError requires a non-nil error")
+	}
+	return &NonRetryableError{cause: err}
+}
+
 // NewTimeoutError creates TimeoutError instance.
 // Use NewHeartbeatTimeoutError to create heartbeat TimeoutError
 func NewTimeoutError(timeoutType shared.TimeoutType, details ...interface{}) *TimeoutError {
@@ -322,6 +391,21 @@ func (e *GenericError) Error() string {
 	return e.err
 }
 
+// Unwrap returns the error wrapped by the error returned from the activity or workflow, if any.
+func (e *GenericError) Unwrap() error {
+	return e.cause
+}
+
+// Error from error interface
+func (e *NonRetryableError) Error() string {
+	return e.cause.Error()
+}
+
+// Unwrap returns the error which must not be retried.
+func (e *NonRetryableError) Unwrap() error {
+	return e.cause
+}
+
 // Error from error interface
 func (e *TimeoutError) Error() string {
 	return fmt.Sprintf("TimeoutType: %v", e.timeoutType)
@@ -421,6 +505,12 @@ func (e *ContinueAsNewError) Header() *shared.Header {
 	return e.params.header
 }
 
+// NewRunID return the run ID of the new run. It is only known when the error is returned by
+// WorkflowRun.GetWithOptions with DisableFollowingRuns, and is empty inside workflow code.
+func (e *ContinueAsNewError) NewRunID() string {
+	return e.newRunID
+}
+
 // newTerminatedError creates NewTerminatedError instance
 func newTerminatedError() *TerminatedError {
 	return &TerminatedError{}
@@ -441,6 +531,56 @@ func (e *UnknownExternalWorkflowExecutionError) Error() string {
 	return "UnknownExternalWorkflowExecution"
 }
 
+// Error from error interface
+func (e *NotRegisteredError) Error() string {
+	supported := strings.Join(e.SupportedTypes, ", ")
+	if e.IsActivity {
+		return fmt.Sprintf("unable to find activityType=%v. Supported types: [%v]", e.TypeName, supported)
+	}
+	return fmt.Sprintf(errMsgUnknownWorkflowType+": %v. Supported types: [%v]", e.TypeName, supported)
+}
+
+// Error from error interface
+func (e *CompatibilityError) Error() string {
+	return fmt.Sprintf("server does not support client %v feature version %v (library version %v), supported versions: %v",
+		e.ClientImpl, e.FeatureVersion, e.LibraryVersion, e.SupportedVersions)
+}
+
+// Unwrap returns the *shared.ClientVersionNotSupportedError returned by the server.
+func (e *CompatibilityError) Unwrap() error {
+	return e.cause
+}
+
+// toCompatibilityError converts a *shared.ClientVersionNotSupportedError into a CompatibilityError and returns any
+// other error unchanged.
+func toCompatibilityError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var compatibilityErr *CompatibilityError
+	if errors.As(err, &compatibilityErr) {
+		return err
+	}
+	var versionErr *shared.ClientVersionNotSupportedError
+	if !errors.As(err, &versionErr) {
+		return err
+	}
+	compatibilityErr = &CompatibilityError{
+		ClientImpl:        versionErr.ClientImpl,
+		FeatureVersion:    versionErr.FeatureVersion,
+		SupportedVersions: versionErr.SupportedVersions,
+		LibraryVersion:    LibraryVersion,
+		cause:             versionErr,
+	}
+	if compatibilityErr.ClientImpl == "" {
+		compatibilityErr.ClientImpl = clientImplHeaderValue
+	}
+	if compatibilityErr.FeatureVersion == "" {
+		compatibilityErr.FeatureVersion = FeatureVersion
+	}
+	return compatibilityErr
+}
+
 // HasValues return whether there are values.
 func (b ErrorDetailsValues) HasValues() bool {
 	return b != nil && len(b) != 0
@@ -83,7 +83,7 @@ func Test_GenericError(t *testing.T) {
 		env.RegisterActivity(errorActivityFn)
 		_, err := env.ExecuteActivity(errorActivityFn)
 		require.Error(t, err)
-		require.Equal(t, &GenericError{"error:foo"}, err)
+		require.Equal(t, &GenericError{err: "error:foo"}, err)
 	})
 	// test workflow error
 	t.Run("workflows", func(t *testing.T) {
@@ -95,10 +95,92 @@ func Test_GenericError(t *testing.T) {
 		env.ExecuteWorkflow(errorWorkflowFn)
 		err := env.GetWorkflowError()
 		require.Error(t, err)
-		require.Equal(t, &GenericError{"error:foo"}, err)
+		require.Equal(t, &GenericError{err: "error:foo"}, err)
 	})
 }
 
+func Test_GenericError_WrappedCauses(t *testing.T) {
+	errNotFound := errors.New("not found")
+	workerOptions := WorkerOptions{FeatureFlags: FeatureFlags{WrappedErrorEncodingEnabled: true}}
+	t.Run("disabled", func(t *testing.T) {
+		errorActivityFn := func() error {
+			return fmt.Errorf("attempt %v: %w", 1, NewCustomError(customErrReasonA))
+		}
+		env := newTestActivityEnv(t)
+		env.RegisterActivity(errorActivityFn)
+		_, err := env.ExecuteActivity(errorActivityFn)
+		require.Error(t, err)
+		require.Equal(t, &GenericError{err: "attempt 1: " + customErrReasonA}, err)
+	})
+	t.Run("sentinel error", func(t *testing.T) {
+		errorActivityFn := func() error {
+			return fmt.Errorf("lookup %v: %w", "key", errNotFound)
+		}
+		env := newTestActivityEnv(t).SetWorkerOptions(workerOptions)
+		env.RegisterActivity(errorActivityFn)
+		_, err := env.ExecuteActivity(errorActivityFn)
+		require.Error(t, err)
+		require.IsType(t, &GenericError{}, err)
+		require.Equal(t, "lookup key: not found", err.Error())
+		require.Equal(t, &GenericError{err: "not found"}, errors.Unwrap(err))
+		require.NotErrorIs(t, err, errNotFound, "error values cannot cross the activity boundary")
+	})
+	t.Run("custom error", func(t *testing.T) {
+		errorActivityFn := func() error {
+			return fmt.Errorf("attempt %v: %w", 1, fmt.Errorf("failed: %w", NewCustomError(customErrReasonA, testErrorDetails1)))
+		}
+		env := newTestActivityEnv(t).SetWorkerOptions(workerOptions)
+		env.RegisterActivity(errorActivityFn)
+		_, err := env.ExecuteActivity(errorActivityFn)
+		require.Error(t, err)
+		require.Equal(t, "attempt 1: failed: "+customErrReasonA, err.Error())
+		var customErr *CustomError
+		require.True(t, errors.As(err, &customErr))
+		require.Equal(t, customErrReasonA, customErr.Reason())
+		var details string
+		require.NoError(t, customErr.Details(&details))
+		require.Equal(t, testErrorDetails1, details)
+	})
+	t.Run("workflows", func(t *testing.T) {
+		errorWorkflowFn := func(ctx Context) error {
+			return fmt.Errorf("workflow: %w", NewCanceledError())
+		}
+		env := newTestWorkflowEnv(t)
+		env.SetWorkerOptions(workerOptions)
+		env.RegisterWorkflow(errorWorkflowFn)
+		env.ExecuteWorkflow(errorWorkflowFn)
+		err := env.GetWorkflowError()
+		require.Error(t, err)
+		require.Equal(t, "workflow: CanceledError", err.Error())
+		var canceledErr *CanceledError
+		require.True(t, errors.As(err, &canceledErr))
+	})
+}
+
+func Test_NonRetryableError(t *testing.T) {
+	reason, details := getErrorDetails(NewNonRetryableError(errors.New("invalid input")), getDefaultDataConverter())
+	require.Equal(t, errReasonNonRetryable, reason)
+	err := constructError(reason, details, getDefaultDataConverter())
+	require.Equal(t, NewNonRetryableError(&GenericError{err: "invalid input"}), err)
+
+	// wrapped, with the custom error cause
+	reason, details = getErrorDetails(fmt.Errorf("validate: %w", NewNonRetryableError(NewCustomError(customErrReasonA, testErrorDetails1))), getDefaultDataConverter())
+	require.Equal(t, errReasonNonRetryable, reason)
+	err = constructError(reason, details, getDefaultDataConverter())
+	require.True(t, errors.As(err, new(*NonRetryableError)))
+	require.Equal(t, "validate: "+customErrReasonA, err.Error())
+	require.False(t, errors.As(err, new(*CustomError)), "the cause is encoded by its message without WrappedErrorEncodingEnabled")
+
+	// prior client versions decode unknown non cadenceInternal reasons as custom errors
+	require.NotPanics(t, func() { NewCustomError(reason, newEncodedValues(details, getDefaultDataConverter())) })
+	var node errorChainNode
+	require.NoError(t, newEncodedValues(details, getDefaultDataConverter()).Get(&node))
+	require.Equal(t, "validate: "+customErrReasonA, node.Message)
+
+	policy := convertRetryPolicy(&RetryPolicy{NonRetriableErrorReasons: []string{"bad-bug"}})
+	require.Equal(t, []string{"bad-bug", errReasonNonRetryable}, policy.NonRetriableErrorReasons)
+}
+
 func Test_ActivityNotRegistered(t *testing.T) {
 	registeredActivityFn, unregisteredActivitFn := "RegisteredActivity", "UnregisteredActivityFn"
 	env := newTestActivityEnv(t)
@@ -0,0 +1,65 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+)
+
+// HistoryToJSON serializes the events of a workflow history to JSON, in the format of the histories downloaded
+// with the cadence CLI: an array of events, with enums written by name.
+func HistoryToJSON(history *s.History) ([]byte, error) {
+	events := history.GetEvents()
+	if events == nil {
+		events = []*s.HistoryEvent{}
+	}
+	data, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize history: %w", err)
+	}
+	return data, nil
+}
+
+// HistoryFromJSON deserializes a workflow history from JSON. It accepts both the array of events written by
+// HistoryToJSON and the cadence CLI, and a history object with an "events" field, as returned by the
+// GetWorkflowExecutionHistory API.
+func HistoryFromJSON(data []byte) (*s.History, error) {
+	var history s.History
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &history); err != nil {
+			return nil, fmt.Errorf("invalid json contents: %w", err)
+		}
+	} else if err := json.Unmarshal(trimmed, &history.Events); err != nil {
+		return nil, fmt.Errorf("invalid json contents: %w", err)
+	}
+
+	for i, event := range history.Events {
+		if event == nil || event.EventType == nil {
+			return nil, fmt.Errorf("invalid json contents: event at index %d has no event type", i)
+		}
+	}
+	return &history, nil
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func TestHistoryJSON_RoundTrip(t *testing.T) {
+	data, err := os.ReadFile("testdata/sampleHistory.json")
+	require.NoError(t, err)
+	history, err := HistoryFromJSON(data)
+	require.NoError(t, err)
+	require.NotEmpty(t, history.Events)
+	assert.Equal(t, s.EventTypeWorkflowExecutionStarted, history.Events[0].GetEventType())
+
+	serialized, err := HistoryToJSON(history)
+	require.NoError(t, err)
+	assert.Contains(t, string(serialized), `"eventType": "WorkflowExecutionStarted"`)
+
+	deserialized, err := HistoryFromJSON(serialized)
+	require.NoError(t, err)
+	require.Len(t, deserialized.Events, len(history.Events))
+	for i := range history.Events {
+		assert.True(t, history.Events[i].Equals(deserialized.Events[i]), "event %d", i)
+	}
+}
+
+func TestHistoryFromJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantEvents int
+		wantErr    string
+	}{
+		{
+			name:       "array of events",
+			data:       `[{"eventId": 1, "eventType": "WorkflowExecutionStarted"}]`,
+			wantEvents: 1,
+		},
+		{
+			name:       "history object",
+			data:       ` {"events": [{"eventId": 1, "eventType": "WorkflowExecutionStarted"}, {"eventId": 2, "eventType": "DecisionTaskScheduled"}]}`,
+			wantEvents: 2,
+		},
+		{
+			name: "empty array",
+			data: `[]`,
+		},
+		{
+			name:    "invalid json",
+			data:    `[{"eventId": 1,`,
+			wantErr: "invalid json contents",
+		},
+		{
+			name:    "unknown event type",
+			data:    `[{"eventId": 1, "eventType": "NotAnEvent"}]`,
+			wantErr: "invalid json contents",
+		},
+		{
+			name:    "missing event type",
+			data:    `[{"eventId": 1}]`,
+			wantErr: "event at index 0 has no event type",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history, err := HistoryFromJSON([]byte(tt.data))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, history.Events, tt.wantEvents)
+		})
+	}
+}
+
+func TestHistoryToJSON_Empty(t *testing.T) {
+	data, err := HistoryToJSON(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(data))
+
+	data, err = HistoryToJSON(&s.History{Events: []*s.HistoryEvent{{
+		EventId:   common.Int64Ptr(1),
+		EventType: s.EventTypeWorkflowExecutionStarted.Ptr(),
+	}}})
+	require.NoError(t, err)
+	history, err := HistoryFromJSON(data)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), history.Events[0].GetEventId())
+}
@@ -70,7 +70,41 @@ type WorkflowInterceptor interface {
 	GetLastCompletionResult(ctx Context, d ...interface{}) error
 }
 
-var _ WorkflowInterceptor = (*WorkflowInterceptorBase)(nil)
+// WorkflowMarkerInterceptor is an optional interface a WorkflowInterceptor implements to also intercept
+// workflow.RecordMarker. It is separate so that existing WorkflowInterceptor implementations keep compiling.
+// WorkflowInterceptorBase implements it by forwarding to t.Next. Interceptors that do not implement it are skipped
+// together with the rest of the chain after them, and the marker is recorded directly.
+type WorkflowMarkerInterceptor interface {
+	RecordMarker(ctx Context, name string, details ...interface{}) error
+}
+
+// WorkflowMemoInterceptor is an optional interface a WorkflowInterceptor implements to also intercept
+// workflow.UpsertMemo, in the same way as WorkflowMarkerInterceptor.
+type WorkflowMemoInterceptor interface {
+	UpsertMemo(ctx Context, memo map[string]interface{}) error
+}
+
+var (
+	_ WorkflowInterceptor       = (*WorkflowInterceptorBase)(nil)
+	_ WorkflowMarkerInterceptor = (*WorkflowInterceptorBase)(nil)
+	_ WorkflowMemoInterceptor   = (*WorkflowInterceptorBase)(nil)
+)
+
+// recordMarker calls RecordMarker of next if it implements WorkflowMarkerInterceptor, or records the marker directly.
+func recordMarker(ctx Context, next WorkflowInterceptor, name string, details []interface{}) error {
+	if i, ok := next.(WorkflowMarkerInterceptor); ok {
+		return i.RecordMarker(ctx, name, details...)
+	}
+	return getEnvInterceptor(ctx).RecordMarker(ctx, name, details...)
+}
+
+// upsertMemo calls UpsertMemo of next if it implements WorkflowMemoInterceptor, or upserts the memo directly.
+func upsertMemo(ctx Context, next WorkflowInterceptor, memo map[string]interface{}) error {
+	if i, ok := next.(WorkflowMemoInterceptor); ok {
+		return i.UpsertMemo(ctx, memo)
+	}
+	return getEnvInterceptor(ctx).UpsertMemo(ctx, memo)
+}
 
 // WorkflowInterceptorBase is a helper type that can simplify creation of WorkflowInterceptorChainFactories
 type WorkflowInterceptorBase struct {
@@ -142,6 +176,11 @@ func (t *WorkflowInterceptorBase) UpsertSearchAttributes(ctx Context, attributes
 	return t.Next.UpsertSearchAttributes(ctx, attributes)
 }
 
+// UpsertMemo forwards to t.Next, see WorkflowMemoInterceptor
+func (t *WorkflowInterceptorBase) UpsertMemo(ctx Context, memo map[string]interface{}) error {
+	return upsertMemo(ctx, t.Next, memo)
+}
+
 // GetSignalChannel forwards to t.Next
 func (t *WorkflowInterceptorBase) GetSignalChannel(ctx Context, signalName string) Channel {
 	return t.Next.GetSignalChannel(ctx, signalName)
@@ -157,6 +196,11 @@ func (t *WorkflowInterceptorBase) MutableSideEffect(ctx Context, id string, f fu
 	return t.Next.MutableSideEffect(ctx, id, f, equals)
 }
 
+// RecordMarker forwards to t.Next, see WorkflowMarkerInterceptor
+func (t *WorkflowInterceptorBase) RecordMarker(ctx Context, name string, details ...interface{}) error {
+	return recordMarker(ctx, t.Next, name, details)
+}
+
 // GetVersion forwards to t.Next
 func (t *WorkflowInterceptorBase) GetVersion(ctx Context, changeID string, minSupported, maxSupported Version) Version {
 	return t.Next.GetVersion(ctx, changeID, minSupported, maxSupported)
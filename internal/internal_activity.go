@@ -28,6 +28,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
@@ -66,6 +67,11 @@ type (
 		WaitForCancellation           bool
 		OriginalTaskListName          string
 		RetryPolicy                   *shared.RetryPolicy
+		// TaskListRoutes maps activity type names to the task list they are scheduled on, see WithActivityTaskListRoute.
+		// The map is shared between contexts and must be copied before it is modified.
+		TaskListRoutes map[string]string
+		// defaults are the worker's DefaultActivityOptions, used for the fields left unset
+		defaults *ActivityOptions
 	}
 
 	localActivityOptions struct {
@@ -177,6 +183,7 @@ func getValidatedActivityOptions(ctx Context) (*activityOptions, error) {
 		// We need task list as a compulsory parameter. This can be removed after registration
 		return nil, errActivityParamsBadRequest
 	}
+	applyDefaultActivityOptions(p)
 	if p.TaskListName == "" {
 		// We default to origin task list name.
 		p.TaskListName = p.OriginalTaskListName
@@ -204,6 +211,45 @@ func getValidatedActivityOptions(ctx Context) (*activityOptions, error) {
 	return p, nil
 }
 
+// routedTaskList returns the task list activityType is routed to, if any. A route to an empty task list
+// name means the workflow's own task list.
+func (p *activityOptions) routedTaskList(activityType string) (string, bool) {
+	taskList, ok := p.TaskListRoutes[activityType]
+	if !ok {
+		return "", false
+	}
+	if strings.TrimSpace(taskList) == "" {
+		return p.OriginalTaskListName, true
+	}
+	return taskList, true
+}
+
+func applyDefaultActivityOptions(p *activityOptions) {
+	d := p.defaults
+	if d == nil {
+		return
+	}
+	if p.TaskListName == "" {
+		p.TaskListName = d.TaskList
+	}
+	if p.ScheduleToStartTimeoutSeconds == 0 {
+		p.ScheduleToStartTimeoutSeconds = common.Int32Ceil(d.ScheduleToStartTimeout.Seconds())
+	}
+	if p.StartToCloseTimeoutSeconds == 0 {
+		p.StartToCloseTimeoutSeconds = common.Int32Ceil(d.StartToCloseTimeout.Seconds())
+	}
+	if p.ScheduleToCloseTimeoutSeconds == 0 {
+		p.ScheduleToCloseTimeoutSeconds = common.Int32Ceil(d.ScheduleToCloseTimeout.Seconds())
+	}
+	if p.HeartbeatTimeoutSeconds == 0 {
+		p.HeartbeatTimeoutSeconds = common.Int32Ceil(d.HeartbeatTimeout.Seconds())
+	}
+	if p.RetryPolicy == nil && d.RetryPolicy != nil {
+		retryPolicy := *d.RetryPolicy
+		p.RetryPolicy = convertRetryPolicy(&retryPolicy)
+	}
+}
+
 func getValidatedLocalActivityOptions(ctx Context) (*localActivityOptions, error) {
 	p := getLocalActivityOptions(ctx)
 	if p == nil {
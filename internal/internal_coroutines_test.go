@@ -576,6 +576,109 @@ func TestChannelClose(t *testing.T) {
 	require.EqualValues(t, expected, history)
 }
 
+func TestChannelLenCap(t *testing.T) {
+	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
+		buffered := NewBufferedChannel(ctx, 2)
+		require.Equal(t, 0, buffered.Len())
+		require.Equal(t, 2, buffered.Cap())
+		require.True(t, buffered.SendAsync("v1"))
+		require.True(t, buffered.SendAsync("v2"))
+		require.False(t, buffered.SendAsync("v3"))
+		require.Equal(t, 2, buffered.Len())
+
+		var v string
+		require.True(t, buffered.ReceiveAsync(&v))
+		require.Equal(t, "v1", v)
+		require.Equal(t, 1, buffered.Len())
+		buffered.Close()
+		ok, more := buffered.ReceiveAsyncWithMoreFlag(&v)
+		require.True(t, ok)
+		require.True(t, more)
+		require.Equal(t, "v2", v)
+		ok, more = buffered.ReceiveAsyncWithMoreFlag(&v)
+		require.False(t, ok)
+		require.False(t, more)
+		require.Equal(t, 0, buffered.Len())
+
+		unbuffered := NewChannel(ctx)
+		require.Equal(t, 0, unbuffered.Cap())
+		Go(ctx, func(ctx Context) {
+			unbuffered.Send(ctx, "blocked")
+		})
+		// the second child runs after the first one blocked on Send
+		step := NewChannel(ctx)
+		Go(ctx, func(ctx Context) {
+			step.Send(ctx, true)
+		})
+		step.Receive(ctx, nil)
+		// values of blocked senders are not buffered
+		require.Equal(t, 0, unbuffered.Len())
+		unbuffered.Receive(ctx, &v)
+		require.Equal(t, "blocked", v)
+	})
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	require.True(t, d.IsDone())
+}
+
+func TestSelectReadyCasesOrder(t *testing.T) {
+	var history []string
+	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
+		newSelector := func(s Selector) Selector {
+			for _, name := range []string{"a", "b", "c"} {
+				name := name
+				c := NewBufferedChannel(ctx, 10)
+				for i := 0; i < 10; i++ {
+					c.SendAsync(i)
+				}
+				s.AddReceive(c, func(c Channel, more bool) {
+					c.ReceiveAsync(nil)
+					history = append(history, name)
+				})
+			}
+			return s
+		}
+		s := newSelector(NewSelector(ctx))
+		for i := 0; i < 4; i++ {
+			s.Select(ctx)
+		}
+		s = newSelector(NewFairSelector(ctx))
+		for i := 0; i < 4; i++ {
+			s.Select(ctx)
+		}
+	})
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	require.True(t, d.IsDone())
+	require.EqualValues(t, []string{"a", "a", "a", "a", "a", "b", "c", "a"}, history)
+}
+
+func TestFairSelectorBlocked(t *testing.T) {
+	var history []string
+	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
+		c1 := NewChannel(ctx)
+		c2 := NewChannel(ctx)
+		Go(ctx, func(ctx Context) {
+			for i := 0; i < 2; i++ {
+				c2.Send(ctx, "c2")
+				c1.Send(ctx, "c1")
+			}
+		})
+		s := NewFairSelector(ctx)
+		receive := func(c Channel, more bool) {
+			var v string
+			c.Receive(ctx, &v)
+			history = append(history, v)
+		}
+		s.AddReceive(c1, receive)
+		s.AddReceive(c2, receive)
+		for i := 0; i < 4; i++ {
+			s.Select(ctx)
+		}
+	})
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	require.True(t, d.IsDone())
+	require.EqualValues(t, []string{"c2", "c1", "c2", "c1"}, history)
+}
+
 func TestSendClosedChannel(t *testing.T) {
 	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
 		defer func() {
@@ -663,6 +766,29 @@ func TestDispatchClose(t *testing.T) {
 	}
 }
 
+func TestDispatcherStats(t *testing.T) {
+	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
+		c := NewChannel(ctx)
+		for i := 0; i < 3; i++ {
+			GoNamed(ctx, fmt.Sprintf("c-%v", i), func(ctx Context) {
+				c.Receive(ctx, nil)
+			})
+		}
+		GoNamed(ctx, "slow", func(ctx Context) {
+			time.Sleep(10 * time.Millisecond)
+		})
+		c.Receive(ctx, nil)
+	})
+	defer d.Close()
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	stats := d.Stats()
+	require.Equal(t, 4, stats.Coroutines)
+	// root, 4 children, then all live coroutines once more to detect that all of them are blocked
+	require.Equal(t, 9, stats.RunQueueLength)
+	require.Equal(t, "slow", stats.MaxCoroutineName)
+	require.True(t, stats.MaxCoroutineRunTime >= 10*time.Millisecond)
+}
+
 func TestPanic(t *testing.T) {
 	var history []string
 	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
@@ -727,6 +853,104 @@ func TestAwaitCancellation(t *testing.T) {
 	require.True(t, ok)
 }
 
+func TestMutex(t *testing.T) {
+	var history []string
+	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
+		mutex := NewMutex(ctx)
+		wg := NewWaitGroup(ctx)
+		for i := 0; i < 3; i++ {
+			i := i
+			wg.Add(1)
+			Go(ctx, func(ctx Context) {
+				defer wg.Done()
+				require.NoError(t, mutex.Lock(ctx))
+				history = append(history, fmt.Sprintf("locked-%v", i))
+				// yield while holding the lock, the other coroutines must not get it
+				getState(ctx).yield("holding mutex")
+				history = append(history, fmt.Sprintf("unlocked-%v", i))
+				mutex.Unlock()
+			})
+		}
+		wg.Wait(ctx)
+		require.True(t, mutex.TryLock())
+		require.False(t, mutex.TryLock())
+		mutex.Unlock()
+		require.Panics(t, mutex.Unlock)
+	})
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	require.True(t, d.IsDone())
+	require.Equal(t, []string{"locked-0", "unlocked-0", "locked-1", "unlocked-1", "locked-2", "unlocked-2"}, history)
+}
+
+func TestSemaphore(t *testing.T) {
+	var history []string
+	var releaseCh Channel
+	d, _ := newDispatcher(createRootTestContext(t), func(ctx Context) {
+		semaphore := NewSemaphore(ctx, 3)
+		releaseCh = NewBufferedChannel(ctx, 10)
+		require.Error(t, semaphore.Acquire(ctx, 4))
+		require.Error(t, semaphore.Acquire(ctx, 0))
+		require.Error(t, semaphore.Acquire(ctx, -1))
+		require.Panics(t, func() { semaphore.TryAcquire(0) })
+		require.Panics(t, func() { semaphore.TryAcquire(-1) })
+		require.NoError(t, semaphore.Acquire(ctx, 2))
+		require.Panics(t, func() { semaphore.Release(0) })
+		require.Panics(t, func() { semaphore.Release(-1) })
+		require.False(t, semaphore.TryAcquire(2), "invalid calls must not change the acquired permits")
+
+		Go(ctx, func(ctx Context) {
+			require.NoError(t, semaphore.Acquire(ctx, 2))
+			history = append(history, "acquired-2")
+		})
+		Go(ctx, func(ctx Context) {
+			// queued behind the larger request, even though one permit is available
+			require.NoError(t, semaphore.Acquire(ctx, 1))
+			history = append(history, "acquired-1")
+		})
+		releaseCh.Receive(ctx, nil)
+		require.False(t, semaphore.TryAcquire(1))
+		semaphore.Release(2)
+		releaseCh.Receive(ctx, nil)
+		require.Panics(t, func() { semaphore.Release(4) })
+	})
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	require.Empty(t, history)
+	releaseCh.SendAsync(true)
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	require.Equal(t, []string{"acquired-2", "acquired-1"}, history)
+	releaseCh.SendAsync(true)
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	require.True(t, d.IsDone())
+}
+
+func TestSemaphoreAcquireCancellation(t *testing.T) {
+	var acquireErr error
+	var acquiredAfterCancel bool
+	var cancelHandler CancelFunc
+	d, _ := newDispatcher(createRootTestContext(t), func(rootCtx Context) {
+		semaphore := NewSemaphore(rootCtx, 2)
+		require.NoError(t, semaphore.Acquire(rootCtx, 1))
+		var ctx Context
+		ctx, cancelHandler = WithCancel(rootCtx)
+		Go(ctx, func(ctx Context) {
+			acquireErr = semaphore.Acquire(ctx, 2)
+		})
+		Go(rootCtx, func(ctx Context) {
+			// unblocked once the canceled request no longer holds the queue
+			require.NoError(t, semaphore.Acquire(ctx, 1))
+			acquiredAfterCancel = true
+		})
+	})
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	require.False(t, acquiredAfterCancel)
+	cancelHandler()
+	require.NoError(t, d.ExecuteUntilAllBlocked())
+	require.True(t, d.IsDone())
+	require.True(t, acquiredAfterCancel)
+	_, ok := acquireErr.(*CanceledError)
+	require.True(t, ok, acquireErr)
+}
+
 func TestFutureSetValue(t *testing.T) {
 	var history []string
 	var f Future
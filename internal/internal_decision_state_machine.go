@@ -132,6 +132,7 @@ const (
 	decisionStateCancellationDecisionSent               decisionState = 7
 	decisionStateCompletedAfterCancellationDecisionSent decisionState = 8
 	decisionStateCompleted                              decisionState = 9
+	decisionStateCancellationInitiated                  decisionState = 10
 )
 
 const (
@@ -161,6 +162,7 @@ const (
 	versionMarkerName           = "Version"
 	localActivityMarkerName     = "LocalActivity"
 	mutableSideEffectMarkerName = "MutableSideEffect"
+	upsertMemoMarkerName        = "UpsertMemo"
 )
 
 func (d decisionState) String() string {
@@ -185,6 +187,8 @@ func (d decisionState) String() string {
 		return "CompletedAfterCancellationDecisionSent"
 	case decisionStateCompleted:
 		return "Completed"
+	case decisionStateCancellationInitiated:
+		return "CancellationInitiated"
 	default:
 		return "Unknown"
 	}
@@ -452,6 +456,35 @@ func (d *activityDecisionStateMachine) handleDecisionSent() {
 	}
 }
 
+func (d *activityDecisionStateMachine) handleCancelInitiatedEvent() {
+	switch d.state {
+	case decisionStateCancellationDecisionSent:
+		// the server accepted the cancel request, it can no longer fail with RequestCancelActivityTaskFailed
+		d.moveState(decisionStateCancellationInitiated, eventCancelInitiated)
+	default:
+		d.decisionStateMachineBase.handleCancelInitiatedEvent()
+	}
+}
+
+func (d *activityDecisionStateMachine) handleCompletionEvent() {
+	switch d.state {
+	case decisionStateCancellationInitiated:
+		// the activity completed, failed or timed out before acting on the cancel request
+		d.moveState(decisionStateCompleted, eventCompletion)
+	default:
+		d.decisionStateMachineBase.handleCompletionEvent()
+	}
+}
+
+func (d *activityDecisionStateMachine) handleCanceledEvent() {
+	switch d.state {
+	case decisionStateCancellationInitiated:
+		d.moveState(decisionStateCompleted, eventCanceled)
+	default:
+		d.decisionStateMachineBase.handleCanceledEvent()
+	}
+}
+
 func (d *activityDecisionStateMachine) handleCancelFailedEvent() {
 	switch d.state {
 	case decisionStateCancellationDecisionSent:
@@ -751,6 +784,8 @@ func (h *decisionsHelper) handleRequestCancelActivityTaskFailed(activityID strin
 	decision.handleCancelFailedEvent()
 }
 
+// getActivityID returns the ID of the activity closed by the event. All of those events are final for the activity,
+// so its scheduled event ID mapping is dropped to not grow with the history.
 func (h *decisionsHelper) getActivityID(event *s.HistoryEvent) string {
 	var scheduledEventID int64 = -1
 	switch event.GetEventType() {
@@ -770,6 +805,7 @@ func (h *decisionsHelper) getActivityID(event *s.HistoryEvent) string {
 	if !ok {
 		panicIllegalState(fmt.Sprintf("unable to find activity ID for the event %v", util.HistoryEventToString(event)))
 	}
+	delete(h.scheduledEventIDToActivityID, scheduledEventID)
 	return activityID
 }
 
@@ -790,7 +826,7 @@ func (h *decisionsHelper) recordVersionMarker(changeID string, version Version,
 	return decision
 }
 
-func (h *decisionsHelper) recordSideEffectMarker(sideEffectID int32, data []byte) decisionStateMachine {
+func (h *decisionsHelper) recordSideEffectMarker(sideEffectID int64, data []byte) decisionStateMachine {
 	markerID := fmt.Sprintf("%v_%v", sideEffectMarkerName, sideEffectID)
 	attributes := &s.RecordMarkerDecisionAttributes{
 		MarkerName: common.StringPtr(sideEffectMarkerName),
@@ -812,6 +848,16 @@ func (h *decisionsHelper) recordLocalActivityMarker(activityID string, result []
 	return decision
 }
 
+func (h *decisionsHelper) recordMarker(markerName string, markerID string, details []byte) decisionStateMachine {
+	attributes := &s.RecordMarkerDecisionAttributes{
+		MarkerName: common.StringPtr(markerName),
+		Details:    details,
+	}
+	decision := h.newMarkerDecisionStateMachine(fmt.Sprintf("%v_%v", markerName, markerID), attributes)
+	h.addDecision(decision)
+	return decision
+}
+
 func (h *decisionsHelper) recordMutableSideEffectMarker(mutableSideEffectID string, data []byte) decisionStateMachine {
 	markerID := fmt.Sprintf("%v_%v", mutableSideEffectMarkerName, mutableSideEffectID)
 	attributes := &s.RecordMarkerDecisionAttributes{
@@ -906,6 +952,7 @@ func (h *decisionsHelper) handleExternalWorkflowExecutionCancelRequested(initiat
 		// no state change for child workflow, it is still in CancellationDecisionSent
 	} else {
 		// this is cancellation for external workflow
+		delete(h.scheduledEventIDToCancellationID, initiatedeventID)
 		decision = h.getDecision(makeDecisionID(decisionTypeCancellation, cancellationID))
 		decision.handleCompletionEvent()
 	}
@@ -921,6 +968,7 @@ func (h *decisionsHelper) handleRequestCancelExternalWorkflowExecutionFailed(ini
 		decision.handleCancelFailedEvent()
 	} else {
 		// this is cancellation for external workflow
+		delete(h.scheduledEventIDToCancellationID, initiatedeventID)
 		decision = h.getDecision(makeDecisionID(decisionTypeCancellation, cancellationID))
 		decision.handleCompletionEvent()
 	}
@@ -971,11 +1019,13 @@ func (h *decisionsHelper) handleSignalExternalWorkflowExecutionFailed(initiatedE
 	return decision
 }
 
+// getSignalID returns the ID of the signal completed or failed by the event, dropping its initiated event ID mapping.
 func (h *decisionsHelper) getSignalID(initiatedEventID int64) string {
 	signalID, ok := h.scheduledEventIDToSignalID[initiatedEventID]
 	if !ok {
 		panic(fmt.Sprintf("unable to find signal ID: %v", initiatedEventID))
 	}
+	delete(h.scheduledEventIDToSignalID, initiatedEventID)
 	return signalID
 }
 
@@ -1030,6 +1080,9 @@ func (h *decisionsHelper) handleChildWorkflowExecutionCanceled(workflowID string
 	return decision
 }
 
+// getDecisions returns the pending decisions in the order they were made. It is called after all the events of
+// a replayed decision batch are processed, and once more when the decision task is completed, so the decisions
+// of a batch are coalesced into a single list instead of being collected per event.
 func (h *decisionsHelper) getDecisions(markAsSent bool) []*s.Decision {
 	var result []*s.Decision
 	for curr := h.orderedDecisions.Front(); curr != nil; {
@@ -1037,6 +1090,10 @@ func (h *decisionsHelper) getDecisions(markAsSent bool) []*s.Decision {
 		d := curr.Value.(decisionStateMachine)
 		decision := d.getDecision()
 		if decision != nil {
+			if result == nil {
+				// a single allocation for the whole decision batch
+				result = make([]*s.Decision, 0, h.orderedDecisions.Len())
+			}
 			result = append(result, decision)
 		}
 
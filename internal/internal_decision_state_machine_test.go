@@ -21,6 +21,7 @@
 package internal
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -272,6 +273,51 @@ func Test_ActivityStateMachine_CompletedAfterCancel(t *testing.T) {
 	h.handleActivityTaskClosed(activityID)
 	require.Equal(t, decisionStateCompletedAfterCancellationDecisionSent, d.getState())
 	require.Equal(t, 0, len(h.getDecisions(false)))
+
+	// cancel failed as the activity is already closed
+	h.handleRequestCancelActivityTaskFailed(activityID)
+	require.Equal(t, decisionStateCompleted, d.getState())
+	require.Nil(t, h.decisions[d.getID()])
+}
+
+func Test_ActivityStateMachine_CompletedAfterCancelRequested(t *testing.T) {
+	t.Parallel()
+	for name, closeActivity := range map[string]func(h *decisionsHelper, activityID string){
+		"completed": func(h *decisionsHelper, activityID string) { h.handleActivityTaskClosed(activityID) },
+		"canceled":  func(h *decisionsHelper, activityID string) { h.handleActivityTaskCanceled(activityID) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			activityID := "test-activity-1"
+			attributes := &s.ScheduleActivityTaskDecisionAttributes{
+				ActivityId: common.StringPtr(activityID),
+			}
+			h := newDecisionsHelper()
+
+			d := h.scheduleActivityTask(attributes)
+			h.getDecisions(true)
+			h.handleActivityTaskScheduled(1, activityID)
+			require.Equal(t, decisionStateInitiated, d.getState())
+
+			// cancel activity while it is running
+			h.requestCancelActivityTask(activityID)
+			require.Equal(t, decisionStateCanceledAfterInitiated, d.getState())
+			decisions := h.getDecisions(true)
+			require.Equal(t, 1, len(decisions))
+			require.Equal(t, s.DecisionTypeRequestCancelActivityTask, decisions[0].GetDecisionType())
+
+			// cancel request accepted by the server
+			h.handleActivityTaskCancelRequested(activityID)
+			require.Equal(t, decisionStateCancellationInitiated, d.getState())
+			require.False(t, d.isDone())
+
+			// activity closed, no cancel failure can follow so the decision is removed right away
+			closeActivity(h, activityID)
+			require.Equal(t, decisionStateCompleted, d.getState())
+			require.True(t, d.isDone())
+			require.Nil(t, h.decisions[d.getID()])
+			require.Equal(t, 0, len(h.getDecisions(false)))
+		})
+	}
 }
 
 func Test_ActivityStateMachine_PanicInvalidStateTransition(t *testing.T) {
@@ -638,3 +684,76 @@ func runAndCatchPanic(f func()) (err *workflowPanicError) {
 	f()
 	return nil
 }
+
+func Test_GetDecisions_KeepsOrderAcrossBatch(t *testing.T) {
+	t.Parallel()
+	h := newDecisionsHelper()
+	for _, timerID := range []string{"timer-1", "timer-2", "timer-3"} {
+		h.startTimer(&s.StartTimerDecisionAttributes{TimerId: common.StringPtr(timerID)})
+	}
+	h.cancelTimer("timer-2")
+
+	decisions := h.getDecisions(true)
+	require.Equal(t, 2, len(decisions))
+	require.Equal(t, "timer-1", decisions[0].StartTimerDecisionAttributes.GetTimerId())
+	require.Equal(t, "timer-3", decisions[1].StartTimerDecisionAttributes.GetTimerId())
+	require.Nil(t, h.getDecisions(true))
+}
+
+func Test_DecisionsHelper_BoundedAfterClose(t *testing.T) {
+	t.Parallel()
+	h := newDecisionsHelper()
+	eventID := int64(0)
+
+	for i := 0; i < 100000; i++ {
+		activityID := fmt.Sprintf("activity-%d", i)
+		h.scheduleActivityTask(&s.ScheduleActivityTaskDecisionAttributes{ActivityId: common.StringPtr(activityID)})
+		require.Len(t, h.getDecisions(true), 1)
+		eventID++
+		h.handleActivityTaskScheduled(eventID, activityID)
+
+		completed := &s.HistoryEvent{
+			EventType: s.EventTypeActivityTaskCompleted.Ptr(),
+			ActivityTaskCompletedEventAttributes: &s.ActivityTaskCompletedEventAttributes{
+				ScheduledEventId: common.Int64Ptr(eventID),
+			},
+		}
+		h.handleActivityTaskClosed(h.getActivityID(completed))
+	}
+
+	signalID := "signal-1"
+	h.signalExternalWorkflowExecution("domain", "wid", "rid", "signal", nil, signalID, false)
+	h.getDecisions(true)
+	eventID++
+	h.handleSignalExternalWorkflowExecutionInitiated(eventID, signalID)
+	h.handleSignalExternalWorkflowExecutionCompleted(eventID)
+
+	cancellationID := "cancel-1"
+	h.requestCancelExternalWorkflowExecution("domain", "wid", "rid", cancellationID, false)
+	h.getDecisions(true)
+	eventID++
+	h.handleRequestCancelExternalWorkflowExecutionInitiated(eventID, "wid", cancellationID)
+	h.handleExternalWorkflowExecutionCancelRequested(eventID, "wid")
+
+	require.Empty(t, h.decisions)
+	require.Equal(t, 0, h.orderedDecisions.Len())
+	require.Empty(t, h.scheduledEventIDToActivityID)
+	require.Empty(t, h.scheduledEventIDToSignalID)
+	require.Empty(t, h.scheduledEventIDToCancellationID)
+}
+
+func BenchmarkGetDecisions(b *testing.B) {
+	attributes := make([]*s.StartTimerDecisionAttributes, 100)
+	for i := range attributes {
+		attributes[i] = &s.StartTimerDecisionAttributes{TimerId: common.StringPtr(fmt.Sprintf("timer-%d", i))}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h := newDecisionsHelper()
+		for _, a := range attributes {
+			h.startTimer(a)
+		}
+		h.getDecisions(true)
+	}
+}
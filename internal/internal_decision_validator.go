@@ -0,0 +1,66 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+)
+
+// validateDecisions checks the decisions of a decision task before they are sent, so that an invalid batch fails the
+// decision task with a descriptive error instead of being rejected by the server. It detects activities scheduled
+// twice, cancellations of activities unknown to the workflow, and conflicting workflow close decisions.
+func validateDecisions(decisions []*s.Decision, helper *decisionsHelper) error {
+	scheduledActivities := make(map[string]struct{})
+	closeDecisionIndex := -1
+	for i, decision := range decisions {
+		switch decision.GetDecisionType() {
+		case s.DecisionTypeScheduleActivityTask:
+			activityID := decision.ScheduleActivityTaskDecisionAttributes.GetActivityId()
+			if _, ok := scheduledActivities[activityID]; ok {
+				return fmt.Errorf("invalid decisions: activity %q is scheduled more than once", activityID)
+			}
+			scheduledActivities[activityID] = struct{}{}
+		case s.DecisionTypeRequestCancelActivityTask:
+			activityID := decision.RequestCancelActivityTaskDecisionAttributes.GetActivityId()
+			_, scheduled := scheduledActivities[activityID]
+			_, known := helper.decisions[makeDecisionID(decisionTypeActivity, activityID)]
+			if !scheduled && !known {
+				return fmt.Errorf("invalid decisions: cancellation requested for unknown activity %q", activityID)
+			}
+		case s.DecisionTypeCompleteWorkflowExecution,
+			s.DecisionTypeFailWorkflowExecution,
+			s.DecisionTypeCancelWorkflowExecution,
+			s.DecisionTypeContinueAsNewWorkflowExecution:
+			if closeDecisionIndex >= 0 {
+				return fmt.Errorf("invalid decisions: conflicting %v and %v decisions",
+					decisions[closeDecisionIndex].GetDecisionType(), decision.GetDecisionType())
+			}
+			closeDecisionIndex = i
+		}
+	}
+	if closeDecisionIndex >= 0 && closeDecisionIndex != len(decisions)-1 {
+		return fmt.Errorf("invalid decisions: %v decision is followed by %v",
+			decisions[closeDecisionIndex].GetDecisionType(), decisions[closeDecisionIndex+1].GetDecisionType())
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common"
+)
+
+func scheduleActivityDecision(activityID string) *s.Decision {
+	decision := createNewDecision(s.DecisionTypeScheduleActivityTask)
+	decision.ScheduleActivityTaskDecisionAttributes = &s.ScheduleActivityTaskDecisionAttributes{
+		ActivityId: common.StringPtr(activityID),
+	}
+	return decision
+}
+
+func cancelActivityDecision(activityID string) *s.Decision {
+	decision := createNewDecision(s.DecisionTypeRequestCancelActivityTask)
+	decision.RequestCancelActivityTaskDecisionAttributes = &s.RequestCancelActivityTaskDecisionAttributes{
+		ActivityId: common.StringPtr(activityID),
+	}
+	return decision
+}
+
+func TestValidateDecisions(t *testing.T) {
+	helper := newDecisionsHelper()
+	helper.scheduleActivityTask(&s.ScheduleActivityTaskDecisionAttributes{ActivityId: common.StringPtr("known")})
+	helper.getDecisions(true)
+
+	tests := []struct {
+		name      string
+		decisions []*s.Decision
+		wantErr   string
+	}{
+		{
+			name: "no decisions",
+		},
+		{
+			name: "valid batch",
+			decisions: []*s.Decision{
+				scheduleActivityDecision("1"),
+				scheduleActivityDecision("2"),
+				cancelActivityDecision("known"),
+				cancelActivityDecision("2"),
+				createNewDecision(s.DecisionTypeCompleteWorkflowExecution),
+			},
+		},
+		{
+			name:      "duplicate activity ID",
+			decisions: []*s.Decision{scheduleActivityDecision("1"), scheduleActivityDecision("1")},
+			wantErr:   `activity "1" is scheduled more than once`,
+		},
+		{
+			name:      "cancel unknown activity",
+			decisions: []*s.Decision{cancelActivityDecision("unknown")},
+			wantErr:   `cancellation requested for unknown activity "unknown"`,
+		},
+		{
+			name: "conflicting close decisions",
+			decisions: []*s.Decision{
+				createNewDecision(s.DecisionTypeCompleteWorkflowExecution),
+				createNewDecision(s.DecisionTypeFailWorkflowExecution),
+			},
+			wantErr: "conflicting CompleteWorkflowExecution and FailWorkflowExecution decisions",
+		},
+		{
+			name: "decision after close decision",
+			decisions: []*s.Decision{
+				createNewDecision(s.DecisionTypeContinueAsNewWorkflowExecution),
+				scheduleActivityDecision("1"),
+			},
+			wantErr: "ContinueAsNewWorkflowExecution decision is followed by ScheduleActivityTask",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDecisions(tt.decisions, helper)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/yarpc"
 
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
 	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
 	s "go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common"
@@ -164,3 +165,24 @@ func TestUpdateDomain(t *testing.T) {
 		})
 	}
 }
+
+func TestDomainClient_ActiveClusterResolver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	primary := workflowservicetest.NewMockClient(ctrl)
+	active := workflowservicetest.NewMockClient(ctrl)
+	notActive := &s.DomainNotActiveError{DomainName: testDomain, CurrentCluster: "cluster-a", ActiveCluster: "cluster-b"}
+	request := &s.UpdateDomainRequest{Name: common.StringPtr(testDomain)}
+	primary.EXPECT().UpdateDomain(gomock.Any(), request, gomock.Any()).Return(nil, notActive)
+	active.EXPECT().UpdateDomain(gomock.Any(), request, gomock.Any()).Return(&s.UpdateDomainResponse{}, nil)
+
+	var resolved []string
+	dc := NewDomainClient(primary, &ClientOptions{
+		Identity: identity,
+		ActiveClusterResolver: func(cluster string) (workflowserviceclient.Interface, error) {
+			resolved = append(resolved, cluster)
+			return active, nil
+		},
+	})
+	assert.NoError(t, dc.Update(context.Background(), request))
+	assert.Equal(t, []string{"cluster-b"}, resolved)
+}
@@ -28,6 +28,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -95,14 +96,14 @@ type (
 		workflowInfo *WorkflowInfo
 
 		decisionsHelper   *decisionsHelper
-		sideEffectResult  map[int32][]byte
+		sideEffectResult  map[int64][]byte
 		changeVersions    map[string]Version
 		pendingLaTasks    map[string]*localActivityTask
 		mutableSideEffect map[string][]byte
 		unstartedLaTasks  map[string]struct{}
 		openSessions      map[string]*SessionInfo
 
-		counterID         int32     // To generate sequence IDs for activity/timer etc.
+		counterID         int64     // To generate sequence IDs for activity/timer etc., in the order workflow code issues them.
 		currentReplayTime time.Time // Indicates current replay time of the decision.
 		currentLocalTime  time.Time // Local time when currentReplayTime was updated.
 
@@ -121,6 +122,9 @@ type (
 		contextPropagators           []ContextPropagator
 		tracer                       opentracing.Tracer
 		workflowInterceptorFactories []WorkflowInterceptorFactory
+		defaultActivityOptions       *ActivityOptions
+		decisionTaskListener         DecisionTaskListener
+		dispatcherStatsHandler       func(info *WorkflowInfo, stats DispatcherStats, isReplay bool)
 	}
 
 	localActivityTask struct {
@@ -205,11 +209,14 @@ func newWorkflowExecutionEventHandler(
 	contextPropagators []ContextPropagator,
 	tracer opentracing.Tracer,
 	workflowInterceptorFactories []WorkflowInterceptorFactory,
+	defaultActivityOptions *ActivityOptions,
+	decisionTaskListener DecisionTaskListener,
+	dispatcherStatsHandler func(info *WorkflowInfo, stats DispatcherStats, isReplay bool),
 ) workflowExecutionEventHandler {
 	context := &workflowEnvironmentImpl{
 		workflowInfo:                 workflowInfo,
 		decisionsHelper:              newDecisionsHelper(),
-		sideEffectResult:             make(map[int32][]byte),
+		sideEffectResult:             make(map[int64][]byte),
 		mutableSideEffect:            make(map[string][]byte),
 		changeVersions:               make(map[string]Version),
 		pendingLaTasks:               make(map[string]*localActivityTask),
@@ -222,6 +229,9 @@ func newWorkflowExecutionEventHandler(
 		contextPropagators:           contextPropagators,
 		tracer:                       tracer,
 		workflowInterceptorFactories: workflowInterceptorFactories,
+		defaultActivityOptions:       defaultActivityOptions,
+		decisionTaskListener:         decisionTaskListener,
+		dispatcherStatsHandler:       dispatcherStatsHandler,
 	}
 	context.logger = logger.With(
 		zapcore.Field{Key: tagWorkflowType, Type: zapcore.StringType, String: workflowInfo.WorkflowType.Name},
@@ -292,6 +302,9 @@ func (wc *workflowEnvironmentImpl) WorkflowInfo() *WorkflowInfo {
 
 func (wc *workflowEnvironmentImpl) Complete(result []byte, err error) {
 	wc.completeHandler(result, err)
+	if wc.decisionTaskListener != nil {
+		wc.decisionTaskListener.OnWorkflowCompleted(wc.workflowInfo, newEncodedValue(result, wc.dataConverter), err, wc.isReplay)
+	}
 }
 
 func (wc *workflowEnvironmentImpl) RequestCancelChildWorkflow(domainName string, workflowID string) {
@@ -338,6 +351,33 @@ func (wc *workflowEnvironmentImpl) UpsertSearchAttributes(attributes map[string]
 	return nil
 }
 
+func (wc *workflowEnvironmentImpl) UpsertMemo(memoMap map[string]interface{}) error {
+	memo, err := getWorkflowMemo(memoMap, wc.dataConverter)
+	if err != nil {
+		return err
+	}
+	details, err := encodeArg(wc.dataConverter, memo.Fields)
+	if err != nil {
+		return err
+	}
+
+	wc.decisionsHelper.recordMarker(upsertMemoMarkerName, wc.GenerateSequenceID(), details)
+	wc.workflowInfo.Memo = mergeMemo(wc.workflowInfo.Memo, memo) // this is for getInfo correctness
+	return nil
+}
+
+// mergeMemo returns a new memo, the current one comes from the workflow started event and is left untouched
+func mergeMemo(current, upsert *shared.Memo) *shared.Memo {
+	fields := make(map[string][]byte, len(current.GetFields())+len(upsert.GetFields()))
+	for k, v := range current.GetFields() {
+		fields[k] = v
+	}
+	for k, v := range upsert.GetFields() {
+		fields[k] = v
+	}
+	return &shared.Memo{Fields: fields}
+}
+
 func (wc *workflowEnvironmentImpl) updateWorkflowInfoWithSearchAttributes(attributes *shared.SearchAttributes) {
 	wc.workflowInfo.SearchAttributes = mergeSearchAttributes(wc.workflowInfo.SearchAttributes, attributes)
 }
@@ -454,10 +494,10 @@ func (wc *workflowEnvironmentImpl) IsReplaying() bool {
 }
 
 func (wc *workflowEnvironmentImpl) GenerateSequenceID() string {
-	return fmt.Sprintf("%d", wc.GenerateSequence())
+	return strconv.FormatInt(wc.GenerateSequence(), 10)
 }
 
-func (wc *workflowEnvironmentImpl) GenerateSequence() int32 {
+func (wc *workflowEnvironmentImpl) GenerateSequence() int64 {
 	result := wc.counterID
 	wc.counterID++
 	return result
@@ -490,6 +530,9 @@ func (wc *workflowEnvironmentImpl) ExecuteActivity(parameters executeActivityPar
 	wc.logger.Debug("ExecuteActivity",
 		zap.String(tagActivityID, activityID),
 		zap.String(tagActivityType, scheduleTaskAttr.ActivityType.GetName()))
+	if wc.decisionTaskListener != nil {
+		wc.decisionTaskListener.OnActivityScheduled(wc.workflowInfo, activityID, scheduleTaskAttr.ActivityType.GetName(), wc.isReplay)
+	}
 
 	return &activityInfo{activityID: activityID}
 }
@@ -654,7 +697,7 @@ func (wc *workflowEnvironmentImpl) SideEffect(f func() ([]byte, error), callback
 		var ok bool
 		result, ok = wc.sideEffectResult[sideEffectID]
 		if !ok {
-			keys := make([]int32, 0, len(wc.sideEffectResult))
+			keys := make([]int64, 0, len(wc.sideEffectResult))
 			for k := range wc.sideEffectResult {
 				keys = append(keys, k)
 			}
@@ -662,7 +705,7 @@ func (wc *workflowEnvironmentImpl) SideEffect(f func() ([]byte, error), callback
 				sideEffectID, keys))
 		}
 		wc.logger.Debug("SideEffect returning already calculated result.",
-			zap.Int32(tagSideEffectID, sideEffectID))
+			zap.Int64(tagSideEffectID, sideEffectID))
 		details = result
 	} else {
 		var err error
@@ -681,7 +724,12 @@ func (wc *workflowEnvironmentImpl) SideEffect(f func() ([]byte, error), callback
 	wc.decisionsHelper.recordSideEffectMarker(sideEffectID, details)
 
 	callback(result, nil)
-	wc.logger.Debug("SideEffect Marker added", zap.Int32(tagSideEffectID, sideEffectID))
+	wc.logger.Debug("SideEffect Marker added", zap.Int64(tagSideEffectID, sideEffectID))
+}
+
+func (wc *workflowEnvironmentImpl) RecordMarker(name string, details []byte) {
+	wc.decisionsHelper.recordMarker(name, wc.GenerateSequenceID(), details)
+	wc.logger.Debug("Marker added", zap.String("MarkerName", name))
 }
 
 func (wc *workflowEnvironmentImpl) MutableSideEffect(id string, f func() interface{}, equals func(a, b interface{}) bool) Value {
@@ -710,8 +758,12 @@ func (wc *workflowEnvironmentImpl) MutableSideEffect(id string, f func() interfa
 func (wc *workflowEnvironmentImpl) isEqualValue(newValue interface{}, encodedOldValue []byte, equals func(a, b interface{}) bool) bool {
 	if newValue == nil {
 		// new value is nil
-		newEncodedValue := wc.encodeValue(nil)
-		return bytes.Equal(newEncodedValue, encodedOldValue)
+		if bytes.Equal(wc.encodeValue(nil), encodedOldValue) {
+			return true
+		}
+		// the encoding of nil is not stable with every data converter, e.g. the encrypted one
+		var oldValue interface{}
+		return newEncodedValue(encodedOldValue, wc.GetDataConverter()).Get(&oldValue) == nil && oldValue == nil
 	}
 
 	oldValue := decodeValue(newEncodedValue(encodedOldValue, wc.GetDataConverter()), newValue)
@@ -774,6 +826,16 @@ func (wc *workflowEnvironmentImpl) GetWorkflowInterceptors() []WorkflowIntercept
 	return wc.workflowInterceptorFactories
 }
 
+func (wc *workflowEnvironmentImpl) ReportDispatcherStats(stats DispatcherStats) {
+	if wc.dispatcherStatsHandler != nil {
+		wc.dispatcherStatsHandler(wc.workflowInfo, stats, wc.isReplay)
+	}
+}
+
+func (wc *workflowEnvironmentImpl) GetDefaultActivityOptions() *ActivityOptions {
+	return wc.defaultActivityOptions
+}
+
 func (weh *workflowExecutionEventHandlerImpl) ProcessEvent(
 	event *m.HistoryEvent,
 	isReplay bool,
@@ -804,11 +866,12 @@ func (weh *workflowExecutionEventHandlerImpl) ProcessEvent(
 
 	switch event.GetEventType() {
 	// Noops
+	// Closed workflows are only replayed, e.g. to answer a query. Their code must not be completed with the close
+	// reason, as that would add a completion decision to a workflow that is already closed.
 	case m.EventTypeWorkflowExecutionCompleted,
 		m.EventTypeWorkflowExecutionTimedOut,
 		m.EventTypeWorkflowExecutionFailed,
 		m.EventTypeDecisionTaskScheduled,
-		m.EventTypeDecisionTaskTimedOut,
 		m.EventTypeDecisionTaskFailed,
 		m.EventTypeActivityTaskStarted,
 		m.EventTypeDecisionTaskCompleted,
@@ -817,9 +880,14 @@ func (weh *workflowExecutionEventHandlerImpl) ProcessEvent(
 		// No Operation
 	case m.EventTypeWorkflowExecutionStarted:
 		err = weh.handleWorkflowExecutionStarted(event.WorkflowExecutionStartedEventAttributes)
+	case m.EventTypeDecisionTaskTimedOut:
+		weh.handleDecisionTaskTimedOut(event)
 	case m.EventTypeDecisionTaskStarted:
 		// Set replay clock.
 		weh.SetCurrentReplayTime(time.Unix(0, event.GetTimestamp()))
+		if weh.decisionTaskListener != nil {
+			weh.decisionTaskListener.OnDecisionTaskStarted(weh.workflowInfo, isReplay)
+		}
 		weh.workflowDefinition.OnDecisionTaskStarted()
 		// Set replay decisionStarted eventID
 		weh.workflowInfo.DecisionStartedEventID = event.GetEventId()
@@ -866,7 +934,7 @@ func (weh *workflowExecutionEventHandlerImpl) ProcessEvent(
 	case m.EventTypeExternalWorkflowExecutionSignaled:
 		weh.handleSignalExternalWorkflowExecutionCompleted(event)
 	case m.EventTypeMarkerRecorded:
-		err = weh.handleMarkerRecorded(event.GetEventId(), event.MarkerRecordedEventAttributes)
+		err = weh.handleMarkerRecorded(event.MarkerRecordedEventAttributes)
 	case m.EventTypeStartChildWorkflowExecutionInitiated:
 		weh.decisionsHelper.handleStartChildWorkflowExecutionInitiated(
 			event.StartChildWorkflowExecutionInitiatedEventAttributes.GetWorkflowId())
@@ -965,6 +1033,15 @@ func (weh *workflowExecutionEventHandlerImpl) handleWorkflowExecutionStarted(
 	return nil
 }
 
+func (weh *workflowExecutionEventHandlerImpl) handleDecisionTaskTimedOut(event *m.HistoryEvent) {
+	// both are replay aware, so the timeout is reported once, by the worker picking up the decision task after it
+	timeoutType := event.DecisionTaskTimedOutEventAttributes.GetTimeoutType()
+	weh.metricsScope.Counter(metrics.DecisionTimeoutCounter).Inc(1)
+	weh.logger.Warn("Decision task timed out, it will be retried.",
+		zap.Int64(tagEventID, event.GetEventId()),
+		zap.String(tagTimeoutType, timeoutType.String()))
+}
+
 func (weh *workflowExecutionEventHandlerImpl) handleActivityTaskCompleted(event *m.HistoryEvent) {
 	activityID := weh.decisionsHelper.getActivityID(event)
 	decision := weh.decisionsHelper.handleActivityTaskClosed(activityID)
@@ -1033,6 +1110,9 @@ func (weh *workflowExecutionEventHandlerImpl) handleTimerFired(event *m.HistoryE
 	if timer.handled {
 		return
 	}
+	if weh.decisionTaskListener != nil {
+		weh.decisionTaskListener.OnTimerFired(weh.workflowInfo, timerID, weh.isReplay)
+	}
 
 	timer.handle(nil, nil)
 }
@@ -1041,14 +1121,11 @@ func (weh *workflowExecutionEventHandlerImpl) handleWorkflowExecutionCancelReque
 	weh.cancelHandler()
 }
 
-func (weh *workflowExecutionEventHandlerImpl) handleMarkerRecorded(
-	eventID int64,
-	attributes *m.MarkerRecordedEventAttributes,
-) error {
+func (weh *workflowExecutionEventHandlerImpl) handleMarkerRecorded(attributes *m.MarkerRecordedEventAttributes) error {
 	encodedValues := newEncodedValues(attributes.Details, weh.dataConverter)
 	switch attributes.GetMarkerName() {
 	case sideEffectMarkerName:
-		var sideEffectID int32
+		var sideEffectID int64
 		var result []byte
 		err := encodedValues.Get(&sideEffectID, &result)
 		if err != nil {
@@ -1077,8 +1154,8 @@ func (weh *workflowExecutionEventHandlerImpl) handleMarkerRecorded(
 		weh.mutableSideEffect[fixedID] = []byte(result)
 		return nil
 	default:
-		return fmt.Errorf("unknown marker name \"%v\" for eventID \"%v\"",
-			attributes.GetMarkerName(), eventID)
+		// recorded by workflow.RecordMarker or UpsertMemo, replaying the workflow code restores their effect
+		return nil
 	}
 }
 
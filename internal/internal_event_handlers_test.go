@@ -22,6 +22,7 @@ package internal
 
 import (
 	"encoding/json"
+	"math"
 	"testing"
 	"time"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/uber-go/tally"
 
 	"go.uber.org/cadence/internal/common"
+	"go.uber.org/cadence/internal/common/metrics"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -78,6 +80,27 @@ func TestReplayAwareLogger(t *testing.T) {
 	assert.Equal(t, wrappedCore.enableLoggingInReplay, &enableLoggingInReplay)
 }
 
+func TestReplayAwareMetricsScope(t *testing.T) {
+	t.Parallel()
+	scope := tally.NewTestScope("", nil)
+	weh := testWorkflowExecutionEventHandler(t, newRegistry(), withEventHandlerMetricsScope(scope))
+
+	weh.isReplay = true
+	weh.GetMetricsScope().Counter("orders").Inc(1) // suppressed, already counted when first executed
+	weh.GetMetricsScope().Tagged(map[string]string{"region": "us"}).Counter("orders").Inc(1)
+
+	weh.isReplay = false
+	weh.GetMetricsScope().Counter("orders").Inc(1)
+	weh.GetMetricsScope().Tagged(map[string]string{"region": "us"}).Counter("orders").Inc(1)
+
+	counters := scope.Snapshot().Counters()
+	require.Len(t, counters, 2)
+	for _, counter := range counters {
+		assert.Equal(t, int64(1), counter.Value())
+		assert.Equal(t, "test", counter.Tags()[tagWorkflowType])
+	}
+}
+
 func testDecodeValueHelper(t *testing.T, env *workflowEnvironmentImpl) {
 	equals := func(a, b interface{}) bool {
 		ao := a.(ActivityOptions)
@@ -192,11 +215,11 @@ func Test_UpsertSearchAttributes(t *testing.T) {
 	require.NoError(t, err)
 	_, ok := env.decisionsHelper.decisions[makeDecisionID(decisionTypeUpsertSearchAttributes, "change2-1")]
 	require.True(t, ok)
-	require.Equal(t, int32(0), env.counterID)
+	require.Equal(t, int64(0), env.counterID)
 
 	err = env.UpsertSearchAttributes(map[string]interface{}{"key": 1})
 	require.NoError(t, err)
-	require.Equal(t, int32(1), env.counterID)
+	require.Equal(t, int64(1), env.counterID)
 }
 
 func Test_MergeSearchAttributes(t *testing.T) {
@@ -328,6 +351,15 @@ func Test_CreateSearchAttributesForChangeVersion(t *testing.T) {
 	require.Equal(t, []string{"cid-1"}, val)
 }
 
+func Test_GenerateSequenceID(t *testing.T) {
+	env := &workflowEnvironmentImpl{counterID: math.MaxInt32}
+
+	// IDs keep the format recorded in existing histories and don't wrap around past the int32 range
+	assert.Equal(t, "2147483647", env.GenerateSequenceID())
+	assert.Equal(t, "2147483648", env.GenerateSequenceID())
+	assert.Equal(t, int64(2147483649), env.GenerateSequence())
+}
+
 func TestHistoryEstimationforSmallEvents(t *testing.T) {
 	taskList := "tasklist"
 	testEvents := []*s.HistoryEvent{
@@ -416,6 +448,25 @@ func TestProcessQuery_KnownQueryTypes(t *testing.T) {
 	assert.Equal(t, "[\"__open_sessions\",\"__query_types\",\"__stack_trace\",\"a\"]\n", string(result))
 }
 
+func TestProcessQuery_OpenSessions(t *testing.T) {
+	weh := testWorkflowExecutionEventHandler(t, newRegistry())
+
+	result, err := weh.ProcessQuery(QueryTypeOpenSessions, nil)
+	require.NoError(t, err)
+	var sessions []*SessionInfo
+	require.NoError(t, DefaultDataConverter.FromData(result, &sessions))
+	assert.Empty(t, sessions)
+
+	weh.AddSession(&SessionInfo{SessionID: "session-1", HostName: "host-1"})
+	weh.AddSession(&SessionInfo{SessionID: "session-2", HostName: "host-2"})
+	weh.RemoveSession("session-2")
+
+	result, err = weh.ProcessQuery(QueryTypeOpenSessions, nil)
+	require.NoError(t, err)
+	require.NoError(t, DefaultDataConverter.FromData(result, &sessions))
+	assert.Equal(t, []*SessionInfo{{SessionID: "session-1", HostName: "host-1"}}, sessions)
+}
+
 func TestWorkflowExecutionEventHandler_ProcessEvent_WorkflowExecutionStarted(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		testRegistry := newRegistry()
@@ -468,7 +519,6 @@ func TestWorkflowExecutionEventHandler_ProcessEvent_Noops(t *testing.T) {
 		s.EventTypeWorkflowExecutionTimedOut,
 		s.EventTypeWorkflowExecutionFailed,
 		s.EventTypeDecisionTaskScheduled,
-		s.EventTypeDecisionTaskTimedOut,
 		s.EventTypeDecisionTaskFailed,
 		s.EventTypeActivityTaskStarted,
 		s.EventTypeDecisionTaskCompleted,
@@ -488,6 +538,98 @@ func TestWorkflowExecutionEventHandler_ProcessEvent_Noops(t *testing.T) {
 	}
 }
 
+func TestWorkflowExecutionEventHandler_ProcessEvent_IsReplaying(t *testing.T) {
+	core, observed := observer.New(zapcore.InfoLevel)
+	scope := tally.NewTestScope("", nil)
+	weh := testWorkflowExecutionEventHandler(t, newRegistry(),
+		withEventHandlerLogger(zap.New(core), false), withEventHandlerMetricsScope(scope))
+	event := &s.HistoryEvent{EventType: s.EventTypeDecisionTaskScheduled.Ptr()}
+
+	// the replay-aware logger and metrics scope consult the same flag as IsReplaying
+	for _, isReplay := range []bool{true, false} {
+		require.NoError(t, weh.ProcessEvent(event, isReplay, false))
+		assert.Equal(t, isReplay, weh.IsReplaying())
+		weh.GetLogger().Info("event processed")
+		weh.GetMetricsScope().Counter("events-processed").Inc(1)
+	}
+
+	assert.Equal(t, 1, observed.Len())
+	require.Len(t, scope.Snapshot().Counters(), 1)
+	for _, counter := range scope.Snapshot().Counters() {
+		assert.Equal(t, int64(1), counter.Value())
+	}
+}
+
+func TestWorkflowExecutionEventHandler_ProcessEvent_ActivityCanceled(t *testing.T) {
+	weh := testWorkflowExecutionEventHandler(t, newRegistry())
+	var result error
+	activity := weh.ExecuteActivity(executeActivityParams{
+		activityOptions: activityOptions{ActivityID: common.StringPtr("aID"), WaitForCancellation: true},
+		ActivityType:    ActivityType{Name: "test-activity"},
+	}, func(r []byte, err error) {
+		result = err
+	})
+	weh.decisionsHelper.getDecisions(true)
+
+	require.NoError(t, weh.ProcessEvent(createTestEventActivityTaskScheduled(5, &s.ActivityTaskScheduledEventAttributes{
+		ActivityId: common.StringPtr(activity.activityID),
+	}), false, false))
+	weh.RequestCancelActivity(activity.activityID)
+	require.Nil(t, result, "waits for the cancellation to be confirmed")
+	decisions := weh.decisionsHelper.getDecisions(true)
+	require.Len(t, decisions, 1)
+	assert.Equal(t, s.DecisionTypeRequestCancelActivityTask, decisions[0].GetDecisionType())
+
+	require.NoError(t, weh.ProcessEvent(&s.HistoryEvent{
+		EventId:   common.Int64Ptr(9),
+		EventType: s.EventTypeActivityTaskCancelRequested.Ptr(),
+		ActivityTaskCancelRequestedEventAttributes: &s.ActivityTaskCancelRequestedEventAttributes{
+			ActivityId: common.StringPtr(activity.activityID),
+		},
+	}, false, false))
+	require.Nil(t, result)
+
+	require.NoError(t, weh.ProcessEvent(&s.HistoryEvent{
+		EventId:   common.Int64Ptr(10),
+		EventType: s.EventTypeActivityTaskCanceled.Ptr(),
+		ActivityTaskCanceledEventAttributes: &s.ActivityTaskCanceledEventAttributes{
+			ScheduledEventId: common.Int64Ptr(5),
+		},
+	}, false, false))
+	var canceledErr *CanceledError
+	assert.ErrorAs(t, result, &canceledErr)
+	assert.Empty(t, weh.decisionsHelper.scheduledEventIDToActivityID)
+	assert.Empty(t, weh.decisionsHelper.decisions)
+}
+
+func TestWorkflowExecutionEventHandler_ProcessEvent_DecisionTaskTimedOut(t *testing.T) {
+	core, observed := observer.New(zapcore.WarnLevel)
+	scope := tally.NewTestScope("", nil)
+	weh := testWorkflowExecutionEventHandler(t, newRegistry(),
+		withEventHandlerLogger(zap.New(core), false), withEventHandlerMetricsScope(scope))
+	event := &s.HistoryEvent{
+		EventId:   common.Int64Ptr(4),
+		EventType: s.EventTypeDecisionTaskTimedOut.Ptr(),
+		DecisionTaskTimedOutEventAttributes: &s.DecisionTaskTimedOutEventAttributes{
+			TimeoutType: s.TimeoutTypeStartToClose.Ptr(),
+		},
+	}
+
+	// already reported before the replay
+	require.NoError(t, weh.ProcessEvent(event, true, false))
+	require.NoError(t, weh.ProcessEvent(event, false, false))
+
+	counters := scope.Snapshot().Counters()
+	require.Len(t, counters, 1)
+	for _, counter := range counters {
+		assert.Equal(t, metrics.DecisionTimeoutCounter, counter.Name())
+		assert.Equal(t, int64(1), counter.Value())
+	}
+	logs := observed.FilterMessageSnippet("Decision task timed out").All()
+	require.Len(t, logs, 1)
+	assert.Equal(t, "START_TO_CLOSE", logs[0].ContextMap()[tagTimeoutType])
+}
+
 func TestWorkflowExecutionEventHandler_ProcessEvent_nil(t *testing.T) {
 	weh := testWorkflowExecutionEventHandler(t, newRegistry())
 
@@ -860,10 +1002,21 @@ func TestEventHandler_handleMarkerRecorded(t *testing.T) {
 				Details:    getSerializedDetails(t, 1, []byte("test")),
 			},
 			assertResult: func(t *testing.T, result *workflowExecutionEventHandlerImpl) {
-				require.Contains(t, result.sideEffectResult, int32(1))
+				require.Contains(t, result.sideEffectResult, int64(1))
 				assert.Equal(t, []byte("test"), result.sideEffectResult[1])
 			},
 		},
+		{
+			// markers recorded before side effect IDs were widened to int64
+			marker: &s.MarkerRecordedEventAttributes{
+				MarkerName: common.StringPtr(sideEffectMarkerName),
+				Details:    getSerializedDetails(t, int32(2), []byte("test")),
+			},
+			assertResult: func(t *testing.T, result *workflowExecutionEventHandlerImpl) {
+				require.Contains(t, result.sideEffectResult, int64(2))
+				assert.Equal(t, []byte("test"), result.sideEffectResult[2])
+			},
+		},
 		{
 			marker: &s.MarkerRecordedEventAttributes{
 				MarkerName: common.StringPtr(versionMarkerName),
@@ -886,7 +1039,7 @@ func TestEventHandler_handleMarkerRecorded(t *testing.T) {
 		},
 	} {
 		weh := testWorkflowExecutionEventHandler(t, newRegistry())
-		err := weh.handleMarkerRecorded(1, tc.marker)
+		err := weh.handleMarkerRecorded(tc.marker)
 		assert.NoError(t, err)
 	}
 }
@@ -897,13 +1050,6 @@ func TestEventHandler_handleMarkerRecorded_failures(t *testing.T) {
 		marker         *s.MarkerRecordedEventAttributes
 		assertErrorStr string
 	}{
-		{
-			name: "unknown marker",
-			marker: &s.MarkerRecordedEventAttributes{
-				MarkerName: common.StringPtr("unknown"),
-			},
-			assertErrorStr: "unknown marker name \"unknown\" for eventID \"1\"",
-		},
 		{
 			name: "side effect with invalid details",
 			marker: &s.MarkerRecordedEventAttributes{
@@ -931,7 +1077,7 @@ func TestEventHandler_handleMarkerRecorded_failures(t *testing.T) {
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			weh := testWorkflowExecutionEventHandler(t, newRegistry())
-			err := weh.handleMarkerRecorded(1, tc.marker)
+			err := weh.handleMarkerRecorded(tc.marker)
 			assert.ErrorContains(t, err, tc.assertErrorStr)
 		})
 	}
@@ -981,18 +1127,285 @@ func TestWorkflowExecutionEnvironment_NewTimer_immediate_calls(t *testing.T) {
 	})
 }
 
-func testWorkflowExecutionEventHandler(t *testing.T, registry *registry) *workflowExecutionEventHandlerImpl {
+func TestWorkflowExecutionEventHandler_SignalExternalWorkflow(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		resultEvent func(initiatedEventID int64) *s.HistoryEvent
+		assertErr   func(t *testing.T, err error)
+	}{
+		{
+			name: "signaled",
+			resultEvent: func(initiatedEventID int64) *s.HistoryEvent {
+				return &s.HistoryEvent{
+					EventType: common.EventTypePtr(s.EventTypeExternalWorkflowExecutionSignaled),
+					ExternalWorkflowExecutionSignaledEventAttributes: &s.ExternalWorkflowExecutionSignaledEventAttributes{
+						InitiatedEventId: common.Int64Ptr(initiatedEventID),
+					},
+				}
+			},
+			assertErr: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "unknown external workflow",
+			resultEvent: func(initiatedEventID int64) *s.HistoryEvent {
+				return &s.HistoryEvent{
+					EventType: common.EventTypePtr(s.EventTypeSignalExternalWorkflowExecutionFailed),
+					SignalExternalWorkflowExecutionFailedEventAttributes: &s.SignalExternalWorkflowExecutionFailedEventAttributes{
+						InitiatedEventId: common.Int64Ptr(initiatedEventID),
+						Cause:            s.SignalExternalWorkflowExecutionFailedCauseUnknownExternalWorkflowExecution.Ptr(),
+					},
+				}
+			},
+			assertErr: func(t *testing.T, err error) {
+				assert.IsType(t, &UnknownExternalWorkflowExecutionError{}, err)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			weh := testWorkflowExecutionEventHandler(t, newRegistry())
+			var callbackCalled bool
+			var callbackErr error
+			weh.SignalExternalWorkflow("test-domain", "wid", "rid", "signal", nil, nil, false, func(result []byte, err error) {
+				callbackCalled = true
+				callbackErr = err
+			})
+			decisions := weh.decisionsHelper.getDecisions(true)
+			require.Len(t, decisions, 1)
+			attributes := decisions[0].SignalExternalWorkflowExecutionDecisionAttributes
+			require.NotNil(t, attributes)
+			assert.Equal(t, "signal", attributes.GetSignalName())
+
+			const initiatedEventID = 5
+			require.NoError(t, weh.ProcessEvent(&s.HistoryEvent{
+				EventId:   common.Int64Ptr(initiatedEventID),
+				EventType: common.EventTypePtr(s.EventTypeSignalExternalWorkflowExecutionInitiated),
+				SignalExternalWorkflowExecutionInitiatedEventAttributes: &s.SignalExternalWorkflowExecutionInitiatedEventAttributes{
+					Control: attributes.Control,
+				},
+			}, false, false))
+			assert.False(t, callbackCalled, "callback must wait for the outcome event")
+
+			require.NoError(t, weh.ProcessEvent(tc.resultEvent(initiatedEventID), false, false))
+			assert.True(t, callbackCalled)
+			tc.assertErr(t, callbackErr)
+		})
+	}
+}
+
+func TestWorkflowExecutionEventHandler_RequestCancelExternalWorkflow(t *testing.T) {
+	execution := &s.WorkflowExecution{
+		WorkflowId: common.StringPtr("wid"),
+		RunId:      common.StringPtr("rid"),
+	}
+	for _, tc := range []struct {
+		name        string
+		resultEvent func(initiatedEventID int64) *s.HistoryEvent
+		assertErr   func(t *testing.T, err error)
+	}{
+		{
+			name: "cancel requested",
+			resultEvent: func(initiatedEventID int64) *s.HistoryEvent {
+				return &s.HistoryEvent{
+					EventType: common.EventTypePtr(s.EventTypeExternalWorkflowExecutionCancelRequested),
+					ExternalWorkflowExecutionCancelRequestedEventAttributes: &s.ExternalWorkflowExecutionCancelRequestedEventAttributes{
+						InitiatedEventId:  common.Int64Ptr(initiatedEventID),
+						WorkflowExecution: execution,
+					},
+				}
+			},
+			assertErr: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "failed",
+			resultEvent: func(initiatedEventID int64) *s.HistoryEvent {
+				return &s.HistoryEvent{
+					EventType: common.EventTypePtr(s.EventTypeRequestCancelExternalWorkflowExecutionFailed),
+					RequestCancelExternalWorkflowExecutionFailedEventAttributes: &s.RequestCancelExternalWorkflowExecutionFailedEventAttributes{
+						InitiatedEventId:  common.Int64Ptr(initiatedEventID),
+						WorkflowExecution: execution,
+						Cause:             s.CancelExternalWorkflowExecutionFailedCauseUnknownExternalWorkflowExecution.Ptr(),
+					},
+				}
+			},
+			assertErr: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "cancel external workflow failed")
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			weh := testWorkflowExecutionEventHandler(t, newRegistry())
+			var callbackCalled bool
+			var callbackErr error
+			weh.RequestCancelExternalWorkflow("test-domain", "wid", "rid", func(result []byte, err error) {
+				callbackCalled = true
+				callbackErr = err
+			})
+			decisions := weh.decisionsHelper.getDecisions(true)
+			require.Len(t, decisions, 1)
+			attributes := decisions[0].RequestCancelExternalWorkflowExecutionDecisionAttributes
+			require.NotNil(t, attributes)
+			assert.Equal(t, "wid", attributes.GetWorkflowId())
+			assert.False(t, attributes.GetChildWorkflowOnly())
+
+			const initiatedEventID = 5
+			require.NoError(t, weh.ProcessEvent(&s.HistoryEvent{
+				EventId:   common.Int64Ptr(initiatedEventID),
+				EventType: common.EventTypePtr(s.EventTypeRequestCancelExternalWorkflowExecutionInitiated),
+				RequestCancelExternalWorkflowExecutionInitiatedEventAttributes: &s.RequestCancelExternalWorkflowExecutionInitiatedEventAttributes{
+					WorkflowExecution: execution,
+					Control:           attributes.Control,
+				},
+			}, false, false))
+			assert.False(t, callbackCalled, "callback must wait for the outcome event")
+
+			require.NoError(t, weh.ProcessEvent(tc.resultEvent(initiatedEventID), false, false))
+			assert.True(t, callbackCalled)
+			tc.assertErr(t, callbackErr)
+		})
+	}
+}
+
+func TestWorkflowExecutionEventHandler_ExecuteChildWorkflow_ParentClosePolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy   ParentClosePolicy
+		expected s.ParentClosePolicy
+	}{
+		{policy: ParentClosePolicyTerminate, expected: s.ParentClosePolicyTerminate},
+		{policy: ParentClosePolicyRequestCancel, expected: s.ParentClosePolicyRequestCancel},
+		{policy: ParentClosePolicyAbandon, expected: s.ParentClosePolicyAbandon},
+	} {
+		t.Run(tc.expected.String(), func(t *testing.T) {
+			weh := testWorkflowExecutionEventHandler(t, newRegistry())
+			err := weh.ExecuteChildWorkflow(executeWorkflowParams{
+				workflowOptions: workflowOptions{
+					domain:            common.StringPtr("test-domain"),
+					taskListName:      common.StringPtr("test-tasklist"),
+					workflowID:        "child-wid",
+					parentClosePolicy: tc.policy,
+				},
+				workflowType: &WorkflowType{Name: "child"},
+			}, func(result []byte, err error) {}, func(r WorkflowExecution, e error) {})
+			require.NoError(t, err)
+
+			decisions := weh.decisionsHelper.getDecisions(true)
+			require.Len(t, decisions, 1)
+			attributes := decisions[0].StartChildWorkflowExecutionDecisionAttributes
+			require.NotNil(t, attributes)
+			assert.Equal(t, tc.expected, attributes.GetParentClosePolicy())
+		})
+	}
+}
+
+func TestWorkflowExecutionEventHandler_ExecuteChildWorkflow_StartedBeforeCompleted(t *testing.T) {
+	weh := testWorkflowExecutionEventHandler(t, newRegistry())
+	var started *WorkflowExecution
+	var result []byte
+	resultCalled := false
+	err := weh.ExecuteChildWorkflow(executeWorkflowParams{
+		workflowOptions: workflowOptions{
+			domain:       common.StringPtr("test-domain"),
+			taskListName: common.StringPtr("test-tasklist"),
+			workflowID:   "child-wid",
+		},
+		workflowType: &WorkflowType{Name: "child"},
+	}, func(r []byte, err error) {
+		assert.NoError(t, err)
+		resultCalled = true
+		result = r
+	}, func(r WorkflowExecution, e error) {
+		assert.NoError(t, e)
+		started = &r
+	})
+	require.NoError(t, err)
+	weh.decisionsHelper.getDecisions(true)
+
+	execution := &s.WorkflowExecution{
+		WorkflowId: common.StringPtr("child-wid"),
+		RunId:      common.StringPtr("child-rid"),
+	}
+	require.NoError(t, weh.ProcessEvent(&s.HistoryEvent{
+		EventId:   common.Int64Ptr(5),
+		EventType: common.EventTypePtr(s.EventTypeStartChildWorkflowExecutionInitiated),
+		StartChildWorkflowExecutionInitiatedEventAttributes: &s.StartChildWorkflowExecutionInitiatedEventAttributes{
+			WorkflowId: common.StringPtr("child-wid"),
+		},
+	}, false, false))
+	require.NoError(t, weh.ProcessEvent(&s.HistoryEvent{
+		EventId:   common.Int64Ptr(6),
+		EventType: common.EventTypePtr(s.EventTypeChildWorkflowExecutionStarted),
+		ChildWorkflowExecutionStartedEventAttributes: &s.ChildWorkflowExecutionStartedEventAttributes{
+			InitiatedEventId:  common.Int64Ptr(5),
+			WorkflowExecution: execution,
+		},
+	}, false, false))
+
+	// the execution is known as soon as the child starts, before it produces a result
+	require.NotNil(t, started)
+	assert.Equal(t, WorkflowExecution{ID: "child-wid", RunID: "child-rid"}, *started)
+	assert.False(t, resultCalled)
+
+	require.NoError(t, weh.ProcessEvent(&s.HistoryEvent{
+		EventId:   common.Int64Ptr(7),
+		EventType: common.EventTypePtr(s.EventTypeChildWorkflowExecutionCompleted),
+		ChildWorkflowExecutionCompletedEventAttributes: &s.ChildWorkflowExecutionCompletedEventAttributes{
+			InitiatedEventId:  common.Int64Ptr(5),
+			WorkflowExecution: execution,
+			Result:            []byte("result"),
+		},
+	}, false, false))
+	assert.True(t, resultCalled)
+	assert.Equal(t, []byte("result"), result)
+}
+
+type testEventHandlerOptions struct {
+	logger                *zap.Logger
+	enableLoggingInReplay bool
+	metricsScope          tally.Scope
+}
+
+type testEventHandlerOption func(*testEventHandlerOptions)
+
+// withEventHandlerLogger sets the logger of the handler and whether it logs during replay.
+func withEventHandlerLogger(logger *zap.Logger, enableLoggingInReplay bool) testEventHandlerOption {
+	return func(o *testEventHandlerOptions) {
+		o.logger = logger
+		o.enableLoggingInReplay = enableLoggingInReplay
+	}
+}
+
+func withEventHandlerMetricsScope(scope tally.Scope) testEventHandlerOption {
+	return func(o *testEventHandlerOptions) {
+		o.metricsScope = scope
+	}
+}
+
+func testWorkflowExecutionEventHandler(t *testing.T, registry *registry, opts ...testEventHandlerOption) *workflowExecutionEventHandlerImpl {
+	options := testEventHandlerOptions{
+		logger:                testlogger.NewZap(t),
+		enableLoggingInReplay: true,
+		metricsScope:          tally.NewTestScope("test", nil),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
 	return newWorkflowExecutionEventHandler(
 		testWorkflowInfo,
 		func(result []byte, err error) {},
-		testlogger.NewZap(t),
-		true,
-		tally.NewTestScope("test", nil),
+		options.logger,
+		options.enableLoggingInReplay,
+		options.metricsScope,
 		registry,
 		&defaultDataConverter{},
 		nil,
 		opentracing.NoopTracer{},
 		nil,
+		nil,
+		nil,
+		nil,
 	).(*workflowExecutionEventHandlerImpl)
 }
 
@@ -29,6 +29,7 @@ const (
 	tagRunID                       = "RunID"
 	tagTaskList                    = "TaskList"
 	tagTimerID                     = "TimerID"
+	tagTimeoutType                 = "TimeoutType"
 	tagWorkflowID                  = "WorkflowID"
 	tagWorkflowType                = "WorkflowType"
 	tagWorkerID                    = "WorkerID"
@@ -27,14 +27,22 @@ import (
 	"errors"
 	"time"
 
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/yarpcerrors"
+
 	s "go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common/backoff"
+	"go.uber.org/cadence/internal/common/metrics"
 )
 
 const (
 	retryServiceOperationInitialInterval    = 20 * time.Millisecond
 	retryServiceOperationExpirationInterval = 60 * time.Second
 	retryServiceOperationBackoff            = 1.2
+
+	retryThrottledOperationInitialInterval = time.Second
+	retryThrottledOperationMaximumInterval = 10 * time.Second
+	retryThrottledOperationBackoff         = 2.0
 )
 
 // Creates a retry policy which allows appropriate retries for the deadline passed in as context.
@@ -124,6 +132,51 @@ func isServiceTransientError(err error) bool {
 	return true
 }
 
+// Creates a retry policy for throttled requests, which backs off more slowly than
+// createDynamicServiceRetryPolicy so that an overloaded server is not kept busy by retries.
+// Expiration follows the context deadline in the same way.
+func createThrottleRetryPolicy(ctx context.Context) backoff.RetryPolicy {
+	timeout := retryServiceOperationExpirationInterval
+	if ctx != nil {
+		now := time.Now()
+		if expiration, ok := ctx.Deadline(); ok && expiration.After(now) {
+			timeout = expiration.Sub(now)
+		}
+	}
+	policy := backoff.NewExponentialRetryPolicy(retryThrottledOperationInitialInterval)
+	policy.SetBackoffCoefficient(retryThrottledOperationBackoff)
+	policy.SetMaximumInterval(retryThrottledOperationMaximumInterval)
+	policy.SetExpirationInterval(timeout)
+	return policy
+}
+
+// isServiceThrottleError reports whether err means the server rejected the request because it is
+// overloaded or the caller is being rate limited, as opposed to other transient failures.
+func isServiceThrottleError(err error) bool {
+	if target := (*s.ServiceBusyError)(nil); errors.As(err, &target) {
+		return true
+	}
+	return yarpcerrors.IsResourceExhausted(err)
+}
+
 func retryWhileTransientError(ctx context.Context, fn func() error) error {
-	return backoff.Retry(ctx, fn, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+	return retryServiceOperation(ctx, nil, fn)
+}
+
+// retryServiceOperation retries fn while it returns transient errors, backing off with the
+// throttle policy after throttle errors. When scope is not nil, every retry is counted as either
+// throttled or transient.
+func retryServiceOperation(ctx context.Context, scope tally.Scope, fn func() error) error {
+	opts := []backoff.RetryOption{backoff.WithThrottlePolicy(createThrottleRetryPolicy(ctx), isServiceThrottleError)}
+	if scope != nil {
+		opts = append(opts, backoff.WithOnRetry(func(_ int, err error, _ time.Duration) {
+			if isServiceThrottleError(err) {
+				scope.Counter(metrics.ServiceThrottledRetryCounter).Inc(1)
+			} else {
+				scope.Counter(metrics.ServiceTransientRetryCounter).Inc(1)
+			}
+		}))
+	}
+	return backoff.RetryWithContext(ctx, func(context.Context) error { return fn() },
+		createDynamicServiceRetryPolicy(ctx), isServiceTransientError, opts...)
 }
@@ -23,11 +23,15 @@ package internal
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/yarpcerrors"
 
 	s "go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common/metrics"
 )
 
 func TestErrRetries(t *testing.T) {
@@ -121,3 +125,28 @@ func TestRetryWhileTransientError(t *testing.T) {
 		})
 	}
 }
+
+func TestIsServiceThrottleError(t *testing.T) {
+	assert.True(t, isServiceThrottleError(&s.ServiceBusyError{}))
+	assert.True(t, isServiceThrottleError(fmt.Errorf("wrapped: %w", &s.ServiceBusyError{})))
+	assert.True(t, isServiceThrottleError(yarpcerrors.ResourceExhaustedErrorf("rate limited")))
+	assert.False(t, isServiceThrottleError(&s.InternalServiceError{}))
+	assert.False(t, isServiceThrottleError(errors.New("unrecognized")))
+}
+
+func TestRetryServiceOperation_Metrics(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	errs := []error{&s.InternalServiceError{}, &s.InternalServiceError{}, yarpcerrors.ResourceExhaustedErrorf("rate limited"), nil}
+	i := 0
+	err := retryServiceOperation(context.Background(), scope, func() error {
+		e := errs[i]
+		i++
+		return e
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, len(errs), i)
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(2), counters[metrics.ServiceTransientRetryCounter+"+"].Value())
+	assert.Equal(t, int64(1), counters[metrics.ServiceThrottledRetryCounter+"+"].Value())
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"container/heap"
+	"sync"
+)
+
+type (
+	// taskDispatchQueue holds the polled tasks waiting for an execution slot of a baseWorker, and hands them out
+	// by priority, highest first, then by poll order. See baseWorkerOptions.taskPriority and
+	// baseWorkerOptions.acquireTaskSlot.
+	taskDispatchQueue struct {
+		sync.Mutex
+		tasks    prioritizedTasks
+		seq      int64
+		notifyCh chan struct{}
+	}
+
+	prioritizedTask struct {
+		task     *polledTask
+		priority int
+		seq      int64
+	}
+
+	// prioritizedTasks implements heap.Interface
+	prioritizedTasks []prioritizedTask
+)
+
+func newTaskDispatchQueue() *taskDispatchQueue {
+	return &taskDispatchQueue{notifyCh: make(chan struct{}, 1)}
+}
+
+// push adds a task to the queue and wakes up a pending pop.
+func (q *taskDispatchQueue) push(task *polledTask, priority int) {
+	q.Lock()
+	heap.Push(&q.tasks, prioritizedTask{task: task, priority: priority, seq: q.seq})
+	q.seq++
+	q.Unlock()
+	q.notify()
+}
+
+// notify wakes up a pending pop, e.g. when a task type slot was released.
+func (q *taskDispatchQueue) notify() {
+	select {
+	case q.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// pop removes the task with the highest priority that acquire accepts from the queue, waiting for one if there
+// is none. A nil acquire accepts every task. It returns false if doneCh is closed first.
+// It must not be called concurrently.
+func (q *taskDispatchQueue) pop(doneCh <-chan struct{}, acquire func(*polledTask) bool) (*polledTask, bool) {
+	for {
+		q.Lock()
+		var skipped []prioritizedTask
+		var found *polledTask
+		for len(q.tasks) > 0 {
+			task := heap.Pop(&q.tasks).(prioritizedTask)
+			if acquire == nil || acquire(task.task) {
+				found = task.task
+				break
+			}
+			skipped = append(skipped, task)
+		}
+		for _, task := range skipped {
+			heap.Push(&q.tasks, task)
+		}
+		q.Unlock()
+		if found != nil {
+			return found, true
+		}
+
+		select {
+		case <-q.notifyCh:
+		case <-doneCh:
+			return nil, false
+		}
+	}
+}
+
+// len returns the number of queued tasks.
+func (q *taskDispatchQueue) len() int {
+	q.Lock()
+	defer q.Unlock()
+	return len(q.tasks)
+}
+
+func (t prioritizedTasks) Len() int { return len(t) }
+
+func (t prioritizedTasks) Less(i, j int) bool {
+	if t[i].priority != t[j].priority {
+		return t[i].priority > t[j].priority
+	}
+	return t[i].seq < t[j].seq
+}
+
+func (t prioritizedTasks) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
+
+func (t *prioritizedTasks) Push(x interface{}) { *t = append(*t, x.(prioritizedTask)) }
+
+func (t *prioritizedTasks) Pop() interface{} {
+	old := *t
+	n := len(old)
+	task := old[n-1]
+	old[n-1] = prioritizedTask{}
+	*t = old[:n-1]
+	return task
+}
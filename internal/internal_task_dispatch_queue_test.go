@@ -0,0 +1,59 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskDispatchQueue(t *testing.T) {
+	q := newTaskDispatchQueue()
+	doneCh := make(chan struct{})
+	tasks := []*polledTask{{task: "low-1"}, {task: "high-1"}, {task: "low-2"}, {task: "high-2"}, {task: "medium"}}
+	for i, priority := range []int{0, 10, 0, 10, 5} {
+		q.push(tasks[i], priority)
+	}
+	assert.Equal(t, 5, q.len())
+
+	for _, expected := range []string{"high-1", "high-2", "medium", "low-1", "low-2"} {
+		task, ok := q.pop(doneCh, nil)
+		require.True(t, ok)
+		assert.Equal(t, expected, task.task)
+	}
+	assert.Equal(t, 0, q.len())
+
+	// pop waits for a task
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.push(&polledTask{task: "late"}, 0)
+	}()
+	task, ok := q.pop(doneCh, nil)
+	require.True(t, ok)
+	assert.Equal(t, "late", task.task)
+
+	close(doneCh)
+	_, ok = q.pop(doneCh, nil)
+	assert.False(t, ok)
+}
@@ -40,13 +40,14 @@ import (
 	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
 	s "go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common"
-	"go.uber.org/cadence/internal/common/backoff"
 	"go.uber.org/cadence/internal/common/cache"
 	"go.uber.org/cadence/internal/common/metrics"
+	"go.uber.org/cadence/internal/common/util"
 )
 
 const (
 	defaultHeartBeatIntervalInSec = 10 * 60
+	defaultHeartbeatThrottleRatio = 0.8
 
 	defaultStickyCacheSize = 10000
 
@@ -57,13 +58,15 @@ const (
 	defaultShortLivedWorkflowTimeoutUpperLimitInSec = 1 * 1800
 
 	defaultMediumLivedWorkflowTimeoutUpperLimitInSec = 8 * 3600
+
+	decisionTaskDebugMaxPayloadSize = 256
 )
 
 type (
 	// workflowExecutionEventHandler process a single event.
 	workflowExecutionEventHandler interface {
-		// Process a single event and return the assosciated decisions.
-		// Return List of decisions made, any error.
+		// Process a single event. The decisions made while processing the events of a decision batch are
+		// collected by the decisions helper of the handler, and retrieved once per batch with getDecisions.
 		ProcessEvent(event *s.HistoryEvent, isReplay bool, isLast bool) error
 		// ProcessQuery process a query request.
 		ProcessQuery(queryType string, queryArgs []byte) ([]byte, error)
@@ -84,6 +87,14 @@ type (
 	activityTask struct {
 		task          *s.PollForActivityTaskResponse
 		pollStartTime time.Time
+		// receivedAt is the local time the poll returned the task, used to measure how long the task
+		// waited in the worker without comparing the local clock against server timestamps.
+		receivedAt time.Time
+		// taskListName is the task list the task was polled from
+		taskListName string
+		// slotHeld is set when the worker acquired the slot of the activity type before dispatching the task,
+		// see RegisterActivityOptions.MaxConcurrentExecutionSize.
+		slotHeld bool
 	}
 
 	// resetStickinessTask wraps a ResetStickyTaskListRequest.
@@ -122,16 +133,25 @@ type (
 		ppMgr                          pressurePointMgr
 		logger                         *zap.Logger
 		identity                       string
+		buildID                        string
 		enableLoggingInReplay          bool
 		disableStickyExecution         bool
 		registry                       *registry
 		laTunnel                       *localActivityTunnel
 		nonDeterministicWorkflowPolicy NonDeterministicWorkflowPolicy
+		workflowPanicPolicy            WorkflowPanicPolicy
 		dataConverter                  DataConverter
 		contextPropagators             []ContextPropagator
 		tracer                         opentracing.Tracer
 		workflowInterceptorFactories   []WorkflowInterceptorFactory
 		disableStrictNonDeterminism    bool
+		payloadThreshold               int
+		defaultActivityOptions         *ActivityOptions
+		historySoftLimits              *HistorySoftLimits
+		decisionTaskListener           DecisionTaskListener
+		dispatcherStatsHandler         func(info *WorkflowInfo, stats DispatcherStats, isReplay bool)
+		decisionTaskDebugLogging       bool
+		featureFlags                   FeatureFlags
 	}
 
 	activityProvider func(name string) activity
@@ -378,15 +398,24 @@ func newWorkflowTaskHandler(
 		ppMgr:                          ppMgr,
 		metricsScope:                   metrics.NewTaggedScope(params.MetricsScope),
 		identity:                       params.Identity,
+		buildID:                        params.BuildID,
 		enableLoggingInReplay:          params.EnableLoggingInReplay,
 		disableStickyExecution:         params.DisableStickyExecution,
 		registry:                       registry,
 		nonDeterministicWorkflowPolicy: params.NonDeterministicWorkflowPolicy,
+		workflowPanicPolicy:            params.WorkflowPanicPolicy,
 		dataConverter:                  params.DataConverter,
 		contextPropagators:             params.ContextPropagators,
 		tracer:                         params.Tracer,
 		workflowInterceptorFactories:   params.WorkflowInterceptorChainFactories,
 		disableStrictNonDeterminism:    params.WorkerBugPorts.DisableStrictNonDeterminismCheck,
+		payloadThreshold:               params.LargePayloadWarningThreshold,
+		defaultActivityOptions:         params.DefaultActivityOptions,
+		historySoftLimits:              params.HistorySoftLimits,
+		decisionTaskListener:           params.DecisionTaskListener,
+		dispatcherStatsHandler:         params.DispatcherStatsHandler,
+		decisionTaskDebugLogging:       params.EnableDecisionTaskDebugLogging,
+		featureFlags:                   params.FeatureFlags,
 	}
 
 	traceLog(func() {
@@ -590,6 +619,9 @@ func (w *workflowExecutionContextImpl) createEventHandler() {
 		w.wth.contextPropagators,
 		w.wth.tracer,
 		w.wth.workflowInterceptorFactories,
+		w.wth.defaultActivityOptions,
+		w.wth.decisionTaskListener,
+		w.wth.dispatcherStatsHandler,
 	)
 	w.eventHandler.Store(eventHandler)
 }
@@ -648,6 +680,14 @@ func (wth *workflowTaskHandlerImpl) createWorkflowContext(task *s.PollForDecisio
 		RetryPolicy:                         attributes.RetryPolicy,
 	}
 
+	if task.GetPreviousStartedEventId() == 0 && task.GetAttempt() == 0 {
+		// only the very first decision task of a run reports the input, so replays don't count it twice
+		recordPayloadSize(
+			wth.metricsScope.GetTaggedScope(tagWorkflowType, task.WorkflowType.GetName()),
+			wth.logger.With(zap.String(tagWorkflowID, workflowID), zap.String(tagRunID, runID)),
+			wth.payloadThreshold, metrics.WorkflowInputSize, attributes.Input)
+	}
+
 	wfStartTime := time.Unix(0, h.Events[0].GetTimestamp())
 	return newWorkflowExecutionContext(wfStartTime, workflowInfo, wth), nil
 }
@@ -819,9 +859,41 @@ process_Workflow_Loop:
 			break process_Workflow_Loop
 		}
 	}
+	if wth.decisionTaskDebugLogging {
+		wth.logDecisionTask(task, response)
+	}
 	return response, err
 }
 
+// logDecisionTask logs the events of a decision task together with the response sent back for it
+func (wth *workflowTaskHandlerImpl) logDecisionTask(task *s.PollForDecisionTaskResponse, response interface{}) {
+	events := make([]string, 0, len(task.History.Events))
+	for _, event := range task.History.Events {
+		events = append(events, fmt.Sprintf("%v %v", event.GetEventId(),
+			util.TruncatedHistoryEventToString(event, decisionTaskDebugMaxPayloadSize)))
+	}
+	fields := []zap.Field{
+		zap.String(tagWorkflowType, task.WorkflowType.GetName()),
+		zap.String(tagWorkflowID, task.WorkflowExecution.GetWorkflowId()),
+		zap.String(tagRunID, task.WorkflowExecution.GetRunId()),
+		zap.Int64("PreviousStartedEventId", task.GetPreviousStartedEventId()),
+		zap.Strings("Events", events),
+	}
+	switch r := response.(type) {
+	case *s.RespondDecisionTaskCompletedRequest:
+		decisions := make([]string, 0, len(r.Decisions))
+		for _, decision := range r.Decisions {
+			decisions = append(decisions, util.TruncatedDecisionToString(decision, decisionTaskDebugMaxPayloadSize))
+		}
+		fields = append(fields, zap.Strings("Decisions", decisions))
+	case *s.RespondDecisionTaskFailedRequest:
+		fields = append(fields, zap.String("FailureCause", r.GetCause().String()))
+	case *s.RespondQueryTaskCompletedRequest:
+		fields = append(fields, zap.String("QueryResult", r.GetCompletedType().String()))
+	}
+	wth.logger.Info("Decision task processed.", fields...)
+}
+
 func (w *workflowExecutionContextImpl) ProcessWorkflowTask(workflowTask *workflowTask) (interface{}, error) {
 	task := workflowTask.task
 	historyIterator := workflowTask.historyIterator
@@ -846,6 +918,8 @@ func (w *workflowExecutionContextImpl) ProcessWorkflowTask(workflowTask *workflo
 			zap.String(tagRunID, task.WorkflowExecution.GetRunId()),
 		)
 	}
+	var replayedEvents int
+	var replayDuration time.Duration
 	// Process events
 ProcessEvents:
 	for {
@@ -860,7 +934,7 @@ ProcessEvents:
 			break ProcessEvents
 		}
 		if binaryChecksum == nil {
-			w.workflowInfo.BinaryChecksum = common.StringPtr(getBinaryChecksum())
+			w.workflowInfo.BinaryChecksum = common.StringPtr(getBuildID(w.wth.buildID))
 		} else {
 			w.workflowInfo.BinaryChecksum = binaryChecksum
 		}
@@ -896,7 +970,12 @@ ProcessEvents:
 				return nil, err
 			}
 
+			startTime := time.Now()
 			err = eventHandler.ProcessEvent(event, isInReplay, isLast)
+			if isInReplay {
+				replayedEvents++
+				replayDuration += time.Since(startTime)
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -927,6 +1006,12 @@ ProcessEvents:
 		}
 	}
 
+	metricsScope := w.wth.metricsScope.GetTaggedScope(tagWorkflowType, task.WorkflowType.GetName())
+	metricsScope.Timer(metrics.DecisionReplayLatency).Record(replayDuration)
+	metricsScope.Histogram(metrics.DecisionReplayedEvents, eventCountBuckets).RecordValue(float64(replayedEvents))
+	metricsScope.Histogram(metrics.DecisionHistoryLength, eventCountBuckets).RecordValue(float64(w.workflowInfo.HistoryCount))
+	metricsScope.Histogram(metrics.DecisionHistorySize, historySizeBuckets).RecordValue(float64(w.workflowInfo.TotalHistoryBytes))
+
 	// Non-deterministic error could happen in 2 different places:
 	//   1) the replay decisions does not match to history events. This is usually due to non backwards compatible code
 	// change to decider logic. For example, change calling one activity to a different activity.
@@ -980,9 +1065,55 @@ ProcessEvents:
 		}
 	}
 
+	if err := w.checkHistorySoftLimits(task, metricsScope); err != nil {
+		// complete workflow with custom error will fail the workflow
+		eventHandler.Complete(nil, err)
+	}
+
 	return w.CompleteDecisionTask(workflowTask, true), nil
 }
 
+// checkHistorySoftLimits reports a workflow whose history is over the worker's soft limits, and returns the
+// error to fail it with if the limits are configured to fail workflows.
+func (w *workflowExecutionContextImpl) checkHistorySoftLimits(task *s.PollForDecisionTaskResponse, scope tally.Scope) error {
+	limits := w.wth.historySoftLimits
+	if limits == nil {
+		return nil
+	}
+	info := HistoryLimitExceededInfo{
+		WorkflowInfo: *w.workflowInfo,
+		EventCount:   w.workflowInfo.HistoryCount,
+		SizeBytes:    w.workflowInfo.TotalHistoryBytes,
+	}
+	exceedsCount := limits.EventCount > 0 && info.EventCount > limits.EventCount
+	exceedsSize := limits.SizeBytes > 0 && info.SizeBytes > limits.SizeBytes
+	if !exceedsCount && !exceedsSize {
+		return nil
+	}
+
+	scope.Counter(metrics.HistorySoftLimitExceeded).Inc(1)
+	w.wth.logger.Warn("Workflow history exceeds soft limits.",
+		zap.String(tagWorkflowType, task.WorkflowType.GetName()),
+		zap.String(tagWorkflowID, task.WorkflowExecution.GetWorkflowId()),
+		zap.String(tagRunID, task.WorkflowExecution.GetRunId()),
+		zap.Int64("HistoryCount", info.EventCount),
+		zap.Int64("HistorySize", info.SizeBytes),
+		zap.Int64("EventCountLimit", limits.EventCount),
+		zap.Int64("SizeBytesLimit", limits.SizeBytes))
+	if limits.OnExceeded != nil {
+		limits.OnExceeded(info)
+	}
+
+	// queries must not change the workflow state, and completed workflows have nothing left to fail
+	if !limits.FailWorkflow || task.Query != nil || w.isWorkflowCompleted {
+		return nil
+	}
+	return NewCustomError("HistorySoftLimitExceeded", fmt.Sprintf(
+		"workflow history has %d events and %d bytes, which exceeds the soft limits of %d events and %d bytes, "+
+			"consider using ContinueAsNew to start a new run with a fresh history",
+		info.EventCount, info.SizeBytes, limits.EventCount, limits.SizeBytes))
+}
+
 func (w *workflowExecutionContextImpl) ProcessLocalActivityResult(workflowTask *workflowTask, lar *localActivityResult) (interface{}, error) {
 	if lar.err != nil && w.retryLocalActivity(lar) {
 		return nil, nil // nothing to do here as we are retrying...
@@ -1042,7 +1173,10 @@ func (w *workflowExecutionContextImpl) retryLocalActivity(lar *localActivityResu
 func getRetryBackoff(lar *localActivityResult, now time.Time) time.Duration {
 	p := lar.task.retryPolicy
 	var errReason string
-	if len(p.NonRetriableErrorReasons) > 0 {
+	var nonRetryableErr *NonRetryableError
+	if errors.As(lar.err, &nonRetryableErr) {
+		errReason = errReasonNonRetryable
+	} else if len(p.NonRetriableErrorReasons) > 0 {
 		if lar.err == ErrDeadlineExceeded {
 			errReason = "timeout:" + s.TimeoutTypeScheduleToClose.String()
 		} else {
@@ -1082,6 +1216,9 @@ func getRetryBackoffWithNowTime(p *RetryPolicy, attempt int32, errReason string,
 	}
 
 	// check if error is non-retriable
+	if errReason == errReasonNonRetryable {
+		return noRetryBackoff
+	}
 	for _, er := range p.NonRetriableErrorReasons {
 		if er == errReason {
 			return noRetryBackoff
@@ -1250,7 +1387,7 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 
 	metricsScope := wth.metricsScope.GetTaggedScope(tagWorkflowType, eventHandler.workflowEnvironmentImpl.workflowInfo.WorkflowType.Name)
 
-	// fail decision task on decider panic
+	// fail decision task on decider panic, unless the workflow is to be failed instead
 	if panicErr, ok := workflowContext.err.(*workflowPanicError); ok {
 		// Workflow panic
 		metricsScope.Counter(metrics.DecisionTaskPanicCounter).Inc(1)
@@ -1260,7 +1397,15 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 			zap.String(tagRunID, task.WorkflowExecution.GetRunId()),
 			zap.String(tagPanicError, panicErr.Error()),
 			zap.String(tagPanicStack, panicErr.StackTrace()))
-		return errorToFailDecisionTask(task.TaskToken, panicErr, wth.identity)
+		switch wth.workflowPanicPolicy {
+		case WorkflowPanicPolicyFailWorkflow:
+			// fall through to the fail workflow decision below
+			workflowContext.err = newPanicError(panicErr.value, panicErr.StackTrace())
+		case WorkflowPanicPolicyBlockWorkflow:
+			return errorToFailDecisionTask(task.TaskToken, panicErr, wth.identity, wth.buildID)
+		default:
+			panic("unknown workflow panic policy.")
+		}
 	}
 
 	// complete decision task
@@ -1292,7 +1437,7 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 		// Workflow failures
 		metricsScope.Counter(metrics.WorkflowFailedCounter).Inc(1)
 		closeDecision = createNewDecision(s.DecisionTypeFailWorkflowExecution)
-		reason, details := getErrorDetails(workflowContext.err, wth.dataConverter)
+		reason, details := getWrappedErrorDetails(workflowContext.err, wth.dataConverter, wth.featureFlags)
 		closeDecision.FailWorkflowExecutionDecisionAttributes = &s.FailWorkflowExecutionDecisionAttributes{
 			Reason:  common.StringPtr(reason),
 			Details: details,
@@ -1304,6 +1449,12 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 		closeDecision.CompleteWorkflowExecutionDecisionAttributes = &s.CompleteWorkflowExecutionDecisionAttributes{
 			Result: workflowContext.result,
 		}
+		recordPayloadSize(metricsScope,
+			wth.logger.With(
+				zap.String(tagWorkflowType, task.WorkflowType.GetName()),
+				zap.String(tagWorkflowID, task.WorkflowExecution.GetWorkflowId()),
+				zap.String(tagRunID, task.WorkflowExecution.GetRunId())),
+			wth.payloadThreshold, metrics.WorkflowResultSize, workflowContext.result)
 	}
 
 	if closeDecision != nil {
@@ -1313,6 +1464,16 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 		forceNewDecision = false
 	}
 
+	if err := validateDecisions(decisions, eventHandler.decisionsHelper); err != nil {
+		wth.logger.Error("Invalid decisions.",
+			zap.String(tagWorkflowType, task.WorkflowType.GetName()),
+			zap.String(tagWorkflowID, task.WorkflowExecution.GetWorkflowId()),
+			zap.String(tagRunID, task.WorkflowExecution.GetRunId()),
+			zap.Error(err))
+		return errorToFailDecisionTask(task.TaskToken, err, wth.identity, wth.buildID)
+	}
+	metricsScope.Histogram(metrics.DecisionCount, eventCountBuckets).RecordValue(float64(len(decisions)))
+
 	var queryResults map[string]*s.WorkflowQueryResult
 	if len(task.Queries) != 0 {
 		queryResults = make(map[string]*s.WorkflowQueryResult)
@@ -1338,12 +1499,12 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 		Identity:                   common.StringPtr(wth.identity),
 		ReturnNewDecisionTask:      common.BoolPtr(true),
 		ForceCreateNewDecisionTask: common.BoolPtr(forceNewDecision),
-		BinaryChecksum:             common.StringPtr(getBinaryChecksum()),
+		BinaryChecksum:             common.StringPtr(getBuildID(wth.buildID)),
 		QueryResults:               queryResults,
 	}
 }
 
-func errorToFailDecisionTask(taskToken []byte, err error, identity string) *s.RespondDecisionTaskFailedRequest {
+func errorToFailDecisionTask(taskToken []byte, err error, identity, buildID string) *s.RespondDecisionTaskFailedRequest {
 	failedCause := s.DecisionTaskFailedCauseWorkflowWorkerUnhandledFailure
 	_, details := getErrorDetails(err, nil)
 	return &s.RespondDecisionTaskFailedRequest{
@@ -1351,7 +1512,7 @@ func errorToFailDecisionTask(taskToken []byte, err error, identity string) *s.Re
 		Cause:          &failedCause,
 		Details:        details,
 		Identity:       common.StringPtr(identity),
-		BinaryChecksum: common.StringPtr(getBinaryChecksum()),
+		BinaryChecksum: common.StringPtr(getBuildID(buildID)),
 	}
 }
 
@@ -1381,6 +1542,8 @@ type cadenceInvoker struct {
 	hbBatchEndTimer       *time.Timer // Whether we started a batch of operations that need to be reported in the cycle. This gets started on a user call.
 	detailsToReport       *[]byte     // Details to be reported in the next reporting interval.
 	lastDetailsReported   *[]byte     // Details that were reported in the last reporting interval.
+	hbThrottleRatio       float64
+	maxHeartbeatThrottle  time.Duration
 	closeCh               chan struct{}
 	workerStopChannel     <-chan struct{}
 	featureFlags          FeatureFlags
@@ -1449,8 +1612,15 @@ func (i *cadenceInvoker) heartbeatAndScheduleNextRun(details []byte) error {
 			deadlineToTrigger = defaultHeartBeatIntervalInSec
 		}
 
-		// We set a deadline at 80% of the timeout.
-		duration := time.Duration(0.8*float32(deadlineToTrigger)) * time.Second
+		// We set a deadline at a fraction of the timeout, 80% by default.
+		ratio := i.hbThrottleRatio
+		if ratio <= 0 {
+			ratio = defaultHeartbeatThrottleRatio
+		}
+		duration := time.Duration(ratio * float64(deadlineToTrigger) * float64(time.Second))
+		if i.maxHeartbeatThrottle > 0 && duration > i.maxHeartbeatThrottle {
+			duration = i.maxHeartbeatThrottle
+		}
 		i.hbBatchEndTimer = time.NewTimer(duration)
 
 		go func() {
@@ -1549,6 +1719,8 @@ func newServiceInvoker(
 	service workflowserviceclient.Interface,
 	cancelHandler func(),
 	heartBeatTimeoutInSec int32,
+	heartbeatThrottleRatio float64,
+	maxHeartbeatThrottle time.Duration,
 	workerStopChannel <-chan struct{},
 	featureFlags FeatureFlags,
 	logger *zap.Logger,
@@ -1561,6 +1733,8 @@ func newServiceInvoker(
 		service:               service,
 		cancelHandler:         cancelHandler,
 		heartBeatTimeoutInSec: heartBeatTimeoutInSec,
+		hbThrottleRatio:       heartbeatThrottleRatio,
+		maxHeartbeatThrottle:  maxHeartbeatThrottle,
 		closeCh:               make(chan struct{}),
 		workerStopChannel:     workerStopChannel,
 		featureFlags:          featureFlags,
@@ -1597,12 +1771,12 @@ func signalWorkflow(
 		Identity:   common.StringPtr(identity),
 	}
 
-	return backoff.Retry(ctx,
+	return retryWhileTransientError(ctx,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
 			defer cancel()
 			return service.SignalWorkflowExecution(tchCtx, request, opt...)
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 }
 
 func recordActivityHeartbeat(
@@ -1618,7 +1792,7 @@ func recordActivityHeartbeat(
 		Identity:  common.StringPtr(identity)}
 
 	var heartbeatResponse *s.RecordActivityTaskHeartbeatResponse
-	heartbeatErr := backoff.Retry(ctx,
+	heartbeatErr := retryWhileTransientError(ctx,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
 			defer cancel()
@@ -1626,7 +1800,7 @@ func recordActivityHeartbeat(
 			var err error
 			heartbeatResponse, err = service.RecordActivityTaskHeartbeat(tchCtx, request, opt...)
 			return err
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 
 	if heartbeatErr == nil && heartbeatResponse != nil && heartbeatResponse.GetCancelRequested() {
 		return NewCanceledError()
@@ -1652,7 +1826,7 @@ func recordActivityHeartbeatByID(
 		Identity:   common.StringPtr(identity)}
 
 	var heartbeatResponse *s.RecordActivityTaskHeartbeatResponse
-	heartbeatErr := backoff.Retry(ctx,
+	heartbeatErr := retryWhileTransientError(ctx,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
 			defer cancel()
@@ -1660,7 +1834,7 @@ func recordActivityHeartbeatByID(
 			var err error
 			heartbeatResponse, err = service.RecordActivityTaskHeartbeatByID(tchCtx, request, opt...)
 			return err
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 
 	if heartbeatErr == nil && heartbeatResponse != nil && heartbeatResponse.GetCancelRequested() {
 		return NewCanceledError()
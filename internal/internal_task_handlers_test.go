@@ -22,6 +22,7 @@
 package internal
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -38,6 +39,7 @@ import (
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
 	"go.uber.org/goleak"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -46,6 +48,7 @@ import (
 	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
 	s "go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common"
+	"go.uber.org/cadence/internal/common/metrics"
 )
 
 const (
@@ -392,6 +395,406 @@ func (t *TaskHandlersTestSuite) TestWorkflowTask_BinaryChecksum() {
 	t.Equal(getBinaryChecksum(), checksums[2])
 }
 
+func (t *TaskHandlersTestSuite) TestWorkflowTask_RecordMarker() {
+	workflowFunc := func(ctx Context) error {
+		if err := RecordMarker(ctx, "LoanApproved", "loan-1", 100); err != nil {
+			return err
+		}
+		t.Error(RecordMarker(ctx, sideEffectMarkerName))
+		return Sleep(ctx, time.Second)
+	}
+	t.registry.RegisterWorkflowWithOptions(workflowFunc, RegisterWorkflowOptions{Name: "RecordMarkerWorkflow"})
+	details, err := encodeArgs(getDefaultDataConverter(), []interface{}{"loan-1", 100})
+	t.NoError(err)
+
+	taskList := "tl1"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+	}
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity: "test-id-1",
+			Logger:   t.logger,
+		},
+	}
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: createWorkflowTask(testEvents, 0, "RecordMarkerWorkflow")}, nil)
+	t.NoError(err)
+	response := request.(*s.RespondDecisionTaskCompletedRequest)
+	t.Equal(2, len(response.Decisions))
+	t.Equal(s.DecisionTypeRecordMarker, response.Decisions[0].GetDecisionType())
+	t.Equal("LoanApproved", response.Decisions[0].RecordMarkerDecisionAttributes.GetMarkerName())
+	t.Equal(details, response.Decisions[0].RecordMarkerDecisionAttributes.Details)
+	t.Equal(s.DecisionTypeStartTimer, response.Decisions[1].GetDecisionType())
+
+	// the recorded marker is a no-op on replay
+	testEvents = append(testEvents,
+		createTestEventDecisionTaskCompleted(4, &s.DecisionTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(2)}),
+		&s.HistoryEvent{
+			EventId:   common.Int64Ptr(5),
+			EventType: s.EventTypeMarkerRecorded.Ptr(),
+			MarkerRecordedEventAttributes: &s.MarkerRecordedEventAttributes{
+				MarkerName: common.StringPtr("LoanApproved"),
+				Details:    details,
+			},
+		},
+		createTestEventTimerStarted(6, 1),
+		createTestEventTimerFired(7, 1),
+		createTestEventDecisionTaskScheduled(8, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(9),
+	)
+	taskHandler = newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	request, err = taskHandler.ProcessWorkflowTask(&workflowTask{task: createWorkflowTask(testEvents, 3, "RecordMarkerWorkflow")}, nil)
+	t.NoError(err)
+	response = request.(*s.RespondDecisionTaskCompletedRequest)
+	t.Equal(1, len(response.Decisions))
+	t.Equal(s.DecisionTypeCompleteWorkflowExecution, response.Decisions[0].GetDecisionType())
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_UpsertMemo() {
+	workflowFunc := func(ctx Context) error {
+		if err := UpsertMemo(ctx, map[string]interface{}{"Stage": "shipping"}); err != nil {
+			return err
+		}
+		var stage, owner string
+		t.NoError(NewValue(GetWorkflowInfo(ctx).Memo.Fields["Stage"]).Get(&stage))
+		t.NoError(NewValue(GetWorkflowInfo(ctx).Memo.Fields["Owner"]).Get(&owner))
+		t.Equal("shipping", stage)
+		t.Equal("team-a", owner)
+		return NewContinueAsNewError(ctx, "UpsertMemoWorkflow")
+	}
+	t.registry.RegisterWorkflowWithOptions(workflowFunc, RegisterWorkflowOptions{Name: "UpsertMemoWorkflow"})
+	owner, err := encodeArg(getDefaultDataConverter(), "team-a")
+	t.NoError(err)
+	stage, err := encodeArg(getDefaultDataConverter(), "shipping")
+	t.NoError(err)
+
+	taskList := "tl1"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{
+			TaskList:                            &s.TaskList{Name: &taskList},
+			ExecutionStartToCloseTimeoutSeconds: common.Int32Ptr(10),
+			TaskStartToCloseTimeoutSeconds:      common.Int32Ptr(1),
+			Memo:                                &s.Memo{Fields: map[string][]byte{"Owner": owner}},
+		}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+	}
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity: "test-id-1",
+			Logger:   t.logger,
+		},
+	}
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: createWorkflowTask(testEvents, 0, "UpsertMemoWorkflow")}, nil)
+	t.NoError(err)
+	response := request.(*s.RespondDecisionTaskCompletedRequest)
+	t.Equal(2, len(response.Decisions))
+	t.Equal(s.DecisionTypeRecordMarker, response.Decisions[0].GetDecisionType())
+	t.Equal(upsertMemoMarkerName, response.Decisions[0].RecordMarkerDecisionAttributes.GetMarkerName())
+	var recorded map[string][]byte
+	t.NoError(NewValue(response.Decisions[0].RecordMarkerDecisionAttributes.Details).Get(&recorded))
+	t.Equal(map[string][]byte{"Stage": stage}, recorded)
+
+	t.Equal(s.DecisionTypeContinueAsNewWorkflowExecution, response.Decisions[1].GetDecisionType())
+	t.Equal(map[string][]byte{"Owner": owner, "Stage": stage},
+		response.Decisions[1].ContinueAsNewWorkflowExecutionDecisionAttributes.Memo.GetFields())
+	t.Equal(map[string][]byte{"Owner": owner}, testEvents[0].WorkflowExecutionStartedEventAttributes.Memo.GetFields())
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_BuildID() {
+	taskList := "tl1"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+	}
+	task := createWorkflowTask(testEvents, 0, "HelloWorld_Workflow")
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity: "test-id-1",
+			Logger:   t.logger,
+			BuildID:  "build-1",
+		},
+	}
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	response := request.(*s.RespondDecisionTaskCompletedRequest)
+	t.Equal("build-1", response.GetBinaryChecksum())
+
+	failed := errorToFailDecisionTask(task.TaskToken, errors.New("failure"), "test-id-1", "build-1")
+	t.Equal("build-1", failed.GetBinaryChecksum())
+	failed = errorToFailDecisionTask(task.TaskToken, errors.New("failure"), "test-id-1", "")
+	t.Equal(getBinaryChecksum(), failed.GetBinaryChecksum())
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_DecisionTaskMetrics() {
+	taskList := "tl1"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+		createTestEventDecisionTaskCompleted(4, &s.DecisionTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(2)}),
+		createTestEventActivityTaskScheduled(5, &s.ActivityTaskScheduledEventAttributes{
+			ActivityId:   common.StringPtr("0"),
+			ActivityType: &s.ActivityType{Name: common.StringPtr("Greeter_Activity")},
+			TaskList:     &s.TaskList{Name: &taskList},
+		}),
+		createTestEventActivityTaskStarted(6, &s.ActivityTaskStartedEventAttributes{}),
+		createTestEventActivityTaskCompleted(7, &s.ActivityTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(5)}),
+		createTestEventDecisionTaskStarted(8),
+	}
+	task := createWorkflowTask(testEvents, 3, "HelloWorld_Workflow")
+	task.NextEventId = common.Int64Ptr(9)
+	scope := tally.NewTestScope("", nil)
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity:     "test-id-1",
+			Logger:       t.logger,
+			MetricsScope: scope,
+		},
+	}
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	t.Len(request.(*s.RespondDecisionTaskCompletedRequest).Decisions, 1)
+
+	snapshot := scope.Snapshot()
+	key := "+" + tagWorkflowType + "=HelloWorld_Workflow"
+	t.Contains(snapshot.Timers(), metrics.DecisionReplayLatency+key)
+	// samples land in the first bucket whose upper bound is not below the value
+	histogramSample := func(name string) float64 {
+		h, ok := snapshot.Histograms()[name+key]
+		t.Require().True(ok, "missing histogram %v", name)
+		for bound, count := range h.Values() {
+			if count > 0 {
+				return bound
+			}
+		}
+		return 0
+	}
+	t.Equal(float64(4), histogramSample(metrics.DecisionReplayedEvents)) // events 1 to 3 are replayed
+	t.Equal(float64(8), histogramSample(metrics.DecisionHistoryLength))
+	t.Equal(float64(1), histogramSample(metrics.DecisionCount))
+	t.Contains(snapshot.Histograms(), metrics.DecisionHistorySize+key)
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_HistorySoftLimits() {
+	taskList := "tl1"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+	}
+
+	tests := map[string]struct {
+		limits            HistorySoftLimits
+		expectExceeded    bool
+		expectedDecisions []s.DecisionType
+	}{
+		"under limits": {
+			limits:            HistorySoftLimits{EventCount: 100},
+			expectedDecisions: []s.DecisionType{s.DecisionTypeScheduleActivityTask},
+		},
+		"event count exceeded": {
+			limits:            HistorySoftLimits{EventCount: 2},
+			expectExceeded:    true,
+			expectedDecisions: []s.DecisionType{s.DecisionTypeScheduleActivityTask},
+		},
+		"event count exceeded and fail workflow": {
+			limits:            HistorySoftLimits{EventCount: 2, FailWorkflow: true},
+			expectExceeded:    true,
+			expectedDecisions: []s.DecisionType{s.DecisionTypeScheduleActivityTask, s.DecisionTypeFailWorkflowExecution},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func() {
+			var exceeded []HistoryLimitExceededInfo
+			limits := tc.limits
+			limits.OnExceeded = func(info HistoryLimitExceededInfo) {
+				exceeded = append(exceeded, info)
+			}
+			task := createWorkflowTask(testEvents, 0, "HelloWorld_Workflow")
+			task.NextEventId = common.Int64Ptr(4)
+			scope := tally.NewTestScope("", nil)
+			params := workerExecutionParameters{
+				TaskList: taskList,
+				WorkerOptions: WorkerOptions{
+					Identity:          "test-id-1",
+					Logger:            t.logger,
+					MetricsScope:      scope,
+					HistorySoftLimits: &limits,
+				},
+			}
+			taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+			request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+			t.NoError(err)
+			decisions := request.(*s.RespondDecisionTaskCompletedRequest).Decisions
+			var decisionTypes []s.DecisionType
+			for _, d := range decisions {
+				decisionTypes = append(decisionTypes, d.GetDecisionType())
+			}
+			t.Equal(tc.expectedDecisions, decisionTypes)
+
+			counterKey := metrics.HistorySoftLimitExceeded + "+" + tagWorkflowType + "=HelloWorld_Workflow"
+			if !tc.expectExceeded {
+				t.Empty(exceeded)
+				t.NotContains(scope.Snapshot().Counters(), counterKey)
+				return
+			}
+			t.Require().Len(exceeded, 1)
+			t.Equal(int64(3), exceeded[0].EventCount)
+			t.Equal("HelloWorld_Workflow", exceeded[0].WorkflowInfo.WorkflowType.Name)
+			t.Equal(int64(1), scope.Snapshot().Counters()[counterKey].Value())
+			if tc.limits.FailWorkflow {
+				attributes := decisions[len(decisions)-1].FailWorkflowExecutionDecisionAttributes
+				t.Equal("HistorySoftLimitExceeded", attributes.GetReason())
+			}
+		})
+	}
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_AwaitWithTimeoutCanceled() {
+	// the canceled timer is resolved synchronously, before Await checks the context
+	awaitWorkflowFn := func(ctx Context) error {
+		ctx, cancel := WithCancel(ctx)
+		Go(ctx, func(ctx Context) {
+			cancel()
+		})
+		ok, err := AwaitWithTimeout(ctx, time.Hour, func() bool { return false })
+		if _, canceled := err.(*CanceledError); !canceled {
+			return fmt.Errorf("expected CanceledError, got ok=%v err=%v", ok, err)
+		}
+		return nil
+	}
+	t.registry.RegisterWorkflowWithOptions(awaitWorkflowFn, RegisterWorkflowOptions{Name: "AwaitWithTimeoutCanceledWorkflow"})
+
+	taskList := "tl1"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+	}
+	task := createWorkflowTask(testEvents, 0, "AwaitWithTimeoutCanceledWorkflow")
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity: "test-id-1",
+			Logger:   t.logger,
+		},
+	}
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	response := request.(*s.RespondDecisionTaskCompletedRequest)
+	t.Equal(1, len(response.Decisions))
+	t.Equal(s.DecisionTypeCompleteWorkflowExecution, response.Decisions[0].GetDecisionType(), response.Decisions[0].String())
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_ReplayMarkersWithEncryptedDataConverter() {
+	keys, err := NewStaticKeyProvider("key", map[string][]byte{"key": make([]byte, 32)})
+	t.NoError(err)
+	dataConverter := NewEncryptedDataConverter(nil, keys)
+	equals := func(a, b interface{}) bool { return a == b }
+	workflowFn := func(ctx Context) error {
+		var sideEffect, mutable string
+		if err := SideEffect(ctx, func(ctx Context) interface{} { return "side effect" }).Get(&sideEffect); err != nil {
+			return err
+		}
+		for i := 0; i < 2; i++ {
+			if err := MutableSideEffect(ctx, "mutable", func(ctx Context) interface{} { return "mutable" }, equals).Get(&mutable); err != nil {
+				return err
+			}
+			MutableSideEffect(ctx, "nil", func(ctx Context) interface{} { return nil }, equals)
+		}
+		version := GetVersion(ctx, "change", DefaultVersion, 1)
+		if err := Sleep(ctx, time.Second); err != nil {
+			return err
+		}
+		if err := MutableSideEffect(ctx, "mutable", func(ctx Context) interface{} { return "mutable" }, equals).Get(&mutable); err != nil {
+			return err
+		}
+		if sideEffect != "side effect" || mutable != "mutable" || version != 1 {
+			return fmt.Errorf("unexpected values %q, %q, %v", sideEffect, mutable, version)
+		}
+		return nil
+	}
+	t.registry.RegisterWorkflowWithOptions(workflowFn, RegisterWorkflowOptions{Name: "EncryptedMarkersWorkflow"})
+
+	taskList := "tl1"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+	}
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity:      "test-id-1",
+			Logger:        t.logger,
+			DataConverter: dataConverter,
+		},
+	}
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: createWorkflowTask(testEvents, 0, "EncryptedMarkersWorkflow")}, nil)
+	t.NoError(err)
+	response := request.(*s.RespondDecisionTaskCompletedRequest)
+	var markers []string
+	testEvents = append(testEvents, createTestEventDecisionTaskCompleted(4, &s.DecisionTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(2)}))
+	for _, d := range response.Decisions {
+		event := &s.HistoryEvent{EventId: common.Int64Ptr(int64(len(testEvents) + 1))}
+		switch d.GetDecisionType() {
+		case s.DecisionTypeRecordMarker:
+			markers = append(markers, d.RecordMarkerDecisionAttributes.GetMarkerName())
+			event.EventType = s.EventTypeMarkerRecorded.Ptr()
+			event.MarkerRecordedEventAttributes = &s.MarkerRecordedEventAttributes{
+				MarkerName: d.RecordMarkerDecisionAttributes.MarkerName,
+				Details:    d.RecordMarkerDecisionAttributes.Details,
+			}
+		case s.DecisionTypeUpsertWorkflowSearchAttributes:
+			event.EventType = s.EventTypeUpsertWorkflowSearchAttributes.Ptr()
+			event.UpsertWorkflowSearchAttributesEventAttributes = &s.UpsertWorkflowSearchAttributesEventAttributes{
+				SearchAttributes: d.UpsertWorkflowSearchAttributesDecisionAttributes.SearchAttributes,
+			}
+		case s.DecisionTypeStartTimer:
+			event.EventType = s.EventTypeTimerStarted.Ptr()
+			event.TimerStartedEventAttributes = &s.TimerStartedEventAttributes{TimerId: d.StartTimerDecisionAttributes.TimerId}
+			testEvents = append(testEvents, event)
+			event = &s.HistoryEvent{
+				EventId:                   common.Int64Ptr(int64(len(testEvents) + 1)),
+				EventType:                 s.EventTypeTimerFired.Ptr(),
+				TimerFiredEventAttributes: &s.TimerFiredEventAttributes{TimerId: d.StartTimerDecisionAttributes.TimerId},
+			}
+		default:
+			t.Failf("unexpected decision", d.String())
+		}
+		testEvents = append(testEvents, event)
+	}
+	// the nil value is only recorded once, although its encryption is not deterministic
+	t.Equal([]string{sideEffectMarkerName, mutableSideEffectMarkerName, mutableSideEffectMarkerName, versionMarkerName}, markers)
+
+	// replaying the whole history decodes every marker again, as after the workflow is evicted from the cache
+	lastEventID := int64(len(testEvents))
+	testEvents = append(testEvents,
+		createTestEventDecisionTaskScheduled(lastEventID+1, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(lastEventID+2),
+	)
+	taskHandler = newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	request, err = taskHandler.ProcessWorkflowTask(&workflowTask{task: createWorkflowTask(testEvents, 3, "EncryptedMarkersWorkflow")}, nil)
+	t.NoError(err)
+	response = request.(*s.RespondDecisionTaskCompletedRequest)
+	t.Equal(1, len(response.Decisions))
+	t.Equal(s.DecisionTypeCompleteWorkflowExecution, response.Decisions[0].GetDecisionType(), response.Decisions[0].String())
+}
+
 func (t *TaskHandlersTestSuite) TestWorkflowTask_ActivityTaskScheduled() {
 	// Schedule an activity and see if we complete workflow.
 	taskList := "tl1"
@@ -597,7 +1000,7 @@ func (t *TaskHandlersTestSuite) TestCacheEvictionWhenErrorOccurs() {
 	task := createWorkflowTask(testEvents, 3, "HelloWorld_Workflow")
 	// newWorkflowTaskWorkerInternal will set the laTunnel in taskHandler, without it, ProcessWorkflowTask()
 	// will fail as it can't find laTunnel in getWorkflowCache().
-	newWorkflowTaskWorkerInternal(taskHandler, t.service, testDomain, params, make(chan struct{}), nil)
+	newWorkflowTaskWorkerInternal(taskHandler, t.service, testDomain, params, make(chan struct{}), nil, nil)
 	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
 
 	t.Error(err)
@@ -632,7 +1035,7 @@ func (t *TaskHandlersTestSuite) TestWithMissingHistoryEvents() {
 		task := createWorkflowTask(testEvents, startEventID, "HelloWorld_Workflow")
 		// newWorkflowTaskWorkerInternal will set the laTunnel in taskHandler, without it, ProcessWorkflowTask()
 		// will fail as it can't find laTunnel in getWorkflowCache().
-		newWorkflowTaskWorkerInternal(taskHandler, t.service, testDomain, params, make(chan struct{}), nil)
+		newWorkflowTaskWorkerInternal(taskHandler, t.service, testDomain, params, make(chan struct{}), nil, nil)
 		request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
 
 		t.Error(err)
@@ -685,7 +1088,7 @@ func (t *TaskHandlersTestSuite) TestWithTruncatedHistory() {
 		task.StartedEventId = common.Int64Ptr(tc.startedEventID)
 		// newWorkflowTaskWorkerInternal will set the laTunnel in taskHandler, without it, ProcessWorkflowTask()
 		// will fail as it can't find laTunnel in getWorkflowCache().
-		newWorkflowTaskWorkerInternal(taskHandler, t.service, testDomain, params, make(chan struct{}), nil)
+		newWorkflowTaskWorkerInternal(taskHandler, t.service, testDomain, params, make(chan struct{}), nil, nil)
 		request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
 
 		if tc.isResultErr {
@@ -708,6 +1111,137 @@ func (t *TaskHandlersTestSuite) TestSideEffectDefer_NonSticky() {
 	t.testSideEffectDeferHelper(true)
 }
 
+type recordingDecisionTaskListener struct {
+	DecisionTaskListenerBase
+	calls  []string
+	result Value
+}
+
+func (l *recordingDecisionTaskListener) OnDecisionTaskStarted(_ *WorkflowInfo, isReplay bool) {
+	l.calls = append(l.calls, fmt.Sprintf("DecisionTaskStarted replay=%v", isReplay))
+}
+
+func (l *recordingDecisionTaskListener) OnActivityScheduled(_ *WorkflowInfo, activityID string, activityType string, isReplay bool) {
+	l.calls = append(l.calls, fmt.Sprintf("ActivityScheduled %v %v replay=%v", activityID, activityType, isReplay))
+}
+
+func (l *recordingDecisionTaskListener) OnTimerFired(_ *WorkflowInfo, timerID string, isReplay bool) {
+	l.calls = append(l.calls, fmt.Sprintf("TimerFired %v replay=%v", timerID, isReplay))
+}
+
+func (l *recordingDecisionTaskListener) OnWorkflowCompleted(_ *WorkflowInfo, result Value, err error, isReplay bool) {
+	l.calls = append(l.calls, fmt.Sprintf("WorkflowCompleted err=%v replay=%v", err, isReplay))
+	l.result = result
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_DecisionTaskListener() {
+	taskList := "tl1"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+		createTestEventDecisionTaskCompleted(4, &s.DecisionTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(2)}),
+		createTestEventActivityTaskScheduled(5, &s.ActivityTaskScheduledEventAttributes{
+			ActivityId:   common.StringPtr("0"),
+			ActivityType: &s.ActivityType{Name: common.StringPtr("Greeter_Activity")},
+			TaskList:     &s.TaskList{Name: &taskList},
+		}),
+		createTestEventActivityTaskStarted(6, &s.ActivityTaskStartedEventAttributes{}),
+		createTestEventActivityTaskCompleted(7, &s.ActivityTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(5)}),
+		createTestEventDecisionTaskStarted(8),
+	}
+	task := createWorkflowTask(testEvents, 3, "HelloWorld_Workflow")
+	listener := &recordingDecisionTaskListener{}
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity:             "test-id-1",
+			Logger:               t.logger,
+			DecisionTaskListener: listener,
+		},
+	}
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	_, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	t.Equal([]string{
+		"DecisionTaskStarted replay=true",
+		"ActivityScheduled 0 Greeter_Activity replay=true",
+		"DecisionTaskStarted replay=false",
+		"WorkflowCompleted err=<nil> replay=false",
+	}, listener.calls)
+
+	// timers of replayed decision tasks are reported as replayed
+	testEvents = []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+		createTestEventDecisionTaskCompleted(4, &s.DecisionTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(2)}),
+		createTestEventTimerStarted(5, 0),
+		createTestEventTimerFired(6, 0),
+		createTestEventDecisionTaskScheduled(7, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(8),
+		createTestEventDecisionTaskCompleted(9, &s.DecisionTaskCompletedEventAttributes{ScheduledEventId: common.Int64Ptr(7)}),
+		createTestEventTimerStarted(10, 1),
+		createTestEventTimerFired(11, 1),
+		createTestEventDecisionTaskScheduled(12, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(13),
+	}
+	task = createWorkflowTask(testEvents, 8, "BinaryChecksumWorkflow")
+	listener.calls = nil
+	taskHandler = newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	_, err = taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	t.Equal([]string{
+		"DecisionTaskStarted replay=true",
+		"TimerFired 0 replay=true",
+		"DecisionTaskStarted replay=true",
+		"TimerFired 1 replay=false",
+		"DecisionTaskStarted replay=false",
+		"WorkflowCompleted err=<nil> replay=false",
+	}, listener.calls)
+	var checksums []string
+	t.NoError(listener.result.Get(&checksums))
+	t.Len(checksums, 3)
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_DecisionTaskDebugLogging() {
+	taskList := "tl1"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{
+			TaskList: &s.TaskList{Name: &taskList},
+			Input:    bytes.Repeat([]byte("a"), 1000),
+		}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+	}
+	task := createWorkflowTask(testEvents, 0, "HelloWorld_Workflow")
+	core, logs := observer.New(zap.InfoLevel)
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity:                       "test-id-1",
+			Logger:                         zap.New(core),
+			EnableDecisionTaskDebugLogging: true,
+		},
+	}
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	_, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+
+	entries := logs.FilterMessage("Decision task processed.").All()
+	t.Require().Len(entries, 1)
+	fields := entries[0].ContextMap()
+	t.Equal("HelloWorld_Workflow", fields[tagWorkflowType])
+	events := fields["Events"].([]interface{})
+	t.Len(events, 3)
+	t.Contains(events[0], "WorkflowExecutionStarted")
+	t.Contains(events[0], "...(1000 bytes)")
+	decisions := fields["Decisions"].([]interface{})
+	t.Require().Len(decisions, 1)
+	t.Contains(decisions[0], "ScheduleActivityTask")
+	t.Contains(decisions[0], "Greeter_Activity")
+}
+
 func (t *TaskHandlersTestSuite) testSideEffectDeferHelper(disableSticky bool) {
 	value := "should not be modified"
 	expectedValue := value
@@ -803,7 +1337,7 @@ func (t *TaskHandlersTestSuite) TestWorkflowTask_NondeterministicDetection() {
 	task = createWorkflowTask(testEvents, 3, "HelloWorld_Workflow")
 	// newWorkflowTaskWorkerInternal will set the laTunnel in taskHandler, without it, ProcessWorkflowTask()
 	// will fail as it can't find laTunnel in getWorkflowCache().
-	newWorkflowTaskWorkerInternal(taskHandler, t.service, testDomain, params, stopC, nil)
+	newWorkflowTaskWorkerInternal(taskHandler, t.service, testDomain, params, stopC, nil, nil)
 	request, err = taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
 	t.Error(err)
 	t.Nil(request)
@@ -954,6 +1488,43 @@ func (t *TaskHandlersTestSuite) TestWorkflowTask_WorkflowPanics() {
 	require.Equal(t.T(), "PanicWorkflow", wfTypeField.String)
 }
 
+func (t *TaskHandlersTestSuite) TestWorkflowTask_WorkflowPanics_FailWorkflowPolicy() {
+	taskList := "taskList"
+	testEvents := []*s.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &s.WorkflowExecutionStartedEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskScheduled(2, &s.DecisionTaskScheduledEventAttributes{TaskList: &s.TaskList{Name: &taskList}}),
+		createTestEventDecisionTaskStarted(3),
+	}
+
+	obs, logs := observer.New(zap.ErrorLevel)
+	logger := zap.New(obs)
+
+	task := createWorkflowTask(testEvents, 3, "PanicWorkflow")
+	params := workerExecutionParameters{
+		TaskList: taskList,
+		WorkerOptions: WorkerOptions{
+			Identity:            "test-id-1",
+			Logger:              logger,
+			WorkflowPanicPolicy: WorkflowPanicPolicyFailWorkflow,
+		},
+	}
+
+	taskHandler := newWorkflowTaskHandler(testDomain, params, nil, t.registry)
+	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	t.NotNil(request)
+	r, ok := request.(*s.RespondDecisionTaskCompletedRequest)
+	t.True(ok)
+	t.EqualValues(s.DecisionTypeFailWorkflowExecution, r.Decisions[0].GetDecisionType())
+	attr := r.Decisions[0].FailWorkflowExecutionDecisionAttributes
+	t.EqualValues("cadenceInternal:Panic", attr.GetReason())
+	details := string(attr.Details)
+	t.True(strings.HasPrefix(details, "\"panicError"), details)
+
+	// the panic is still logged
+	require.Len(t.T(), logs.FilterMessage("Workflow panic.").All(), 1)
+}
+
 func (t *TaskHandlersTestSuite) TestGetWorkflowInfo() {
 	taskList := "taskList"
 	parentID := "parentID"
@@ -1044,7 +1615,7 @@ func (t *TaskHandlersTestSuite) TestConsistentQuery_InvalidQueryTask() {
 	task := createWorkflowTask(nil, 3, "HelloWorld_Workflow")
 	task.Query = &s.WorkflowQuery{}
 	task.Queries = map[string]*s.WorkflowQuery{"query_id": {}}
-	newWorkflowTaskWorkerInternal(taskHandler, t.service, testDomain, params, make(chan struct{}), nil)
+	newWorkflowTaskWorkerInternal(taskHandler, t.service, testDomain, params, make(chan struct{}), nil, nil)
 	// query and queries are both specified so this is an invalid task
 	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
 
@@ -1346,6 +1917,8 @@ func (t *TaskHandlersTestSuite) TestHeartBeat_Interleaved() {
 		service:               mockService,
 		taskToken:             nil,
 		heartBeatTimeoutInSec: 3,
+		// batches of 80% of the timeout are capped to two seconds
+		maxHeartbeatThrottle: 2 * time.Second,
 	}
 
 	heartbeatErr := cadenceInvoker.BatchHeartbeat([]byte("1"))
@@ -1431,6 +2004,8 @@ func (t *TaskHandlersTestSuite) TestHeartBeat_NilResponseWithError() {
 		mockService,
 		func() {},
 		0,
+		0,
+		0,
 		make(chan struct{}),
 		FeatureFlags{},
 		logger,
@@ -1461,6 +2036,8 @@ func (t *TaskHandlersTestSuite) TestHeartBeat_NilResponseWithDomainNotActiveErro
 		mockService,
 		cancelHandler,
 		0,
+		0,
+		0,
 		make(chan struct{}),
 		FeatureFlags{},
 		logger,
@@ -1911,6 +2488,15 @@ func Test__getRetryBackoffWithNowTime(t *testing.T) {
 			maxInterval:     time.Minute,
 			result:          noRetryBackoff,
 		},
+		{
+			name:            "non retryable error marker",
+			maxAttempts:     5,
+			attempt:         2,
+			errReason:       errReasonNonRetryable,
+			initialInterval: time.Minute,
+			maxInterval:     time.Minute,
+			result:          noRetryBackoff,
+		},
 		{
 			name:            "fallback to max interval when calculated backoff is 0",
 			maxAttempts:     5,
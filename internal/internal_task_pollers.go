@@ -37,6 +37,7 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/pborman/uuid"
 	"github.com/uber-go/tally"
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
 
 	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
@@ -70,6 +71,8 @@ type (
 	// basePoller is the base class for all poller implementations
 	basePoller struct {
 		shutdownC <-chan struct{}
+		// pollTimeout of the poll requests, pollTaskServiceTimeOut when not set
+		pollTimeout time.Duration
 	}
 
 	// workflowTaskPoller implements polling/processing a workflow task
@@ -78,6 +81,7 @@ type (
 		domain       string
 		taskListName string
 		identity     string
+		buildID      string
 		service      workflowserviceclient.Interface
 		taskHandler  WorkflowTaskHandler
 		ldaTunnel    localDispatcher
@@ -93,6 +97,7 @@ type (
 		stickyBacklog           int64
 		requestLock             sync.Mutex
 		featureFlags            FeatureFlags
+		pollHeaders             map[string]string
 	}
 
 	// activityTaskPoller implements polling/processing a workflow task
@@ -107,6 +112,15 @@ type (
 		logger              *zap.Logger
 		activitiesPerSecond float64
 		featureFlags        FeatureFlags
+		pollHeaders         map[string]string
+	}
+
+	// multiTaskListActivityTaskPoller polls several activity task lists in weighted round-robin order. As a single
+	// poller of one worker, the task lists share its pollers and execution slots.
+	multiTaskListActivityTaskPoller struct {
+		pollers  map[string]*activityTaskPoller
+		schedule []*activityTaskPoller
+		next     *atomic.Uint64
 	}
 
 	// locallyDispatchedActivityTaskPoller implements polling/processing a locally dispatched activity task
@@ -265,7 +279,11 @@ func (bp *basePoller) doPoll(
 	var result interface{}
 
 	doneC := make(chan struct{})
-	ctx, cancel, _ := newChannelContext(context.Background(), featureFlags, chanTimeout(pollTaskServiceTimeOut))
+	pollTimeout := bp.pollTimeout
+	if pollTimeout <= 0 {
+		pollTimeout = pollTaskServiceTimeOut
+	}
+	ctx, cancel, _ := newChannelContext(context.Background(), featureFlags, chanTimeout(pollTimeout))
 
 	go func() {
 		result, err = pollFunc(ctx)
@@ -290,21 +308,27 @@ func newWorkflowTaskPoller(
 	domain string,
 	params workerExecutionParameters,
 ) *workflowTaskPoller {
-	return &workflowTaskPoller{
-		basePoller:                   basePoller{shutdownC: params.WorkerStopChannel},
+	wtp := &workflowTaskPoller{
+		basePoller:                   basePoller{shutdownC: params.WorkerStopChannel, pollTimeout: params.TaskPollTimeout},
 		service:                      service,
 		domain:                       domain,
 		taskListName:                 params.TaskList,
 		identity:                     params.Identity,
+		buildID:                      params.BuildID,
 		taskHandler:                  taskHandler,
-		ldaTunnel:                    ldaTunnel,
 		metricsScope:                 metrics.NewTaggedScope(params.MetricsScope),
 		logger:                       params.Logger,
 		stickyUUID:                   uuid.New(),
 		disableStickyExecution:       params.DisableStickyExecution,
 		StickyScheduleToStartTimeout: params.StickyScheduleToStartTimeout,
 		featureFlags:                 params.FeatureFlags,
+		pollHeaders:                  params.PollHeaders,
 	}
+	// a nil tunnel must not be stored in the interface, which would then not be nil
+	if ldaTunnel != nil {
+		wtp.ldaTunnel = ldaTunnel
+	}
+	return wtp
 }
 
 // PollTask polls a new task
@@ -416,7 +440,7 @@ func (wtp *workflowTaskPoller) RespondTaskCompletedWithMetrics(completedRequest
 			zap.String(tagRunID, task.WorkflowExecution.GetRunId()),
 			zap.Error(taskErr))
 		// convert err to DecisionTaskFailed
-		completedRequest = errorToFailDecisionTask(task.TaskToken, taskErr, wtp.identity)
+		completedRequest = errorToFailDecisionTask(task.TaskToken, taskErr, wtp.identity, wtp.buildID)
 	} else {
 		metricsScope.Counter(metrics.DecisionTaskCompletedCounter).Inc(1)
 	}
@@ -436,11 +460,11 @@ func (wtp *workflowTaskPoller) RespondTaskCompletedWithMetrics(completedRequest
 func (wtp *workflowTaskPoller) respondTaskCompleted(completedRequest interface{}, task *s.PollForDecisionTaskResponse) (response *s.RespondDecisionTaskCompletedResponse, err error) {
 	ctx := context.Background()
 	// Respond task completion.
-	err = backoff.Retry(ctx,
+	err = retryServiceOperation(ctx, wtp.metricsScope,
 		func() error {
 			response, err = wtp.respondTaskCompletedAttempt(completedRequest, task)
 			return err
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 
 	return response, err
 }
@@ -562,7 +586,7 @@ func newLocalActivityPoller(params workerExecutionParameters, laTunnel *localAct
 		activityTracker:    params.WorkerStats.ActivityTracker,
 	}
 	return &localActivityTaskPoller{
-		basePoller:   basePoller{shutdownC: params.WorkerStopChannel},
+		basePoller:   basePoller{shutdownC: params.WorkerStopChannel, pollTimeout: params.TaskPollTimeout},
 		handler:      handler,
 		metricsScope: params.MetricsScope,
 		logger:       params.Logger,
@@ -790,7 +814,7 @@ func (wtp *workflowTaskPoller) getNextPollRequest() (request *s.PollForDecisionT
 		Domain:         common.StringPtr(wtp.domain),
 		TaskList:       common.TaskListPtr(taskList),
 		Identity:       common.StringPtr(wtp.identity),
-		BinaryChecksum: common.StringPtr(getBinaryChecksum()),
+		BinaryChecksum: common.StringPtr(getBuildID(wtp.buildID)),
 	}
 }
 
@@ -806,7 +830,7 @@ func (wtp *workflowTaskPoller) poll(ctx context.Context) (interface{}, error) {
 	request := wtp.getNextPollRequest()
 	defer wtp.release(request.TaskList.GetKind())
 
-	response, err := wtp.service.PollForDecisionTask(ctx, request, getYarpcCallOptions(wtp.featureFlags)...)
+	response, err := wtp.service.PollForDecisionTask(ctx, request, getPollYarpcCallOptions(wtp.featureFlags, wtp.pollHeaders)...)
 	if err != nil {
 		retryable := isServiceTransientError(err)
 
@@ -952,7 +976,7 @@ func newGetHistoryPageFunc(
 		metricsScope.Counter(metrics.WorkflowGetHistoryCounter).Inc(1)
 		startTime := time.Now()
 		var resp *s.GetWorkflowExecutionHistoryResponse
-		err := backoff.Retry(ctx,
+		err := retryServiceOperation(ctx, metricsScope,
 			func() error {
 				tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
 				defer cancel()
@@ -964,7 +988,7 @@ func newGetHistoryPageFunc(
 					NextPageToken: nextPageToken,
 				}, opt...)
 				return err1
-			}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+			})
 		if err != nil {
 			metricsScope.Counter(metrics.WorkflowGetHistoryFailedCounter).Inc(1)
 			return nil, nil, err
@@ -1026,7 +1050,7 @@ func newActivityTaskPoller(taskHandler ActivityTaskHandler, service workflowserv
 	domain string, params workerExecutionParameters) *activityTaskPoller {
 
 	activityTaskPoller := &activityTaskPoller{
-		basePoller:          basePoller{shutdownC: params.WorkerStopChannel},
+		basePoller:          basePoller{shutdownC: params.WorkerStopChannel, pollTimeout: params.TaskPollTimeout},
 		taskHandler:         taskHandler,
 		service:             service,
 		domain:              domain,
@@ -1036,6 +1060,7 @@ func newActivityTaskPoller(taskHandler ActivityTaskHandler, service workflowserv
 		metricsScope:        metrics.NewTaggedScope(params.MetricsScope),
 		activitiesPerSecond: params.TaskListActivitiesPerSecond,
 		featureFlags:        params.FeatureFlags,
+		pollHeaders:         params.PollHeaders,
 	}
 	return activityTaskPoller
 }
@@ -1055,7 +1080,7 @@ func (atp *activityTaskPoller) poll(ctx context.Context) (*s.PollForActivityTask
 		Identity:         common.StringPtr(atp.identity),
 		TaskListMetadata: &s.TaskListMetadata{MaxTasksPerSecond: &atp.activitiesPerSecond},
 	}
-	response, err := atp.service.PollForActivityTask(ctx, request, getYarpcCallOptions(atp.featureFlags)...)
+	response, err := atp.service.PollForActivityTask(ctx, request, getPollYarpcCallOptions(atp.featureFlags, atp.pollHeaders)...)
 
 	if err != nil {
 		retryable := isServiceTransientError(err)
@@ -1108,7 +1133,7 @@ func (atp *activityTaskPoller) pollWithMetrics(ctx context.Context,
 		return nil, err
 	}
 	if response == nil || len(response.TaskToken) == 0 {
-		return &activityTask{}, nil
+		return &activityTask{taskListName: atp.taskListName}, nil
 	}
 
 	workflowType := response.WorkflowType.GetName()
@@ -1120,7 +1145,7 @@ func (atp *activityTaskPoller) pollWithMetrics(ctx context.Context,
 	scheduledToStartLatency := time.Duration(response.GetStartedTimestamp() - response.GetScheduledTimestampOfThisAttempt())
 	metricsScope.Timer(metrics.ActivityScheduledToStartLatency).Record(scheduledToStartLatency)
 
-	return &activityTask{task: response, pollStartTime: startTime}, nil
+	return &activityTask{task: response, pollStartTime: startTime, receivedAt: time.Now(), taskListName: atp.taskListName}, nil
 }
 
 // PollTask polls a new task
@@ -1133,6 +1158,29 @@ func (atp *activityTaskPoller) PollTask() (interface{}, error) {
 	return activityTask, nil
 }
 
+// waitActivitySlot waits for the slot of the activity type of the task until the activity deadline, for activity
+// types registered with RegisterActivityOptions.MaxConcurrentExecutionSize.
+func (atp *activityTaskPoller) waitActivitySlot(task *s.PollForActivityTaskResponse) (func(), error) {
+	handler, ok := atp.taskHandler.(*activityTaskHandlerImpl)
+	if !ok || handler.registry == nil {
+		return func() {}, nil
+	}
+	slots := handler.registry.getActivityTypeSlots(task.ActivityType.GetName())
+	if slots == nil {
+		return func() {}, nil
+	}
+	timer := time.NewTimer(time.Until(calculateActivityDeadline(task)))
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-timer.C:
+		return nil, context.DeadlineExceeded
+	case <-atp.shutdownC:
+		return nil, errShutdown
+	}
+}
+
 // ProcessTask processes a new task
 func (atp *activityTaskPoller) ProcessTask(task interface{}) error {
 	if atp.shuttingDown() {
@@ -1152,6 +1200,34 @@ func (atp *activityTaskPoller) ProcessTask(task interface{}) error {
 	activityType := activityTask.task.ActivityType.GetName()
 	metricsScope := getMetricsScopeForActivity(atp.metricsScope, workflowType, activityType)
 
+	var waited time.Duration
+	if !activityTask.receivedAt.IsZero() {
+		waited = time.Since(activityTask.receivedAt)
+	}
+	if isActivityTaskExpired(activityTask.task, waited) {
+		// The server has already timed this attempt out; executing it would only waste a slot.
+		metricsScope.Counter(metrics.ActivityTaskExpiredCounter).Inc(1)
+		atp.logger.Warn("Skipping activity task received after its deadline.",
+			zap.String(tagWorkflowID, activityTask.task.WorkflowExecution.GetWorkflowId()),
+			zap.String(tagRunID, activityTask.task.WorkflowExecution.GetRunId()),
+			zap.String(tagActivityType, activityType),
+			zap.String(tagActivityID, activityTask.task.GetActivityId()))
+		return nil
+	}
+
+	if !activityTask.slotHeld {
+		// the worker does not dispatch through the dispatch queue, wait for the slot of the activity type here
+		release, err := atp.waitActivitySlot(activityTask.task)
+		if err != nil {
+			atp.logger.Warn("Activity timed out waiting for a concurrent execution slot.",
+				zap.String(tagWorkflowID, activityTask.task.WorkflowExecution.GetWorkflowId()),
+				zap.String(tagRunID, activityTask.task.WorkflowExecution.GetRunId()),
+				zap.String(tagActivityType, activityType))
+			return err
+		}
+		defer release()
+	}
+
 	executionStartTime := time.Now()
 	// Process the activity task.
 	request, err := atp.taskHandler.Execute(atp.taskListName, activityTask.task)
@@ -1185,6 +1261,55 @@ func (atp *activityTaskPoller) ProcessTask(task interface{}) error {
 	return nil
 }
 
+func newMultiTaskListActivityTaskPoller(taskHandler ActivityTaskHandler, service workflowserviceclient.Interface,
+	domain string, params workerExecutionParameters, taskLists []ActivityTaskListWeight) *multiTaskListActivityTaskPoller {
+	weights := []ActivityTaskListWeight{{Name: params.TaskList, Weight: 1}}
+	for _, tl := range taskLists {
+		if tl.Name == params.TaskList {
+			weights[0].Weight = tl.Weight
+		} else {
+			weights = append(weights, tl)
+		}
+	}
+
+	poller := &multiTaskListActivityTaskPoller{
+		pollers: make(map[string]*activityTaskPoller, len(weights)),
+		next:    atomic.NewUint64(0),
+	}
+	for _, tl := range weights {
+		tlParams := params
+		tlParams.TaskList = tl.Name
+		tlParams.MetricsScope = tagScope(params.MetricsScope, tagTaskList, tl.Name)
+		p := newActivityTaskPoller(taskHandler, service, domain, tlParams)
+		poller.pollers[tl.Name] = p
+
+		weight := tl.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		for i := 0; i < weight; i++ {
+			poller.schedule = append(poller.schedule, p)
+		}
+	}
+	return poller
+}
+
+// PollTask polls a new task from the next task list in the schedule
+func (p *multiTaskListActivityTaskPoller) PollTask() (interface{}, error) {
+	next := p.next.Inc() - 1
+	return p.schedule[next%uint64(len(p.schedule))].PollTask()
+}
+
+// ProcessTask processes a task with the poller of the task list it was polled from
+func (p *multiTaskListActivityTaskPoller) ProcessTask(task interface{}) error {
+	activityTask := task.(*activityTask)
+	poller, ok := p.pollers[activityTask.taskListName]
+	if !ok {
+		panic(fmt.Sprintf("activity task from unknown task list %q", activityTask.taskListName))
+	}
+	return poller.ProcessTask(task)
+}
+
 func newLocallyDispatchedActivityTaskPoller(taskHandler ActivityTaskHandler, service workflowserviceclient.Interface,
 	domain string, params workerExecutionParameters) *locallyDispatchedActivityTaskPoller {
 	locallyDispatchedActivityTaskPoller := &locallyDispatchedActivityTaskPoller{
@@ -1251,29 +1376,29 @@ func reportActivityComplete(
 	var reportErr error
 	switch request := request.(type) {
 	case *s.RespondActivityTaskCanceledRequest:
-		reportErr = backoff.Retry(ctx,
+		reportErr = retryServiceOperation(ctx, metricsScope,
 			func() error {
 				tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
 				defer cancel()
 
 				return service.RespondActivityTaskCanceled(tchCtx, request, opt...)
-			}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+			})
 	case *s.RespondActivityTaskFailedRequest:
-		reportErr = backoff.Retry(ctx,
+		reportErr = retryServiceOperation(ctx, metricsScope,
 			func() error {
 				tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
 				defer cancel()
 
 				return service.RespondActivityTaskFailed(tchCtx, request, opt...)
-			}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+			})
 	case *s.RespondActivityTaskCompletedRequest:
-		reportErr = backoff.Retry(ctx,
+		reportErr = retryServiceOperation(ctx, metricsScope,
 			func() error {
 				tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
 				defer cancel()
 
 				return service.RespondActivityTaskCompleted(tchCtx, request, opt...)
-			}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+			})
 	}
 	if reportErr == nil {
 		switch request.(type) {
@@ -1304,29 +1429,29 @@ func reportActivityCompleteByID(
 	var reportErr error
 	switch request := request.(type) {
 	case *s.RespondActivityTaskCanceledByIDRequest:
-		reportErr = backoff.Retry(ctx,
+		reportErr = retryServiceOperation(ctx, metricsScope,
 			func() error {
 				tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
 				defer cancel()
 
 				return service.RespondActivityTaskCanceledByID(tchCtx, request, opt...)
-			}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+			})
 	case *s.RespondActivityTaskFailedByIDRequest:
-		reportErr = backoff.Retry(ctx,
+		reportErr = retryServiceOperation(ctx, metricsScope,
 			func() error {
 				tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
 				defer cancel()
 
 				return service.RespondActivityTaskFailedByID(tchCtx, request, opt...)
-			}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+			})
 	case *s.RespondActivityTaskCompletedByIDRequest:
-		reportErr = backoff.Retry(ctx,
+		reportErr = retryServiceOperation(ctx, metricsScope,
 			func() error {
 				tchCtx, cancel, opt := newChannelContext(ctx, featureFlags)
 				defer cancel()
 
 				return service.RespondActivityTaskCompletedByID(tchCtx, request, opt...)
-			}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+			})
 	}
 	if reportErr == nil {
 		switch request.(type) {
@@ -1342,8 +1467,38 @@ func reportActivityCompleteByID(
 	return reportErr
 }
 
+// isActivityCanceledError reports whether an activity result error should be reported as a
+// cancellation rather than a failure: a CanceledError, possibly wrapped by activity code with
+// fmt.Errorf("...: %w", err), or context.Canceled itself.
+func isActivityCanceledError(err error) bool {
+	var canceledErr *CanceledError
+	return errors.As(err, &canceledErr) || err == context.Canceled
+}
+
+// activityCancellationResult returns context.Canceled in place of an error that wraps it, e.g.
+// fmt.Errorf("...: %w", ctx.Err()), when the activity context was canceled because the cancellation
+// of the activity was requested, so that the error is reported as a cancellation. Otherwise err is
+// returned unchanged: a call that failed with its own canceled context does not cancel the activity.
+func activityCancellationResult(ctx context.Context, cancelRequested bool, err error) error {
+	if cancelRequested && ctx.Err() == context.Canceled && err != context.Canceled &&
+		errors.Is(err, context.Canceled) && !isActivityCanceledError(err) {
+		return context.Canceled
+	}
+	return err
+}
+
+// unwrapCanceledError returns the CanceledError wrapped by err, if any, so that its details are
+// reported; otherwise err is returned unchanged.
+func unwrapCanceledError(err error) error {
+	var canceledErr *CanceledError
+	if errors.As(err, &canceledErr) {
+		return canceledErr
+	}
+	return err
+}
+
 func convertActivityResultToRespondRequest(identity string, taskToken, result []byte, err error,
-	dataConverter DataConverter) interface{} {
+	dataConverter DataConverter, featureFlags FeatureFlags) interface{} {
 	if err == ErrActivityResultPending {
 		// activity result is pending and will be completed asynchronously.
 		// nothing to report at this point
@@ -1357,8 +1512,8 @@ func convertActivityResultToRespondRequest(identity string, taskToken, result []
 			Identity:  common.StringPtr(identity)}
 	}
 
-	reason, details := getErrorDetails(err, dataConverter)
-	if _, ok := err.(*CanceledError); ok || err == context.Canceled {
+	reason, details := getWrappedErrorDetails(unwrapCanceledError(err), dataConverter, featureFlags)
+	if isActivityCanceledError(err) {
 		return &s.RespondActivityTaskCanceledRequest{
 			TaskToken: taskToken,
 			Details:   details,
@@ -1373,7 +1528,7 @@ func convertActivityResultToRespondRequest(identity string, taskToken, result []
 }
 
 func convertActivityResultToRespondRequestByID(identity, domain, workflowID, runID, activityID string,
-	result []byte, err error, dataConverter DataConverter) interface{} {
+	result []byte, err error, dataConverter DataConverter, featureFlags FeatureFlags) interface{} {
 	if err == ErrActivityResultPending {
 		// activity result is pending and will be completed asynchronously.
 		// nothing to report at this point
@@ -1390,8 +1545,8 @@ func convertActivityResultToRespondRequestByID(identity, domain, workflowID, run
 			Identity:   common.StringPtr(identity)}
 	}
 
-	reason, details := getErrorDetails(err, dataConverter)
-	if _, ok := err.(*CanceledError); ok || err == context.Canceled {
+	reason, details := getWrappedErrorDetails(unwrapCanceledError(err), dataConverter, featureFlags)
+	if isActivityCanceledError(err) {
 		return &s.RespondActivityTaskCanceledByIDRequest{
 			Domain:     common.StringPtr(domain),
 			WorkflowID: common.StringPtr(workflowID),
@@ -23,6 +23,7 @@ package internal
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -33,6 +34,8 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/uber-go/tally"
+	yarpcencoding "go.uber.org/yarpc/api/encoding"
+	"go.uber.org/yarpc/api/transport"
 
 	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
 	s "go.uber.org/cadence/.gen/go/shared"
@@ -103,7 +106,7 @@ func TestRespondTaskCompleted_failed(t *testing.T) {
 		}, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(assert.AnError)
 
 		// We cannot test RespondTaskCompleted since it uses backoff and has a hardcoded retry mechanism for 60 seconds.
-		_, err := poller.respondTaskCompletedAttempt(errorToFailDecisionTask(testTaskToken, assert.AnError, _testIdentity), &s.PollForDecisionTaskResponse{
+		_, err := poller.respondTaskCompletedAttempt(errorToFailDecisionTask(testTaskToken, assert.AnError, _testIdentity, ""), &s.PollForDecisionTaskResponse{
 			TaskToken: testTaskToken,
 			Attempt:   common.Int64Ptr(0),
 		})
@@ -174,6 +177,41 @@ func TestProcessTask_failures(t *testing.T) {
 	})
 }
 
+func TestDoPoll_Timeout(t *testing.T) {
+	bp := basePoller{shutdownC: make(chan struct{}), pollTimeout: 50 * time.Millisecond}
+	start := time.Now()
+	_, err := bp.doPoll(FeatureFlags{}, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), pollTaskServiceTimeOut)
+}
+
+func TestDoPoll_CanceledOnShutdown(t *testing.T) {
+	shutdownC := make(chan struct{})
+	bp := basePoller{shutdownC: shutdownC}
+	polling := make(chan struct{})
+	canceled := make(chan struct{})
+	go func() {
+		<-polling
+		close(shutdownC)
+	}()
+
+	_, err := bp.doPoll(FeatureFlags{}, func(ctx context.Context) (interface{}, error) {
+		close(polling)
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	})
+	assert.Equal(t, errShutdown, err)
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("poll context was not canceled on shutdown")
+	}
+}
+
 func buildWorkflowTaskPoller(t *testing.T) (*workflowTaskPoller, *workflowservicetest.MockClient, *MockWorkflowTaskHandler, *mockLocalDispatcher) {
 	ctrl := gomock.NewController(t)
 	mockService := workflowservicetest.NewMockClient(ctrl)
@@ -198,3 +236,173 @@ func buildWorkflowTaskPoller(t *testing.T) (*workflowTaskPoller, *workflowservic
 		featureFlags:                 FeatureFlags{},
 	}, mockService, taskHandler, lda
 }
+
+type countingActivityTaskHandler struct {
+	executed  int
+	taskLists []string
+}
+
+func (h *countingActivityTaskHandler) Execute(taskList string, _ *s.PollForActivityTaskResponse) (interface{}, error) {
+	h.executed++
+	h.taskLists = append(h.taskLists, taskList)
+	return ErrActivityResultPending, nil
+}
+
+func TestActivityTaskPoller_ExpiredTask(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	handler := &countingActivityTaskHandler{}
+	poller := newActivityTaskPoller(handler, nil, _testDomainName, workerExecutionParameters{
+		TaskList:          _testTaskList,
+		WorkerOptions:     WorkerOptions{Identity: _testIdentity, Logger: testlogger.NewZap(t), MetricsScope: scope},
+		WorkerStopChannel: make(chan struct{}),
+	})
+	newTask := func(scheduled, started, received time.Time) *activityTask {
+		return &activityTask{receivedAt: received, task: &s.PollForActivityTaskResponse{
+			TaskToken:                       []byte("token"),
+			WorkflowExecution:               &s.WorkflowExecution{WorkflowId: common.StringPtr("wid"), RunId: common.StringPtr("rid")},
+			ActivityId:                      common.StringPtr("0"),
+			ActivityType:                    &s.ActivityType{Name: common.StringPtr("activity")},
+			WorkflowType:                    &s.WorkflowType{Name: common.StringPtr("workflow")},
+			ScheduledTimestampOfThisAttempt: common.Int64Ptr(scheduled.UnixNano()),
+			StartedTimestamp:                common.Int64Ptr(started.UnixNano()),
+			ScheduleToCloseTimeoutSeconds:   common.Int32Ptr(10),
+			StartToCloseTimeoutSeconds:      common.Int32Ptr(5),
+		}}
+	}
+	expired := func() int64 {
+		var count int64
+		for _, c := range scope.Snapshot().Counters() {
+			if c.Name() == metrics.ActivityTaskExpiredCounter {
+				count += c.Value()
+			}
+		}
+		return count
+	}
+
+	now := time.Now()
+	// schedule-to-close elapsed on the server before the task was started
+	require.NoError(t, poller.ProcessTask(newTask(now.Add(-time.Minute), now, now)))
+	// start-to-close elapsed while the task waited in the worker
+	require.NoError(t, poller.ProcessTask(newTask(now.Add(-10*time.Second), now.Add(-10*time.Second), now.Add(-10*time.Second))))
+	assert.Equal(t, 0, handler.executed)
+	assert.Equal(t, int64(2), expired())
+
+	// the server clock is an hour behind the worker, which must not make the task look expired
+	serverNow := now.Add(-time.Hour)
+	require.NoError(t, poller.ProcessTask(newTask(serverNow, serverNow, now)))
+	// within the margin of the start-to-close deadline
+	require.NoError(t, poller.ProcessTask(newTask(now, now, now.Add(-5*time.Second))))
+	assert.Equal(t, 2, handler.executed)
+	assert.Equal(t, int64(2), expired())
+}
+
+func TestConvertActivityResultToRespondRequest_Canceled(t *testing.T) {
+	dc := getDefaultDataConverter()
+	canceledErr := NewCanceledError("details")
+	expectedDetails, err := encodeArgs(dc, []interface{}{"details"})
+	require.NoError(t, err)
+
+	for name, err := range map[string]error{
+		"canceled error":         canceledErr,
+		"wrapped canceled error": fmt.Errorf("aborting: %w", canceledErr),
+	} {
+		t.Run(name, func(t *testing.T) {
+			res := convertActivityResultToRespondRequest(_testIdentity, []byte("token"), nil, err, dc, FeatureFlags{})
+			require.IsType(t, &s.RespondActivityTaskCanceledRequest{}, res)
+			assert.Equal(t, expectedDetails, res.(*s.RespondActivityTaskCanceledRequest).Details)
+
+			resByID := convertActivityResultToRespondRequestByID(_testIdentity, _testDomainName, "wid", "rid", "0", nil, err, dc, FeatureFlags{})
+			require.IsType(t, &s.RespondActivityTaskCanceledByIDRequest{}, resByID)
+			assert.Equal(t, expectedDetails, resByID.(*s.RespondActivityTaskCanceledByIDRequest).Details)
+		})
+	}
+	t.Run("context canceled", func(t *testing.T) {
+		res := convertActivityResultToRespondRequest(_testIdentity, []byte("token"), nil, context.Canceled, dc, FeatureFlags{})
+		assert.IsType(t, &s.RespondActivityTaskCanceledRequest{}, res)
+	})
+	t.Run("wrapped context canceled fails", func(t *testing.T) {
+		res := convertActivityResultToRespondRequest(_testIdentity, []byte("token"), nil, fmt.Errorf("heartbeat: %w", context.Canceled), dc, FeatureFlags{})
+		assert.IsType(t, &s.RespondActivityTaskFailedRequest{}, res)
+	})
+	t.Run("other error fails", func(t *testing.T) {
+		res := convertActivityResultToRespondRequest(_testIdentity, []byte("token"), nil, assert.AnError, dc, FeatureFlags{})
+		assert.IsType(t, &s.RespondActivityTaskFailedRequest{}, res)
+	})
+}
+
+func TestActivityCancellationResult(t *testing.T) {
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	wrapped := fmt.Errorf("heartbeat: %w", context.Canceled)
+	canceledErr := fmt.Errorf("aborting: %w", NewCanceledError())
+
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		cancelRequested bool
+		err             error
+		expected        error
+	}{
+		{"cancel requested", canceledCtx, true, wrapped, context.Canceled},
+		{"cancel not requested", canceledCtx, false, wrapped, wrapped},
+		{"context not canceled", context.Background(), true, wrapped, wrapped},
+		{"canceled error kept", canceledCtx, true, canceledErr, canceledErr},
+		{"other error kept", canceledCtx, true, assert.AnError, assert.AnError},
+		{"no error", canceledCtx, true, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, activityCancellationResult(tt.ctx, tt.cancelRequested, tt.err))
+		})
+	}
+}
+
+func TestGetPollYarpcCallOptions(t *testing.T) {
+	var options []yarpcencoding.CallOption
+	for _, opt := range getPollYarpcCallOptions(FeatureFlags{}, map[string]string{"zone": "dca1", "rack": "r42"}) {
+		options = append(options, yarpcencoding.CallOption(opt))
+	}
+	request := &transport.Request{}
+	_, err := yarpcencoding.NewOutboundCall(options...).WriteToRequest(context.Background(), request)
+	require.NoError(t, err)
+
+	headers := request.Headers.Items()
+	assert.Equal(t, "dca1", headers["zone"])
+	assert.Equal(t, "r42", headers["rack"])
+	assert.Equal(t, LibraryVersion, headers[libraryVersionHeaderName])
+	assert.Len(t, getPollYarpcCallOptions(FeatureFlags{}, nil), len(getYarpcCallOptions(FeatureFlags{})))
+}
+
+func TestMultiTaskListActivityTaskPoller(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	service := workflowservicetest.NewMockClient(ctrl)
+	handler := &countingActivityTaskHandler{}
+	poller := newMultiTaskListActivityTaskPoller(handler, service, _testDomainName, workerExecutionParameters{
+		TaskList:          _testTaskList,
+		WorkerOptions:     WorkerOptions{Identity: _testIdentity, Logger: testlogger.NewZap(t)},
+		WorkerStopChannel: make(chan struct{}),
+	}, []ActivityTaskListWeight{{Name: "gpu"}, {Name: "bulk", Weight: 2}})
+
+	var polled []string
+	service.EXPECT().PollForActivityTask(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, request *s.PollForActivityTaskRequest, _ ...interface{}) (*s.PollForActivityTaskResponse, error) {
+			polled = append(polled, request.TaskList.GetName())
+			return &s.PollForActivityTaskResponse{
+				TaskToken:         []byte("token"),
+				WorkflowExecution: &s.WorkflowExecution{WorkflowId: common.StringPtr("wid"), RunId: common.StringPtr("rid")},
+				ActivityId:        common.StringPtr("0"),
+				ActivityType:      &s.ActivityType{Name: common.StringPtr("activity")},
+				WorkflowType:      &s.WorkflowType{Name: common.StringPtr("workflow")},
+			}, nil
+		}).Times(8)
+
+	for i := 0; i < 8; i++ {
+		task, err := poller.PollTask()
+		require.NoError(t, err)
+		require.NoError(t, poller.ProcessTask(task))
+	}
+	expected := []string{_testTaskList, "gpu", "bulk", "bulk", _testTaskList, "gpu", "bulk", "bulk"}
+	assert.Equal(t, expected, polled)
+	// every task is executed on behalf of the task list it was polled from
+	assert.Equal(t, expected, handler.taskLists)
+}
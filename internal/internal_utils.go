@@ -25,10 +25,12 @@ package internal
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -41,6 +43,7 @@ import (
 	s "go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common"
 	"go.uber.org/cadence/internal/common/metrics"
+	"go.uber.org/cadence/internal/common/rpcheaders"
 )
 
 const (
@@ -75,6 +78,10 @@ type (
 	FeatureFlags struct {
 		WorkflowExecutionAlreadyCompletedErrorEnabled bool
 		PollerAutoScalerEnabled                       bool
+		// WrappedErrorEncodingEnabled preserves the chain of errors wrapped by activity and workflow errors, see
+		// GenericError. Workers prior to this client version fail to decode such errors, so it must only be enabled
+		// once all the workers of the domain are upgraded.
+		WrappedErrorEncodingEnabled bool
 	}
 )
 
@@ -121,6 +128,11 @@ func getYarpcCallOptions(featureFlags FeatureFlags) []yarpc.CallOption {
 	)
 }
 
+// getPollYarpcCallOptions adds WorkerOptions.PollHeaders to the call options of the poll requests
+func getPollYarpcCallOptions(featureFlags FeatureFlags, pollHeaders map[string]string) []yarpc.CallOption {
+	return append(getYarpcCallOptions(featureFlags), rpcheaders.CallOptions(pollHeaders, nil)...)
+}
+
 // ContextBuilder stores all Channel-specific parameters that will
 // be stored inside of a context.
 type contextBuilder struct {
@@ -200,13 +212,32 @@ func newChannelContextHelper(
 	return ctx, cancelFn, getYarpcCallOptions(featureFlags)
 }
 
-// GetWorkerIdentity gets a default identity for the worker.
+// workerIdentityFunc holds the func(WorkerIdentityInfo) string set by SetWorkerIdentityFunc.
+var workerIdentityFunc atomic.Value
+
+// GetWorkerIdentity gets a default identity for the worker, of the form taskList@host@pid@libraryVersion@uuid.
+// The task list is omitted for clients.
 //
 // This contains a random UUID, generated each time it is called, to prevent identity collisions when workers share
 // other host/pid/etc information.  These alone are not guaranteed to be unique, especially when Docker is involved.
 // Take care to retrieve this only once per worker.
 func getWorkerIdentity(tasklistName string) string {
-	return fmt.Sprintf("%d@%s@%s@%s", os.Getpid(), getHostName(), tasklistName, uuid.New())
+	info := WorkerIdentityInfo{
+		TaskList:       tasklistName,
+		HostName:       getHostName(),
+		PID:            os.Getpid(),
+		LibraryVersion: LibraryVersion,
+	}
+	if fn, ok := workerIdentityFunc.Load().(func(WorkerIdentityInfo) string); ok && fn != nil {
+		if identity := fn(info); identity != "" {
+			return identity
+		}
+	}
+	identity := fmt.Sprintf("%s@%d@%s@%s", info.HostName, info.PID, info.LibraryVersion, uuid.New())
+	if tasklistName != "" {
+		identity = tasklistName + "@" + identity
+	}
+	return identity
 }
 
 func getHostName() string {
@@ -217,6 +248,16 @@ func getHostName() string {
 	return hostName
 }
 
+// GetHostSpecificTaskList returns the task list which is unique to the current host for the given task list.
+// Calling it with a task list which is already host specific returns the same task list.
+func GetHostSpecificTaskList(taskList string) string {
+	suffix := "@" + getHostName()
+	if strings.HasSuffix(taskList, suffix) {
+		return taskList
+	}
+	return taskList + suffix
+}
+
 func getWorkerTaskList(stickyUUID string) string {
 	// includes hostname for debuggability, stickyUUID guarantees the uniqueness
 	return fmt.Sprintf("%s:%s", getHostName(), stickyUUID)
@@ -291,11 +332,72 @@ func getErrorDetails(err error, dataConverter DataConverter) (string, []byte) {
 		}
 		return fmt.Sprintf("%v %v", errReasonTimeout, err.timeoutType), data
 	default:
+		var nonRetryableErr *NonRetryableError
+		if errors.As(err, &nonRetryableErr) {
+			return getNonRetryableErrorDetails(err, dataConverter, FeatureFlags{})
+		}
 		// will be convert to GenericError when receiving from server.
 		return errReasonGeneric, []byte(err.Error())
 	}
 }
 
+// getWrappedErrorDetails gets reason and details like getErrorDetails, but keeps the chain of errors wrapped by err
+// if FeatureFlags.WrappedErrorEncodingEnabled is set.
+func getWrappedErrorDetails(err error, dataConverter DataConverter, featureFlags FeatureFlags) (string, []byte) {
+	var nonRetryableErr *NonRetryableError
+	if errors.As(err, &nonRetryableErr) {
+		return getNonRetryableErrorDetails(err, dataConverter, featureFlags)
+	}
+	return getErrorChainDetails(err, dataConverter, featureFlags)
+}
+
+// getErrorChainDetails gets reason and details of err, ignoring any NonRetryableError it wraps.
+func getErrorChainDetails(err error, dataConverter DataConverter, featureFlags FeatureFlags) (string, []byte) {
+	switch err.(type) {
+	case *CustomError, *CanceledError, *PanicError, *TimeoutError:
+		return getErrorDetails(err, dataConverter)
+	}
+	if featureFlags.WrappedErrorEncodingEnabled && errors.Unwrap(err) != nil {
+		data, err0 := encodeArg(dataConverter, getErrorChain(err, dataConverter))
+		if err0 != nil {
+			panic(err0)
+		}
+		return errReasonWrapped, data
+	}
+	return errReasonGeneric, []byte(err.Error())
+}
+
+// getNonRetryableErrorDetails encodes err, which is or wraps a NonRetryableError, as a single errorChainNode with
+// the reason and details of its cause, so that prior client versions can still decode it as a CustomError.
+func getNonRetryableErrorDetails(err error, dataConverter DataConverter, featureFlags FeatureFlags) (string, []byte) {
+	if nonRetryableErr, ok := err.(*NonRetryableError); ok {
+		err = nonRetryableErr.cause
+	}
+	node := errorChainNode{Message: err.Error()}
+	node.Reason, node.Details = getErrorChainDetails(err, dataConverter, featureFlags)
+	data, err0 := encodeArg(dataConverter, node)
+	if err0 != nil {
+		panic(err0)
+	}
+	return errReasonNonRetryable, data
+}
+
+// getErrorChain flattens err and the errors it wraps. The chain ends at the first cadence error, which is encoded
+// with its reason and details.
+func getErrorChain(err error, dataConverter DataConverter) []errorChainNode {
+	var chain []errorChainNode
+	for ; err != nil; err = errors.Unwrap(err) {
+		node := errorChainNode{Message: err.Error()}
+		switch err.(type) {
+		case *CustomError, *CanceledError, *PanicError, *TimeoutError:
+			node.Reason, node.Details = getErrorDetails(err, dataConverter)
+			return append(chain, node)
+		}
+		chain = append(chain, node)
+	}
+	return chain
+}
+
 // constructError construct error from reason and details sending down from server.
 func constructError(reason string, details []byte, dataConverter DataConverter) error {
 	if strings.HasPrefix(reason, errReasonTimeout) {
@@ -324,6 +426,15 @@ func constructError(reason string, details []byte, dataConverter DataConverter)
 	case errReasonCanceled:
 		details := newEncodedValues(details, dataConverter)
 		return NewCanceledError(details)
+	case errReasonWrapped:
+		// errors wrapping other errors.
+		return constructErrorChain(details, dataConverter)
+	case errReasonNonRetryable:
+		var node errorChainNode
+		if err := newEncodedValues(details, dataConverter).Get(&node); err != nil || node.Reason == "" {
+			return NewNonRetryableError(&GenericError{err: string(details)})
+		}
+		return NewNonRetryableError(constructError(node.Reason, node.Details, dataConverter))
 	default:
 		details := newEncodedValues(details, dataConverter)
 		err := NewCustomError(reason, details)
@@ -331,12 +442,38 @@ func constructError(reason string, details []byte, dataConverter DataConverter)
 	}
 }
 
+// constructErrorChain rebuilds the error chain encoded by getErrorChain, from the innermost error outwards.
+func constructErrorChain(details []byte, dataConverter DataConverter) error {
+	var chain []errorChainNode
+	if err := newEncodedValues(details, dataConverter).Get(&chain); err != nil || len(chain) == 0 {
+		return &GenericError{err: string(details)}
+	}
+	var err error
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].Reason != "" {
+			err = constructError(chain[i].Reason, chain[i].Details, dataConverter)
+		} else {
+			err = &GenericError{err: chain[i].Message, cause: err}
+		}
+	}
+	return err
+}
+
 func getKillSignal() <-chan os.Signal {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
 	return c
 }
 
+// InterruptCh returns a channel which receives the signal when the process gets SIGINT or SIGTERM.
+func InterruptCh() <-chan interface{} {
+	ret := make(chan interface{}, 1)
+	go func() {
+		ret <- <-getKillSignal()
+	}()
+	return ret
+}
+
 // getMetricsScopeForActivity return properly tagged tally scope for activity
 func getMetricsScopeForActivity(ts *metrics.TaggedScope, workflowType, activityType string) tally.Scope {
 	return ts.GetTaggedScope(tagWorkflowType, workflowType, tagActivityType, activityType)
@@ -347,6 +484,28 @@ func getMetricsScopeForLocalActivity(ts *metrics.TaggedScope, workflowType, loca
 	return ts.GetTaggedScope(tagWorkflowType, workflowType, tagLocalActivityType, localActivityType)
 }
 
+var (
+	// payloadSizeBuckets covers payloads from 1KB up to 32MB
+	payloadSizeBuckets = tally.MustMakeExponentialValueBuckets(1024, 2, 16)
+	// historySizeBuckets covers histories from 1KB up to 64MB, beyond the default server limit
+	historySizeBuckets = tally.MustMakeExponentialValueBuckets(1024, 2, 17)
+	// eventCountBuckets covers counts from 1 up to 64K, beyond the default server history length limit
+	eventCountBuckets = tally.MustMakeExponentialValueBuckets(1, 2, 17)
+)
+
+// recordPayloadSize emits the payload size as a histogram and warns when it exceeds the threshold.
+// A threshold of zero or less disables the warning.
+func recordPayloadSize(scope tally.Scope, logger *zap.Logger, threshold int, metricName string, payload []byte) {
+	size := len(payload)
+	scope.Histogram(metricName, payloadSizeBuckets).RecordValue(float64(size))
+	if threshold > 0 && size > threshold {
+		logger.Warn("Payload size exceeds threshold.",
+			zap.String("PayloadType", metricName),
+			zap.Int("PayloadSize", size),
+			zap.Int("Threshold", threshold))
+	}
+}
+
 func getTimeoutTypeFromErrReason(reason string) (s.TimeoutType, error) {
 	timeoutTypeStr := reason[strings.Index(reason, " ")+1:]
 	var timeoutType s.TimeoutType
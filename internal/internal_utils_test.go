@@ -22,10 +22,18 @@ package internal
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
 	s "go.uber.org/cadence/.gen/go/shared"
 )
@@ -161,3 +169,50 @@ func TestConstructError_TimeoutError(t *testing.T) {
 	require.Equal(t, s.TimeoutTypeHeartbeat, timeoutErr.TimeoutType())
 	require.False(t, timeoutErr.HasDetails())
 }
+
+func TestGetHostSpecificTaskList(t *testing.T) {
+	t.Parallel()
+	hostSpecific := GetHostSpecificTaskList("tl")
+	require.Equal(t, "tl@"+getHostName(), hostSpecific)
+	require.Equal(t, hostSpecific, GetHostSpecificTaskList(hostSpecific))
+}
+
+func TestRecordPayloadSize(t *testing.T) {
+	t.Parallel()
+	core, observed := observer.New(zapcore.WarnLevel)
+	scope := tally.NewTestScope("", nil)
+
+	recordPayloadSize(scope, zap.New(core), 0, "size", make([]byte, 2048))
+	recordPayloadSize(scope, zap.New(core), 4096, "size", make([]byte, 2048))
+	assert.Zero(t, observed.Len(), "no warning when disabled or below the threshold")
+
+	recordPayloadSize(scope, zap.New(core), 1024, "size", make([]byte, 2048))
+	assert.Equal(t, 1, observed.Len())
+
+	histograms := scope.Snapshot().Histograms()
+	require.Contains(t, histograms, "size+")
+	var total int64
+	for _, count := range histograms["size+"].Values() {
+		total += count
+	}
+	assert.Equal(t, int64(3), total)
+}
+
+func TestGetWorkerIdentity(t *testing.T) {
+	identity := getWorkerIdentity("tasklist")
+	parts := strings.Split(identity, "@")
+	require.Len(t, parts, 5, identity)
+	assert.Equal(t, []string{"tasklist", getHostName(), strconv.Itoa(os.Getpid()), LibraryVersion}, parts[:4])
+	assert.NotEqual(t, identity, getWorkerIdentity("tasklist"), "identities must be unique")
+	assert.True(t, strings.HasPrefix(getWorkerIdentity(""), getHostName()+"@"))
+
+	SetWorkerIdentityFunc(func(info WorkerIdentityInfo) string {
+		if info.TaskList == "" {
+			return ""
+		}
+		return "custom@" + info.TaskList
+	})
+	defer SetWorkerIdentityFunc(nil)
+	assert.Equal(t, "custom@tasklist", getWorkerIdentity("tasklist"))
+	assert.True(t, strings.HasPrefix(getWorkerIdentity(""), getHostName()+"@"), "empty identities fall back to the default")
+}
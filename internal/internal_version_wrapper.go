@@ -0,0 +1,241 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+
+	"go.uber.org/yarpc"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+// workflowServiceVersionWrapper converts the error returned by the server when it rejects the client library and
+// feature versions sent in the call headers (see getYarpcCallOptions) into a CompatibilityError.
+type workflowServiceVersionWrapper struct {
+	service workflowserviceclient.Interface
+}
+
+func newWorkflowServiceVersionWrapper(service workflowserviceclient.Interface) workflowserviceclient.Interface {
+	return &workflowServiceVersionWrapper{service: service}
+}
+
+func (w *workflowServiceVersionWrapper) DeprecateDomain(ctx context.Context, request *shared.DeprecateDomainRequest, opts ...yarpc.CallOption) error {
+	return toCompatibilityError(w.service.DeprecateDomain(ctx, request, opts...))
+}
+
+func (w *workflowServiceVersionWrapper) ListDomains(ctx context.Context, request *shared.ListDomainsRequest, opts ...yarpc.CallOption) (*shared.ListDomainsResponse, error) {
+	result, err := w.service.ListDomains(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) DescribeDomain(ctx context.Context, request *shared.DescribeDomainRequest, opts ...yarpc.CallOption) (*shared.DescribeDomainResponse, error) {
+	result, err := w.service.DescribeDomain(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) DescribeWorkflowExecution(ctx context.Context, request *shared.DescribeWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.DescribeWorkflowExecutionResponse, error) {
+	result, err := w.service.DescribeWorkflowExecution(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) GetWorkflowExecutionHistory(ctx context.Context, request *shared.GetWorkflowExecutionHistoryRequest, opts ...yarpc.CallOption) (*shared.GetWorkflowExecutionHistoryResponse, error) {
+	result, err := w.service.GetWorkflowExecutionHistory(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) ListClosedWorkflowExecutions(ctx context.Context, request *shared.ListClosedWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListClosedWorkflowExecutionsResponse, error) {
+	result, err := w.service.ListClosedWorkflowExecutions(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) ListOpenWorkflowExecutions(ctx context.Context, request *shared.ListOpenWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListOpenWorkflowExecutionsResponse, error) {
+	result, err := w.service.ListOpenWorkflowExecutions(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) ListWorkflowExecutions(ctx context.Context, request *shared.ListWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+	result, err := w.service.ListWorkflowExecutions(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) ListArchivedWorkflowExecutions(ctx context.Context, request *shared.ListArchivedWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListArchivedWorkflowExecutionsResponse, error) {
+	result, err := w.service.ListArchivedWorkflowExecutions(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) ScanWorkflowExecutions(ctx context.Context, request *shared.ListWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+	result, err := w.service.ScanWorkflowExecutions(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) CountWorkflowExecutions(ctx context.Context, request *shared.CountWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.CountWorkflowExecutionsResponse, error) {
+	result, err := w.service.CountWorkflowExecutions(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) PollForActivityTask(ctx context.Context, request *shared.PollForActivityTaskRequest, opts ...yarpc.CallOption) (*shared.PollForActivityTaskResponse, error) {
+	result, err := w.service.PollForActivityTask(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) PollForDecisionTask(ctx context.Context, request *shared.PollForDecisionTaskRequest, opts ...yarpc.CallOption) (*shared.PollForDecisionTaskResponse, error) {
+	result, err := w.service.PollForDecisionTask(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) RecordActivityTaskHeartbeat(ctx context.Context, request *shared.RecordActivityTaskHeartbeatRequest, opts ...yarpc.CallOption) (*shared.RecordActivityTaskHeartbeatResponse, error) {
+	result, err := w.service.RecordActivityTaskHeartbeat(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) RecordActivityTaskHeartbeatByID(ctx context.Context, request *shared.RecordActivityTaskHeartbeatByIDRequest, opts ...yarpc.CallOption) (*shared.RecordActivityTaskHeartbeatResponse, error) {
+	result, err := w.service.RecordActivityTaskHeartbeatByID(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) RegisterDomain(ctx context.Context, request *shared.RegisterDomainRequest, opts ...yarpc.CallOption) error {
+	return toCompatibilityError(w.service.RegisterDomain(ctx, request, opts...))
+}
+
+func (w *workflowServiceVersionWrapper) RequestCancelWorkflowExecution(ctx context.Context, request *shared.RequestCancelWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	return toCompatibilityError(w.service.RequestCancelWorkflowExecution(ctx, request, opts...))
+}
+
+func (w *workflowServiceVersionWrapper) RespondActivityTaskCanceled(ctx context.Context, request *shared.RespondActivityTaskCanceledRequest, opts ...yarpc.CallOption) error {
+	return toCompatibilityError(w.service.RespondActivityTaskCanceled(ctx, request, opts...))
+}
+
+func (w *workflowServiceVersionWrapper) RespondActivityTaskCompleted(ctx context.Context, request *shared.RespondActivityTaskCompletedRequest, opts ...yarpc.CallOption) error {
+	return toCompatibilityError(w.service.RespondActivityTaskCompleted(ctx, request, opts...))
+}
+
+func (w *workflowServiceVersionWrapper) RespondActivityTaskFailed(ctx context.Context, request *shared.RespondActivityTaskFailedRequest, opts ...yarpc.CallOption) error {
+	return toCompatibilityError(w.service.RespondActivityTaskFailed(ctx, request, opts...))
+}
+
+func (w *workflowServiceVersionWrapper) RespondActivityTaskCanceledByID(ctx context.Context, request *shared.RespondActivityTaskCanceledByIDRequest, opts ...yarpc.CallOption) error {
+	return toCompatibilityError(w.service.RespondActivityTaskCanceledByID(ctx, request, opts...))
+}
+
+func (w *workflowServiceVersionWrapper) RespondActivityTaskCompletedByID(ctx context.Context, request *shared.RespondActivityTaskCompletedByIDRequest, opts ...yarpc.CallOption) error {
+	return toCompatibilityError(w.service.RespondActivityTaskCompletedByID(ctx, request, opts...))
+}
+
+func (w *workflowServiceVersionWrapper) RespondActivityTaskFailedByID(ctx context.Context, request *shared.RespondActivityTaskFailedByIDRequest, opts ...yarpc.CallOption) error {
+	return toCompatibilityError(w.service.RespondActivityTaskFailedByID(ctx, request, opts...))
+}
+
+func (w *workflowServiceVersionWrapper) RespondDecisionTaskCompleted(ctx context.Context, request *shared.RespondDecisionTaskCompletedRequest, opts ...yarpc.CallOption) (*shared.RespondDecisionTaskCompletedResponse, error) {
+	response, err := w.service.RespondDecisionTaskCompleted(ctx, request, opts...)
+	return response, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) RespondDecisionTaskFailed(ctx context.Context, request *shared.RespondDecisionTaskFailedRequest, opts ...yarpc.CallOption) error {
+	return toCompatibilityError(w.service.RespondDecisionTaskFailed(ctx, request, opts...))
+}
+
+func (w *workflowServiceVersionWrapper) SignalWorkflowExecution(ctx context.Context, request *shared.SignalWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	return toCompatibilityError(w.service.SignalWorkflowExecution(ctx, request, opts...))
+}
+
+func (w *workflowServiceVersionWrapper) SignalWithStartWorkflowExecution(ctx context.Context, request *shared.SignalWithStartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionResponse, error) {
+	result, err := w.service.SignalWithStartWorkflowExecution(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) SignalWithStartWorkflowExecutionAsync(ctx context.Context, request *shared.SignalWithStartWorkflowExecutionAsyncRequest, opts ...yarpc.CallOption) (*shared.SignalWithStartWorkflowExecutionAsyncResponse, error) {
+	result, err := w.service.SignalWithStartWorkflowExecutionAsync(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) StartWorkflowExecution(ctx context.Context, request *shared.StartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionResponse, error) {
+	result, err := w.service.StartWorkflowExecution(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) StartWorkflowExecutionAsync(ctx context.Context, request *shared.StartWorkflowExecutionAsyncRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionAsyncResponse, error) {
+	result, err := w.service.StartWorkflowExecutionAsync(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) TerminateWorkflowExecution(ctx context.Context, request *shared.TerminateWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	return toCompatibilityError(w.service.TerminateWorkflowExecution(ctx, request, opts...))
+}
+
+func (w *workflowServiceVersionWrapper) ResetWorkflowExecution(ctx context.Context, request *shared.ResetWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.ResetWorkflowExecutionResponse, error) {
+	result, err := w.service.ResetWorkflowExecution(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) UpdateDomain(ctx context.Context, request *shared.UpdateDomainRequest, opts ...yarpc.CallOption) (*shared.UpdateDomainResponse, error) {
+	result, err := w.service.UpdateDomain(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) QueryWorkflow(ctx context.Context, request *shared.QueryWorkflowRequest, opts ...yarpc.CallOption) (*shared.QueryWorkflowResponse, error) {
+	result, err := w.service.QueryWorkflow(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) ResetStickyTaskList(ctx context.Context, request *shared.ResetStickyTaskListRequest, opts ...yarpc.CallOption) (*shared.ResetStickyTaskListResponse, error) {
+	result, err := w.service.ResetStickyTaskList(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) DescribeTaskList(ctx context.Context, request *shared.DescribeTaskListRequest, opts ...yarpc.CallOption) (*shared.DescribeTaskListResponse, error) {
+	result, err := w.service.DescribeTaskList(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) RespondQueryTaskCompleted(ctx context.Context, request *shared.RespondQueryTaskCompletedRequest, opts ...yarpc.CallOption) error {
+	return toCompatibilityError(w.service.RespondQueryTaskCompleted(ctx, request, opts...))
+}
+
+func (w *workflowServiceVersionWrapper) GetSearchAttributes(ctx context.Context, opts ...yarpc.CallOption) (*shared.GetSearchAttributesResponse, error) {
+	result, err := w.service.GetSearchAttributes(ctx, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) ListTaskListPartitions(ctx context.Context, request *shared.ListTaskListPartitionsRequest, opts ...yarpc.CallOption) (*shared.ListTaskListPartitionsResponse, error) {
+	result, err := w.service.ListTaskListPartitions(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) GetClusterInfo(ctx context.Context, opts ...yarpc.CallOption) (*shared.ClusterInfo, error) {
+	result, err := w.service.GetClusterInfo(ctx, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) GetTaskListsByDomain(ctx context.Context, request *shared.GetTaskListsByDomainRequest, opts ...yarpc.CallOption) (*shared.GetTaskListsByDomainResponse, error) {
+	result, err := w.service.GetTaskListsByDomain(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
+
+func (w *workflowServiceVersionWrapper) RefreshWorkflowTasks(ctx context.Context, request *shared.RefreshWorkflowTasksRequest, opts ...yarpc.CallOption) error {
+	return toCompatibilityError(w.service.RefreshWorkflowTasks(ctx, request, opts...))
+}
+
+func (w *workflowServiceVersionWrapper) RestartWorkflowExecution(ctx context.Context, request *shared.RestartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.RestartWorkflowExecutionResponse, error) {
+	result, err := w.service.RestartWorkflowExecution(ctx, request, opts...)
+	return result, toCompatibilityError(err)
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+)
+
+func TestWorkflowServiceVersionWrapper(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	service := workflowservicetest.NewMockClient(ctrl)
+	wrapper := newWorkflowServiceVersionWrapper(service)
+
+	versionErr := &shared.ClientVersionNotSupportedError{
+		FeatureVersion:    "1.0.0",
+		ClientImpl:        "uber-go",
+		SupportedVersions: ">=2.0.0",
+	}
+	service.EXPECT().DescribeDomain(gomock.Any(), gomock.Any(), callOptions()...).Return(nil, versionErr)
+
+	_, err := wrapper.DescribeDomain(context.Background(), &shared.DescribeDomainRequest{}, getYarpcCallOptions(FeatureFlags{})...)
+	var compatibilityErr *CompatibilityError
+	require.True(t, errors.As(err, &compatibilityErr))
+	assert.Equal(t, "1.0.0", compatibilityErr.FeatureVersion)
+	assert.Equal(t, "uber-go", compatibilityErr.ClientImpl)
+	assert.Equal(t, ">=2.0.0", compatibilityErr.SupportedVersions)
+	assert.Equal(t, LibraryVersion, compatibilityErr.LibraryVersion)
+	assert.True(t, errors.Is(err, versionErr))
+	assert.True(t, isNonRetriableError(err))
+	assert.False(t, isServiceTransientError(err))
+}
+
+func TestToCompatibilityError(t *testing.T) {
+	assert.NoError(t, toCompatibilityError(nil))
+
+	other := errors.New("other")
+	assert.Equal(t, other, toCompatibilityError(other))
+
+	err := toCompatibilityError(fmt.Errorf("wrapped: %w", &shared.ClientVersionNotSupportedError{}))
+	var compatibilityErr *CompatibilityError
+	require.True(t, errors.As(err, &compatibilityErr))
+	assert.Equal(t, FeatureVersion, compatibilityErr.FeatureVersion)
+	assert.Equal(t, clientImplHeaderValue, compatibilityErr.ClientImpl)
+	assert.Equal(t, err, toCompatibilityError(err))
+}
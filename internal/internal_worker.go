@@ -33,6 +33,7 @@ import (
 	"os"
 	"reflect"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -42,6 +43,7 @@ import (
 	"go.uber.org/cadence/internal/common/debug"
 
 	"go.uber.org/cadence/internal/common/isolationgroup"
+	"go.uber.org/cadence/internal/common/rpcheaders"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/pborman/uuid"
@@ -52,7 +54,6 @@ import (
 	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
 	"go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common/auth"
-	"go.uber.org/cadence/internal/common/backoff"
 	"go.uber.org/cadence/internal/common/metrics"
 	"go.uber.org/cadence/internal/common/util"
 )
@@ -125,6 +126,8 @@ type (
 		workflowTaskHandler                WorkflowTaskHandler
 		activityTaskHandler                ActivityTaskHandler
 		useLocallyDispatchedActivityPoller bool
+		// additionalActivityTaskLists are polled by the activity worker along with its own task list
+		additionalActivityTaskLists []ActivityTaskListWeight
 	}
 
 	// workerExecutionParameters defines worker configure/execution options.
@@ -235,7 +238,7 @@ func verifyDomainExist(
 	}
 
 	// exponential backoff retry for upto a minute
-	return backoff.Retry(ctx, descDomainOp, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+	return retryWhileTransientError(ctx, descDomainOp)
 }
 
 func newWorkflowWorkerInternal(
@@ -257,7 +260,7 @@ func newWorkflowWorkerInternal(
 	} else {
 		taskHandler = newWorkflowTaskHandler(domain, params, ppMgr, registry)
 	}
-	return newWorkflowTaskWorkerInternal(taskHandler, service, domain, params, workerStopChannel, ldaTunnel)
+	return newWorkflowTaskWorkerInternal(taskHandler, service, domain, params, workerStopChannel, ldaTunnel, registry)
 }
 
 func newWorkflowTaskWorkerInternal(
@@ -267,6 +270,7 @@ func newWorkflowTaskWorkerInternal(
 	params workerExecutionParameters,
 	stopC chan struct{},
 	ldaTunnel *locallyDispatchedActivityTunnel,
+	registry *registry,
 ) *workflowWorker {
 	ensureRequiredParams(&params)
 	poller := newWorkflowTaskPoller(
@@ -295,6 +299,11 @@ func newWorkflowTaskWorkerInternal(
 		workerType:        "DecisionWorker",
 		shutdownTimeout:   params.WorkerStopTimeout,
 		pollerTracker:     params.WorkerStats.PollerTracker,
+		taskPriority:      workflowTaskPriority,
+		acquireTaskSlot:   getWorkflowTaskSlotFunc(registry),
+		useDispatchQueue: func() bool {
+			return registry != nil && registry.hasWorkflowSlots()
+		},
 	},
 		params.Logger,
 		params.MetricsScope,
@@ -455,6 +464,8 @@ func newActivityWorker(
 	if overrides != nil && overrides.useLocallyDispatchedActivityPoller {
 		taskPoller = newLocallyDispatchedActivityTaskPoller(taskHandler, service, domain, params)
 		workerType = "LocallyDispatchedActivityWorker"
+	} else if overrides != nil && len(overrides.additionalActivityTaskLists) > 0 {
+		taskPoller = newMultiTaskListActivityTaskPoller(taskHandler, service, domain, params, overrides.additionalActivityTaskLists)
 	} else {
 		taskPoller = newActivityTaskPoller(
 			taskHandler,
@@ -463,7 +474,7 @@ func newActivityWorker(
 			params,
 		)
 	}
-	return newActivityTaskWorker(service, domain, params, sessionTokenBucket, workerStopChannel, taskPoller, workerType)
+	return newActivityTaskWorker(service, domain, params, sessionTokenBucket, workerStopChannel, taskPoller, workerType, env)
 }
 
 func newActivityTaskWorker(
@@ -474,8 +485,10 @@ func newActivityTaskWorker(
 	stopC chan struct{},
 	poller taskPoller,
 	workerType string,
+	registry *registry,
 ) (worker *activityWorker) {
 	ensureRequiredParams(&workerParams)
+	userPriority := workerParams.ActivityTaskPriority
 	base := newBaseWorker(
 		baseWorkerOptions{
 			pollerAutoScaler: pollerAutoScalerOptions{
@@ -497,6 +510,12 @@ func newActivityTaskWorker(
 			shutdownTimeout:   workerParams.WorkerStopTimeout,
 			userContextCancel: workerParams.UserContextCancel,
 			pollerTracker:     workerParams.WorkerStats.PollerTracker,
+			taskPriority:      getActivityTaskPriorityFunc(userPriority),
+			taskQueueSize:     workerParams.ActivityTaskDispatchQueueSize,
+			acquireTaskSlot:   getActivityTaskSlotFunc(registry),
+			useDispatchQueue: func() bool {
+				return userPriority != nil || (registry != nil && registry.hasActivitySlots())
+			},
 		},
 
 		workerParams.Logger,
@@ -515,6 +534,82 @@ func newActivityTaskWorker(
 	}
 }
 
+// getActivityTaskPriorityFunc adapts WorkerOptions.ActivityTaskPriority to the polled activity tasks.
+// All tasks have the same priority if it is nil.
+func getActivityTaskPriorityFunc(priority func(ActivityTaskPriorityInfo) int) func(task interface{}) (int, bool) {
+	return func(task interface{}) (int, bool) {
+		activityTask, ok := task.(*activityTask)
+		if !ok {
+			return 0, true
+		}
+		if activityTask.task == nil {
+			// empty poll response
+			return 0, false
+		}
+		if priority == nil {
+			return 0, true
+		}
+		return priority(ActivityTaskPriorityInfo{
+			TaskList:     activityTask.taskListName,
+			WorkflowType: activityTask.task.WorkflowType.GetName(),
+			ActivityType: activityTask.task.ActivityType.GetName(),
+			Header:       activityTask.task.Header.GetFields(),
+		}), true
+	}
+}
+
+// getActivityTaskSlotFunc acquires the slots of the activity types registered with
+// RegisterActivityOptions.MaxConcurrentExecutionSize for the queued activity tasks.
+func getActivityTaskSlotFunc(registry *registry) func(task interface{}) (func(), bool) {
+	if registry == nil {
+		return nil
+	}
+	return func(task interface{}) (func(), bool) {
+		activityTask, ok := task.(*activityTask)
+		if !ok || activityTask.task == nil {
+			return nil, true
+		}
+		release, ok := tryAcquireSlot(registry.getActivityTypeSlots(activityTask.task.ActivityType.GetName()))
+		activityTask.slotHeld = ok
+		return release, ok
+	}
+}
+
+// workflowTaskPriority drops the empty decision poll responses from the dispatch queue.
+func workflowTaskPriority(task interface{}) (int, bool) {
+	workflowTask, ok := task.(*workflowTask)
+	return 0, !ok || workflowTask.task != nil
+}
+
+// getWorkflowTaskSlotFunc acquires the slots of the workflow types registered with
+// RegisterWorkflowOptions.MaxConcurrentExecutionSize for the queued decision tasks.
+func getWorkflowTaskSlotFunc(registry *registry) func(task interface{}) (func(), bool) {
+	if registry == nil {
+		return nil
+	}
+	return func(task interface{}) (func(), bool) {
+		workflowTask, ok := task.(*workflowTask)
+		if !ok || workflowTask.task == nil {
+			return nil, true
+		}
+		return tryAcquireSlot(registry.getWorkflowTypeSlots(workflowTask.task.WorkflowType.GetName()))
+	}
+}
+
+// tryAcquireSlot takes a slot from slots without blocking, and returns the function releasing it.
+// A nil slots channel has no limit.
+func tryAcquireSlot(slots chan struct{}) (func(), bool) {
+	if slots == nil {
+		return nil, true
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	default:
+		return nil, false
+	}
+}
+
 // Start the worker.
 func (aw *activityWorker) Start() error {
 	err := verifyDomainExist(aw.workflowService, aw.domain, aw.worker.logger, aw.executionParameters.FeatureFlags)
@@ -669,6 +764,7 @@ type workflowExecutor struct {
 	workflowType string
 	fn           interface{}
 	path         string
+	options      RegisterWorkflowOptions
 }
 
 func (we *workflowExecutor) Execute(ctx Context, input []byte) ([]byte, error) {
@@ -704,6 +800,10 @@ func (we *workflowExecutor) GetFunction() interface{} {
 	return we.fn
 }
 
+func (we *workflowExecutor) GetOptions() RegisterWorkflowOptions {
+	return we.options
+}
+
 // Wrapper to execute activity functions.
 type activityExecutor struct {
 	name    string
@@ -798,11 +898,14 @@ type aggregatedWorker struct {
 	workflowWorker                  *workflowWorker
 	activityWorker                  *activityWorker
 	locallyDispatchedActivityWorker *activityWorker
+	hostSpecificActivityWorker      *activityWorker
 	sessionWorker                   *sessionWorker
 	shadowWorker                    *shadowWorker
 	logger                          *zap.Logger
 	registry                        *registry
 	workerstats                     debug.WorkerStats
+	onStart                         func()
+	onStop                          func()
 }
 
 var _ debug.Debugger = &aggregatedWorker{}
@@ -813,6 +916,9 @@ func (aw *aggregatedWorker) GetRegisteredWorkflows() []RegistryWorkflowInfo {
 	for _, wf := range workflows {
 		result = append(result, wf)
 	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].WorkflowType().Name < result[j].WorkflowType().Name
+	})
 	return result
 }
 
@@ -822,6 +928,9 @@ func (aw *aggregatedWorker) GetRegisteredActivities() []RegistryActivityInfo {
 	for _, a := range activities {
 		result = append(result, a)
 	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ActivityType().Name < result[j].ActivityType().Name
+	})
 	return result
 }
 
@@ -841,6 +950,14 @@ func (aw *aggregatedWorker) RegisterActivityWithOptions(a interface{}, options R
 	aw.registry.RegisterActivityWithOptions(a, options)
 }
 
+func (aw *aggregatedWorker) DeregisterWorkflow(w interface{}) error {
+	return aw.registry.DeregisterWorkflow(w)
+}
+
+func (aw *aggregatedWorker) DeregisterActivity(a interface{}) error {
+	return aw.registry.DeregisterActivity(a)
+}
+
 func (aw *aggregatedWorker) Start() error {
 	if _, err := initBinaryChecksum(); err != nil {
 		return fmt.Errorf("failed to get executable checksum: %v", err)
@@ -881,6 +998,19 @@ func (aw *aggregatedWorker) Start() error {
 					return err
 				}
 			}
+			if aw.hostSpecificActivityWorker != nil {
+				if err := aw.hostSpecificActivityWorker.Start(); err != nil {
+					// stop workflow worker.
+					if aw.workflowWorker != nil {
+						aw.workflowWorker.Stop()
+					}
+					aw.activityWorker.Stop()
+					if aw.locallyDispatchedActivityWorker != nil {
+						aw.locallyDispatchedActivityWorker.Stop()
+					}
+					return err
+				}
+			}
 			aw.logger.Info("Started Activity Worker")
 		}
 	}
@@ -897,6 +1027,9 @@ func (aw *aggregatedWorker) Start() error {
 			if aw.locallyDispatchedActivityWorker != nil {
 				aw.locallyDispatchedActivityWorker.Stop()
 			}
+			if aw.hostSpecificActivityWorker != nil {
+				aw.hostSpecificActivityWorker.Stop()
+			}
 			return err
 		}
 		aw.logger.Info("Started Session Worker")
@@ -913,6 +1046,9 @@ func (aw *aggregatedWorker) Start() error {
 			if aw.locallyDispatchedActivityWorker != nil {
 				aw.locallyDispatchedActivityWorker.Stop()
 			}
+			if aw.hostSpecificActivityWorker != nil {
+				aw.hostSpecificActivityWorker.Stop()
+			}
 			if aw.sessionWorker != nil {
 				aw.sessionWorker.Stop()
 			}
@@ -921,6 +1057,9 @@ func (aw *aggregatedWorker) Start() error {
 		aw.logger.Info("Started Shadow Worker")
 	}
 
+	if aw.onStart != nil {
+		aw.onStart()
+	}
 	return nil
 }
 
@@ -968,6 +1107,14 @@ func initBinaryChecksum() (string, error) {
 	return bcsVal, err
 }
 
+// getBuildID returns the given build ID of a worker, or the binary checksum of the process if it is not set
+func getBuildID(buildID string) string {
+	if buildID != "" {
+		return buildID
+	}
+	return getBinaryChecksum()
+}
+
 func getBinaryChecksum() string {
 	bcsVal, ok := binaryChecksum.Load().(string)
 	if ok {
@@ -992,6 +1139,20 @@ func (aw *aggregatedWorker) Run() error {
 	return nil
 }
 
+func (aw *aggregatedWorker) RunWithInterrupt(interruptCh <-chan interface{}) error {
+	if err := aw.Start(); err != nil {
+		return err
+	}
+	v, ok := <-interruptCh
+	if ok {
+		aw.logger.Info("Worker has been interrupted", zap.Any("Interrupt", v))
+	} else {
+		aw.logger.Info("Worker interrupt channel has been closed")
+	}
+	aw.Stop()
+	return nil
+}
+
 func (aw *aggregatedWorker) Stop() {
 	if aw.workflowWorker != nil {
 		aw.workflowWorker.Stop()
@@ -1002,6 +1163,9 @@ func (aw *aggregatedWorker) Stop() {
 	if aw.locallyDispatchedActivityWorker != nil {
 		aw.locallyDispatchedActivityWorker.Stop()
 	}
+	if aw.hostSpecificActivityWorker != nil {
+		aw.hostSpecificActivityWorker.Stop()
+	}
 	if aw.sessionWorker != nil {
 		aw.sessionWorker.Stop()
 	}
@@ -1009,6 +1173,41 @@ func (aw *aggregatedWorker) Stop() {
 		aw.shadowWorker.Stop()
 	}
 	aw.logger.Info("Stopped Worker")
+	if aw.onStop != nil {
+		aw.onStop()
+	}
+}
+
+func (aw *aggregatedWorker) Health() WorkerHealth {
+	var pollers []PollerHealth
+	addPollers := func(bw *baseWorker, taskList string) {
+		if bw.isWorkerStarted.Load() {
+			pollers = append(pollers, bw.health(taskList))
+		}
+	}
+	addActivityPollers := func(w *activityWorker) {
+		if w != nil {
+			addPollers(w.worker, w.executionParameters.TaskList)
+		}
+	}
+	if aw.workflowWorker != nil {
+		addPollers(aw.workflowWorker.worker, aw.workflowWorker.executionParameters.TaskList)
+	}
+	addActivityPollers(aw.activityWorker)
+	addActivityPollers(aw.locallyDispatchedActivityWorker)
+	addActivityPollers(aw.hostSpecificActivityWorker)
+	if aw.sessionWorker != nil {
+		addActivityPollers(aw.sessionWorker.creationWorker)
+		addActivityPollers(aw.sessionWorker.activityWorker)
+	}
+	if aw.shadowWorker != nil {
+		addActivityPollers(aw.shadowWorker.activityWorker)
+	}
+	return WorkerHealth{
+		Pollers:              pollers,
+		RegisteredWorkflows:  len(aw.registry.GetRegisteredWorkflowTypes()),
+		RegisteredActivities: len(aw.registry.getRegisteredActivities()),
+	}
 }
 
 func (aw *aggregatedWorker) GetWorkerStats() debug.WorkerStats {
@@ -1043,6 +1242,12 @@ func newAggregatedWorker(
 	}
 
 	ensureRequiredParams(&workerParams)
+	if wOptions.MetricsPrefix != "" {
+		workerParams.MetricsScope = workerParams.MetricsScope.SubScope(wOptions.MetricsPrefix)
+	}
+	if len(wOptions.MetricsTags) > 0 {
+		workerParams.MetricsScope = workerParams.MetricsScope.Tagged(wOptions.MetricsTags)
+	}
 	workerParams.MetricsScope = tagScope(workerParams.MetricsScope, tagDomain, domain, tagTaskList, taskList, clientImplHeaderName, clientImplHeaderValue)
 	workerParams.Logger = workerParams.Logger.With(
 		zapcore.Field{Key: tagDomain, Type: zapcore.StringType, String: domain},
@@ -1050,12 +1255,17 @@ func newAggregatedWorker(
 		zapcore.Field{Key: tagWorkerID, Type: zapcore.StringType, String: workerParams.Identity},
 	)
 	logger := workerParams.Logger
+	if options.ServiceWrapper != nil {
+		service = options.ServiceWrapper(service)
+	}
 	if options.Authorization != nil {
 		service = auth.NewWorkflowServiceWrapper(service, options.Authorization)
 	}
 	if options.IsolationGroup != "" {
 		service = isolationgroup.NewWorkflowServiceWrapper(service, options.IsolationGroup)
 	}
+	service = rpcheaders.NewWorkflowServiceWrapper(service, options.Headers)
+	service = newWorkflowServiceVersionWrapper(service)
 	service = metrics.NewWorkflowServiceWrapper(service, workerParams.MetricsScope)
 	processTestTags(&wOptions, &workerParams)
 
@@ -1066,20 +1276,24 @@ func newAggregatedWorker(
 	var ldaTunnel *locallyDispatchedActivityTunnel
 
 	// activity types.
-	var activityWorker, locallyDispatchedActivityWorker *activityWorker
+	var activityWorker, locallyDispatchedActivityWorker, hostSpecificActivityWorker *activityWorker
 
 	if !wOptions.DisableActivityWorker {
+		var activityOverrides *workerOverrides
+		if len(wOptions.AdditionalActivityTaskLists) > 0 {
+			activityOverrides = &workerOverrides{additionalActivityTaskLists: wOptions.AdditionalActivityTaskLists}
+		}
 		activityWorker = newActivityWorker(
 			service,
 			domain,
 			workerParams,
-			nil,
+			activityOverrides,
 			registry,
 			nil,
 		)
 
 		// do not dispatch locally if TaskListActivitiesPerSecond is set
-		if workerParams.TaskListActivitiesPerSecond == defaultTaskListActivitiesPerSecond {
+		if workerParams.TaskListActivitiesPerSecond == defaultTaskListActivitiesPerSecond && !wOptions.DisableActivityLocalDispatch {
 			// TODO update taskPoller interface so one activity worker can multiplex on multiple pollers
 			locallyDispatchedActivityWorker = newActivityWorker(
 				service,
@@ -1092,6 +1306,19 @@ func newAggregatedWorker(
 			ldaTunnel = locallyDispatchedActivityWorker.poller.(*locallyDispatchedActivityTaskPoller).ldaTunnel
 			ldaTunnel.metricsScope = metrics.NewTaggedScope(workerParams.MetricsScope)
 		}
+
+		if wOptions.EnableHostSpecificTaskList {
+			hostSpecificParams := workerParams
+			hostSpecificParams.TaskList = GetHostSpecificTaskList(taskList)
+			hostSpecificActivityWorker = newActivityWorker(
+				service,
+				domain,
+				hostSpecificParams,
+				nil,
+				registry,
+				nil,
+			)
+		}
 	}
 
 	// workflow factory.
@@ -1153,11 +1380,14 @@ func newAggregatedWorker(
 		workflowWorker:                  workflowWorker,
 		activityWorker:                  activityWorker,
 		locallyDispatchedActivityWorker: locallyDispatchedActivityWorker,
+		hostSpecificActivityWorker:      hostSpecificActivityWorker,
 		sessionWorker:                   sessionWorker,
 		shadowWorker:                    shadowWorker,
 		logger:                          logger,
 		registry:                        registry,
 		workerstats:                     workerParams.WorkerStats,
+		onStart:                         wOptions.OnStart,
+		onStop:                          wOptions.OnStop,
 	}, nil
 }
 
@@ -1278,6 +1508,9 @@ func AugmentWorkerOptions(options WorkerOptions) WorkerOptions {
 	if options.TaskListActivitiesPerSecond == 0 {
 		options.TaskListActivitiesPerSecond = defaultTaskListActivitiesPerSecond
 	}
+	if options.TaskPollTimeout <= 0 {
+		options.TaskPollTimeout = pollTaskServiceTimeOut
+	}
 	if options.StickyScheduleToStartTimeout.Seconds() == 0 {
 		options.StickyScheduleToStartTimeout = stickyDecisionScheduleToStartTimeoutSeconds * time.Second
 	}
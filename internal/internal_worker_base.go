@@ -35,6 +35,7 @@ import (
 	"go.uber.org/cadence/internal/common/debug"
 
 	"github.com/uber-go/tally"
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"golang.org/x/time/rate"
@@ -48,6 +49,9 @@ import (
 const (
 	retryPollOperationInitialInterval = 20 * time.Millisecond
 	retryPollOperationMaxInterval     = 10 * time.Second
+
+	pollerPanicInitialBackoff = 100 * time.Millisecond
+	pollerPanicMaxBackoff     = 10 * time.Second
 )
 
 var (
@@ -89,13 +93,17 @@ type (
 		RegisterQueryHandler(handler func(queryType string, queryArgs []byte) ([]byte, error))
 		IsReplaying() bool
 		MutableSideEffect(id string, f func() interface{}, equals func(a, b interface{}) bool) Value
+		RecordMarker(name string, details []byte)
 		GetDataConverter() DataConverter
 		AddSession(sessionInfo *SessionInfo)
 		RemoveSession(sessionID string)
 		GetContextPropagators() []ContextPropagator
 		UpsertSearchAttributes(attributes map[string]interface{}) error
+		UpsertMemo(memo map[string]interface{}) error
 		GetRegistry() *registry
 		GetWorkflowInterceptors() []WorkflowInterceptorFactory
+		GetDefaultActivityOptions() *ActivityOptions
+		ReportDispatcherStats(stats DispatcherStats)
 	}
 
 	// WorkflowDefinition wraps the code that can execute a workflow.
@@ -125,12 +133,25 @@ type (
 		userContextCancel context.CancelFunc
 		host              string
 		pollerTracker     debug.PollerTracker
+		// taskPriority enables the dispatch of polled tasks by priority, highest first. It returns false for the
+		// tasks which do not need to be executed, e.g. empty poll responses.
+		taskPriority func(task interface{}) (int, bool)
+		// taskQueueSize is the number of polled tasks waiting in the dispatch queue.
+		taskQueueSize int
+		// acquireTaskSlot acquires, without blocking, a slot of the type of a polled task for the types with a
+		// concurrency limit. It returns false when no slot of the type is free, and a release function otherwise,
+		// which is nil for types without a limit. Tasks without a free slot stay in the dispatch queue without
+		// holding an execution slot, while tasks of other types are executed.
+		acquireTaskSlot func(task interface{}) (release func(), ok bool)
+		// useDispatchQueue is called when the worker starts, the dispatch queue is used if it returns true.
+		// If it is nil, the dispatch queue is used when taskPriority is set.
+		useDispatchQueue func() bool
 	}
 
 	// baseWorker that wraps worker activities.
 	baseWorker struct {
 		options              baseWorkerOptions
-		isWorkerStarted      bool
+		isWorkerStarted      atomic.Bool
 		shutdownCh           chan struct{}  // Channel used to shut down the go routines.
 		shutdownWG           sync.WaitGroup // The WaitGroup for shutting down existing routines.
 		pollLimiter          *rate.Limiter
@@ -145,10 +166,23 @@ type (
 		pollerAutoScaler   *pollerAutoScaler
 		taskQueueCh        chan interface{}
 		sessionTokenBucket *sessionTokenBucket
+
+		// set when the dispatch queue is used, see baseWorkerOptions.useDispatchQueue: polled tasks wait in
+		// dispatchQueue for one of the maxConcurrentTask execution slots.
+		dispatchQueue    *taskDispatchQueue
+		executionSlotsCh chan struct{}
+
+		lastPollSuccessTime atomic.Time
+		lastPollError       atomic.Error
+		// consecutive poller panics, reset by a successful poll
+		pollerPanics atomic.Int32
 	}
 
 	polledTask struct {
-		task interface{}
+		task     interface{}
+		polledAt time.Time
+		// releaseSlot releases the task type slot acquired by baseWorkerOptions.acquireTaskSlot, if any.
+		releaseSlot func()
 	}
 )
 
@@ -195,12 +229,34 @@ func newBaseWorker(options baseWorkerOptions, logger *zap.Logger, metricsScope t
 	return bw
 }
 
+// initDispatchQueue sets up the dispatch queue when the worker uses it, see baseWorkerOptions.useDispatchQueue.
+func (bw *baseWorker) initDispatchQueue() {
+	if bw.options.useDispatchQueue != nil {
+		if !bw.options.useDispatchQueue() {
+			return
+		}
+	} else if bw.options.taskPriority == nil {
+		return
+	}
+	// poll requests are also granted for the queued tasks, so that there are tasks to choose from when an
+	// execution slot frees up.
+	queueSize := bw.options.taskQueueSize
+	if queueSize <= 0 {
+		queueSize = bw.options.pollerCount
+	}
+	bw.pollerRequestCh = make(chan struct{}, bw.options.maxConcurrentTask+queueSize)
+	bw.dispatchQueue = newTaskDispatchQueue()
+	bw.executionSlotsCh = make(chan struct{}, bw.options.maxConcurrentTask)
+}
+
 // Start starts a fixed set of routines to do the work.
 func (bw *baseWorker) Start() {
-	if bw.isWorkerStarted {
+	if bw.isWorkerStarted.Load() {
 		return
 	}
 
+	bw.initDispatchQueue()
+
 	bw.metricsScope.Counter(metrics.WorkerStartCounter).Inc(1)
 
 	if bw.pollerAutoScaler != nil {
@@ -215,7 +271,7 @@ func (bw *baseWorker) Start() {
 	bw.shutdownWG.Add(1)
 	go bw.runTaskDispatcher()
 
-	bw.isWorkerStarted = true
+	bw.isWorkerStarted.Store(true)
 	traceLog(func() {
 		bw.logger.Info("Started Worker",
 			zap.Int("PollerCount", bw.options.pollerCount),
@@ -252,7 +308,7 @@ func (bw *baseWorker) runPoller() {
 			if bw.sessionTokenBucket != nil {
 				bw.sessionTokenBucket.waitForAvailableToken()
 			}
-			bw.pollTask()
+			bw.pollTaskWithRecover()
 		}
 	}
 }
@@ -260,10 +316,17 @@ func (bw *baseWorker) runPoller() {
 func (bw *baseWorker) runTaskDispatcher() {
 	defer bw.shutdownWG.Done()
 
-	for i := 0; i < bw.options.maxConcurrentTask; i++ {
+	for i := 0; i < cap(bw.pollerRequestCh); i++ {
 		bw.pollerRequestCh <- struct{}{}
 	}
 
+	if bw.dispatchQueue != nil {
+		bw.shutdownWG.Add(1)
+		go bw.dispatchNonPolledTasks()
+		bw.dispatchByPriority()
+		return
+	}
+
 	for {
 		// wait for new task or shutdown
 		select {
@@ -283,6 +346,100 @@ func (bw *baseWorker) runTaskDispatcher() {
 	}
 }
 
+// dispatchByPriority executes the queued task with the highest priority whenever an execution slot is free.
+func (bw *baseWorker) dispatchByPriority() {
+	for {
+		select {
+		case <-bw.shutdownCh:
+			return
+		case bw.executionSlotsCh <- struct{}{}:
+		}
+		task, ok := bw.dispatchQueue.pop(bw.shutdownCh, bw.acquireTaskSlot)
+		if !ok {
+			return
+		}
+		bw.metricsScope.Gauge(metrics.TaskDispatchQueueSize).Update(float64(bw.dispatchQueue.len()))
+		if bw.taskLimiter.Wait(bw.limiterContext) != nil {
+			if bw.isShutdown() {
+				return
+			}
+		}
+		bw.shutdownWG.Add(1)
+		go bw.processTask(task)
+	}
+}
+
+// acquireTaskSlot acquires the task type slot of a queued task, see baseWorkerOptions.acquireTaskSlot.
+func (bw *baseWorker) acquireTaskSlot(task *polledTask) bool {
+	if bw.options.acquireTaskSlot == nil {
+		return true
+	}
+	release, ok := bw.options.acquireTaskSlot(task.task)
+	if !ok {
+		return false
+	}
+	if release != nil {
+		task.releaseSlot = func() {
+			release()
+			bw.dispatchQueue.notify() // a queued task of the same type may be dispatched now
+		}
+	}
+	return true
+}
+
+// dispatchNonPolledTasks executes the tasks which are not polled, e.g. local activity results, when polled tasks
+// are dispatched through the dispatch queue.
+func (bw *baseWorker) dispatchNonPolledTasks() {
+	defer bw.shutdownWG.Done()
+	for {
+		select {
+		case <-bw.shutdownCh:
+			return
+		case task := <-bw.taskQueueCh:
+			bw.shutdownWG.Add(1)
+			go bw.processTask(task)
+		}
+	}
+}
+
+// pollTaskWithRecover polls for a task, recovering from panics so that the poller keeps running: the panic is
+// logged, and the poll is requested again after a backoff growing with the consecutive panics.
+func (bw *baseWorker) pollTaskWithRecover() {
+	defer func() {
+		if p := recover(); p != nil {
+			bw.metricsScope.Counter(metrics.PollerPanicCounter).Inc(1)
+			topLine := fmt.Sprintf("poller for %s [panic]:", bw.options.workerType)
+			st := getStackTraceRaw(topLine, 7, 0)
+			bw.logger.Error("Unhandled panic in poller.",
+				zap.String(tagPanicError, fmt.Sprintf("%v", p)),
+				zap.String(tagPanicStack, st))
+			bw.lastPollError.Store(fmt.Errorf("poller panic: %v", p))
+			bw.retrier.Failed()
+
+			timer := time.NewTimer(pollerPanicBackoff(bw.pollerPanics.Inc()))
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-bw.shutdownCh:
+			}
+			bw.pollerRequestCh <- struct{}{}
+		}
+	}()
+	bw.pollTask()
+}
+
+// pollerPanicBackoff returns the delay before polling again after consecutive poller panics.
+func pollerPanicBackoff(panics int32) time.Duration {
+	backoff := pollerPanicInitialBackoff
+	for i := int32(1); i < panics && backoff < pollerPanicMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > pollerPanicMaxBackoff {
+		return pollerPanicMaxBackoff
+	}
+	return backoff
+}
+
 /*
 There are three types of constraint on polling tasks:
 1. poller auto scaler is to constraint number of concurrent pollers
@@ -308,6 +465,7 @@ func (bw *baseWorker) pollTask() {
 			bw.logger.Debug("Failed to poll for task.", zap.Error(err))
 		}
 		if err != nil {
+			bw.lastPollError.Store(err)
 			if isNonRetriableError(err) {
 				bw.logger.Error("Worker received non-retriable error. Shutting down.", zap.Error(err))
 				p, _ := os.FindProcess(os.Getpid())
@@ -316,6 +474,9 @@ func (bw *baseWorker) pollTask() {
 			}
 			bw.retrier.Failed()
 		} else {
+			bw.lastPollSuccessTime.Store(time.Now())
+			bw.lastPollError.Store(nil)
+			bw.pollerPanics.Store(0)
 			if bw.pollerAutoScaler != nil {
 				if pErr := bw.pollerAutoScaler.CollectUsage(task); pErr != nil {
 					bw.logger.Sugar().Warnw("poller auto scaler collect usage error",
@@ -327,9 +488,20 @@ func (bw *baseWorker) pollTask() {
 		}
 	}
 
-	if task != nil {
+	if task != nil && bw.dispatchQueue != nil {
+		priority, ok := 0, true
+		if bw.options.taskPriority != nil {
+			priority, ok = bw.options.taskPriority(task)
+		}
+		if ok {
+			bw.dispatchQueue.push(&polledTask{task: task, polledAt: time.Now()}, priority)
+			bw.metricsScope.Gauge(metrics.TaskDispatchQueueSize).Update(float64(bw.dispatchQueue.len()))
+		} else {
+			bw.pollerRequestCh <- struct{}{} // nothing to execute, trigger a new poll
+		}
+	} else if task != nil {
 		select {
-		case bw.taskQueueCh <- &polledTask{task}:
+		case bw.taskQueueCh <- &polledTask{task: task, polledAt: time.Now()}:
 		case <-bw.shutdownCh:
 		}
 	} else {
@@ -346,7 +518,8 @@ func isNonRetriableError(err error) bool {
 		*shared.ClientVersionNotSupportedError:
 		return true
 	}
-	return false
+	var compatibilityErr *CompatibilityError
+	return errors.As(err, &compatibilityErr)
 }
 
 func (bw *baseWorker) processTask(task interface{}) {
@@ -356,6 +529,8 @@ func (bw *baseWorker) processTask(task interface{}) {
 	polledTask, isPolledTask := task.(*polledTask)
 	if isPolledTask {
 		task = polledTask.task
+		// time spent waiting for the dispatcher and task rate limiter after the poll returned
+		bw.metricsScope.Timer(metrics.PollToDispatchLatency).Record(time.Since(polledTask.polledAt))
 	}
 	defer func() {
 		if p := recover(); p != nil {
@@ -368,6 +543,12 @@ func (bw *baseWorker) processTask(task interface{}) {
 		}
 
 		if isPolledTask {
+			if polledTask.releaseSlot != nil {
+				polledTask.releaseSlot()
+			}
+			if bw.executionSlotsCh != nil {
+				<-bw.executionSlotsCh
+			}
 			bw.pollerRequestCh <- struct{}{}
 		}
 	}()
@@ -381,6 +562,17 @@ func (bw *baseWorker) processTask(task interface{}) {
 	}
 }
 
+// health returns the poller health of the worker
+func (bw *baseWorker) health(taskList string) PollerHealth {
+	return PollerHealth{
+		WorkerType:             bw.options.workerType,
+		TaskList:               taskList,
+		Running:                bw.isWorkerStarted.Load() && !bw.isShutdown(),
+		LastSuccessfulPollTime: bw.lastPollSuccessTime.Load(),
+		LastPollError:          bw.lastPollError.Load(),
+	}
+}
+
 func (bw *baseWorker) Run() {
 	bw.Start()
 	d := <-getKillSignal()
@@ -392,7 +584,7 @@ func (bw *baseWorker) Run() {
 
 // Stop is a blocking call and cleans up all the resources associated with worker.
 func (bw *baseWorker) Stop() {
-	if !bw.isWorkerStarted {
+	if !bw.isWorkerStarted.Load() {
 		return
 	}
 	close(bw.shutdownCh)
@@ -0,0 +1,297 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/tally"
+	"go.uber.org/atomic"
+
+	"go.uber.org/cadence/internal/common/debug"
+	"go.uber.org/cadence/internal/common/metrics"
+	"go.uber.org/cadence/internal/common/testlogger"
+)
+
+// blockingTaskPoller returns a task on every poll and blocks processing until released
+type blockingTaskPoller struct {
+	polls   atomic.Int32
+	release chan struct{}
+	once    sync.Once
+}
+
+func (p *blockingTaskPoller) PollTask() (interface{}, error) {
+	p.polls.Inc()
+	return struct{}{}, nil
+}
+
+func (p *blockingTaskPoller) ProcessTask(interface{}) error {
+	<-p.release
+	return nil
+}
+
+func (p *blockingTaskPoller) unblock() {
+	p.once.Do(func() { close(p.release) })
+}
+
+func TestBaseWorker_PollOnlyWithAvailableCapacity(t *testing.T) {
+	poller := &blockingTaskPoller{release: make(chan struct{})}
+	defer poller.unblock()
+
+	bw := newBaseWorker(baseWorkerOptions{
+		pollerCount:       5,
+		maxConcurrentTask: 2,
+		maxTaskPerSecond:  1000,
+		taskWorker:        poller,
+		workerType:        "TestWorker",
+		shutdownTimeout:   time.Second,
+		pollerTracker:     debug.NewNoopPollerTracker(),
+	},
+		testlogger.NewZap(t),
+		tally.NoopScope,
+		nil,
+	)
+	bw.Start()
+
+	// all execution slots are taken by blocked tasks, so no more polls can happen
+	assert.Eventually(t, func() bool { return poller.polls.Load() == 2 }, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(2), poller.polls.Load())
+
+	poller.unblock()
+	assert.Eventually(t, func() bool { return poller.polls.Load() > 2 }, time.Second, 10*time.Millisecond)
+	bw.Stop()
+}
+
+func TestBaseWorker_PollToDispatchLatency(t *testing.T) {
+	poller := &blockingTaskPoller{release: make(chan struct{})}
+	poller.unblock()
+	scope := tally.NewTestScope("", nil)
+
+	bw := newBaseWorker(baseWorkerOptions{
+		pollerCount:       1,
+		maxConcurrentTask: 1,
+		maxTaskPerSecond:  1000,
+		taskWorker:        poller,
+		workerType:        "TestWorker",
+		shutdownTimeout:   time.Second,
+		pollerTracker:     debug.NewNoopPollerTracker(),
+	},
+		testlogger.NewZap(t),
+		scope,
+		nil,
+	)
+	bw.Start()
+	defer bw.Stop()
+
+	assert.Eventually(t, func() bool {
+		timer, ok := scope.Snapshot().Timers()[metrics.PollToDispatchLatency+"+WorkerType=TestWorker"]
+		return ok && len(timer.Values()) > 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+// panickingTaskPoller panics on the first polls, then returns tasks
+type panickingTaskPoller struct {
+	panics    atomic.Int32
+	processed atomic.Int32
+}
+
+func (p *panickingTaskPoller) PollTask() (interface{}, error) {
+	if p.panics.Inc() <= 2 {
+		panic("malformed task")
+	}
+	return struct{}{}, nil
+}
+
+func (p *panickingTaskPoller) ProcessTask(interface{}) error {
+	p.processed.Inc()
+	return nil
+}
+
+func TestBaseWorker_PollerRecoversFromPanic(t *testing.T) {
+	poller := &panickingTaskPoller{}
+	scope := tally.NewTestScope("", nil)
+
+	bw := newBaseWorker(baseWorkerOptions{
+		pollerCount:       1,
+		maxConcurrentTask: 1,
+		maxTaskPerSecond:  1000,
+		taskWorker:        poller,
+		workerType:        "TestWorker",
+		shutdownTimeout:   time.Second,
+		pollerTracker:     debug.NewNoopPollerTracker(),
+	},
+		testlogger.NewZap(t),
+		scope,
+		nil,
+	)
+	bw.Start()
+	defer bw.Stop()
+
+	// the poller keeps running after the panics, and the execution slot is not leaked
+	assert.Eventually(t, func() bool { return poller.processed.Load() > 1 }, 5*time.Second, 10*time.Millisecond)
+	counter, ok := scope.Snapshot().Counters()[metrics.PollerPanicCounter+"+WorkerType=TestWorker"]
+	if assert.True(t, ok) {
+		assert.Equal(t, int64(2), counter.Value())
+	}
+	assert.Zero(t, bw.pollerPanics.Load())
+}
+
+func TestPollerPanicBackoff(t *testing.T) {
+	assert.Equal(t, pollerPanicInitialBackoff, pollerPanicBackoff(1))
+	assert.Equal(t, 2*pollerPanicInitialBackoff, pollerPanicBackoff(2))
+	assert.Equal(t, 4*pollerPanicInitialBackoff, pollerPanicBackoff(3))
+	assert.Equal(t, pollerPanicMaxBackoff, pollerPanicBackoff(100))
+}
+
+// numberedTaskPoller returns numbered tasks up to a limit, and records the order they are processed in
+type numberedTaskPoller struct {
+	sync.Mutex
+	polls     int
+	limit     int
+	processed []int
+	release   chan struct{}
+	// blocked returns whether processing the task waits for release, all tasks wait if it is nil
+	blocked func(n int) bool
+}
+
+func (p *numberedTaskPoller) PollTask() (interface{}, error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.polls == p.limit {
+		time.Sleep(10 * time.Millisecond)
+		return -1, nil // empty poll
+	}
+	p.polls++
+	return p.polls, nil
+}
+
+func (p *numberedTaskPoller) ProcessTask(task interface{}) error {
+	if p.blocked == nil || p.blocked(task.(int)) {
+		<-p.release
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.processed = append(p.processed, task.(int))
+	return nil
+}
+
+func (p *numberedTaskPoller) getPolls() int {
+	p.Lock()
+	defer p.Unlock()
+	return p.polls
+}
+
+func TestBaseWorker_DispatchByPriority(t *testing.T) {
+	poller := &numberedTaskPoller{limit: 5, release: make(chan struct{})}
+	bw := newBaseWorker(baseWorkerOptions{
+		pollerCount:       1,
+		maxConcurrentTask: 1,
+		maxTaskPerSecond:  1000,
+		taskWorker:        poller,
+		workerType:        "TestWorker",
+		shutdownTimeout:   time.Second,
+		pollerTracker:     debug.NewNoopPollerTracker(),
+		taskPriority: func(task interface{}) (int, bool) {
+			n := task.(int)
+			// even tasks are latency-sensitive
+			return (n + 1) % 2, n > 0
+		},
+		taskQueueSize: 4,
+	},
+		testlogger.NewZap(t),
+		tally.NoopScope,
+		nil,
+	)
+	bw.Start()
+	defer bw.Stop()
+
+	// a task takes the only execution slot, and the other ones are queued
+	assert.Eventually(t, func() bool { return poller.getPolls() == 5 }, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return bw.dispatchQueue.len() == 4 }, time.Second, 10*time.Millisecond)
+
+	close(poller.release)
+	assert.Eventually(t, func() bool {
+		poller.Lock()
+		defer poller.Unlock()
+		return len(poller.processed) == 5
+	}, time.Second, 10*time.Millisecond)
+	// the queued tasks are executed by priority, then in poll order
+	var expected []int
+	for _, n := range []int{2, 4, 1, 3, 5} {
+		if n != poller.processed[0] {
+			expected = append(expected, n)
+		}
+	}
+	assert.Equal(t, expected, poller.processed[1:])
+}
+
+func TestBaseWorker_DispatchWithTaskSlots(t *testing.T) {
+	// tasks 1 and 2 have a type limited to one concurrent task
+	poller := &numberedTaskPoller{limit: 3, release: make(chan struct{}), blocked: func(n int) bool { return n == 1 }}
+	limitedSlots := make(chan struct{}, 1)
+	bw := newBaseWorker(baseWorkerOptions{
+		pollerCount:       1,
+		maxConcurrentTask: 2,
+		maxTaskPerSecond:  1000,
+		taskWorker:        poller,
+		workerType:        "TestWorker",
+		shutdownTimeout:   time.Second,
+		pollerTracker:     debug.NewNoopPollerTracker(),
+		taskPriority: func(task interface{}) (int, bool) {
+			return 0, task.(int) > 0
+		},
+		acquireTaskSlot: func(task interface{}) (func(), bool) {
+			if task.(int) > 2 {
+				return nil, true
+			}
+			return tryAcquireSlot(limitedSlots)
+		},
+		useDispatchQueue: func() bool { return true },
+	},
+		testlogger.NewZap(t),
+		tally.NoopScope,
+		nil,
+	)
+	bw.Start()
+	defer bw.Stop()
+
+	// task 2 waits for the slot of its type in the queue, without blocking task 3
+	assert.Eventually(t, func() bool {
+		poller.Lock()
+		defer poller.Unlock()
+		return len(poller.processed) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []int{3}, poller.processed)
+	assert.Equal(t, 1, bw.dispatchQueue.len())
+
+	close(poller.release)
+	assert.Eventually(t, func() bool {
+		poller.Lock()
+		defer poller.Unlock()
+		return len(poller.processed) == 3
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []int{3, 1, 2}, poller.processed)
+	assert.Empty(t, limitedSlots)
+}
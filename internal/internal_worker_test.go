@@ -41,12 +41,15 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/uber-go/tally"
+	"go.uber.org/atomic"
 	"go.uber.org/yarpc"
 	"go.uber.org/zap"
 
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
 	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
 	"go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common"
+	"go.uber.org/cadence/internal/common/metrics"
 )
 
 func testInternalWorkerRegister(r *registry) {
@@ -223,6 +226,135 @@ func (s *internalWorkerTestSuite) TestCreateShadowWorker() {
 	s.Nil(worker.sessionWorker)
 }
 
+func (s *internalWorkerTestSuite) TestCreateWorker_ActivityLocalDispatch() {
+	completeDecision := func(worker *aggregatedWorker) *shared.ScheduleActivityTaskDecisionAttributes {
+		attr := &shared.ScheduleActivityTaskDecisionAttributes{
+			ActivityId:   common.StringPtr("0"),
+			ActivityType: &shared.ActivityType{Name: common.StringPtr("activity")},
+			TaskList:     &shared.TaskList{Name: common.StringPtr("testGroupName2")},
+		}
+		request := &shared.RespondDecisionTaskCompletedRequest{
+			Decisions: []*shared.Decision{{
+				DecisionType:                           shared.DecisionTypeScheduleActivityTask.Ptr(),
+				ScheduleActivityTaskDecisionAttributes: attr,
+			}},
+		}
+		task := &shared.PollForDecisionTaskResponse{WorkflowType: &shared.WorkflowType{Name: common.StringPtr("workflow")}}
+		poller := worker.workflowWorker.poller.(*workflowTaskPoller)
+		_, err := poller.RespondTaskCompletedWithMetrics(request, nil, task, time.Now())
+		s.NoError(err)
+		return attr
+	}
+
+	worker := createWorkerWithThrottle(s.T(), s.service, 0, WorkerOptions{})
+	s.NotNil(worker.locallyDispatchedActivityWorker)
+
+	worker = createWorkerWithThrottle(s.T(), s.service, 0, WorkerOptions{DisableActivityLocalDispatch: true})
+	s.NotNil(worker.activityWorker)
+	s.Nil(worker.locallyDispatchedActivityWorker)
+	s.False(completeDecision(worker).GetRequestLocalDispatch())
+
+	// local dispatch would bypass the task list rate limit
+	worker = createWorkerWithThrottle(s.T(), s.service, 500, WorkerOptions{})
+	s.Nil(worker.locallyDispatchedActivityWorker)
+	s.False(completeDecision(worker).GetRequestLocalDispatch())
+}
+
+func (s *internalWorkerTestSuite) TestCreateWorker_WithHostSpecificTaskList() {
+	worker := createWorkerWithThrottle(s.T(), s.service, 0, WorkerOptions{EnableHostSpecificTaskList: true})
+	s.NotNil(worker.hostSpecificActivityWorker)
+	s.Equal(GetHostSpecificTaskList("testGroupName2"), worker.hostSpecificActivityWorker.executionParameters.TaskList)
+	err := worker.Start()
+	require.NoError(s.T(), err)
+	time.Sleep(time.Millisecond * 200)
+	worker.Stop()
+}
+
+func (s *internalWorkerTestSuite) TestCreateWorker_HealthAndLifecycleHooks() {
+	var started, stopped atomic.Bool
+	worker := createWorkerWithThrottle(s.T(), s.service, 0, WorkerOptions{
+		OnStart: func() { started.Store(true) },
+		OnStop:  func() { stopped.Store(true) },
+	})
+	s.Empty(worker.Health().Pollers)
+
+	// health can be probed while the worker starts
+	probeDone := make(chan struct{})
+	go func() {
+		defer close(probeDone)
+		for len(worker.Health().Pollers) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	err := worker.Start()
+	require.NoError(s.T(), err)
+	<-probeDone
+	s.True(started.Load())
+	s.Eventually(func() bool {
+		for _, p := range worker.Health().Pollers {
+			// locally dispatched activity pollers only return with a dispatched activity
+			if p.WorkerType != "LocallyDispatchedActivityWorker" && p.LastSuccessfulPollTime.IsZero() {
+				return false
+			}
+		}
+		return true
+	}, time.Second, 10*time.Millisecond)
+	health := worker.Health()
+	s.NotEmpty(health.Pollers)
+	s.Equal(len(worker.registry.GetRegisteredWorkflowTypes()), health.RegisteredWorkflows)
+	s.Positive(health.RegisteredActivities) // session activities
+	var workerTypes []string
+	for _, p := range health.Pollers {
+		s.True(p.Running, p.WorkerType)
+		s.NoError(p.LastPollError)
+		workerTypes = append(workerTypes, p.WorkerType)
+	}
+	s.Contains(workerTypes, "LocallyDispatchedActivityWorker")
+
+	worker.Stop()
+	s.True(stopped.Load())
+	for _, p := range worker.Health().Pollers {
+		s.False(p.Running, p.WorkerType)
+	}
+}
+
+func (s *internalWorkerTestSuite) TestCreateWorker_WithServiceWrapper() {
+	wrapped := &pollCountingService{}
+	worker := createWorkerWithThrottle(s.T(), s.service, 0, WorkerOptions{
+		ServiceWrapper: func(service workflowserviceclient.Interface) workflowserviceclient.Interface {
+			wrapped.Interface = service
+			return wrapped
+		},
+	})
+	err := worker.Start()
+	require.NoError(s.T(), err)
+	s.Eventually(func() bool { return wrapped.activityPolls.Load() > 0 }, time.Second, 10*time.Millisecond)
+	worker.Stop()
+}
+
+func (s *internalWorkerTestSuite) TestCreateWorker_WithMetricsPrefixAndTags() {
+	scope := tally.NewTestScope("", nil)
+	worker := createWorkerWithThrottle(s.T(), s.service, 0, WorkerOptions{
+		MetricsScope:  scope,
+		MetricsPrefix: "service",
+		MetricsTags:   map[string]string{"env": "test", tagDomain: "overridden"},
+	})
+	err := worker.Start()
+	require.NoError(s.T(), err)
+	worker.Stop()
+
+	var found bool
+	for _, counter := range scope.Snapshot().Counters() {
+		if counter.Name() != "service."+metrics.WorkerStartCounter {
+			continue
+		}
+		found = true
+		s.Equal("test", counter.Tags()["env"])
+		s.Equal("testDomain", counter.Tags()[tagDomain])
+	}
+	s.True(found, "worker start counter not emitted with prefix")
+}
+
 func (s *internalWorkerTestSuite) TestCreateWorker_WithAutoScaler() {
 	worker := createWorkerWithAutoscaler(s.T(), s.service)
 	err := worker.Start()
@@ -258,6 +390,21 @@ func (s *internalWorkerTestSuite) TestCreateWorkerRun() {
 	wg.Wait()
 }
 
+func (s *internalWorkerTestSuite) TestCreateWorkerRunWithInterrupt() {
+	var stopped bool
+	worker := createWorkerWithThrottle(s.T(), s.service, 0, WorkerOptions{OnStop: func() { stopped = true }})
+	interruptCh := make(chan interface{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- worker.RunWithInterrupt(interruptCh)
+	}()
+	time.Sleep(time.Millisecond * 200)
+	s.False(stopped)
+	close(interruptCh)
+	s.NoError(<-errCh)
+	s.True(stopped)
+}
+
 func (s *internalWorkerTestSuite) TestNoActivitiesOrWorkflows() {
 	t := s.T()
 	w := createWorker(s.T(), s.service)
@@ -365,6 +512,21 @@ func createShadowWorker(
 	})
 }
 
+// pollCountingService counts the activity polls passing through it
+type pollCountingService struct {
+	workflowserviceclient.Interface
+	activityPolls atomic.Int32
+}
+
+func (s *pollCountingService) PollForActivityTask(
+	ctx context.Context,
+	request *shared.PollForActivityTaskRequest,
+	opts ...yarpc.CallOption,
+) (*shared.PollForActivityTaskResponse, error) {
+	s.activityPolls.Inc()
+	return s.Interface.PollForActivityTask(ctx, request, opts...)
+}
+
 func createWorkerWithThrottle(
 	t *testing.T,
 	service *workflowservicetest.MockClient,
@@ -869,6 +1031,28 @@ func TestRegisterActivityWithOptions(t *testing.T) {
 	fn := a[0].GetFunction()
 	assert.Equal(t, reflect.Func, reflect.ValueOf(fn).Kind())
 	assert.Equal(t, getFunctionName(testActivityMultipleArgs), runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name())
+	assert.True(t, a[0].GetOptions().EnableShortName)
+}
+
+func TestGetRegisteredTypes_SortedWithOptions(t *testing.T) {
+	r := newRegistry()
+	w := &aggregatedWorker{registry: r}
+	w.RegisterWorkflowWithOptions(testWorkflowSample, RegisterWorkflowOptions{Name: "b-workflow"})
+	w.RegisterWorkflowWithOptions(testWorkflowNoArgs, RegisterWorkflowOptions{Name: "a-workflow"})
+	w.RegisterActivityWithOptions(testActivityMultipleArgs, RegisterActivityOptions{Name: "b-activity", MaxConcurrentExecutionSize: 2})
+	w.RegisterActivityWithOptions(testActivityNoResult, RegisterActivityOptions{Name: "a-activity"})
+
+	wfs := w.GetRegisteredWorkflows()
+	require.Len(t, wfs, 2)
+	assert.Equal(t, "a-workflow", wfs[0].WorkflowType().Name)
+	assert.Equal(t, "b-workflow", wfs[1].WorkflowType().Name)
+	assert.Equal(t, RegisterWorkflowOptions{Name: "b-workflow"}, wfs[1].GetOptions())
+
+	activities := w.GetRegisteredActivities()
+	require.Len(t, activities, 2)
+	assert.Equal(t, "a-activity", activities[0].ActivityType().Name)
+	assert.Equal(t, "b-activity", activities[1].ActivityType().Name)
+	assert.Equal(t, 2, activities[1].GetOptions().MaxConcurrentExecutionSize)
 }
 
 type testErrorDetails struct {
@@ -1347,6 +1531,7 @@ func Test_augmentWorkerOptions(t *testing.T) {
 				DisableActivityWorker:                   false,
 				DisableStickyExecution:                  false,
 				StickyScheduleToStartTimeout:            time.Minute * 4,
+				TaskPollTimeout:                         pollTaskServiceTimeOut,
 				BackgroundActivityContext:               context.Background(),
 				NonDeterministicWorkflowPolicy:          NonDeterministicWorkflowPolicyBlockWorkflow,
 				DataConverter:                           DefaultDataConverter,
@@ -1388,6 +1573,7 @@ func Test_augmentWorkerOptions(t *testing.T) {
 				DisableActivityWorker:                   false,
 				DisableStickyExecution:                  false,
 				StickyScheduleToStartTimeout:            time.Second * 5,
+				TaskPollTimeout:                         pollTaskServiceTimeOut,
 				BackgroundActivityContext:               nil,
 				NonDeterministicWorkflowPolicy:          NonDeterministicWorkflowPolicyBlockWorkflow,
 				DataConverter:                           DefaultDataConverter,
@@ -1498,3 +1684,69 @@ func TestTestValidateFnFormat_Workflow(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkerStop_CancelsPolls(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	service := workflowservicetest.NewMockClient(mockCtrl)
+	service.EXPECT().DescribeDomain(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.DescribeDomainResponse{}, nil).AnyTimes()
+	polling := make(chan struct{}, 1)
+	canceled := make(chan struct{}, 1)
+	service.EXPECT().PollForActivityTask(gomock.Any(), gomock.Any(), callOptions()...).DoAndReturn(
+		func(ctx context.Context, _ *shared.PollForActivityTaskRequest, _ ...yarpc.CallOption) (*shared.PollForActivityTaskResponse, error) {
+			select {
+			case polling <- struct{}{}:
+			default:
+			}
+			<-ctx.Done()
+			select {
+			case canceled <- struct{}{}:
+			default:
+			}
+			return nil, ctx.Err()
+		}).AnyTimes()
+
+	worker, err := newAggregatedWorker(service, "domain", "tasklist", WorkerOptions{
+		Logger:                           testlogger.NewZap(t),
+		DisableWorkflowWorker:            true,
+		MaxConcurrentActivityTaskPollers: 1,
+		TaskPollTimeout:                  time.Minute,
+	})
+	require.NoError(t, err)
+	worker.RegisterActivityWithOptions(func(ctx context.Context) error { return nil }, RegisterActivityOptions{Name: "activity"})
+	require.NoError(t, worker.Start())
+	<-polling
+
+	start := time.Now()
+	worker.Stop()
+	assert.Less(t, time.Since(start), 5*time.Second, "stop waited for the long poll")
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("poll context was not canceled on stop")
+	}
+}
+
+func TestWorkerHeaders_SentWithEveryCall(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	service := workflowservicetest.NewMockClient(mockCtrl)
+	headerOption := append(callOptions(), gomock.Any())
+	service.EXPECT().DescribeDomain(gomock.Any(), gomock.Any(), headerOption...).Return(&shared.DescribeDomainResponse{}, nil).AnyTimes()
+	polled := make(chan struct{})
+	var pollOnce sync.Once
+	service.EXPECT().PollForActivityTask(gomock.Any(), gomock.Any(), headerOption...).DoAndReturn(
+		func(context.Context, *shared.PollForActivityTaskRequest, ...yarpc.CallOption) (*shared.PollForActivityTaskResponse, error) {
+			pollOnce.Do(func() { close(polled) })
+			return &shared.PollForActivityTaskResponse{}, nil
+		}).AnyTimes()
+
+	worker, err := newAggregatedWorker(service, "domain", "tasklist", WorkerOptions{
+		Logger:                testlogger.NewZap(t),
+		DisableWorkflowWorker: true,
+		Headers:               map[string]string{"routing-key": "a"},
+	})
+	require.NoError(t, err)
+	worker.RegisterActivityWithOptions(func(ctx context.Context) error { return nil }, RegisterActivityOptions{Name: "activity"})
+	require.NoError(t, worker.Start())
+	defer worker.Stop()
+	<-polled
+}
@@ -29,6 +29,7 @@ import (
 	"reflect"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -80,6 +81,23 @@ type (
 		settable Settable // used to unblock the future when all coroutines have completed
 	}
 
+	// Implements Semaphore interface
+	semaphoreImpl struct {
+		size     int64              // the number of permits of the semaphore
+		acquired int64              // the number of permits currently held
+		waiters  []*semaphoreWaiter // coroutines blocked in Acquire, in the order they arrived
+	}
+
+	semaphoreWaiter struct {
+		n        int64 // the number of permits to acquire
+		acquired bool  // set once the permits are handed over to the waiter
+	}
+
+	// Implements Mutex interface
+	mutexImpl struct {
+		semaphore *semaphoreImpl
+	}
+
 	// Dispatcher is a container of a set of coroutines.
 	dispatcher interface {
 		// ExecuteUntilAllBlocked executes coroutines one by one in deterministic order
@@ -89,6 +107,8 @@ type (
 		IsDone() bool
 		Close()             // Destroys all coroutines without waiting for their completion
 		StackTrace() string // Stack trace of all coroutines owned by the Dispatcher instance
+		// Stats returns the statistics of the last ExecuteUntilAllBlocked call
+		Stats() DispatcherStats
 	}
 
 	// Workflow is an interface that any workflow should implement.
@@ -100,6 +120,7 @@ type (
 		Execute(ctx Context, input []byte) (result []byte, err error)
 		WorkflowType() WorkflowType
 		GetFunction() interface{}
+		GetOptions() RegisterWorkflowOptions
 	}
 
 	sendCallback struct {
@@ -140,12 +161,21 @@ type (
 		name        string
 		cases       []*selectCase // cases that this select is comprised from
 		defaultFunc *func()       // default case
+		fair        bool          // rotates the first checked case after every selected case
+		nextCase    int           // index of the case checked first by a fair selector
 	}
 
 	// unblockFunc is passed evaluated by a coroutine yield. When it returns false the yield returns to a caller.
 	// stackDepth is the depth of stack from the last blocking call relevant to user.
 	// Used to truncate internal stack frames from thread stack.
-	unblockFunc func(status string, stackDepth int) (keepBlocked bool)
+	unblockFunc func(status coroutineStatus, stackDepth int) (keepBlocked bool)
+
+	// coroutineStatus describes why a coroutine yielded. It is formatted only when a stack trace is requested,
+	// so blocking on a channel or selector doesn't allocate a status string on every yield.
+	coroutineStatus struct {
+		blockedOn string // name of the channel or selector the coroutine is blocked on, if any
+		operation string
+	}
 
 	coroutineState struct {
 		name         string
@@ -166,6 +196,7 @@ type (
 		executing        bool       // currently running ExecuteUntilAllBlocked. Used to avoid recursive calls to it.
 		mutex            sync.Mutex // used to synchronize executing
 		closed           bool
+		stats            DispatcherStats // statistics of the last ExecuteUntilAllBlocked call
 	}
 
 	// The current timeout resolution implementation is in seconds and uses math.Ceil() as the duration. But is
@@ -252,6 +283,8 @@ const (
 var _ Channel = (*channelImpl)(nil)
 var _ Selector = (*selectorImpl)(nil)
 var _ WaitGroup = (*waitGroupImpl)(nil)
+var _ Semaphore = (*semaphoreImpl)(nil)
+var _ Mutex = (*mutexImpl)(nil)
 var _ dispatcher = (*dispatcherImpl)(nil)
 
 var stackBuf [100000]byte
@@ -439,6 +472,12 @@ func newWorkflowContext(env workflowEnvironment, interceptors WorkflowIntercepto
 	rootCtx = WithDataConverter(rootCtx, env.GetDataConverter())
 	rootCtx = withContextPropagators(rootCtx, env.GetContextPropagators())
 	getActivityOptions(rootCtx).OriginalTaskListName = wInfo.TaskListName
+	if defaults := env.GetDefaultActivityOptions(); defaults != nil {
+		if defaults.TaskList != "" {
+			rootCtx = WithTaskList(rootCtx, defaults.TaskList)
+		}
+		getActivityOptions(rootCtx).defaults = defaults
+	}
 
 	return rootCtx
 }
@@ -542,6 +581,7 @@ func newDispatcher(rootCtx Context, root func(ctx Context)) (*dispatcherImpl, Co
 func executeDispatcher(ctx Context, dispatcher dispatcher) {
 	env := getWorkflowEnvironment(ctx)
 	panicErr := dispatcher.ExecuteUntilAllBlocked()
+	reportDispatcherStats(env, dispatcher.Stats())
 	if panicErr != nil {
 		env.Complete(nil, panicErr)
 		return
@@ -562,6 +602,14 @@ func executeDispatcher(ctx Context, dispatcher dispatcher) {
 	env.Complete(rp.workflowResult, rp.error)
 }
 
+func reportDispatcherStats(env workflowEnvironment, stats DispatcherStats) {
+	scope := env.GetMetricsScope()
+	scope.Gauge(metrics.WorkflowCoroutines).Update(float64(stats.Coroutines))
+	scope.Histogram(metrics.WorkflowCoroutineRuns, eventCountBuckets).RecordValue(float64(stats.RunQueueLength))
+	scope.Timer(metrics.WorkflowCoroutineMaxRunTime).Record(stats.MaxCoroutineRunTime)
+	env.ReportDispatcherStats(stats)
+}
+
 // For troubleshooting stack pretty printing only.
 // Set to true to see full stack trace that includes framework methods.
 const disableCleanStackTraces = false
@@ -586,6 +634,27 @@ func getState(ctx Context) *coroutineState {
 
 func (c *channelImpl) Receive(ctx Context, valuePtr interface{}) (more bool) {
 	state := getState(ctx)
+	// Fast path that doesn't allocate a receive callback when a value is already available.
+	for {
+		v, ok, m := c.receiveAsyncImpl(nil)
+		if !ok && !m { // channel closed and empty
+			return m
+		}
+		if !ok {
+			break
+		}
+		err := c.assignValue(v, valuePtr)
+		if err == nil {
+			state.unblocked()
+			return m
+		}
+		// corrupt signal. Drop and reset process
+	}
+	return c.receiveBlocking(state, valuePtr)
+}
+
+// receiveBlocking registers a receive callback with the channel and blocks until a value is delivered to it
+func (c *channelImpl) receiveBlocking(state *coroutineState, valuePtr interface{}) (more bool) {
 	hasResult := false
 	var result interface{}
 	callback := &receiveCallback{
@@ -622,12 +691,23 @@ func (c *channelImpl) Receive(ctx Context, valuePtr interface{}) (more bool) {
 				}
 				break // Corrupt signal. Drop and reset process.
 			}
-			state.yield(fmt.Sprintf("blocked on %s.Receive", c.name))
+			state.yieldBlockedOn(1, c.name, "Receive") // omit receiveBlocking frame
 		}
 	}
 
 }
 
+func (c *channelImpl) Len() int {
+	if c.recValue != nil {
+		return len(c.buffer) + 1
+	}
+	return len(c.buffer)
+}
+
+func (c *channelImpl) Cap() int {
+	return c.size
+}
+
 func (c *channelImpl) ReceiveAsync(valuePtr interface{}) (ok bool) {
 	ok, _ = c.ReceiveAsyncWithMoreFlag(valuePtr)
 	return ok
@@ -735,7 +815,7 @@ func (c *channelImpl) Send(ctx Context, v interface{}) {
 		if c.closed {
 			panic("Closed channel")
 		}
-		state.yield(fmt.Sprintf("blocked on %s.Send", c.name))
+		state.yieldBlockedOn(0, c.name, "Send")
 	}
 }
 
@@ -790,7 +870,7 @@ func (c *channelImpl) assignValue(from interface{}, to interface{}) error {
 // initialYield called at the beginning of the coroutine execution
 // stackDepth is the depth of top of the stack to omit when stack trace is generated
 // to hide frames internal to the framework.
-func (s *coroutineState) initialYield(stackDepth int, status string) {
+func (s *coroutineState) initialYield(stackDepth int, status coroutineStatus) {
 	if s.blocked.Swap(true) {
 		panic("trying to block on coroutine which is already blocked, most likely a wrong Context is used to do blocking" +
 			" call (like Future.Get() or Channel.Receive()")
@@ -807,10 +887,25 @@ func (s *coroutineState) initialYield(stackDepth int, status string) {
 // this call blocks
 func (s *coroutineState) yield(status string) {
 	s.aboutToBlock <- true
-	s.initialYield(3, status) // omit three levels of stack. To adjust change to 0 and count the lines to remove.
+	s.initialYield(3, coroutineStatus{operation: status}) // omit three levels of stack. To adjust change to 0 and count the lines to remove.
 	s.keptBlocked = true
 }
 
+// yieldBlockedOn is yield for a coroutine blocked on an operation of the named channel or selector.
+// internalFrames is the number of additional framework frames between the operation and this call.
+func (s *coroutineState) yieldBlockedOn(internalFrames int, name, operation string) {
+	s.aboutToBlock <- true
+	s.initialYield(3+internalFrames, coroutineStatus{blockedOn: name, operation: operation}) // same stack depth as in yield
+	s.keptBlocked = true
+}
+
+func (s coroutineStatus) String() string {
+	if s.blockedOn == "" {
+		return s.operation
+	}
+	return "blocked on " + s.blockedOn + "." + s.operation
+}
+
 func getStackTrace(coroutineName, status string, stackDepth int) string {
 	top := fmt.Sprintf("coroutine %s [%s]:", coroutineName, status)
 	// Omit top stackDepth frames + top status line.
@@ -837,7 +932,7 @@ func (s *coroutineState) unblocked() {
 }
 
 func (s *coroutineState) call() {
-	s.unblock <- func(status string, stackDepth int) bool {
+	s.unblock <- func(status coroutineStatus, stackDepth int) bool {
 		return false // unblock
 	}
 	<-s.aboutToBlock
@@ -850,7 +945,7 @@ func (s *coroutineState) close() {
 
 func (s *coroutineState) exit() {
 	if !s.closed {
-		s.unblock <- func(status string, stackDepth int) bool {
+		s.unblock <- func(status coroutineStatus, stackDepth int) bool {
 			runtime.Goexit()
 			return true
 		}
@@ -862,15 +957,15 @@ func (s *coroutineState) stackTrace() string {
 		return ""
 	}
 	stackCh := make(chan string, 1)
-	s.unblock <- func(status string, stackDepth int) bool {
-		stackCh <- getStackTrace(s.name, status, stackDepth+2)
+	s.unblock <- func(status coroutineStatus, stackDepth int) bool {
+		stackCh <- getStackTrace(s.name, status.String(), stackDepth+2)
 		return true
 	}
 	return <-stackCh
 }
 
 func (d *dispatcherImpl) newCoroutine(ctx Context, f func(ctx Context)) Context {
-	return d.newNamedCoroutine(ctx, fmt.Sprintf("%v", d.sequence+1), f)
+	return d.newNamedCoroutine(ctx, strconv.Itoa(d.sequence+1), f)
 }
 
 func (d *dispatcherImpl) newNamedCoroutine(ctx Context, name string, f func(ctx Context)) Context {
@@ -884,7 +979,7 @@ func (d *dispatcherImpl) newNamedCoroutine(ctx Context, name string, f func(ctx
 				crt.panicError = newWorkflowPanicError(r, st)
 			}
 		}()
-		crt.initialYield(1, "")
+		crt.initialYield(1, coroutineStatus{})
 		f(spawned)
 	}(state)
 	return spawned
@@ -912,7 +1007,11 @@ func (d *dispatcherImpl) ExecuteUntilAllBlocked() (err error) {
 	}
 	d.executing = true
 	d.mutex.Unlock()
-	defer func() { d.executing = false }()
+	d.stats = DispatcherStats{}
+	defer func() {
+		d.executing = false
+		d.stats.Coroutines = len(d.coroutines)
+	}()
 	allBlocked := false
 	// Keep executing until at least one goroutine made some progress
 	for !allBlocked {
@@ -924,7 +1023,9 @@ func (d *dispatcherImpl) ExecuteUntilAllBlocked() (err error) {
 			if !c.closed {
 				// TODO: Support handling of panic in a coroutine by dispatcher.
 				// TODO: Dump all outstanding coroutines if one of them panics
+				start := time.Now()
 				c.call()
+				d.recordCoroutineRun(c.name, time.Since(start))
 			}
 			// c.call() can close the context so check again
 			if c.closed {
@@ -950,6 +1051,18 @@ func (d *dispatcherImpl) ExecuteUntilAllBlocked() (err error) {
 	return nil
 }
 
+func (d *dispatcherImpl) recordCoroutineRun(name string, runTime time.Duration) {
+	d.stats.RunQueueLength++
+	if runTime > d.stats.MaxCoroutineRunTime {
+		d.stats.MaxCoroutineRunTime = runTime
+		d.stats.MaxCoroutineName = name
+	}
+}
+
+func (d *dispatcherImpl) Stats() DispatcherStats {
+	return d.stats
+}
+
 func (d *dispatcherImpl) IsDone() bool {
 	return len(d.coroutines) == 0
 }
@@ -1017,7 +1130,13 @@ func (s *selectorImpl) Select(ctx Context) {
 		}
 	}()
 
-	for _, pair := range s.cases {
+	start := 0
+	if s.fair && len(s.cases) > 0 {
+		start = s.nextCase % len(s.cases)
+	}
+	for i := range s.cases {
+		index := (start + i) % len(s.cases)
+		pair := s.cases[index]
 		if pair.receiveFunc != nil {
 			f := *pair.receiveFunc
 			c := pair.channel
@@ -1027,6 +1146,7 @@ func (s *selectorImpl) Select(ctx Context) {
 						return false
 					}
 					readyBranch = func() {
+						s.nextCase = index + 1
 						c.recValue = &v
 						f(c, more)
 					}
@@ -1045,6 +1165,7 @@ func (s *selectorImpl) Select(ctx Context) {
 				if more {
 					c.recValue = &v
 				}
+				s.nextCase = index + 1
 				f(c, more)
 				return
 			}
@@ -1062,6 +1183,7 @@ func (s *selectorImpl) Select(ctx Context) {
 						return false
 					}
 					readyBranch = func() {
+						s.nextCase = index + 1
 						f()
 					}
 					return true
@@ -1074,6 +1196,7 @@ func (s *selectorImpl) Select(ctx Context) {
 				// become ready they won't consume the value for this Select() call.
 				readyBranch = func() {
 				}
+				s.nextCase = index + 1
 				f()
 				return
 			}
@@ -1090,6 +1213,7 @@ func (s *selectorImpl) Select(ctx Context) {
 						return false
 					}
 					readyBranch = func() {
+						s.nextCase = index + 1
 						p.futureFunc = nil
 						f(p.future)
 					}
@@ -1104,6 +1228,7 @@ func (s *selectorImpl) Select(ctx Context) {
 				// become ready they won't consume the value for this Select() call.
 				readyBranch = func() {
 				}
+				s.nextCase = index + 1
 				p.futureFunc = nil
 				f(p.future)
 				return
@@ -1125,7 +1250,7 @@ func (s *selectorImpl) Select(ctx Context) {
 			state.unblocked()
 			return
 		}
-		state.yield(fmt.Sprintf("blocked on %s.Select", s.name))
+		state.yieldBlockedOn(0, s.name, "Select")
 	}
 }
 
@@ -1140,6 +1265,9 @@ func getValidatedWorkflowFunction(workflowFunc interface{}, args []interface{},
 	switch getKind(fType) {
 	case reflect.String:
 		fnName = reflect.ValueOf(workflowFunc).String()
+		if fnName == "" {
+			return nil, nil, errors.New("missing workflow type")
+		}
 
 	case reflect.Func:
 		if err := validateFunctionArgs(workflowFunc, args, true); err != nil {
@@ -1206,6 +1334,61 @@ func validateCronSchedule(cronSchedule string) error {
 	return err
 }
 
+func validateWorkflowTimeoutBounds(options RegisterWorkflowOptions) error {
+	if err := validateTimeoutBounds("ExecutionStartToCloseTimeout", options.MinExecutionStartToCloseTimeout, options.MaxExecutionStartToCloseTimeout); err != nil {
+		return err
+	}
+	return validateTimeoutBounds("DecisionTaskStartToCloseTimeout", options.MinDecisionTaskStartToCloseTimeout, options.MaxDecisionTaskStartToCloseTimeout)
+}
+
+func validateTimeoutBounds(name string, min, max time.Duration) error {
+	if min < 0 || max < 0 {
+		return fmt.Errorf("negative %v bound provided", name)
+	}
+	if max > 0 && min > max {
+		return fmt.Errorf("minimum %v %v is greater than maximum %v", name, min, max)
+	}
+	return nil
+}
+
+// validateWorkflowTimeouts checks the timeouts of a workflow start request against the bounds the workflow type
+// was registered with, if any.
+func validateWorkflowTimeouts(r *registry, workflowType string, executionTimeoutSeconds, decisionTaskTimeoutSeconds int32) error {
+	if r == nil {
+		return nil
+	}
+	options, ok := r.getWorkflowOptions(workflowType)
+	if !ok {
+		return nil
+	}
+	if err := checkTimeoutBounds(
+		workflowType,
+		"ExecutionStartToCloseTimeout",
+		time.Duration(executionTimeoutSeconds)*time.Second,
+		options.MinExecutionStartToCloseTimeout,
+		options.MaxExecutionStartToCloseTimeout,
+	); err != nil {
+		return err
+	}
+	return checkTimeoutBounds(
+		workflowType,
+		"DecisionTaskStartToCloseTimeout",
+		time.Duration(decisionTaskTimeoutSeconds)*time.Second,
+		options.MinDecisionTaskStartToCloseTimeout,
+		options.MaxDecisionTaskStartToCloseTimeout,
+	)
+}
+
+func checkTimeoutBounds(workflowType, name string, timeout, min, max time.Duration) error {
+	if min > 0 && timeout < min {
+		return fmt.Errorf("%v %v of workflow type %v is below the registered minimum %v", name, timeout, workflowType, min)
+	}
+	if max > 0 && timeout > max {
+		return fmt.Errorf("%v %v of workflow type %v is above the registered maximum %v", name, timeout, workflowType, max)
+	}
+	return nil
+}
+
 func getWorkflowEnvOptions(ctx Context) *workflowOptions {
 	options := ctx.Value(workflowEnvOptionsContextKey)
 	if options != nil {
@@ -1471,3 +1654,86 @@ func (wg *waitGroupImpl) Wait(ctx Context) {
 	}
 	wg.future, wg.settable = NewFuture(ctx)
 }
+
+// Acquire blocks until n permits are acquired, waiters are served in FIFO order
+// so that a coroutine releasing and re-acquiring permits cannot starve the others.
+func (s *semaphoreImpl) Acquire(ctx Context, n int64) error {
+	if n <= 0 {
+		return fmt.Errorf("cannot acquire %d permits, the number of permits must be positive", n)
+	}
+	if n > s.size {
+		return fmt.Errorf("cannot acquire %d permits from a semaphore of size %d", n, s.size)
+	}
+	if s.TryAcquire(n) {
+		return nil
+	}
+
+	waiter := &semaphoreWaiter{n: n}
+	s.waiters = append(s.waiters, waiter)
+	if err := Await(ctx, func() bool { return waiter.acquired }); err != nil {
+		for i, w := range s.waiters {
+			if w == waiter {
+				s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+				break
+			}
+		}
+		// the canceled waiter may have been blocking smaller requests behind it
+		s.notifyWaiters()
+		return err
+	}
+	return nil
+}
+
+// TryAcquire acquires n permits if they are available and no coroutine is waiting for permits
+func (s *semaphoreImpl) TryAcquire(n int64) bool {
+	if n <= 0 {
+		panic("semaphore acquired a non-positive number of permits")
+	}
+	if len(s.waiters) > 0 || s.size-s.acquired < n {
+		return false
+	}
+	s.acquired += n
+	return true
+}
+
+// Release releases n permits and hands them over to the waiting coroutines
+func (s *semaphoreImpl) Release(n int64) {
+	if n <= 0 {
+		panic("semaphore released a non-positive number of permits")
+	}
+	if n > s.acquired {
+		panic("semaphore released more permits than held")
+	}
+	s.acquired -= n
+	s.notifyWaiters()
+}
+
+func (s *semaphoreImpl) notifyWaiters() {
+	for len(s.waiters) > 0 {
+		waiter := s.waiters[0]
+		if s.size-s.acquired < waiter.n {
+			return
+		}
+		s.acquired += waiter.n
+		waiter.acquired = true
+		s.waiters = s.waiters[1:]
+	}
+}
+
+// Lock blocks until the mutex is acquired
+func (m *mutexImpl) Lock(ctx Context) error {
+	return m.semaphore.Acquire(ctx, 1)
+}
+
+// TryLock acquires the mutex if it is not locked
+func (m *mutexImpl) TryLock() bool {
+	return m.semaphore.TryAcquire(1)
+}
+
+// Unlock releases the mutex
+func (m *mutexImpl) Unlock() {
+	if m.semaphore.acquired == 0 {
+		panic("unlock of unlocked Mutex")
+	}
+	m.semaphore.Release(1)
+}
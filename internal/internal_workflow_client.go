@@ -27,6 +27,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"go.uber.org/cadence/internal/common/serializer"
@@ -83,6 +84,11 @@ type (
 		// error. This is a blocking API.
 		Get(ctx context.Context, valuePtr interface{}) error
 
+		// GetWithOptions behaves like Get, with options controlling how the result is awaited.
+		// With DisableFollowingRuns set, a run that continued as new returns *ContinueAsNewError
+		// describing the new run instead of waiting for it.
+		GetWithOptions(ctx context.Context, valuePtr interface{}, options WorkflowRunGetOptions) error
+
 		// NOTE: if the started workflow return ContinueAsNewError during the workflow execution, the
 		// return result of GetRunID() will be the started workflow run ID, not the new run ID caused by ContinueAsNewError,
 		// however, Get(ctx context.Context, valuePtr interface{}) will return result from the run which did not return ContinueAsNewError.
@@ -92,6 +98,13 @@ type (
 		// NOTE: DO NOT USE client.ExecuteWorkflow API INSIDE A WORKFLOW, USE workflow.ExecuteChildWorkflow instead
 	}
 
+	// WorkflowRunGetOptions are the options for WorkflowRun.GetWithOptions.
+	WorkflowRunGetOptions struct {
+		// DisableFollowingRuns stops Get from following the workflow to the new run when the awaited run
+		// continued as new. By default the result of the last run of the workflow is returned.
+		DisableFollowingRuns bool
+	}
+
 	// workflowRunImpl is an implementation of WorkflowRun
 	workflowRunImpl struct {
 		workflowFn    interface{}
@@ -154,34 +167,73 @@ func (wc *workflowClient) StartWorkflow(
 	}
 
 	var response *s.StartWorkflowExecutionResponse
+	deduplicated := false
 
 	// Start creating workflow request.
-	err = backoff.Retry(ctx,
+	err = retryWhileTransientError(ctx,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
 
 			var err1 error
 			response, err1 = wc.workflowService.StartWorkflowExecution(tchCtx, startRequest, opt...)
+			if alreadyStarted, ok := err1.(*s.WorkflowExecutionAlreadyStartedError); ok &&
+				alreadyStarted.GetStartRequestId() == startRequest.GetRequestId() {
+				// an earlier attempt of this request has already started the execution, e.g. the
+				// response was lost to a transport error, so report that execution instead of failing.
+				response = &s.StartWorkflowExecutionResponse{RunId: alreadyStarted.RunId}
+				deduplicated = true
+				return nil
+			}
 			return err1
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 
 	if err != nil {
-		return nil, err
-	}
-
-	if wc.metricsScope != nil {
+		alreadyStarted, ok := err.(*s.WorkflowExecutionAlreadyStartedError)
+		if !ok || !options.TreatAlreadyStartedAsSuccess || alreadyStarted.GetRunId() == "" {
+			return nil, err
+		}
+		response = &s.StartWorkflowExecutionResponse{RunId: alreadyStarted.RunId}
+	} else if wc.metricsScope != nil {
 		scope := wc.metricsScope.GetTaggedScope(tagTaskList, options.TaskList, tagWorkflowType, *startRequest.WorkflowType.Name)
 		scope.Counter(metrics.WorkflowStartCounter).Inc(1)
 	}
 
 	executionInfo := &WorkflowExecution{
-		ID:    *startRequest.WorkflowId,
-		RunID: response.GetRunId(),
+		ID:           *startRequest.WorkflowId,
+		RunID:        response.GetRunId(),
+		Deduplicated: deduplicated,
+	}
+	if options.WaitForDecisionTaskStarted > 0 {
+		if err := wc.waitForDecisionTaskStarted(ctx, executionInfo, options.WaitForDecisionTaskStarted); err != nil {
+			return executionInfo, err
+		}
 	}
 	return executionInfo, nil
 }
 
+// waitForDecisionTaskStarted long polls the history of the execution until its first
+// DecisionTaskStarted event, the workflow closes or the timeout elapses.
+func (wc *workflowClient) waitForDecisionTaskStarted(ctx context.Context, execution *WorkflowExecution, timeout time.Duration) error {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	iter := wc.GetWorkflowHistory(waitCtx, execution.ID, execution.RunID, true, s.HistoryEventFilterTypeAllEvent)
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			if ctx.Err() == nil && waitCtx.Err() != nil {
+				return ErrDecisionTaskNotStarted
+			}
+			return err
+		}
+		if event.GetEventType() == s.EventTypeDecisionTaskStarted {
+			return nil
+		}
+	}
+	return ErrDecisionTaskNotStarted
+}
+
 // StartWorkflowAsync behaves like StartWorkflow except that the request is queued and processed by Cadence backend asynchronously.
 // See StartWorkflow for details about inputs and usage.
 func (wc *workflowClient) StartWorkflowAsync(
@@ -200,7 +252,7 @@ func (wc *workflowClient) StartWorkflowAsync(
 	}
 
 	// Start creating workflow request.
-	err = backoff.Retry(ctx,
+	err = retryWhileTransientError(ctx,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
@@ -208,7 +260,7 @@ func (wc *workflowClient) StartWorkflowAsync(
 			var err1 error
 			_, err1 = wc.workflowService.StartWorkflowExecutionAsync(tchCtx, asyncStartRequest, opt...)
 			return err1
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 
 	if err != nil {
 		return nil, err
@@ -257,8 +309,9 @@ func (wc *workflowClient) ExecuteWorkflow(ctx context.Context, options StartWork
 		workflowID = executionInfo.ID
 	}
 
+	domain := wc.getDomain(ctx)
 	iterFn := func(fnCtx context.Context, fnRunID string) HistoryEventIterator {
-		return wc.GetWorkflowHistory(fnCtx, workflowID, fnRunID, true, s.HistoryEventFilterTypeCloseEvent)
+		return wc.GetWorkflowHistory(WithDomain(fnCtx, domain), workflowID, fnRunID, true, s.HistoryEventFilterTypeCloseEvent)
 	}
 
 	return &workflowRunImpl{
@@ -278,8 +331,9 @@ func (wc *workflowClient) ExecuteWorkflow(ctx context.Context, options StartWork
 // subjected to change in the future.
 func (wc *workflowClient) GetWorkflow(ctx context.Context, workflowID string, runID string) WorkflowRun {
 
+	domain := wc.getDomain(ctx)
 	iterFn := func(fnCtx context.Context, fnRunID string) HistoryEventIterator {
-		return wc.GetWorkflowHistory(fnCtx, workflowID, fnRunID, true, s.HistoryEventFilterTypeCloseEvent)
+		return wc.GetWorkflowHistory(WithDomain(fnCtx, domain), workflowID, fnRunID, true, s.HistoryEventFilterTypeCloseEvent)
 	}
 
 	return &workflowRunImpl{
@@ -298,7 +352,7 @@ func (wc *workflowClient) SignalWorkflow(ctx context.Context, workflowID string,
 	if err != nil {
 		return err
 	}
-	return signalWorkflow(ctx, wc.workflowService, wc.identity, wc.domain, workflowID, runID, signalName, input, wc.featureFlags)
+	return signalWorkflow(ctx, wc.workflowService, wc.identity, wc.getDomain(ctx), workflowID, runID, signalName, input, wc.featureFlags)
 }
 
 // SignalWithStartWorkflow sends a signal to a running workflow.
@@ -320,7 +374,7 @@ func (wc *workflowClient) SignalWithStartWorkflow(
 	var response *s.StartWorkflowExecutionResponse
 
 	// Start creating workflow request.
-	err = backoff.Retry(ctx,
+	err = retryWhileTransientError(ctx,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
@@ -328,7 +382,7 @@ func (wc *workflowClient) SignalWithStartWorkflow(
 			var err1 error
 			response, err1 = wc.workflowService.SignalWithStartWorkflowExecution(tchCtx, signalWithStartRequest, opt...)
 			return err1
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 
 	if err != nil {
 		return nil, err
@@ -366,7 +420,7 @@ func (wc *workflowClient) SignalWithStartWorkflowAsync(
 		Request: signalWithStartRequest,
 	}
 
-	err = backoff.Retry(ctx,
+	err = retryWhileTransientError(ctx,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
@@ -374,7 +428,7 @@ func (wc *workflowClient) SignalWithStartWorkflowAsync(
 			var err1 error
 			_, err1 = wc.workflowService.SignalWithStartWorkflowExecutionAsync(tchCtx, asyncSignalWithStartRequest, opt...)
 			return err1
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 
 	if err != nil {
 		return nil, err
@@ -396,7 +450,7 @@ func (wc *workflowClient) SignalWithStartWorkflowAsync(
 // If runID is omit, it will terminate currently running workflow (if there is one) based on the workflowID.
 func (wc *workflowClient) CancelWorkflow(ctx context.Context, workflowID string, runID string, opts ...Option) error {
 	request := &s.RequestCancelWorkflowExecutionRequest{
-		Domain: common.StringPtr(wc.domain),
+		Domain: common.StringPtr(wc.getDomain(ctx)),
 		WorkflowExecution: &s.WorkflowExecution{
 			WorkflowId: common.StringPtr(workflowID),
 			RunId:      getRunID(runID),
@@ -412,12 +466,12 @@ func (wc *workflowClient) CancelWorkflow(ctx context.Context, workflowID string,
 		}
 	}
 
-	return backoff.Retry(ctx,
+	return retryWhileTransientError(ctx,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
 			return wc.workflowService.RequestCancelWorkflowExecution(tchCtx, request, opt...)
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 }
 
 // TerminateWorkflow terminates a workflow execution.
@@ -425,7 +479,7 @@ func (wc *workflowClient) CancelWorkflow(ctx context.Context, workflowID string,
 // If runID is omit, it will terminate currently running workflow (if there is one) based on the workflowID.
 func (wc *workflowClient) TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details []byte) error {
 	request := &s.TerminateWorkflowExecutionRequest{
-		Domain: common.StringPtr(wc.domain),
+		Domain: common.StringPtr(wc.getDomain(ctx)),
 		WorkflowExecution: &s.WorkflowExecution{
 			WorkflowId: common.StringPtr(workflowID),
 			RunId:      getRunID(runID),
@@ -435,12 +489,12 @@ func (wc *workflowClient) TerminateWorkflow(ctx context.Context, workflowID stri
 		Identity: common.StringPtr(wc.identity),
 	}
 
-	err := backoff.Retry(ctx,
+	err := retryWhileTransientError(ctx,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
 			return wc.workflowService.TerminateWorkflowExecution(tchCtx, request, opt...)
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 
 	return err
 }
@@ -454,7 +508,7 @@ func (wc *workflowClient) GetWorkflowHistory(
 	filterType s.HistoryEventFilterType,
 ) HistoryEventIterator {
 
-	domain := wc.domain
+	domain := wc.getDomain(ctx)
 	paginate := func(nextToken []byte) (*s.GetWorkflowExecutionHistoryResponse, error) {
 		request := &s.GetWorkflowExecutionHistoryRequest{
 			Domain: common.StringPtr(domain),
@@ -560,7 +614,7 @@ func (wc *workflowClient) CompleteActivity(ctx context.Context, taskToken []byte
 			return err0
 		}
 	}
-	request := convertActivityResultToRespondRequest(wc.identity, taskToken, data, err, wc.dataConverter)
+	request := convertActivityResultToRespondRequest(wc.identity, taskToken, data, err, wc.dataConverter, wc.featureFlags)
 	return reportActivityComplete(ctx, wc.workflowService, request, wc.metricsScope, wc.featureFlags)
 }
 
@@ -582,7 +636,7 @@ func (wc *workflowClient) CompleteActivityByID(ctx context.Context, domain, work
 		}
 	}
 
-	request := convertActivityResultToRespondRequestByID(wc.identity, domain, workflowID, runID, activityID, data, err, wc.dataConverter)
+	request := convertActivityResultToRespondRequestByID(wc.identity, domain, workflowID, runID, activityID, data, err, wc.dataConverter, wc.featureFlags)
 	return reportActivityCompleteByID(ctx, wc.workflowService, request, wc.metricsScope, wc.featureFlags)
 }
 
@@ -612,17 +666,17 @@ func (wc *workflowClient) RecordActivityHeartbeatByID(ctx context.Context,
 //   - EntityNotExistError
 func (wc *workflowClient) ListClosedWorkflow(ctx context.Context, request *s.ListClosedWorkflowExecutionsRequest) (*s.ListClosedWorkflowExecutionsResponse, error) {
 	if len(request.GetDomain()) == 0 {
-		request.Domain = common.StringPtr(wc.domain)
+		request.Domain = common.StringPtr(wc.getDomain(ctx))
 	}
 	var response *s.ListClosedWorkflowExecutionsResponse
-	err := backoff.Retry(ctx,
+	err := retryWhileTransientError(ctx,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
 			response, err1 = wc.workflowService.ListClosedWorkflowExecutions(tchCtx, request, opt...)
 			return err1
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 	if err != nil {
 		return nil, err
 	}
@@ -636,17 +690,17 @@ func (wc *workflowClient) ListClosedWorkflow(ctx context.Context, request *s.Lis
 //   - EntityNotExistError
 func (wc *workflowClient) ListOpenWorkflow(ctx context.Context, request *s.ListOpenWorkflowExecutionsRequest) (*s.ListOpenWorkflowExecutionsResponse, error) {
 	if len(request.GetDomain()) == 0 {
-		request.Domain = common.StringPtr(wc.domain)
+		request.Domain = common.StringPtr(wc.getDomain(ctx))
 	}
 	var response *s.ListOpenWorkflowExecutionsResponse
-	err := backoff.Retry(ctx,
+	err := retryWhileTransientError(ctx,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
 			response, err1 = wc.workflowService.ListOpenWorkflowExecutions(tchCtx, request, opt...)
 			return err1
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 	if err != nil {
 		return nil, err
 	}
@@ -656,17 +710,17 @@ func (wc *workflowClient) ListOpenWorkflow(ctx context.Context, request *s.ListO
 // ListWorkflow implementation
 func (wc *workflowClient) ListWorkflow(ctx context.Context, request *s.ListWorkflowExecutionsRequest) (*s.ListWorkflowExecutionsResponse, error) {
 	if len(request.GetDomain()) == 0 {
-		request.Domain = common.StringPtr(wc.domain)
+		request.Domain = common.StringPtr(wc.getDomain(ctx))
 	}
 	var response *s.ListWorkflowExecutionsResponse
-	err := backoff.Retry(ctx,
+	err := retryWhileTransientError(ctx,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
 			response, err1 = wc.workflowService.ListWorkflowExecutions(tchCtx, request, opt...)
 			return err1
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 	if err != nil {
 		return nil, err
 	}
@@ -676,10 +730,10 @@ func (wc *workflowClient) ListWorkflow(ctx context.Context, request *s.ListWorkf
 // ListArchivedWorkflow implementation
 func (wc *workflowClient) ListArchivedWorkflow(ctx context.Context, request *s.ListArchivedWorkflowExecutionsRequest) (*s.ListArchivedWorkflowExecutionsResponse, error) {
 	if len(request.GetDomain()) == 0 {
-		request.Domain = common.StringPtr(wc.domain)
+		request.Domain = common.StringPtr(wc.getDomain(ctx))
 	}
 	var response *s.ListArchivedWorkflowExecutionsResponse
-	err := backoff.Retry(ctx,
+	err := retryWhileTransientError(ctx,
 		func() error {
 			var err1 error
 			timeout := maxListArchivedWorkflowTimeout
@@ -698,7 +752,7 @@ func (wc *workflowClient) ListArchivedWorkflow(ctx context.Context, request *s.L
 			defer cancel()
 			response, err1 = wc.workflowService.ListArchivedWorkflowExecutions(tchCtx, request, opt...)
 			return err1
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 	if err != nil {
 		return nil, err
 	}
@@ -708,17 +762,17 @@ func (wc *workflowClient) ListArchivedWorkflow(ctx context.Context, request *s.L
 // ScanWorkflow implementation
 func (wc *workflowClient) ScanWorkflow(ctx context.Context, request *s.ListWorkflowExecutionsRequest) (*s.ListWorkflowExecutionsResponse, error) {
 	if len(request.GetDomain()) == 0 {
-		request.Domain = common.StringPtr(wc.domain)
+		request.Domain = common.StringPtr(wc.getDomain(ctx))
 	}
 	var response *s.ListWorkflowExecutionsResponse
-	err := backoff.Retry(ctx,
+	err := retryWhileTransientError(ctx,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
 			response, err1 = wc.workflowService.ScanWorkflowExecutions(tchCtx, request, opt...)
 			return err1
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 	if err != nil {
 		return nil, err
 	}
@@ -728,17 +782,17 @@ func (wc *workflowClient) ScanWorkflow(ctx context.Context, request *s.ListWorkf
 // CountWorkflow implementation
 func (wc *workflowClient) CountWorkflow(ctx context.Context, request *s.CountWorkflowExecutionsRequest) (*s.CountWorkflowExecutionsResponse, error) {
 	if len(request.GetDomain()) == 0 {
-		request.Domain = common.StringPtr(wc.domain)
+		request.Domain = common.StringPtr(wc.getDomain(ctx))
 	}
 	var response *s.CountWorkflowExecutionsResponse
-	err := backoff.Retry(ctx,
+	err := retryWhileTransientError(ctx,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
 			response, err1 = wc.workflowService.CountWorkflowExecutions(tchCtx, request, opt...)
 			return err1
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 	if err != nil {
 		return nil, err
 	}
@@ -748,17 +802,17 @@ func (wc *workflowClient) CountWorkflow(ctx context.Context, request *s.CountWor
 // ResetWorkflow implementation
 func (wc *workflowClient) ResetWorkflow(ctx context.Context, request *s.ResetWorkflowExecutionRequest) (*s.ResetWorkflowExecutionResponse, error) {
 	if len(request.GetDomain()) == 0 {
-		request.Domain = common.StringPtr(wc.domain)
+		request.Domain = common.StringPtr(wc.getDomain(ctx))
 	}
 	var response *s.ResetWorkflowExecutionResponse
-	err := backoff.Retry(ctx,
+	err := retryWhileTransientError(ctx,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
 			response, err1 = wc.workflowService.ResetWorkflowExecution(tchCtx, request, opt...)
 			return err1
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 	if err != nil {
 		return nil, err
 	}
@@ -768,14 +822,14 @@ func (wc *workflowClient) ResetWorkflow(ctx context.Context, request *s.ResetWor
 // GetSearchAttributes implementation
 func (wc *workflowClient) GetSearchAttributes(ctx context.Context) (*s.GetSearchAttributesResponse, error) {
 	var response *s.GetSearchAttributesResponse
-	err := backoff.Retry(ctx,
+	err := retryWhileTransientError(ctx,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
 			response, err1 = wc.workflowService.GetSearchAttributes(tchCtx, opt...)
 			return err1
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 	if err != nil {
 		return nil, err
 	}
@@ -789,21 +843,21 @@ func (wc *workflowClient) GetSearchAttributes(ctx context.Context) (*s.GetSearch
 //   - EntityNotExistError
 func (wc *workflowClient) DescribeWorkflowExecution(ctx context.Context, workflowID, runID string) (*s.DescribeWorkflowExecutionResponse, error) {
 	request := &s.DescribeWorkflowExecutionRequest{
-		Domain: common.StringPtr(wc.domain),
+		Domain: common.StringPtr(wc.getDomain(ctx)),
 		Execution: &s.WorkflowExecution{
 			WorkflowId: common.StringPtr(workflowID),
 			RunId:      common.StringPtr(runID),
 		},
 	}
 	var response *s.DescribeWorkflowExecutionResponse
-	err := backoff.Retry(ctx,
+	err := retryWhileTransientError(ctx,
 		func() error {
 			var err1 error
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
 			response, err1 = wc.workflowService.DescribeWorkflowExecution(tchCtx, request, opt...)
 			return err1
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 	if err != nil {
 		return nil, err
 	}
@@ -847,7 +901,8 @@ type QueryWorkflowWithOptionsRequest struct {
 
 	// QueryType is a required field which specifies the query you want to run.
 	// By default, cadence supports "__stack_trace" as a standard query type, which will return string value
-	// representing the call stack of the target workflow. The target workflow could also setup different query handler to handle custom query types.
+	// representing the call stack of the target workflow. "__query_types" lists the query types the workflow supports and
+	// "__open_sessions" returns the sessions currently open in it. The target workflow could also setup different query handler to handle custom query types.
 	// See comments at workflow.SetQueryHandler(ctx Context, queryType string, handler interface{}) for more details on how to setup query handler within the target workflow.
 	QueryType string
 
@@ -891,7 +946,7 @@ func (wc *workflowClient) QueryWorkflowWithOptions(ctx context.Context, request
 		}
 	}
 	req := &s.QueryWorkflowRequest{
-		Domain: common.StringPtr(wc.domain),
+		Domain: common.StringPtr(wc.getDomain(ctx)),
 		Execution: &s.WorkflowExecution{
 			WorkflowId: common.StringPtr(request.WorkflowID),
 			RunId:      getRunID(request.RunID),
@@ -905,14 +960,14 @@ func (wc *workflowClient) QueryWorkflowWithOptions(ctx context.Context, request
 	}
 
 	var resp *s.QueryWorkflowResponse
-	err := backoff.Retry(ctx,
+	err := retryWhileTransientError(ctx,
 		func() error {
 			tchCtx, cancel, opt := newChannelContextForQuery(ctx, wc.featureFlags)
 			defer cancel()
 			var err error
 			resp, err = wc.workflowService.QueryWorkflow(tchCtx, req, opt...)
 			return err
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 	if err != nil {
 		return nil, err
 	}
@@ -939,20 +994,20 @@ func (wc *workflowClient) QueryWorkflowWithOptions(ctx context.Context, request
 //   - EntityNotExistError
 func (wc *workflowClient) DescribeTaskList(ctx context.Context, tasklist string, tasklistType s.TaskListType) (*s.DescribeTaskListResponse, error) {
 	request := &s.DescribeTaskListRequest{
-		Domain:       common.StringPtr(wc.domain),
+		Domain:       common.StringPtr(wc.getDomain(ctx)),
 		TaskList:     &s.TaskList{Name: common.StringPtr(tasklist)},
 		TaskListType: &tasklistType,
 	}
 
 	var resp *s.DescribeTaskListResponse
-	err := backoff.Retry(ctx,
+	err := retryWhileTransientError(ctx,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
 			var err error
 			resp, err = wc.workflowService.DescribeTaskList(tchCtx, request, opt...)
 			return err
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
 	if err != nil {
 		return nil, err
 	}
@@ -960,6 +1015,101 @@ func (wc *workflowClient) DescribeTaskList(ctx context.Context, tasklist string,
 	return resp, nil
 }
 
+// TaskListBacklogOptions are optional parameters of GetTaskListBacklog.
+type TaskListBacklogOptions struct {
+	// CountOpenWorkflows also counts the open workflows of the task list, which requires advanced visibility.
+	CountOpenWorkflows bool
+
+	// EmitMetrics updates the task-list-decision-backlog, task-list-activity-backlog and, with CountOpenWorkflows,
+	// task-list-open-workflows gauges of the client's metrics scope, tagged with the task list.
+	EmitMetrics bool
+}
+
+// TaskListBacklog is the estimated backlog of a task list returned by GetTaskListBacklog.
+type TaskListBacklog struct {
+	// TaskList is the name of the task list.
+	TaskList string
+
+	// DecisionTasks and ActivityTasks are the numbers of tasks waiting for a worker to poll them, as estimated by
+	// the server.
+	DecisionTasks int64
+	ActivityTasks int64
+
+	// OpenWorkflows is the number of open workflows of the task list. It is only set with CountOpenWorkflows.
+	OpenWorkflows int64
+}
+
+// GetTaskListBacklog estimates the backlog of the decision and activity task lists of the name, e.g. for autoscalers
+// to scale workers on demand.
+// The errors it can return:
+//   - BadRequestError
+//   - InternalServiceError
+//   - EntityNotExistError
+func (wc *workflowClient) GetTaskListBacklog(ctx context.Context, tasklist string, options *TaskListBacklogOptions) (*TaskListBacklog, error) {
+	if options == nil {
+		options = &TaskListBacklogOptions{}
+	}
+	backlog := &TaskListBacklog{TaskList: tasklist}
+	var err error
+	if backlog.DecisionTasks, err = wc.getTaskListBacklogCountHint(ctx, tasklist, s.TaskListTypeDecision); err != nil {
+		return nil, err
+	}
+	if backlog.ActivityTasks, err = wc.getTaskListBacklogCountHint(ctx, tasklist, s.TaskListTypeActivity); err != nil {
+		return nil, err
+	}
+	if options.CountOpenWorkflows {
+		resp, err := wc.CountWorkflow(ctx, &s.CountWorkflowExecutionsRequest{
+			Query: common.StringPtr(fmt.Sprintf(`%v = "%v" and %v = missing`, keyTaskList, escapeQueryValue(tasklist), keyCloseTime)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		backlog.OpenWorkflows = resp.GetCount()
+	}
+
+	if options.EmitMetrics && wc.metricsScope != nil {
+		scope := wc.metricsScope.GetTaggedScope(tagTaskList, tasklist)
+		scope.Gauge(metrics.TaskListDecisionBacklogGauge).Update(float64(backlog.DecisionTasks))
+		scope.Gauge(metrics.TaskListActivityBacklogGauge).Update(float64(backlog.ActivityTasks))
+		if options.CountOpenWorkflows {
+			scope.Gauge(metrics.TaskListOpenWorkflowsGauge).Update(float64(backlog.OpenWorkflows))
+		}
+	}
+	return backlog, nil
+}
+
+// visibilityQueryEscaper escapes the characters that would end a quoted string in a visibility query.
+var visibilityQueryEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `'`, `\'`)
+
+// escapeQueryValue escapes value to be used as a quoted string in a visibility query, so that names containing
+// quotes cannot change the query.
+func escapeQueryValue(value string) string {
+	return visibilityQueryEscaper.Replace(value)
+}
+
+func (wc *workflowClient) getTaskListBacklogCountHint(ctx context.Context, tasklist string, tasklistType s.TaskListType) (int64, error) {
+	request := &s.DescribeTaskListRequest{
+		Domain:                common.StringPtr(wc.getDomain(ctx)),
+		TaskList:              &s.TaskList{Name: common.StringPtr(tasklist)},
+		TaskListType:          &tasklistType,
+		IncludeTaskListStatus: common.BoolPtr(true),
+	}
+
+	var resp *s.DescribeTaskListResponse
+	err := retryWhileTransientError(ctx,
+		func() error {
+			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
+			defer cancel()
+			var err error
+			resp, err = wc.workflowService.DescribeTaskList(tchCtx, request, opt...)
+			return err
+		})
+	if err != nil {
+		return 0, err
+	}
+	return resp.GetTaskListStatus().GetBacklogCountHint(), nil
+}
+
 // RefreshWorkflowTasks refreshes all the tasks of a given workflow.
 // - workflow ID of the workflow.
 // - runID can be default(empty string). if empty string then it will pick the running execution of that workflow ID.
@@ -970,19 +1120,27 @@ func (wc *workflowClient) DescribeTaskList(ctx context.Context, tasklist string,
 //   - EntityNotExistError
 func (wc *workflowClient) RefreshWorkflowTasks(ctx context.Context, workflowID, runID string) error {
 	request := &s.RefreshWorkflowTasksRequest{
-		Domain: common.StringPtr(wc.domain),
+		Domain: common.StringPtr(wc.getDomain(ctx)),
 		Execution: &s.WorkflowExecution{
 			WorkflowId: common.StringPtr(workflowID),
 			RunId:      getRunID(runID),
 		},
 	}
 
-	return backoff.Retry(ctx,
+	return retryWhileTransientError(ctx,
 		func() error {
 			tchCtx, cancel, opt := newChannelContext(ctx, wc.featureFlags)
 			defer cancel()
 			return wc.workflowService.RefreshWorkflowTasks(tchCtx, request, opt...)
-		}, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+		})
+}
+
+// getDomain returns the domain set on the context with WithDomain, or the domain the client was created with.
+func (wc *workflowClient) getDomain(ctx context.Context) string {
+	if domain, ok := ctx.Value(domainContextKey{}).(string); ok && domain != "" {
+		return domain
+	}
+	return wc.domain
 }
 
 func (wc *workflowClient) getWorkflowHeader(ctx context.Context) *s.Header {
@@ -1030,6 +1188,9 @@ func (wc *workflowClient) getWorkflowStartRequest(
 	if err != nil {
 		return nil, err
 	}
+	if err := validateWorkflowTimeouts(wc.registry, workflowType.Name, executionTimeout, decisionTaskTimeout); err != nil {
+		return nil, err
+	}
 
 	memo, err := getWorkflowMemo(options.Memo, wc.dataConverter)
 	if err != nil {
@@ -1074,8 +1235,8 @@ func (wc *workflowClient) getWorkflowStartRequest(
 
 	// run propagators to extract information about tracing and other stuff, store in headers field
 	startRequest := &s.StartWorkflowExecutionRequest{
-		Domain:                              common.StringPtr(wc.domain),
-		RequestId:                           common.StringPtr(uuid.New()),
+		Domain:                              common.StringPtr(wc.getDomain(ctx)),
+		RequestId:                           common.StringPtr(getStartRequestID(options)),
 		WorkflowId:                          common.StringPtr(workflowID),
 		WorkflowType:                        workflowTypePtr(*workflowType),
 		TaskList:                            common.TaskListPtr(s.TaskList{Name: common.StringPtr(options.TaskList)}),
@@ -1137,6 +1298,9 @@ func (wc *workflowClient) getSignalWithStartRequest(
 	if err != nil {
 		return nil, err
 	}
+	if err := validateWorkflowTimeouts(wc.registry, workflowType.Name, executionTimeout, decisionTaskTimeout); err != nil {
+		return nil, err
+	}
 
 	memo, err := getWorkflowMemo(options.Memo, wc.dataConverter)
 	if err != nil {
@@ -1174,8 +1338,8 @@ func (wc *workflowClient) getSignalWithStartRequest(
 	header := wc.getWorkflowHeader(ctx)
 
 	signalWithStartRequest := &s.SignalWithStartWorkflowExecutionRequest{
-		Domain:                              common.StringPtr(wc.domain),
-		RequestId:                           common.StringPtr(uuid.New()),
+		Domain:                              common.StringPtr(wc.getDomain(ctx)),
+		RequestId:                           common.StringPtr(getStartRequestID(options)),
 		WorkflowId:                          common.StringPtr(workflowID),
 		WorkflowType:                        workflowTypePtr(*workflowType),
 		TaskList:                            common.TaskListPtr(s.TaskList{Name: common.StringPtr(options.TaskList)}),
@@ -1263,6 +1427,10 @@ func (workflowRun *workflowRunImpl) GetID() string {
 }
 
 func (workflowRun *workflowRunImpl) Get(ctx context.Context, valuePtr interface{}) error {
+	return workflowRun.GetWithOptions(ctx, valuePtr, WorkflowRunGetOptions{})
+}
+
+func (workflowRun *workflowRunImpl) GetWithOptions(ctx context.Context, valuePtr interface{}, options WorkflowRunGetOptions) error {
 
 	iter := workflowRun.iterFn(ctx, workflowRun.currentRunID)
 	if !iter.HasNext() {
@@ -1298,8 +1466,23 @@ func (workflowRun *workflowRunImpl) Get(ctx context.Context, valuePtr interface{
 		err = NewTimeoutError(attributes.GetTimeoutType())
 	case s.EventTypeWorkflowExecutionContinuedAsNew:
 		attributes := closeEvent.WorkflowExecutionContinuedAsNewEventAttributes
+		if options.DisableFollowingRuns {
+			return &ContinueAsNewError{
+				params: &executeWorkflowParams{
+					workflowOptions: workflowOptions{
+						taskListName:                        common.StringPtr(attributes.TaskList.GetName()),
+						executionStartToCloseTimeoutSeconds: attributes.ExecutionStartToCloseTimeoutSeconds,
+						taskStartToCloseTimeoutSeconds:      attributes.TaskStartToCloseTimeoutSeconds,
+					},
+					workflowType: &WorkflowType{Name: attributes.WorkflowType.GetName()},
+					input:        attributes.Input,
+					header:       attributes.Header,
+				},
+				newRunID: attributes.GetNewExecutionRunId(),
+			}
+		}
 		workflowRun.currentRunID = attributes.GetNewExecutionRunId()
-		return workflowRun.Get(ctx, valuePtr)
+		return workflowRun.GetWithOptions(ctx, valuePtr, options)
 	default:
 		err = fmt.Errorf("Unexpected event type %s when handling workflow execution result", closeEvent.GetEventType())
 	}
@@ -1337,3 +1520,10 @@ func serializeSearchAttributes(input map[string]interface{}) (*s.SearchAttribute
 	}
 	return &s.SearchAttributes{IndexedFields: attr}, nil
 }
+
+func getStartRequestID(options StartWorkflowOptions) string {
+	if options.RequestID != "" {
+		return options.RequestID
+	}
+	return uuid.New()
+}
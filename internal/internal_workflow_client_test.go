@@ -29,12 +29,16 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
 	"go.uber.org/yarpc"
 
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
 	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
 	"go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common"
@@ -445,6 +449,52 @@ func (s *workflowRunSuite) TestExecuteWorkflow_NoDup_Success() {
 	s.Equal(workflowResult, decodedResult)
 }
 
+func (s *workflowRunSuite) TestExecuteWorkflow_WithDomain() {
+	otherDomain := "other-domain"
+	createResponse := &shared.StartWorkflowExecutionResponse{
+		RunId: common.StringPtr(runID),
+	}
+	s.workflowServiceClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), callOptions()...).
+		DoAndReturn(func(_ context.Context, request *shared.StartWorkflowExecutionRequest, _ ...yarpc.CallOption) (*shared.StartWorkflowExecutionResponse, error) {
+			s.Equal(otherDomain, request.GetDomain())
+			return createResponse, nil
+		}).Times(1)
+
+	eventType := shared.EventTypeWorkflowExecutionCompleted
+	encodedResult, _ := encodeArg(getDefaultDataConverter(), "result")
+	getRequest := getGetWorkflowExecutionHistoryRequest(shared.HistoryEventFilterTypeCloseEvent)
+	getRequest.Domain = common.StringPtr(otherDomain)
+	getResponse := &shared.GetWorkflowExecutionHistoryResponse{
+		History: &shared.History{
+			Events: []*shared.HistoryEvent{
+				{
+					EventType: &eventType,
+					WorkflowExecutionCompletedEventAttributes: &shared.WorkflowExecutionCompletedEventAttributes{
+						Result: encodedResult,
+					},
+				},
+			},
+		},
+	}
+	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptions()...).Return(getResponse, nil).Times(1)
+
+	workflowRun, err := s.workflowClient.ExecuteWorkflow(
+		WithDomain(context.Background(), otherDomain),
+		StartWorkflowOptions{
+			ID:                              workflowID,
+			TaskList:                        tasklist,
+			ExecutionStartToCloseTimeout:    timeoutInSeconds * time.Second,
+			DecisionTaskStartToCloseTimeout: timeoutInSeconds * time.Second,
+			WorkflowIDReusePolicy:           workflowIDReusePolicy,
+		}, workflowType,
+	)
+	s.NoError(err)
+	// the run keeps operating on the domain it was started in
+	var result string
+	s.NoError(workflowRun.Get(context.Background(), &result))
+	s.Equal("result", result)
+}
+
 func (s *workflowRunSuite) TestExecuteWorkflow_NoDup_RawHistory_Success() {
 	createResponse := &shared.StartWorkflowExecutionResponse{
 		RunId: common.StringPtr(runID),
@@ -951,6 +1001,38 @@ func (s *workflowRunSuite) TestExecuteWorkflow_NoDup_ContinueAsNew() {
 	s.Equal(workflowResult, decodedResult)
 }
 
+func (s *workflowRunSuite) TestGetWorkflow_DisableFollowingRuns() {
+	newRunID := "some other random run ID"
+	filterType := shared.HistoryEventFilterTypeCloseEvent
+	eventType := shared.EventTypeWorkflowExecutionContinuedAsNew
+	getRequest := getGetWorkflowExecutionHistoryRequest(filterType)
+	getResponse := &shared.GetWorkflowExecutionHistoryResponse{
+		History: &shared.History{
+			Events: []*shared.HistoryEvent{
+				{
+					EventType: &eventType,
+					WorkflowExecutionContinuedAsNewEventAttributes: &shared.WorkflowExecutionContinuedAsNewEventAttributes{
+						NewExecutionRunId: common.StringPtr(newRunID),
+						WorkflowType:      &shared.WorkflowType{Name: common.StringPtr(workflowType)},
+						Input:             []byte("input"),
+					},
+				},
+			},
+		},
+	}
+	// the new run must not be fetched
+	s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), getRequest, callOptions()...).Return(getResponse, nil).Times(1)
+
+	workflowRun := s.workflowClient.GetWorkflow(context.Background(), workflowID, runID)
+	err := workflowRun.GetWithOptions(context.Background(), nil, WorkflowRunGetOptions{DisableFollowingRuns: true})
+	var continueAsNewErr *ContinueAsNewError
+	s.Require().ErrorAs(err, &continueAsNewErr)
+	s.Equal(newRunID, continueAsNewErr.NewRunID())
+	s.Equal(workflowType, continueAsNewErr.WorkflowType().Name)
+	s.Equal([]byte("input"), continueAsNewErr.Input())
+	s.Equal(runID, workflowRun.GetRunID())
+}
+
 func (s *workflowRunSuite) TestGetWorkflow() {
 	filterType := shared.HistoryEventFilterTypeCloseEvent
 	eventType := shared.EventTypeWorkflowExecutionCompleted
@@ -1127,6 +1209,232 @@ func (s *workflowClientTestSuite) TestStartWorkflow() {
 	s.Equal(createResponse.GetRunId(), resp.RunID)
 }
 
+func (s *workflowClientTestSuite) TestStartWorkflow_RegisteredTimeoutBounds() {
+	client := s.client.(*workflowClient)
+	client.registry.RegisterWorkflowWithOptions(func(ctx Context) error { return nil }, RegisterWorkflowOptions{
+		Name:                               "bounded-workflow",
+		MinExecutionStartToCloseTimeout:    time.Minute,
+		MaxExecutionStartToCloseTimeout:    time.Hour,
+		MaxDecisionTaskStartToCloseTimeout: 30 * time.Second,
+	})
+	options := StartWorkflowOptions{
+		ID:                           workflowID,
+		TaskList:                     tasklist,
+		ExecutionStartToCloseTimeout: time.Second,
+	}
+
+	_, err := client.StartWorkflow(context.Background(), options, "bounded-workflow")
+	s.EqualError(err, "ExecutionStartToCloseTimeout 1s of workflow type bounded-workflow is below the registered minimum 1m0s")
+
+	options.ExecutionStartToCloseTimeout = 2 * time.Hour
+	_, err = client.SignalWithStartWorkflow(context.Background(), workflowID, "signal", nil, options, "bounded-workflow")
+	s.EqualError(err, "ExecutionStartToCloseTimeout 2h0m0s of workflow type bounded-workflow is above the registered maximum 1h0m0s")
+
+	options.ExecutionStartToCloseTimeout = time.Hour
+	options.DecisionTaskStartToCloseTimeout = time.Minute
+	_, err = client.StartWorkflow(context.Background(), options, "bounded-workflow")
+	s.EqualError(err, "DecisionTaskStartToCloseTimeout 1m0s of workflow type bounded-workflow is above the registered maximum 30s")
+
+	// the default decision task timeout is within bounds
+	options.DecisionTaskStartToCloseTimeout = 0
+	s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.StartWorkflowExecutionResponse{RunId: common.StringPtr(runID)}, nil)
+	resp, err := client.StartWorkflow(context.Background(), options, "bounded-workflow")
+	s.NoError(err)
+	s.Equal(runID, resp.RunID)
+}
+
+func (s *workflowClientTestSuite) TestStartWorkflow_WaitForDecisionTaskStarted() {
+	client := s.client.(*workflowClient)
+	options := StartWorkflowOptions{
+		ID:                              workflowID,
+		TaskList:                        tasklist,
+		ExecutionStartToCloseTimeout:    timeoutInSeconds,
+		DecisionTaskStartToCloseTimeout: timeoutInSeconds,
+		WaitForDecisionTaskStarted:      time.Minute,
+	}
+	event := func(eventType shared.EventType) *shared.HistoryEvent {
+		return &shared.HistoryEvent{EventType: eventType.Ptr()}
+	}
+
+	s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.StartWorkflowExecutionResponse{RunId: common.StringPtr(runID)}, nil)
+	gomock.InOrder(
+		s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&shared.GetWorkflowExecutionHistoryResponse{
+				History: &shared.History{Events: []*shared.HistoryEvent{
+					event(shared.EventTypeWorkflowExecutionStarted),
+					event(shared.EventTypeDecisionTaskScheduled),
+				}},
+				NextPageToken: []byte("next"),
+			}, nil),
+		s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&shared.GetWorkflowExecutionHistoryResponse{
+				History:       &shared.History{Events: []*shared.HistoryEvent{event(shared.EventTypeDecisionTaskStarted)}},
+				NextPageToken: []byte("next"),
+			}, nil),
+	)
+
+	resp, err := client.StartWorkflow(context.Background(), options, "workflowType")
+	s.NoError(err)
+	s.Equal(runID, resp.RunID)
+}
+
+func (s *workflowClientTestSuite) TestStartWorkflow_WaitForDecisionTaskStarted_Closed() {
+	client := s.client.(*workflowClient)
+	options := StartWorkflowOptions{
+		ID:                              workflowID,
+		TaskList:                        tasklist,
+		ExecutionStartToCloseTimeout:    timeoutInSeconds,
+		DecisionTaskStartToCloseTimeout: timeoutInSeconds,
+		WaitForDecisionTaskStarted:      time.Minute,
+	}
+
+	s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.StartWorkflowExecutionResponse{RunId: common.StringPtr(runID)}, nil)
+	s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.GetWorkflowExecutionHistoryResponse{
+			History: &shared.History{Events: []*shared.HistoryEvent{
+				{EventType: shared.EventTypeWorkflowExecutionStarted.Ptr()},
+				{EventType: shared.EventTypeWorkflowExecutionTerminated.Ptr()},
+			}},
+		}, nil)
+
+	resp, err := client.StartWorkflow(context.Background(), options, "workflowType")
+	s.Equal(ErrDecisionTaskNotStarted, err)
+	s.Equal(runID, resp.RunID)
+}
+
+func (s *workflowClientTestSuite) TestStartWorkflow_TreatAlreadyStartedAsSuccess() {
+	client := s.client.(*workflowClient)
+	options := StartWorkflowOptions{
+		ID:                              workflowID,
+		TaskList:                        tasklist,
+		ExecutionStartToCloseTimeout:    timeoutInSeconds,
+		DecisionTaskStartToCloseTimeout: timeoutInSeconds,
+	}
+	alreadyStarted := &shared.WorkflowExecutionAlreadyStartedError{
+		StartRequestId: common.StringPtr("other-request-id"),
+		RunId:          common.StringPtr(runID),
+	}
+	s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, alreadyStarted).Times(2)
+
+	resp, err := client.StartWorkflow(context.Background(), options, "workflowType")
+	s.Equal(alreadyStarted, err)
+	s.Nil(resp)
+
+	options.TreatAlreadyStartedAsSuccess = true
+	resp, err = client.StartWorkflow(context.Background(), options, "workflowType")
+	s.NoError(err)
+	s.Equal(&WorkflowExecution{ID: workflowID, RunID: runID}, resp)
+}
+
+func (s *workflowClientTestSuite) TestStartWorkflow_RequestIDReusedOnRetry() {
+	client := s.client.(*workflowClient)
+	options := StartWorkflowOptions{
+		ID:                              workflowID,
+		RequestID:                       "test-request-id",
+		TaskList:                        tasklist,
+		ExecutionStartToCloseTimeout:    timeoutInSeconds,
+		DecisionTaskStartToCloseTimeout: timeoutInSeconds,
+	}
+	f1 := func(ctx Context, r []byte) string {
+		return "result"
+	}
+
+	// the first attempt starts the execution but its response is lost, the retry is
+	// reported as a duplicate of the same request and must resolve to that execution.
+	gomock.InOrder(
+		s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, &shared.InternalServiceError{}).
+			Do(func(_ interface{}, req *shared.StartWorkflowExecutionRequest, _ ...interface{}) {
+				s.Equal("test-request-id", req.GetRequestId())
+			}),
+		s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, &shared.WorkflowExecutionAlreadyStartedError{
+				StartRequestId: common.StringPtr("test-request-id"),
+				RunId:          common.StringPtr(runID),
+			}).
+			Do(func(_ interface{}, req *shared.StartWorkflowExecutionRequest, _ ...interface{}) {
+				s.Equal("test-request-id", req.GetRequestId())
+			}),
+	)
+
+	resp, err := client.StartWorkflow(context.Background(), options, f1, []byte("test"))
+	s.NoError(err)
+	s.Equal(runID, resp.RunID)
+	s.True(resp.Deduplicated)
+
+	// a start which succeeds on the first attempt is not a duplicate
+	s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.StartWorkflowExecutionResponse{RunId: common.StringPtr(runID)}, nil)
+	resp, err = client.StartWorkflow(context.Background(), options, f1, []byte("test"))
+	s.NoError(err)
+	s.Equal(runID, resp.RunID)
+	s.False(resp.Deduplicated)
+
+	// a duplicate started by a different request is still reported as an error
+	s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, &shared.WorkflowExecutionAlreadyStartedError{
+			StartRequestId: common.StringPtr("other-request-id"),
+			RunId:          common.StringPtr(runID),
+		})
+	_, err = client.StartWorkflow(context.Background(), options, f1, []byte("test"))
+	s.IsType(&shared.WorkflowExecutionAlreadyStartedError{}, err)
+}
+
+func (s *workflowClientTestSuite) TestStartWorkflow_CallerContext() {
+	type ctxKey struct{}
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.WithValue(context.Background(), ctxKey{}, "value"), deadline)
+	defer cancel()
+	options := StartWorkflowOptions{
+		ID:                              workflowID,
+		TaskList:                        tasklist,
+		ExecutionStartToCloseTimeout:    timeoutInSeconds,
+		DecisionTaskStartToCloseTimeout: timeoutInSeconds,
+	}
+
+	// the call is made with a context derived from the caller's one, and canceling it stops the retries.
+	s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, &shared.InternalServiceError{}).
+		Do(func(callCtx context.Context, _ *shared.StartWorkflowExecutionRequest, _ ...interface{}) {
+			s.Equal("value", callCtx.Value(ctxKey{}))
+			callDeadline, ok := callCtx.Deadline()
+			s.True(ok)
+			s.False(callDeadline.After(deadline))
+			cancel()
+		}).Times(1)
+
+	_, err := s.client.StartWorkflow(ctx, options, "workflow-type")
+	s.Error(err)
+}
+
+func (s *workflowClientTestSuite) TestStartWorkflow_WithServiceWrapper() {
+	wrapped := workflowservicetest.NewMockClient(s.mockCtrl)
+	client := NewClient(s.service, domain, &ClientOptions{
+		ServiceWrapper: func(service workflowserviceclient.Interface) workflowserviceclient.Interface {
+			s.Equal(s.service, service)
+			return wrapped
+		},
+	})
+	options := StartWorkflowOptions{
+		ID:                              workflowID,
+		TaskList:                        tasklist,
+		ExecutionStartToCloseTimeout:    timeoutInSeconds,
+		DecisionTaskStartToCloseTimeout: timeoutInSeconds,
+	}
+
+	// the call goes through the wrapper instead of the original service
+	wrapped.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.StartWorkflowExecutionResponse{RunId: common.StringPtr(runID)}, nil)
+
+	resp, err := client.StartWorkflow(context.Background(), options, "workflow-type")
+	s.NoError(err)
+	s.Equal(runID, resp.RunID)
+}
+
 func (s *workflowClientTestSuite) TestStartWorkflow_WithContext() {
 	s.client = NewClient(s.service, domain, &ClientOptions{ContextPropagators: []ContextPropagator{NewStringMapPropagator([]string{testHeader})}})
 	client := s.client.(*workflowClient)
@@ -1768,6 +2076,22 @@ func (s *workflowClientTestSuite) TestTerminateWorkflow() {
 	s.NoError(err)
 }
 
+func (s *workflowClientTestSuite) TestTerminateWorkflow_WithDomain() {
+	expectedRequest := &shared.TerminateWorkflowExecutionRequest{
+		Domain: common.StringPtr("other-domain"),
+		WorkflowExecution: &shared.WorkflowExecution{
+			WorkflowId: common.StringPtr(workflowID),
+			RunId:      common.StringPtr(runID),
+		},
+		Reason:   common.StringPtr("test reason"),
+		Identity: common.StringPtr(identity),
+	}
+	s.service.EXPECT().TerminateWorkflowExecution(gomock.Any(), expectedRequest, gomock.All(gomock.Any())).Return(nil)
+
+	err := s.client.TerminateWorkflow(WithDomain(context.Background(), "other-domain"), workflowID, runID, "test reason", nil)
+	s.NoError(err)
+}
+
 func (s *workflowClientTestSuite) TestDescribeTaskList() {
 	testcases := []struct {
 		name     string
@@ -2461,6 +2785,100 @@ func (s *workflowClientTestSuite) TestQueryWorkflowWithOptions() {
 	}
 }
 
+func (s *workflowClientTestSuite) TestGetWorkflowBuildIDs() {
+	decisionTaskCompleted := func(id int64, buildID string) *shared.HistoryEvent {
+		return &shared.HistoryEvent{
+			EventId:   common.Int64Ptr(id),
+			EventType: shared.EventTypeDecisionTaskCompleted.Ptr(),
+			DecisionTaskCompletedEventAttributes: &shared.DecisionTaskCompletedEventAttributes{
+				BinaryChecksum: common.StringPtr(buildID),
+			},
+		}
+	}
+	s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.GetWorkflowExecutionHistoryResponse{
+			History: &shared.History{Events: []*shared.HistoryEvent{
+				{EventId: common.Int64Ptr(1), EventType: shared.EventTypeWorkflowExecutionStarted.Ptr()},
+				decisionTaskCompleted(4, "build-1"),
+				decisionTaskCompleted(9, "build-2"),
+				decisionTaskCompleted(14, "build-1"),
+				decisionTaskCompleted(19, ""),
+			}},
+		}, nil)
+
+	buildIDs, err := GetWorkflowBuildIDs(context.Background(), s.client, workflowID, runID)
+	s.NoError(err)
+	s.Equal([]string{"build-1", "build-2"}, buildIDs)
+
+	s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, &shared.EntityNotExistsError{})
+	_, err = GetWorkflowBuildIDs(context.Background(), s.client, workflowID, runID)
+	s.Error(err)
+}
+
+func (s *workflowClientTestSuite) TestGetTaskListBacklog() {
+	scope := tally.NewTestScope("", nil)
+	client := NewClient(s.service, domain, &ClientOptions{Identity: identity, MetricsScope: scope})
+
+	describeTaskList := func(tasklistType shared.TaskListType, backlog int64) {
+		s.service.EXPECT().DescribeTaskList(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, request *shared.DescribeTaskListRequest, _ ...yarpc.CallOption) (*shared.DescribeTaskListResponse, error) {
+				s.Equal(tasklist, request.GetTaskList().GetName())
+				s.Equal(tasklistType, request.GetTaskListType())
+				s.True(request.GetIncludeTaskListStatus())
+				return &shared.DescribeTaskListResponse{
+					TaskListStatus: &shared.TaskListStatus{BacklogCountHint: common.Int64Ptr(backlog)},
+				}, nil
+			})
+	}
+	describeTaskList(shared.TaskListTypeDecision, 3)
+	describeTaskList(shared.TaskListTypeActivity, 42)
+	backlog, err := client.GetTaskListBacklog(context.Background(), tasklist, nil)
+	s.NoError(err)
+	s.Equal(&TaskListBacklog{TaskList: tasklist, DecisionTasks: 3, ActivityTasks: 42}, backlog)
+	s.Empty(scope.Snapshot().Gauges())
+
+	describeTaskList(shared.TaskListTypeDecision, 5)
+	describeTaskList(shared.TaskListTypeActivity, 7)
+	s.service.EXPECT().CountWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, request *shared.CountWorkflowExecutionsRequest, _ ...yarpc.CallOption) (*shared.CountWorkflowExecutionsResponse, error) {
+			s.Equal(`TaskList = "`+tasklist+`" and CloseTime = missing`, request.GetQuery())
+			return &shared.CountWorkflowExecutionsResponse{Count: common.Int64Ptr(11)}, nil
+		})
+	backlog, err = client.GetTaskListBacklog(context.Background(), tasklist, &TaskListBacklogOptions{
+		CountOpenWorkflows: true,
+		EmitMetrics:        true,
+	})
+	s.NoError(err)
+	s.Equal(&TaskListBacklog{TaskList: tasklist, DecisionTasks: 5, ActivityTasks: 7, OpenWorkflows: 11}, backlog)
+	gauges := make(map[string]float64)
+	for _, gauge := range scope.Snapshot().Gauges() {
+		s.Equal(tasklist, gauge.Tags()[tagTaskList])
+		gauges[gauge.Name()] = gauge.Value()
+	}
+	s.Equal(map[string]float64{
+		metrics.TaskListDecisionBacklogGauge: 5,
+		metrics.TaskListActivityBacklogGauge: 7,
+		metrics.TaskListOpenWorkflowsGauge:   11,
+	}, gauges)
+
+	s.service.EXPECT().DescribeTaskList(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, &shared.EntityNotExistsError{})
+	_, err = client.GetTaskListBacklog(context.Background(), tasklist, nil)
+	s.Error(err)
+
+	// quotes in the name are escaped rather than ending the string of the query
+	s.service.EXPECT().DescribeTaskList(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&shared.DescribeTaskListResponse{}, nil).Times(2)
+	s.service.EXPECT().CountWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, request *shared.CountWorkflowExecutionsRequest, _ ...yarpc.CallOption) (*shared.CountWorkflowExecutionsResponse, error) {
+			s.Equal(`TaskList = "it\'s \"quoted\" \\" and CloseTime = missing`, request.GetQuery())
+			return &shared.CountWorkflowExecutionsResponse{}, nil
+		})
+	_, err = client.GetTaskListBacklog(context.Background(), `it's "quoted" \`, &TaskListBacklogOptions{CountOpenWorkflows: true})
+	s.NoError(err)
+}
+
 func (s *workflowClientTestSuite) TestGetWorkflowHistory() {
 	// Page 1 of 2
 	//// Events
@@ -2521,6 +2939,37 @@ func (s *workflowClientTestSuite) TestGetWorkflowHistory() {
 	}
 }
 
+func TestNewClient_Options(t *testing.T) {
+	service := workflowservicetest.NewMockClient(gomock.NewController(t))
+
+	t.Run("defaults", func(t *testing.T) {
+		wc := NewClient(service, domain, nil).(*workflowClient)
+		assert.Equal(t, domain, wc.domain)
+		assert.NotEmpty(t, wc.identity)
+		assert.Equal(t, getDefaultDataConverter(), wc.dataConverter)
+		assert.Empty(t, wc.contextPropagators)
+		assert.Equal(t, opentracing.NoopTracer{}, wc.tracer)
+	})
+	t.Run("options", func(t *testing.T) {
+		dataConverter := newTestDataConverter()
+		propagator := NewStringMapPropagator([]string{testHeader})
+		tracer := mocktracer.New()
+		wc := NewClient(service, domain, &ClientOptions{
+			MetricsScope:       tally.NewTestScope("", nil),
+			Identity:           "test-identity",
+			DataConverter:      dataConverter,
+			ContextPropagators: []ContextPropagator{propagator},
+			Tracer:             tracer,
+		}).(*workflowClient)
+		assert.Equal(t, "test-identity", wc.identity)
+		assert.Equal(t, dataConverter, wc.dataConverter)
+		assert.Equal(t, tracer, wc.tracer)
+		require.Len(t, wc.contextPropagators, 2)
+		assert.Equal(t, propagator, wc.contextPropagators[0])
+		assert.IsType(t, &tracingContextPropagator{}, wc.contextPropagators[1])
+	})
+}
+
 func TestGetWorkflowStartRequest(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -2599,6 +3048,17 @@ func TestGetWorkflowStartRequest(t *testing.T) {
 			workflowFunc: func(ctx Context) {},
 			wantErr:      "negative DecisionTaskStartToCloseTimeout provided",
 		},
+		{
+			name: "empty workflow type",
+			options: StartWorkflowOptions{
+				ID:                              workflowID,
+				TaskList:                        tasklist,
+				ExecutionStartToCloseTimeout:    10 * time.Second,
+				DecisionTaskStartToCloseTimeout: 5 * time.Second,
+			},
+			workflowFunc: "", // this causes error
+			wantErr:      "missing workflow type",
+		},
 		{
 			name: "negative DelayStart",
 			options: StartWorkflowOptions{
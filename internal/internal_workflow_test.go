@@ -255,6 +255,83 @@ func (s *WorkflowUnitTest) Test_SplitJoinActivityWorkflow() {
 	}, trace)
 }
 
+func (s *WorkflowUnitTest) Test_RecordMarkerWorkflow() {
+	env := newTestWorkflowEnv(s.T())
+	env.RegisterWorkflowWithOptions(func(ctx Context) (string, error) {
+		if err := RecordMarker(ctx, "LoanApproved", "loan-1"); err != nil {
+			return "", err
+		}
+		ctx = WithActivityOptions(ctx, ActivityOptions{ScheduleToStartTimeout: time.Minute, StartToCloseTimeout: time.Minute})
+		var activityID string
+		err := ExecuteActivity(ctx, "testActivityWithOptions").Get(ctx, &activityID)
+		return activityID, err
+	}, RegisterWorkflowOptions{Name: "recordMarkerWorkflow"})
+	env.RegisterActivityWithOptions(testAct, RegisterActivityOptions{Name: "testActivityWithOptions"})
+	env.OnActivity(testAct, mock.Anything).Return(func(ctx context.Context) (string, error) {
+		return GetActivityInfo(ctx).ActivityID, nil
+	})
+	tracer := tracingInterceptorFactory{}
+	env.SetWorkerOptions(WorkerOptions{WorkflowInterceptorChainFactories: []WorkflowInterceptorFactory{&tracer}})
+	env.ExecuteWorkflow("recordMarkerWorkflow")
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var activityID string
+	s.NoError(env.GetWorkflowResult(&activityID))
+	// the marker took the first sequence ID, as it does in a real workflow
+	s.Equal("1", activityID)
+	s.Equal([]string{
+		"ExecuteWorkflow recordMarkerWorkflow begin",
+		"RecordMarker LoanApproved",
+		"ExecuteActivity testActivityWithOptions",
+		"ExecuteWorkflow recordMarkerWorkflow end",
+	}, tracer.instances[0].trace)
+}
+
+func (s *WorkflowUnitTest) Test_UpsertMemoWorkflow() {
+	env := newTestWorkflowEnv(s.T())
+	env.RegisterWorkflowWithOptions(func(ctx Context) (string, error) {
+		if err := UpsertMemo(ctx, map[string]interface{}{"Stage": "shipping"}); err != nil {
+			return "", err
+		}
+		var stage string
+		if err := NewValue(GetWorkflowInfo(ctx).Memo.Fields["Stage"]).Get(&stage); err != nil || stage != "shipping" {
+			return "", fmt.Errorf("memo not updated: %q, %v", stage, err)
+		}
+		ctx = WithActivityOptions(ctx, ActivityOptions{ScheduleToStartTimeout: time.Minute, StartToCloseTimeout: time.Minute})
+		var activityID string
+		err := ExecuteActivity(ctx, "testActivityWithOptions").Get(ctx, &activityID)
+		return activityID, err
+	}, RegisterWorkflowOptions{Name: "upsertMemoWorkflow"})
+	env.RegisterActivityWithOptions(testAct, RegisterActivityOptions{Name: "testActivityWithOptions"})
+	env.OnActivity(testAct, mock.Anything).Return(func(ctx context.Context) (string, error) {
+		return GetActivityInfo(ctx).ActivityID, nil
+	})
+	tracer := tracingInterceptorFactory{}
+	env.SetWorkerOptions(WorkerOptions{WorkflowInterceptorChainFactories: []WorkflowInterceptorFactory{&tracer}})
+	env.ExecuteWorkflow("upsertMemoWorkflow")
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var activityID string
+	s.NoError(env.GetWorkflowResult(&activityID))
+	// the memo marker took the first sequence ID, as it does in a real workflow
+	s.Equal("1", activityID)
+	s.Equal([]string{
+		"ExecuteWorkflow upsertMemoWorkflow begin",
+		"UpsertMemo",
+		"ExecuteActivity testActivityWithOptions",
+		"ExecuteWorkflow upsertMemoWorkflow end",
+	}, tracer.instances[0].trace)
+}
+
+func TestNextCronTime_UTC(t *testing.T) {
+	// 11:30 UTC, the schedule must not be evaluated in the zone of the given time
+	now := time.Date(2018, 12, 20, 16, 30, 0, 0, time.FixedZone("UTC+5", 5*60*60))
+	next, err := nextCronTime("0 9 * * *", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2018, 12, 21, 9, 0, 0, 0, time.UTC), next)
+	require.Equal(t, time.UTC, next.Location())
+}
+
 func TestWorkflowPanic(t *testing.T) {
 	env := newTestWorkflowEnv(t)
 	env.RegisterActivity(testAct)
@@ -1303,6 +1380,16 @@ func (t *tracingInterceptor) ExecuteActivity(ctx Context, activityType string, a
 	return t.Next.ExecuteActivity(ctx, activityType, args...)
 }
 
+func (t *tracingInterceptor) RecordMarker(ctx Context, name string, details ...interface{}) error {
+	t.trace = append(t.trace, "RecordMarker "+name)
+	return t.Next.(WorkflowMarkerInterceptor).RecordMarker(ctx, name, details...)
+}
+
+func (t *tracingInterceptor) UpsertMemo(ctx Context, memo map[string]interface{}) error {
+	t.trace = append(t.trace, "UpsertMemo")
+	return t.Next.(WorkflowMemoInterceptor).UpsertMemo(ctx, memo)
+}
+
 func (t *tracingInterceptor) ExecuteWorkflow(ctx Context, workflowType string, args ...interface{}) []interface{} {
 	t.trace = append(t.trace, "ExecuteWorkflow "+workflowType+" begin")
 	result := t.Next.ExecuteWorkflow(ctx, workflowType, args...)
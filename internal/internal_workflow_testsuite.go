@@ -441,6 +441,13 @@ func (env *testWorkflowEnvironmentImpl) setWorkerOptions(options WorkerOptions)
 	if options.Logger != nil {
 		env.workerOptions.Logger = options.Logger
 	}
+	if options.DefaultActivityOptions != nil {
+		env.workerOptions.DefaultActivityOptions = options.DefaultActivityOptions
+	}
+	if options.DispatcherStatsHandler != nil {
+		env.workerOptions.DispatcherStatsHandler = options.DispatcherStatsHandler
+	}
+	env.workerOptions.FeatureFlags = options.FeatureFlags
 	env.workflowInterceptors = options.WorkflowInterceptorChainFactories
 }
 
@@ -843,7 +850,7 @@ func (env *testWorkflowEnvironmentImpl) Complete(result []byte, err error) {
 		case *workflowPanicError:
 			env.testError = newPanicError(err.value, err.stackTrace)
 		default:
-			reason, details := getErrorDetails(err, dc)
+			reason, details := getWrappedErrorDetails(err, dc, env.workerOptions.FeatureFlags)
 			env.testError = constructError(reason, details, dc)
 		}
 	} else {
@@ -1009,7 +1016,7 @@ func (env *testWorkflowEnvironmentImpl) CompleteActivity(taskToken []byte, resul
 				zap.String(tagActivityID, activityID))
 			return
 		}
-		request := convertActivityResultToRespondRequest("test-identity", taskToken, data, err, env.GetDataConverter())
+		request := convertActivityResultToRespondRequest("test-identity", taskToken, data, err, env.GetDataConverter(), env.workerOptions.FeatureFlags)
 		env.handleActivityResult(activityID, request, activityHandle.activityType, env.GetDataConverter())
 	}, false /* do not auto schedule decision task, because activity might be still pending */)
 
@@ -2003,6 +2010,23 @@ func (env *testWorkflowEnvironmentImpl) UpsertSearchAttributes(attributes map[st
 	return err
 }
 
+func (env *testWorkflowEnvironmentImpl) UpsertMemo(memoMap map[string]interface{}) error {
+	memo, err := getWorkflowMemo(memoMap, env.GetDataConverter())
+	if err != nil {
+		return err
+	}
+	// the marker takes a sequence ID like in a real workflow, so generated IDs match
+	env.nextID()
+	env.workflowInfo.Memo = mergeMemo(env.workflowInfo.Memo, memo)
+	return nil
+}
+
+func (env *testWorkflowEnvironmentImpl) RecordMarker(name string, details []byte) {
+	// the marker takes a sequence ID like in a real workflow, so generated IDs match
+	env.nextID()
+	env.logger.Debug("Marker recorded", zap.String("MarkerName", name))
+}
+
 func (env *testWorkflowEnvironmentImpl) MutableSideEffect(id string, f func() interface{}, equals func(a, b interface{}) bool) Value {
 	return newEncodedValue(env.encodeValue(f()), env.GetDataConverter())
 }
@@ -2129,6 +2153,16 @@ func (env *testWorkflowEnvironmentImpl) GetWorkflowInterceptors() []WorkflowInte
 	return env.workflowInterceptors
 }
 
+func (env *testWorkflowEnvironmentImpl) GetDefaultActivityOptions() *ActivityOptions {
+	return env.workerOptions.DefaultActivityOptions
+}
+
+func (env *testWorkflowEnvironmentImpl) ReportDispatcherStats(stats DispatcherStats) {
+	if env.workerOptions.DispatcherStatsHandler != nil {
+		env.workerOptions.DispatcherStatsHandler(env.workflowInfo, stats, false)
+	}
+}
+
 func newTestSessionEnvironment(testWorkflowEnvironment *testWorkflowEnvironmentImpl,
 	params *workerExecutionParameters, concurrentSessionExecutionSize int) *testSessionEnvironmentImpl {
 	resourceID := params.SessionResourceID
@@ -357,6 +357,66 @@ func (s *WorkflowTestSuiteUnitTest) Test_WorkflowActivityCancellation() {
 	s.Equal(activityMap["slow"], cancelledActivityID)
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityTaskListRoute() {
+	taskListActivity := func(ctx context.Context) (string, error) {
+		return GetActivityInfo(ctx).TaskList, nil
+	}
+	otherActivity := func(ctx context.Context) (string, error) {
+		return GetActivityInfo(ctx).TaskList, nil
+	}
+	workflowFn := func(ctx Context) ([]string, error) {
+		ctx = WithActivityOptions(ctx, ActivityOptions{
+			TaskList:               "activity-tasklist",
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+		})
+		routed := WithActivityTaskListRoute(ctx, taskListActivity, "gpu-tasklist")
+		local := WithActivityTaskListRoute(routed, otherActivity, "")
+
+		var taskLists []string
+		for _, call := range []struct {
+			ctx      Context
+			activity interface{}
+		}{
+			{ctx, taskListActivity},
+			{routed, taskListActivity},
+			{routed, otherActivity},
+			{local, taskListActivity},
+			{local, otherActivity},
+		} {
+			var taskList string
+			if err := ExecuteActivity(call.ctx, call.activity).Get(call.ctx, &taskList); err != nil {
+				return nil, err
+			}
+			taskLists = append(taskLists, taskList)
+		}
+		// routing must not leak into the activity options of the context
+		taskLists = append(taskLists, *GetActivityTaskList(routed), GetWorkflowInfo(ctx).TaskListName)
+		return taskLists, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(taskListActivity)
+	env.RegisterActivity(otherActivity)
+	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var taskLists []string
+	s.NoError(env.GetWorkflowResult(&taskLists))
+	workflowTaskList := taskLists[len(taskLists)-1]
+	s.NotEqual("activity-tasklist", workflowTaskList)
+	s.Equal([]string{
+		"activity-tasklist",
+		"gpu-tasklist",
+		"activity-tasklist",
+		"gpu-tasklist",
+		workflowTaskList,
+		"activity-tasklist",
+		workflowTaskList,
+	}, taskLists)
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_ActivityWithUserContext() {
 	testKey, testValue := testContextKey("test_key"), "test_value"
 	userCtx := context.WithValue(context.Background(), testKey, testValue)
@@ -2069,6 +2129,90 @@ func (s *WorkflowTestSuiteUnitTest) Test_CancelExternalWorkflow() {
 	s.NoError(env.GetWorkflowError())
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_CancellationScope() {
+	workflowFn := func(ctx Context) ([]string, error) {
+		ctx = WithActivityOptions(ctx, s.activityOptions)
+		// race two providers, and cancel the one which lost as a group with its scope
+		scope, cancel := WithCancel(ctx)
+		slow := ExecuteActivity(scope, testActivityHello, "slow")
+		fast := ExecuteActivity(scope, testActivityHello, "fast")
+		var winner string
+		var winnerErr error
+		NewSelector(ctx).AddFuture(slow, func(f Future) {
+			winnerErr = f.Get(ctx, &winner)
+		}).AddFuture(fast, func(f Future) {
+			winnerErr = f.Get(ctx, &winner)
+		}).Select(ctx)
+		cancel()
+		if winnerErr != nil {
+			return nil, winnerErr
+		}
+		slowErr := slow.Get(ctx, nil)
+
+		// the parent context is not canceled with the scope
+		var after string
+		err := ExecuteActivity(ctx, testActivityHello, "after").Get(ctx, &after)
+		return []string{winner, fmt.Sprintf("%T", slowErr), after}, err
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(testActivityHello)
+	env.OnActivity(testActivityHello, mock.Anything, "slow").After(time.Hour).Return("hello_slow", nil)
+	env.OnActivity(testActivityHello, mock.Anything, "fast").After(time.Minute).Return("hello_fast", nil)
+	env.OnActivity(testActivityHello, mock.Anything, "after").Return("hello_after", nil)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result []string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal([]string{"hello_fast", "*internal.CanceledError", "hello_after"}, result)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_WithTimeout() {
+	workflowFn := func(ctx Context, msg string) (string, error) {
+		ctx = WithActivityOptions(ctx, s.activityOptions)
+		timeoutCtx, cancel := WithTimeout(ctx, 10*time.Minute)
+		defer cancel()
+		var result string
+		err := ExecuteActivity(timeoutCtx, testActivityHello, msg).Get(ctx, &result)
+		if err != nil {
+			if timeoutCtx.Err() != ErrDeadlineExceeded {
+				return "", errors.New("deadline exceeded error is expected")
+			}
+			return "timed out", nil
+		}
+		return result, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(testActivityHello)
+	env.OnActivity(testActivityHello, mock.Anything, "slow").After(time.Hour).Return("hello_slow", nil)
+	start := env.Now()
+	env.ExecuteWorkflow(workflowFn, "slow")
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("timed out", result)
+	s.Equal(10*time.Minute, env.Now().Sub(start))
+
+	env = s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(testActivityHello)
+	env.OnActivity(testActivityHello, mock.Anything, "fast").After(time.Minute).Return("hello_fast", nil)
+	start = env.Now()
+	env.ExecuteWorkflow(workflowFn, "fast")
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("hello_fast", result)
+	// the timer is canceled when the activity completes
+	s.Equal(time.Minute, env.Now().Sub(start))
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_DisconnectedContext() {
 	childWorkflowFn := func(ctx Context) (string, error) {
 		err := NewTimer(ctx, time.Minute*10).Get(ctx, nil)
@@ -2284,6 +2428,80 @@ func (s *WorkflowTestSuiteUnitTest) Test_DrainSignalChannel() {
 	s.Equal("s1s2", result)
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetry_NonRetryableError() {
+	attemptCount := 0
+	activityFn := func(ctx context.Context) error {
+		attemptCount++
+		return NewNonRetryableError(fmt.Errorf("invalid input: %w", NewCustomError("bad-input", "details")))
+	}
+
+	workflowFn := func(ctx Context) error {
+		ao := ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+			RetryPolicy: &RetryPolicy{
+				MaximumAttempts:    5,
+				InitialInterval:    time.Second,
+				BackoffCoefficient: 2,
+				ExpirationInterval: time.Minute,
+			},
+		}
+		ctx = WithActivityOptions(ctx, ao)
+		return ExecuteActivity(ctx, activityFn).Get(ctx, nil)
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(WorkerOptions{FeatureFlags: FeatureFlags{WrappedErrorEncodingEnabled: true}})
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(activityFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.Equal(1, attemptCount)
+	err := env.GetWorkflowError()
+	var nonRetryableErr *NonRetryableError
+	s.True(errors.As(err, &nonRetryableErr))
+	s.Equal("invalid input: bad-input", err.Error())
+	var customErr *CustomError
+	s.True(errors.As(err, &customErr))
+	s.Equal("bad-input", customErr.Reason())
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetry_WrappedNonRetryableError() {
+	attemptCount := 0
+	activityFn := func(ctx context.Context) error {
+		attemptCount++
+		return fmt.Errorf("validate: %w", NewNonRetryableError(errors.New("invalid input")))
+	}
+
+	workflowFn := func(ctx Context) error {
+		ao := ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+			RetryPolicy: &RetryPolicy{
+				MaximumAttempts:    5,
+				InitialInterval:    time.Second,
+				BackoffCoefficient: 2,
+				ExpirationInterval: time.Minute,
+			},
+		}
+		ctx = WithActivityOptions(ctx, ao)
+		return ExecuteActivity(ctx, activityFn).Get(ctx, nil)
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(activityFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.Equal(1, attemptCount)
+	err := env.GetWorkflowError()
+	var nonRetryableErr *NonRetryableError
+	s.True(errors.As(err, &nonRetryableErr))
+	s.Equal("validate: invalid input", err.Error())
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetry() {
 	attempt1Count := 0
 	activityFailedFn := func(ctx context.Context) (string, error) {
@@ -2348,6 +2566,56 @@ func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetry() {
 	s.Equal(3, attempt2Count)
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityWithWorkerDefaultOptions() {
+	activityFn := func(ctx context.Context) (string, error) {
+		info := GetActivityInfo(ctx)
+		if info.Attempt < 1 {
+			return "", NewCustomError("bad-luck")
+		}
+		return fmt.Sprintf("%v:%v:%v", info.TaskList, info.HeartbeatTimeout, info.Attempt), nil
+	}
+
+	workflowFn := func(ctx Context) ([]string, error) {
+		var defaulted, overridden string
+		if err := ExecuteActivity(ctx, activityFn).Get(ctx, &defaulted); err != nil {
+			return nil, err
+		}
+		ctx = WithActivityOptions(ctx, ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+			HeartbeatTimeout:       time.Second,
+		})
+		if err := ExecuteActivity(ctx, activityFn).Get(ctx, &overridden); err != nil {
+			return nil, err
+		}
+		return []string{defaulted, overridden}, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(WorkerOptions{
+		DefaultActivityOptions: &ActivityOptions{
+			TaskList:               "platform-tl",
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+			HeartbeatTimeout:       5 * time.Second,
+			RetryPolicy: &RetryPolicy{
+				MaximumAttempts: 2,
+				InitialInterval: time.Second,
+			},
+		},
+	})
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(activityFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result []string
+	s.NoError(env.GetWorkflowResult(&result))
+	// fields left unset by the workflow, including the retry policy, come from the worker defaults
+	s.Equal([]string{"platform-tl:5s:1", "platform-tl:1s:1"}, result)
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_ActivityHeartbeatRetry() {
 	var startedFrom []int
 	activityHeartBeatFn := func(ctx context.Context, firstTaskID, taskCount int) error {
@@ -2585,6 +2853,40 @@ func (s *WorkflowTestSuiteUnitTest) Test_ChildWorkflowRetry() {
 	s.Equal("retry-done", result)
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_ChildWorkflowRetry_FromContextDefaults() {
+	childWorkflowFn := func(ctx Context) (int32, error) {
+		info := GetWorkflowInfo(ctx)
+		if info.Attempt < 1 {
+			return 0, NewCustomError("bad-luck")
+		}
+		return info.Attempt, nil
+	}
+
+	workflowFn := func(ctx Context) (int32, error) {
+		// scope wide defaults set field by field, child calls don't repeat the options
+		ctx = WithExecutionStartToCloseTimeout(ctx, time.Minute)
+		ctx = WithWorkflowRetryPolicy(ctx, RetryPolicy{
+			MaximumAttempts:    2,
+			InitialInterval:    time.Second,
+			ExpirationInterval: time.Minute,
+		})
+		var attempt int32
+		err := ExecuteChildWorkflow(ctx, childWorkflowFn).Get(ctx, &attempt)
+		return attempt, err
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(childWorkflowFn)
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var attempt int32
+	s.NoError(env.GetWorkflowResult(&attempt))
+	s.Equal(int32(1), attempt)
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_SignalChildWorkflowRetry() {
 	childWorkflowFn := func(ctx Context) (string, error) {
 		info := GetWorkflowInfo(ctx)
@@ -2893,6 +3195,75 @@ func (s *WorkflowTestSuiteUnitTest) Test_CronChildWorkflow() {
 	s.Equal(4, lastCompletionResult)
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_DispatcherStatsHandler() {
+	workflowFn := func(ctx Context) error {
+		for i := 0; i < 3; i++ {
+			Go(ctx, func(ctx Context) {
+				_ = Sleep(ctx, time.Hour)
+			})
+		}
+		return Sleep(ctx, time.Minute)
+	}
+
+	var stats []DispatcherStats
+	env := s.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(WorkerOptions{
+		DispatcherStatsHandler: func(info *WorkflowInfo, s DispatcherStats, isReplay bool) {
+			stats = append(stats, s)
+		},
+	})
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	s.Len(stats, 3)
+	// the first run only sets up the root coroutine
+	s.Equal(1, stats[0].Coroutines)
+	s.Equal(4, stats[1].Coroutines)
+	// children blocked on their timers are still alive when the workflow completes
+	s.Equal(3, stats[2].Coroutines)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_SleepUntilNextCronTime() {
+	workflowFn := func(ctx Context) ([]time.Time, error) {
+		if _, err := NextCronTime(ctx, "not a cron"); err == nil {
+			return nil, errors.New("invalid cron schedule is expected to fail")
+		}
+		var wakeups []time.Time
+		for i := 0; i < 2; i++ {
+			next, err := NextCronTime(ctx, "0 9 * * *")
+			if err != nil {
+				return nil, err
+			}
+			if err := SleepUntil(ctx, next); err != nil {
+				return nil, err
+			}
+			wakeups = append(wakeups, Now(ctx))
+		}
+		// a time in the past does not block
+		if err := SleepUntil(ctx, Now(ctx).Add(-time.Hour)); err != nil {
+			return nil, err
+		}
+		return append(wakeups, Now(ctx)), nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	startTime, _ := time.Parse(time.RFC3339, "2018-12-20T16:30:00Z")
+	env.SetStartTime(startTime)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var wakeups []time.Time
+	s.NoError(env.GetWorkflowResult(&wakeups))
+	s.Require().Len(wakeups, 3)
+	s.True(wakeups[0].Equal(time.Date(2018, 12, 21, 9, 0, 0, 0, time.UTC)), wakeups[0])
+	s.True(wakeups[1].Equal(time.Date(2018, 12, 22, 9, 0, 0, 0, time.UTC)), wakeups[1])
+	s.True(wakeups[2].Equal(wakeups[1]))
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_CronWorkflow() {
 	var totalRuns int
 	cronWorkflow := func(ctx Context) (int, error) {
@@ -3108,6 +3479,53 @@ func (s *WorkflowTestSuiteUnitTest) Test_AwaitWithTimeout() {
 	s.False(result)
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_AwaitWithTimeout_ConditionAndTimeout() {
+	workflowFn := func(ctx Context) ([]bool, error) {
+		value := false
+		Go(ctx, func(ctx Context) {
+			_ = Sleep(ctx, time.Minute)
+			value = true
+		})
+		timedOut, err := AwaitWithTimeout(ctx, time.Second, func() bool { return value })
+		if err != nil {
+			return nil, err
+		}
+		satisfied, err := AwaitWithTimeout(ctx, time.Hour, func() bool { return value })
+		if err != nil {
+			return nil, err
+		}
+		return []bool{timedOut, satisfied}, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result []bool
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal([]bool{false, true}, result)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_AwaitWithTimeout_Canceled() {
+	workflowFn := func(ctx Context) error {
+		ctx, cancel := WithCancel(ctx)
+		Go(ctx, func(ctx Context) {
+			_ = Sleep(ctx, time.Second)
+			cancel()
+		})
+		_, err := AwaitWithTimeout(ctx, time.Hour, func() bool { return false })
+		return err
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
+	var canceledErr *CanceledError
+	s.True(errors.As(env.GetWorkflowError(), &canceledErr), env.GetWorkflowError())
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_Regression_ExecuteChildWorkflowWithCanceledContext() {
 	// cancelTime of:
 	// - <0 == do not cancel
@@ -0,0 +1,133 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+type (
+	// protoDataConverter uses protobuf encoding when all values are proto messages, and the default data converter
+	// for everything else.
+	protoDataConverter struct {
+		fallback DataConverter
+	}
+
+	// protoEncoding encapsulates length delimited protobuf encoding and decoding of proto messages.
+	protoEncoding struct{}
+)
+
+// NewProtoDataConverter creates a data converter which encodes values with binary protobuf when all of them are
+// proto messages generated with gogo/protobuf. Other values are encoded by the default data converter, as JSON or
+// thrift. Messages generated with golang/protobuf v1.4 or later are not supported.
+// Values are decoded into pointers to proto messages, or pointers to pointers to proto messages.
+// Nil proto messages are encoded as empty messages.
+// The encoded data carries no marker of its encoding, which is chosen by the types of the values only: data must be
+// decoded into the same proto message types it was encoded from, e.g. changing an activity argument from a struct to
+// a proto message breaks the decoding of the already scheduled activities.
+func NewProtoDataConverter() DataConverter {
+	return &protoDataConverter{fallback: getDefaultDataConverter()}
+}
+
+func (dc *protoDataConverter) ToData(value ...interface{}) ([]byte, error) {
+	if !isUseProtoEncoding(value) {
+		return dc.fallback.ToData(value...)
+	}
+	return protoEncoding{}.Marshal(value)
+}
+
+func (dc *protoDataConverter) FromData(input []byte, valuePtr ...interface{}) error {
+	if !isUseProtoDecoding(valuePtr) {
+		return dc.fallback.FromData(input, valuePtr...)
+	}
+	return protoEncoding{}.Unmarshal(input, valuePtr)
+}
+
+// isUseProtoEncoding checks if the objects passed in are all proto messages.
+func isUseProtoEncoding(objs []interface{}) bool {
+	if len(objs) == 0 {
+		return false
+	}
+	for _, obj := range objs {
+		if _, ok := obj.(proto.Message); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isUseProtoDecoding checks if the objects passed in are all pointers to proto messages,
+// or pointers to pointers to proto messages.
+func isUseProtoDecoding(objs []interface{}) bool {
+	if len(objs) == 0 {
+		return false
+	}
+	for _, obj := range objs {
+		if _, ok := obj.(proto.Message); ok {
+			continue
+		}
+		t := reflect.TypeOf(obj)
+		if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Ptr || !t.Elem().Implements(protoMessageType) {
+			return false
+		}
+	}
+	return true
+}
+
+// Marshal encodes an array of proto messages into bytes
+func (g protoEncoding) Marshal(objs []interface{}) ([]byte, error) {
+	buf := proto.NewBuffer(nil)
+	for i, obj := range objs {
+		msg := obj.(proto.Message)
+		if v := reflect.ValueOf(msg); v.Kind() == reflect.Ptr && v.IsNil() {
+			msg = reflect.New(v.Type().Elem()).Interface().(proto.Message)
+		}
+		if err := buf.EncodeMessage(msg); err != nil {
+			return nil, fmt.Errorf("unable to encode argument: %d, %v, with protobuf error: %v", i, reflect.TypeOf(obj), err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes a byte array into the passed in proto messages
+func (g protoEncoding) Unmarshal(data []byte, objs []interface{}) error {
+	buf := proto.NewBuffer(data)
+	for i, obj := range objs {
+		msg, ok := obj.(proto.Message)
+		if !ok {
+			// pointer to pointer to a proto message
+			ptr := reflect.ValueOf(obj).Elem()
+			ptr.Set(reflect.New(ptr.Type().Elem()))
+			msg = ptr.Interface().(proto.Message)
+		} else {
+			msg.Reset()
+		}
+		if err := buf.DecodeMessage(msg); err != nil {
+			return fmt.Errorf("unable to decode argument: %d, %v, with protobuf error: %v", i, reflect.TypeOf(obj), err)
+		}
+	}
+	return nil
+}
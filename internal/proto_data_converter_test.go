@@ -0,0 +1,80 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/stretchr/testify/require"
+	apiv1 "github.com/uber/cadence-idl/go/proto/api/v1"
+)
+
+func TestProtoDataConverter(t *testing.T) {
+	dc := NewProtoDataConverter()
+	execution := &apiv1.WorkflowExecution{WorkflowId: "wid", RunId: "rid"}
+
+	t.Run("proto messages", func(t *testing.T) {
+		data, err := dc.ToData(execution, &types.StringValue{Value: "value"})
+		require.NoError(t, err)
+
+		var decoded *apiv1.WorkflowExecution
+		value := types.StringValue{Value: "previous"}
+		require.NoError(t, dc.FromData(data, &decoded, &value))
+		require.Equal(t, execution, decoded)
+		require.Equal(t, "value", value.Value)
+	})
+
+	t.Run("nil proto message", func(t *testing.T) {
+		data, err := dc.ToData((*apiv1.WorkflowExecution)(nil))
+		require.NoError(t, err)
+
+		var decoded *apiv1.WorkflowExecution
+		require.NoError(t, dc.FromData(data, &decoded))
+		require.Equal(t, &apiv1.WorkflowExecution{}, decoded)
+	})
+
+	t.Run("json fallback", func(t *testing.T) {
+		data, err := dc.ToData("string", execution)
+		require.NoError(t, err)
+		expected, err := getDefaultDataConverter().ToData("string", execution)
+		require.NoError(t, err)
+		require.Equal(t, expected, data)
+
+		var s string
+		var decoded *apiv1.WorkflowExecution
+		require.NoError(t, dc.FromData(data, &s, &decoded))
+		require.Equal(t, "string", s)
+		require.Equal(t, execution, decoded)
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		var decoded *apiv1.WorkflowExecution
+		require.Error(t, dc.FromData([]byte{0xff}, &decoded))
+	})
+
+	t.Run("workflow function", func(t *testing.T) {
+		f := func(ctx Context, e *apiv1.WorkflowExecution) string {
+			return e.WorkflowId
+		}
+		require.Equal(t, "wid", testDataConverterFunction(t, dc, f, new(emptyCtx), execution))
+	})
+}
@@ -63,6 +63,7 @@ const (
 	keyCloseStatus  = "CloseStatus"
 	keyStartTime    = "StartTime"
 	keyCloseTime    = "CloseTime"
+	keyTaskList     = "TaskList"
 )
 
 var (
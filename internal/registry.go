@@ -43,6 +43,9 @@ func newRegistry() *registry {
 		workflowAliasMap: make(map[string]string),
 		activityFuncMap:  make(map[string]activity),
 		activityAliasMap: make(map[string]string),
+		activityTypeMap:  make(map[string]string),
+		activitySlots:    make(map[string]chan struct{}),
+		workflowSlots:    make(map[string]chan struct{}),
 		next:             getGlobalRegistry(),
 	}
 }
@@ -54,6 +57,9 @@ func getGlobalRegistry() *registry {
 			workflowAliasMap: make(map[string]string),
 			activityFuncMap:  make(map[string]activity),
 			activityAliasMap: make(map[string]string),
+			activityTypeMap:  make(map[string]string),
+			activitySlots:    make(map[string]chan struct{}),
+			workflowSlots:    make(map[string]chan struct{}),
 		}
 	})
 	return globalRegistry
@@ -65,7 +71,10 @@ type registry struct {
 	workflowAliasMap map[string]string
 	activityFuncMap  map[string]activity
 	activityAliasMap map[string]string
-	next             *registry // Allows to chain registries
+	activityTypeMap  map[string]string        // Additional activity type names (RegisterActivityOptions.Aliases) to registered names
+	activitySlots    map[string]chan struct{} // Execution slots of activities registered with MaxConcurrentExecutionSize
+	workflowSlots    map[string]chan struct{} // Decision task slots of workflows registered with MaxConcurrentExecutionSize
+	next             *registry                // Allows to chain registries
 }
 
 func (r *registry) RegisterWorkflow(af interface{}) {
@@ -81,6 +90,9 @@ func (r *registry) RegisterWorkflowWithOptions(
 	if err := validateFnFormat(fnType, true); err != nil {
 		panic(err)
 	}
+	if err := validateWorkflowTimeoutBounds(options); err != nil {
+		panic(err)
+	}
 	fnName := getFunctionName(wf)
 	alias := options.Name
 	registerName := fnName
@@ -100,7 +112,8 @@ func (r *registry) RegisterWorkflowWithOptions(
 			panic(fmt.Sprintf("workflow name \"%v\" is already registered", registerName))
 		}
 	}
-	r.workflowFuncMap[registerName] = &workflowExecutor{registerName, wf, fnName}
+	r.workflowFuncMap[registerName] = &workflowExecutor{workflowType: registerName, fn: wf, path: fnName, options: options}
+	setSlotsNoLock(r.workflowSlots, registerName, options.MaxConcurrentExecutionSize)
 	if len(alias) > 0 || options.EnableShortName {
 		r.workflowAliasMap[fnName] = registerName
 	}
@@ -124,6 +137,95 @@ func (r *registry) RegisterActivityWithOptions(af interface{}, options RegisterA
 	}
 }
 
+// DeregisterWorkflow removes a workflow type, identified by its function or registered name, from this registry.
+// A function is removed under every name it was registered with. Chained registries are not affected.
+// A *NotRegisteredError is returned if the workflow is not registered.
+func (r *registry) DeregisterWorkflow(wf interface{}) error {
+	r.Lock()
+	defer r.Unlock()
+
+	var registerNames []string
+	fnName := getFunctionName(wf)
+	if _, ok := wf.(string); ok {
+		registerName := fnName
+		if alias, ok := r.workflowAliasMap[registerName]; ok {
+			registerName = alias
+		}
+		if _, ok := r.workflowFuncMap[registerName]; ok {
+			registerNames = append(registerNames, registerName)
+		}
+	} else {
+		for registerName, w := range r.workflowFuncMap {
+			if we, ok := w.(*workflowExecutor); ok && we.path == fnName {
+				registerNames = append(registerNames, registerName)
+			}
+		}
+	}
+	if len(registerNames) == 0 {
+		err := &NotRegisteredError{TypeName: fnName}
+		for registerName := range r.workflowFuncMap {
+			err.SupportedTypes = append(err.SupportedTypes, registerName)
+		}
+		return err
+	}
+	for _, registerName := range registerNames {
+		delete(r.workflowFuncMap, registerName)
+		removeAliasesNoLock(r.workflowAliasMap, registerName)
+		delete(r.workflowSlots, registerName)
+	}
+	return nil
+}
+
+// DeregisterActivity removes an activity type, identified by its function or registered name, from this registry.
+// A function is removed under every name it was registered with. Chained registries are not affected.
+// A *NotRegisteredError is returned if the activity is not registered.
+func (r *registry) DeregisterActivity(af interface{}) error {
+	r.Lock()
+	defer r.Unlock()
+
+	var registerNames []string
+	fnName := getFunctionName(af)
+	if _, ok := af.(string); ok {
+		registerName := fnName
+		if alias, ok := r.activityAliasMap[registerName]; ok {
+			registerName = alias
+		} else if alias, ok := r.activityTypeMap[registerName]; ok {
+			registerName = alias
+		}
+		if _, ok := r.activityFuncMap[registerName]; ok {
+			registerNames = append(registerNames, registerName)
+		}
+	} else {
+		for registerName, a := range r.activityFuncMap {
+			if ae, ok := a.(*activityExecutor); ok && ae.path == fnName {
+				registerNames = append(registerNames, registerName)
+			}
+		}
+	}
+	if len(registerNames) == 0 {
+		err := &NotRegisteredError{TypeName: fnName, IsActivity: true}
+		for registerName := range r.activityFuncMap {
+			err.SupportedTypes = append(err.SupportedTypes, registerName)
+		}
+		return err
+	}
+	for _, registerName := range registerNames {
+		delete(r.activityFuncMap, registerName)
+		removeAliasesNoLock(r.activityAliasMap, registerName)
+		removeAliasesNoLock(r.activityTypeMap, registerName)
+		delete(r.activitySlots, registerName)
+	}
+	return nil
+}
+
+func removeAliasesNoLock(aliasMap map[string]string, registerName string) {
+	for fnName, alias := range aliasMap {
+		if alias == registerName {
+			delete(aliasMap, fnName)
+		}
+	}
+}
+
 func (r *registry) GetRegisteredWorkflows() []workflow {
 	r.Lock()
 	var result []workflow
@@ -159,14 +261,20 @@ func (r *registry) registerActivityFunction(af interface{}, options RegisterActi
 	defer r.Unlock()
 
 	if !options.DisableAlreadyRegisteredCheck {
-		if _, ok := r.getActivityNoLock(registerName); ok {
-			return fmt.Errorf("activity type \"%v\" is already registered", registerName)
+		for _, name := range append([]string{registerName}, options.Aliases...) {
+			if _, ok := r.getActivityNoLock(name); ok {
+				return fmt.Errorf("activity type \"%v\" is already registered", name)
+			}
 		}
 	}
 	r.activityFuncMap[registerName] = &activityExecutor{registerName, af, options, fnName}
 	if len(alias) > 0 || options.EnableShortName {
 		r.activityAliasMap[fnName] = registerName
 	}
+	for _, name := range options.Aliases {
+		r.activityTypeMap[name] = registerName
+	}
+	setSlotsNoLock(r.activitySlots, registerName, options.MaxConcurrentExecutionSize)
 
 	return nil
 }
@@ -209,6 +317,7 @@ func (r *registry) registerActivityStruct(aStruct interface{}, options RegisterA
 		if len(structPrefix) > 0 || options.EnableShortName {
 			r.activityAliasMap[methodName] = registerName
 		}
+		setSlotsNoLock(r.activitySlots, registerName, options.MaxConcurrentExecutionSize)
 		count++
 	}
 
@@ -219,6 +328,80 @@ func (r *registry) registerActivityStruct(aStruct interface{}, options RegisterA
 	return nil
 }
 
+func setSlotsNoLock(slots map[string]chan struct{}, registerName string, size int) {
+	if size > 0 {
+		slots[registerName] = make(chan struct{}, size)
+	} else {
+		delete(slots, registerName)
+	}
+}
+
+// getActivitySlots returns the channel limiting concurrent executions of the activity type,
+// or nil if the activity type has no concurrency limit.
+func (r *registry) getActivitySlots(registerName string) chan struct{} {
+	r.Lock() // do not defer for Unlock to call next.getActivitySlots without lock
+	slots, ok := r.activitySlots[registerName]
+	if !ok && r.next != nil {
+		r.Unlock()
+		return r.next.getActivitySlots(registerName)
+	}
+	r.Unlock()
+	return slots
+}
+
+// getActivityTypeSlots returns the channel limiting concurrent executions of the activity type of a task,
+// or nil if the activity type is unknown or has no concurrency limit.
+func (r *registry) getActivityTypeSlots(activityType string) chan struct{} {
+	a, ok := r.GetActivity(activityType)
+	if !ok || a.GetOptions().MaxConcurrentExecutionSize <= 0 {
+		return nil
+	}
+	return r.getActivitySlots(a.ActivityType().Name)
+}
+
+// getWorkflowTypeSlots returns the channel limiting concurrent decision tasks of the workflow type of a task,
+// or nil if the workflow type is unknown or has no concurrency limit.
+func (r *registry) getWorkflowTypeSlots(workflowType string) chan struct{} {
+	if alias, ok := r.getWorkflowAlias(workflowType); ok {
+		workflowType = alias
+	}
+	for reg := r; reg != nil; reg = reg.next {
+		reg.Lock()
+		slots, ok := reg.workflowSlots[workflowType]
+		reg.Unlock()
+		if ok {
+			return slots
+		}
+	}
+	return nil
+}
+
+// hasActivitySlots returns whether an activity type is registered with a concurrency limit.
+func (r *registry) hasActivitySlots() bool {
+	for reg := r; reg != nil; reg = reg.next {
+		reg.Lock()
+		n := len(reg.activitySlots)
+		reg.Unlock()
+		if n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWorkflowSlots returns whether a workflow type is registered with a concurrency limit.
+func (r *registry) hasWorkflowSlots() bool {
+	for reg := r; reg != nil; reg = reg.next {
+		reg.Lock()
+		n := len(reg.workflowSlots)
+		reg.Unlock()
+		if n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func getShortFunctionName(fnName string) string {
 	elements := strings.Split(fnName, ".")
 	return elements[len(elements)-1]
@@ -252,6 +435,21 @@ func (r *registry) getWorkflowFn(fnName string) (interface{}, bool) {
 	return nil, ok
 }
 
+// getWorkflowOptions returns the options a workflow type was registered with.
+func (r *registry) getWorkflowOptions(registerName string) (RegisterWorkflowOptions, bool) {
+	r.Lock() // do not defer for Unlock to call next.getWorkflowOptions without lock
+	wf, ok := r.workflowFuncMap[registerName]
+	if !ok && r.next != nil {
+		r.Unlock()
+		return r.next.getWorkflowOptions(registerName)
+	}
+	r.Unlock()
+	if we, isExecutor := wf.(*workflowExecutor); ok && isExecutor {
+		return we.options, true
+	}
+	return RegisterWorkflowOptions{}, false
+}
+
 func (r *registry) getWorkflowNoLock(registerName string) (interface{}, bool) {
 	a, ok := r.workflowFuncMap[registerName]
 	if !ok && r.next != nil {
@@ -301,6 +499,9 @@ func (r *registry) GetActivity(fnName string) (activity, bool) {
 	if !ok { // if exact match is not found, check for backwards compatible name without -fm suffix
 		a, ok = r.activityFuncMap[strings.TrimSuffix(fnName, "-fm")]
 	}
+	if !ok { // then check for additional names the activity was registered with
+		a, ok = r.getActivityByAliasNoLock(fnName)
+	}
 	if !ok && r.next != nil {
 		r.Unlock()
 		return r.next.GetActivity(fnName)
@@ -309,8 +510,20 @@ func (r *registry) GetActivity(fnName string) (activity, bool) {
 	return a, ok
 }
 
+func (r *registry) getActivityByAliasNoLock(name string) (activity, bool) {
+	registerName, ok := r.activityTypeMap[name]
+	if !ok {
+		return nil, false
+	}
+	a, ok := r.activityFuncMap[registerName]
+	return a, ok
+}
+
 func (r *registry) getActivityNoLock(registerName string) (activity, bool) {
 	a, ok := r.activityFuncMap[registerName]
+	if !ok {
+		a, ok = r.getActivityByAliasNoLock(registerName)
+	}
 	if !ok && r.next != nil {
 		return r.next.getActivityNoLock(registerName)
 	}
@@ -338,8 +551,7 @@ func (r *registry) getWorkflowDefinition(wt WorkflowType) (workflowDefinition, e
 	}
 	wf, ok := r.getWorkflowFn(lookup)
 	if !ok {
-		supported := strings.Join(r.GetRegisteredWorkflowTypes(), ", ")
-		return nil, fmt.Errorf(errMsgUnknownWorkflowType+": %v. Supported types: [%v]", lookup, supported)
+		return nil, &NotRegisteredError{TypeName: lookup, SupportedTypes: r.GetRegisteredWorkflowTypes()}
 	}
 	wd := &workflowExecutor{workflowType: lookup, fn: wf}
 	return newSyncWorkflowDefinition(wd), nil
@@ -21,7 +21,9 @@
 package internal
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -84,6 +86,16 @@ func TestWorkflowRegistration(t *testing.T) {
 			workflowType:      "go.uber.org/cadence/internal.testWorkflowFunction",
 			resolveByFunction: testWorkflowFunction,
 		},
+		{
+			msg: "register workflow with inverted timeout bounds (should panic)",
+			register: func(r *registry) {
+				r.RegisterWorkflowWithOptions(testWorkflowFunction, RegisterWorkflowOptions{
+					MinExecutionStartToCloseTimeout: time.Hour,
+					MaxExecutionStartToCloseTimeout: time.Minute,
+				})
+			},
+			registerPanic: true,
+		},
 		{
 			msg: "register duplicated workflow in chained registry (should panic)",
 			register: func(r *registry) {
@@ -252,6 +264,110 @@ func TestActivityRegistration(t *testing.T) {
 	}
 }
 
+func TestActivityRegistrationWithAliases(t *testing.T) {
+	r := newRegistry()
+	r.RegisterActivityWithOptions(testActivityFunction, RegisterActivityOptions{
+		Name:    "activity.new",
+		Aliases: []string{"activity.old", "go.uber.org/cadence/internal.oldActivityFunction"},
+	})
+
+	for _, name := range []string{"activity.new", "activity.old", "go.uber.org/cadence/internal.oldActivityFunction"} {
+		a, ok := r.GetActivity(name)
+		require.True(t, ok, name)
+		require.Equal(t, "activity.new", a.ActivityType().Name)
+	}
+	require.Len(t, r.activityFuncMap, 1)
+	// workflows keep scheduling under the registered name
+	require.Equal(t, "activity.new", getActivityFunctionName(r, testActivityFunction))
+
+	require.Panics(t, func() {
+		r.RegisterActivityWithOptions(func() error { return nil }, RegisterActivityOptions{Name: "activity.old"})
+	})
+
+	require.NoError(t, r.DeregisterActivity("activity.new"))
+	_, ok := r.GetActivity("activity.old")
+	require.False(t, ok)
+}
+
+func TestWorkflowDeregistration(t *testing.T) {
+	t.Run("deregister by function", func(t *testing.T) {
+		r := newRegistry()
+		r.next = nil // isolate from global registrations made by other tests
+		r.RegisterWorkflow(testWorkflowFunction)
+		require.NoError(t, r.DeregisterWorkflow(testWorkflowFunction))
+		_, ok := r.getWorkflowFn("go.uber.org/cadence/internal.testWorkflowFunction")
+		require.False(t, ok)
+		require.Error(t, r.DeregisterWorkflow(testWorkflowFunction))
+	})
+	t.Run("deregister by function registered under several names", func(t *testing.T) {
+		r := newRegistry()
+		r.next = nil
+		r.RegisterWorkflowWithOptions(testWorkflowFunction, RegisterWorkflowOptions{Name: "workflow.first"})
+		r.RegisterWorkflowWithOptions(testWorkflowFunction, RegisterWorkflowOptions{Name: "workflow.second"})
+		require.NoError(t, r.DeregisterWorkflow(testWorkflowFunction))
+		require.Empty(t, r.workflowFuncMap)
+		require.Empty(t, r.workflowAliasMap)
+	})
+	t.Run("not registered", func(t *testing.T) {
+		r := newRegistry()
+		r.next = nil
+		r.RegisterWorkflowWithOptions(testWorkflowFunction, RegisterWorkflowOptions{Name: "workflow.alias"})
+		err := r.DeregisterWorkflow("workflow.unknown")
+		var notRegistered *NotRegisteredError
+		require.True(t, errors.As(err, &notRegistered))
+		require.Equal(t, &NotRegisteredError{TypeName: "workflow.unknown", SupportedTypes: []string{"workflow.alias"}}, notRegistered)
+
+		_, err = r.getWorkflowDefinition(WorkflowType{Name: "workflow.unknown"})
+		require.True(t, errors.As(err, &notRegistered))
+		require.Equal(t, "workflow.unknown", notRegistered.TypeName)
+	})
+	t.Run("deregister by alias", func(t *testing.T) {
+		r := newRegistry()
+		r.next = nil
+		r.RegisterWorkflowWithOptions(testWorkflowFunction, RegisterWorkflowOptions{Name: "workflow.alias"})
+		require.NoError(t, r.DeregisterWorkflow("workflow.alias"))
+		_, ok := r.getWorkflowFn("workflow.alias")
+		require.False(t, ok)
+		_, ok = r.getWorkflowAlias("go.uber.org/cadence/internal.testWorkflowFunction")
+		require.False(t, ok)
+		// can be registered again after removal
+		r.RegisterWorkflowWithOptions(testWorkflowFunction, RegisterWorkflowOptions{Name: "workflow.alias"})
+	})
+}
+
+func TestActivityDeregistration(t *testing.T) {
+	t.Run("deregister by function", func(t *testing.T) {
+		r := newRegistry()
+		r.next = nil
+		r.RegisterActivityWithOptions(testActivityFunction, RegisterActivityOptions{Name: "activity.alias"})
+		require.NoError(t, r.DeregisterActivity(testActivityFunction))
+		_, ok := r.GetActivity("activity.alias")
+		require.False(t, ok)
+		require.Error(t, r.DeregisterActivity("activity.alias"))
+	})
+	t.Run("deregister by additional name", func(t *testing.T) {
+		r := newRegistry()
+		r.next = nil
+		r.RegisterActivityWithOptions(testActivityFunction, RegisterActivityOptions{Name: "activity.new", Aliases: []string{"activity.old"}})
+		require.NoError(t, r.DeregisterActivity("activity.old"))
+		require.Empty(t, r.activityFuncMap)
+		require.Empty(t, r.activityTypeMap)
+		require.Empty(t, r.activityAliasMap)
+
+		err := r.DeregisterActivity(testActivityFunction)
+		var notRegistered *NotRegisteredError
+		require.True(t, errors.As(err, &notRegistered))
+		require.True(t, notRegistered.IsActivity)
+	})
+	t.Run("deregister struct method by name", func(t *testing.T) {
+		r := newRegistry()
+		r.next = nil
+		r.RegisterActivityWithOptions(&testActivityStruct{}, RegisterActivityOptions{Name: "prefix."})
+		require.NoError(t, r.DeregisterActivity("prefix.Method"))
+		require.Empty(t, r.activityFuncMap)
+	})
+}
+
 type testWorkflowStruct struct{}
 type testActivityStruct struct{}
 
@@ -260,3 +376,27 @@ func (ts *testActivityStruct) Method() error            { return nil }
 
 func testActivityFunction() error            { return nil }
 func testWorkflowFunction(ctx Context) error { return nil }
+
+func TestRegistrationSlots(t *testing.T) {
+	r := newRegistry()
+	require.False(t, r.hasWorkflowSlots())
+	require.False(t, r.hasActivitySlots())
+
+	r.RegisterWorkflowWithOptions(testWorkflowFunction, RegisterWorkflowOptions{Name: "workflow.limited", MaxConcurrentExecutionSize: 2})
+	r.RegisterWorkflow(testWorkflowReturnStruct)
+	r.RegisterActivityWithOptions(testActivityFunction, RegisterActivityOptions{Name: "activity.limited", MaxConcurrentExecutionSize: 3})
+	r.RegisterActivity(testActivityReturnString)
+
+	require.True(t, r.hasWorkflowSlots())
+	require.True(t, r.hasActivitySlots())
+	require.Equal(t, 2, cap(r.getWorkflowTypeSlots("workflow.limited")))
+	require.Nil(t, r.getWorkflowTypeSlots(getFunctionName(testWorkflowReturnStruct)))
+	require.Nil(t, r.getWorkflowTypeSlots("unknown"))
+	require.Equal(t, 3, cap(r.getActivityTypeSlots("activity.limited")))
+	require.Nil(t, r.getActivityTypeSlots(getFunctionName(testActivityReturnString)))
+	require.Nil(t, r.getActivityTypeSlots("unknown"))
+
+	require.NoError(t, r.DeregisterWorkflow("workflow.limited"))
+	require.False(t, r.hasWorkflowSlots())
+	require.Nil(t, r.getWorkflowTypeSlots("workflow.limited"))
+}
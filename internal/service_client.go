@@ -0,0 +1,688 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	yarpcpeer "go.uber.org/yarpc/peer"
+	"go.uber.org/yarpc/peer/pendingheap"
+	"go.uber.org/yarpc/peer/roundrobin"
+	"go.uber.org/yarpc/transport/tchannel"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common/metrics"
+)
+
+const (
+	defaultServiceClientServiceName = "cadence-frontend"
+	defaultServiceClientCallerName  = "cadence-client"
+
+	defaultHealthCheckFailureThreshold = 3
+
+	// serviceClientDrainTimeout bounds how long a replaced connection is kept open for the calls still in flight
+	// on it, long enough for a long poll to complete.
+	serviceClientDrainTimeout = pollTaskServiceTimeOut
+)
+
+type (
+	// ServiceClient is a client of the Cadence frontend service created by NewServiceClient. It owns the underlying
+	// connections, so Close it once it is no longer used.
+	ServiceClient interface {
+		workflowserviceclient.Interface
+
+		// Close stops the client and closes its connections.
+		Close() error
+	}
+
+	// ServiceClientOption configures a ServiceClient created by NewServiceClient.
+	ServiceClientOption func(*serviceClientOptions)
+
+	serviceClientOptions struct {
+		serviceName                 string
+		callerName                  string
+		logger                      *zap.Logger
+		tracer                      opentracing.Tracer
+		metricsScope                tally.Scope
+		healthCheckInterval         time.Duration
+		healthCheckFailureThreshold int
+		onConnectivityChange        func(connected bool)
+		peerResolver                PeerResolver
+		peerRefreshInterval         time.Duration
+		loadBalancing               LoadBalancing
+	}
+
+	// serviceClient forwards calls to the client of its current connection, which the health check replaces when
+	// the service has been unreachable for healthCheckFailureThreshold consecutive checks.
+	serviceClient struct {
+		options serviceClientOptions
+		logger  *zap.Logger
+		dial    func() (workflowserviceclient.Interface, func() error, error)
+
+		sync.RWMutex
+		client workflowserviceclient.Interface
+		stop   func() error
+		// inflight tracks the calls made on the current connection, so that a replaced connection is only
+		// stopped once they completed.
+		inflight *sync.WaitGroup
+
+		closeCh   chan struct{}
+		closeOnce sync.Once
+		closeWG   sync.WaitGroup
+	}
+)
+
+// WithServiceName sets the name of the Cadence frontend service to call.
+// Defaults to "cadence-frontend".
+func WithServiceName(name string) ServiceClientOption {
+	return func(o *serviceClientOptions) {
+		o.serviceName = name
+	}
+}
+
+// WithCallerName sets the name the client identifies itself with to the service.
+// Defaults to "cadence-client".
+func WithCallerName(name string) ServiceClientOption {
+	return func(o *serviceClientOptions) {
+		o.callerName = name
+	}
+}
+
+// WithServiceClientLogger sets the logger of the underlying transport and the health check. Defaults to no logging.
+func WithServiceClientLogger(logger *zap.Logger) ServiceClientOption {
+	return func(o *serviceClientOptions) {
+		o.logger = logger
+	}
+}
+
+// WithServiceClientTracer sets the tracer of the underlying transport. Defaults to the global tracer.
+func WithServiceClientTracer(tracer opentracing.Tracer) ServiceClientOption {
+	return func(o *serviceClientOptions) {
+		o.tracer = tracer
+	}
+}
+
+// WithServiceClientMetricsScope sets the scope the health check reports failed checks, lost connectivity and
+// reconnects to. Defaults to no metrics.
+func WithServiceClientMetricsScope(scope tally.Scope) ServiceClientOption {
+	return func(o *serviceClientOptions) {
+		o.metricsScope = scope
+	}
+}
+
+// WithHealthCheck pings the service every interval. Once failureThreshold consecutive pings failed to reach it, the
+// connectivity is reported as lost and the client re-dials all hosts; it keeps doing so every failureThreshold
+// failed pings until the service is reachable again. Calls in flight on the previous connection, such as long polls,
+// are allowed to complete before it is closed. A failureThreshold of 0 defaults to 3.
+// Defaults to no health checks.
+func WithHealthCheck(interval time.Duration, failureThreshold int) ServiceClientOption {
+	return func(o *serviceClientOptions) {
+		o.healthCheckInterval = interval
+		o.healthCheckFailureThreshold = failureThreshold
+	}
+}
+
+// WithConnectivityCallback sets a function that the health check calls with false when the connectivity to the
+// service is lost and with true once it is restored. It must not block.
+func WithConnectivityCallback(fn func(connected bool)) ServiceClientOption {
+	return func(o *serviceClientOptions) {
+		o.onConnectivityChange = fn
+	}
+}
+
+// WithPeerResolver discovers the frontend hosts with resolver, e.g. one created by NewDNSPeerResolver, instead of
+// using a static list, and refreshes them every refreshInterval. The hosts stay unchanged while the resolver fails.
+// A refreshInterval of 0 only resolves them when connecting.
+func WithPeerResolver(resolver PeerResolver, refreshInterval time.Duration) ServiceClientOption {
+	return func(o *serviceClientOptions) {
+		o.peerResolver = resolver
+		o.peerRefreshInterval = refreshInterval
+	}
+}
+
+// WithLoadBalancing sets the strategy to spread the calls across the frontend hosts.
+// Defaults to LoadBalancingRoundRobin.
+func WithLoadBalancing(loadBalancing LoadBalancing) ServiceClientOption {
+	return func(o *serviceClientOptions) {
+		o.loadBalancing = loadBalancing
+	}
+}
+
+// NewServiceClient creates a TChannel client of the Cadence frontend service reachable at the given host:port
+// addresses, or at the addresses returned by the resolver set with WithPeerResolver, in which case hosts must be
+// empty. Calls are spread across the hosts as set with WithLoadBalancing; connections that fail are re-established
+// in the background with exponential backoff, and hosts are skipped while they are unreachable.
+// See WithHealthCheck to also re-dial the hosts when the service stays unreachable.
+// The result is meant to be passed to NewClient, NewDomainClient and NewWorker.
+func NewServiceClient(hosts []string, opts ...ServiceClientOption) (ServiceClient, error) {
+	options := serviceClientOptions{
+		serviceName: defaultServiceClientServiceName,
+		callerName:  defaultServiceClientCallerName,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	switch {
+	case options.peerResolver != nil && len(hosts) > 0:
+		return nil, errors.New("hosts must be empty when a peer resolver is set")
+	case options.peerResolver == nil && len(hosts) == 0:
+		return nil, errors.New("at least one host is required")
+	case options.peerResolver == nil:
+		for _, host := range hosts {
+			if _, _, err := net.SplitHostPort(host); err != nil {
+				return nil, fmt.Errorf("invalid host %q: %w", host, err)
+			}
+		}
+		options.peerResolver = staticPeerResolver(hosts)
+	}
+	switch options.loadBalancing {
+	case LoadBalancingRoundRobin, LoadBalancingLeastPending:
+	default:
+		return nil, fmt.Errorf("unknown load balancing strategy %v", options.loadBalancing)
+	}
+	return newServiceClient(options, func() (workflowserviceclient.Interface, func() error, error) {
+		return dialServiceClient(options)
+	})
+}
+
+func newServiceClient(
+	options serviceClientOptions,
+	dial func() (workflowserviceclient.Interface, func() error, error),
+) (*serviceClient, error) {
+	client, stop, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	if options.healthCheckFailureThreshold <= 0 {
+		options.healthCheckFailureThreshold = defaultHealthCheckFailureThreshold
+	}
+	if options.metricsScope == nil {
+		options.metricsScope = tally.NoopScope
+	}
+	logger := options.logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	c := &serviceClient{
+		options:  options,
+		logger:   logger,
+		dial:     dial,
+		client:   client,
+		stop:     stop,
+		inflight: &sync.WaitGroup{},
+		closeCh:  make(chan struct{}),
+	}
+	if options.healthCheckInterval > 0 {
+		c.closeWG.Add(1)
+		go c.healthCheckLoop()
+	}
+	return c, nil
+}
+
+func dialServiceClient(options serviceClientOptions) (workflowserviceclient.Interface, func() error, error) {
+	transportOptions := []tchannel.TransportOption{tchannel.ServiceName(options.callerName)}
+	if options.logger != nil {
+		transportOptions = append(transportOptions, tchannel.Logger(options.logger))
+	}
+	if options.tracer != nil {
+		transportOptions = append(transportOptions, tchannel.Tracer(options.tracer))
+	}
+	tchannelTransport, err := tchannel.NewTransport(transportOptions...)
+	if err != nil {
+		return nil, nil, err
+	}
+	var list peer.ChooserList
+	if options.loadBalancing == LoadBalancingLeastPending {
+		list = pendingheap.New(tchannelTransport)
+	} else {
+		list = roundrobin.New(tchannelTransport)
+	}
+	logger := options.logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	chooser := yarpcpeer.Bind(list, func(list peer.List) transport.Lifecycle {
+		return newPeerListUpdater(list, options.peerResolver, options.peerRefreshInterval, logger)
+	})
+	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+		Name: options.callerName,
+		Outbounds: yarpc.Outbounds{
+			options.serviceName: {Unary: tchannelTransport.NewOutbound(chooser)},
+		},
+	})
+	if err := dispatcher.Start(); err != nil {
+		return nil, nil, err
+	}
+	return workflowserviceclient.New(dispatcher.ClientConfig(options.serviceName)), dispatcher.Stop, nil
+}
+
+func (c *serviceClient) current() workflowserviceclient.Interface {
+	c.RLock()
+	defer c.RUnlock()
+	return c.client
+}
+
+// acquire returns the client of the current connection and a function to call once the call made with it
+// completed.
+func (c *serviceClient) acquire() (workflowserviceclient.Interface, func()) {
+	c.RLock()
+	defer c.RUnlock()
+	inflight := c.inflight
+	inflight.Add(1)
+	return c.client, inflight.Done
+}
+
+func (c *serviceClient) healthCheckLoop() {
+	defer c.closeWG.Done()
+	ticker := time.NewTicker(c.options.healthCheckInterval)
+	defer ticker.Stop()
+
+	connected := true
+	failures := 0
+	for {
+		select {
+		case <-c.closeCh:
+			return
+		case <-ticker.C:
+		}
+
+		err := c.ping()
+		if err == nil {
+			failures = 0
+			if !connected {
+				connected = true
+				c.logger.Info("Connectivity to cadence service restored.")
+				c.notifyConnectivityChange(true)
+			}
+			continue
+		}
+		failures++
+		c.options.metricsScope.Counter(metrics.ServiceHealthCheckFailedCounter).Inc(1)
+		if failures < c.options.healthCheckFailureThreshold {
+			continue
+		}
+		failures = 0
+		if connected {
+			connected = false
+			c.logger.Warn("Connectivity to cadence service lost.", zap.Error(err))
+			c.options.metricsScope.Counter(metrics.ServiceConnectivityLostCounter).Inc(1)
+			c.notifyConnectivityChange(false)
+		}
+		c.redial()
+	}
+}
+
+// ping reports an error only if the service could not be reached: any response of the service, including an error
+// returned by it, means it is reachable.
+func (c *serviceClient) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.healthCheckInterval)
+	defer cancel()
+	_, err := c.current().GetClusterInfo(ctx, getYarpcCallOptions(FeatureFlags{})...)
+	if isServiceConnectivityError(err) {
+		return err
+	}
+	return nil
+}
+
+func (c *serviceClient) redial() {
+	client, stop, err := c.dial()
+	if err != nil {
+		c.logger.Warn("Failed to re-dial cadence service.", zap.Error(err))
+		return
+	}
+	c.Lock()
+	oldStop, oldInflight := c.stop, c.inflight
+	c.client, c.stop, c.inflight = client, stop, &sync.WaitGroup{}
+	c.Unlock()
+	c.options.metricsScope.Counter(metrics.ServiceReconnectCounter).Inc(1)
+	c.closeWG.Add(1)
+	go c.drain(oldStop, oldInflight)
+}
+
+// drain stops a replaced connection once the calls in flight on it completed, serviceClientDrainTimeout passed or
+// the client is closed, so that the re-dial does not abort long polls that are still being answered.
+func (c *serviceClient) drain(stop func() error, inflight *sync.WaitGroup) {
+	defer c.closeWG.Done()
+	drained := make(chan struct{})
+	go func() {
+		inflight.Wait()
+		close(drained)
+	}()
+	timer := time.NewTimer(serviceClientDrainTimeout)
+	defer timer.Stop()
+	select {
+	case <-drained:
+	case <-timer.C:
+	case <-c.closeCh:
+	}
+	if err := stop(); err != nil {
+		c.logger.Warn("Failed to stop previous connection to cadence service.", zap.Error(err))
+	}
+}
+
+func (c *serviceClient) notifyConnectivityChange(connected bool) {
+	if c.options.onConnectivityChange != nil {
+		c.options.onConnectivityChange(connected)
+	}
+}
+
+func (c *serviceClient) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closeCh)
+		c.closeWG.Wait()
+		c.Lock()
+		defer c.Unlock()
+		err = c.stop()
+	})
+	return err
+}
+
+// isServiceConnectivityError returns true if err means that the service could not be reached, as opposed to an
+// error returned by the service. Unknown errors are returned by a service that was reached, so they do not count.
+func isServiceConnectivityError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if !yarpcerrors.IsStatus(err) {
+		return false
+	}
+	switch yarpcerrors.FromError(err).Code() {
+	case yarpcerrors.CodeUnavailable, yarpcerrors.CodeDeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+func (c *serviceClient) DeprecateDomain(ctx context.Context, request *shared.DeprecateDomainRequest, opts ...yarpc.CallOption) error {
+	client, release := c.acquire()
+	defer release()
+	return client.DeprecateDomain(ctx, request, opts...)
+}
+
+func (c *serviceClient) ListDomains(ctx context.Context, request *shared.ListDomainsRequest, opts ...yarpc.CallOption) (*shared.ListDomainsResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.ListDomains(ctx, request, opts...)
+}
+
+func (c *serviceClient) DescribeDomain(ctx context.Context, request *shared.DescribeDomainRequest, opts ...yarpc.CallOption) (*shared.DescribeDomainResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.DescribeDomain(ctx, request, opts...)
+}
+
+func (c *serviceClient) DescribeWorkflowExecution(ctx context.Context, request *shared.DescribeWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.DescribeWorkflowExecutionResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.DescribeWorkflowExecution(ctx, request, opts...)
+}
+
+func (c *serviceClient) GetWorkflowExecutionHistory(ctx context.Context, request *shared.GetWorkflowExecutionHistoryRequest, opts ...yarpc.CallOption) (*shared.GetWorkflowExecutionHistoryResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.GetWorkflowExecutionHistory(ctx, request, opts...)
+}
+
+func (c *serviceClient) ListClosedWorkflowExecutions(ctx context.Context, request *shared.ListClosedWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListClosedWorkflowExecutionsResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.ListClosedWorkflowExecutions(ctx, request, opts...)
+}
+
+func (c *serviceClient) ListOpenWorkflowExecutions(ctx context.Context, request *shared.ListOpenWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListOpenWorkflowExecutionsResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.ListOpenWorkflowExecutions(ctx, request, opts...)
+}
+
+func (c *serviceClient) ListWorkflowExecutions(ctx context.Context, request *shared.ListWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.ListWorkflowExecutions(ctx, request, opts...)
+}
+
+func (c *serviceClient) ListArchivedWorkflowExecutions(ctx context.Context, request *shared.ListArchivedWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListArchivedWorkflowExecutionsResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.ListArchivedWorkflowExecutions(ctx, request, opts...)
+}
+
+func (c *serviceClient) ScanWorkflowExecutions(ctx context.Context, request *shared.ListWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.ListWorkflowExecutionsResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.ScanWorkflowExecutions(ctx, request, opts...)
+}
+
+func (c *serviceClient) CountWorkflowExecutions(ctx context.Context, request *shared.CountWorkflowExecutionsRequest, opts ...yarpc.CallOption) (*shared.CountWorkflowExecutionsResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.CountWorkflowExecutions(ctx, request, opts...)
+}
+
+func (c *serviceClient) PollForActivityTask(ctx context.Context, request *shared.PollForActivityTaskRequest, opts ...yarpc.CallOption) (*shared.PollForActivityTaskResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.PollForActivityTask(ctx, request, opts...)
+}
+
+func (c *serviceClient) PollForDecisionTask(ctx context.Context, request *shared.PollForDecisionTaskRequest, opts ...yarpc.CallOption) (*shared.PollForDecisionTaskResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.PollForDecisionTask(ctx, request, opts...)
+}
+
+func (c *serviceClient) RecordActivityTaskHeartbeat(ctx context.Context, request *shared.RecordActivityTaskHeartbeatRequest, opts ...yarpc.CallOption) (*shared.RecordActivityTaskHeartbeatResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.RecordActivityTaskHeartbeat(ctx, request, opts...)
+}
+
+func (c *serviceClient) RecordActivityTaskHeartbeatByID(ctx context.Context, request *shared.RecordActivityTaskHeartbeatByIDRequest, opts ...yarpc.CallOption) (*shared.RecordActivityTaskHeartbeatResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.RecordActivityTaskHeartbeatByID(ctx, request, opts...)
+}
+
+func (c *serviceClient) RegisterDomain(ctx context.Context, request *shared.RegisterDomainRequest, opts ...yarpc.CallOption) error {
+	client, release := c.acquire()
+	defer release()
+	return client.RegisterDomain(ctx, request, opts...)
+}
+
+func (c *serviceClient) RequestCancelWorkflowExecution(ctx context.Context, request *shared.RequestCancelWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	client, release := c.acquire()
+	defer release()
+	return client.RequestCancelWorkflowExecution(ctx, request, opts...)
+}
+
+func (c *serviceClient) RespondActivityTaskCanceled(ctx context.Context, request *shared.RespondActivityTaskCanceledRequest, opts ...yarpc.CallOption) error {
+	client, release := c.acquire()
+	defer release()
+	return client.RespondActivityTaskCanceled(ctx, request, opts...)
+}
+
+func (c *serviceClient) RespondActivityTaskCompleted(ctx context.Context, request *shared.RespondActivityTaskCompletedRequest, opts ...yarpc.CallOption) error {
+	client, release := c.acquire()
+	defer release()
+	return client.RespondActivityTaskCompleted(ctx, request, opts...)
+}
+
+func (c *serviceClient) RespondActivityTaskFailed(ctx context.Context, request *shared.RespondActivityTaskFailedRequest, opts ...yarpc.CallOption) error {
+	client, release := c.acquire()
+	defer release()
+	return client.RespondActivityTaskFailed(ctx, request, opts...)
+}
+
+func (c *serviceClient) RespondActivityTaskCanceledByID(ctx context.Context, request *shared.RespondActivityTaskCanceledByIDRequest, opts ...yarpc.CallOption) error {
+	client, release := c.acquire()
+	defer release()
+	return client.RespondActivityTaskCanceledByID(ctx, request, opts...)
+}
+
+func (c *serviceClient) RespondActivityTaskCompletedByID(ctx context.Context, request *shared.RespondActivityTaskCompletedByIDRequest, opts ...yarpc.CallOption) error {
+	client, release := c.acquire()
+	defer release()
+	return client.RespondActivityTaskCompletedByID(ctx, request, opts...)
+}
+
+func (c *serviceClient) RespondActivityTaskFailedByID(ctx context.Context, request *shared.RespondActivityTaskFailedByIDRequest, opts ...yarpc.CallOption) error {
+	client, release := c.acquire()
+	defer release()
+	return client.RespondActivityTaskFailedByID(ctx, request, opts...)
+}
+
+func (c *serviceClient) RespondDecisionTaskCompleted(ctx context.Context, request *shared.RespondDecisionTaskCompletedRequest, opts ...yarpc.CallOption) (*shared.RespondDecisionTaskCompletedResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.RespondDecisionTaskCompleted(ctx, request, opts...)
+}
+
+func (c *serviceClient) RespondDecisionTaskFailed(ctx context.Context, request *shared.RespondDecisionTaskFailedRequest, opts ...yarpc.CallOption) error {
+	client, release := c.acquire()
+	defer release()
+	return client.RespondDecisionTaskFailed(ctx, request, opts...)
+}
+
+func (c *serviceClient) SignalWorkflowExecution(ctx context.Context, request *shared.SignalWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	client, release := c.acquire()
+	defer release()
+	return client.SignalWorkflowExecution(ctx, request, opts...)
+}
+
+func (c *serviceClient) SignalWithStartWorkflowExecution(ctx context.Context, request *shared.SignalWithStartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.SignalWithStartWorkflowExecution(ctx, request, opts...)
+}
+
+func (c *serviceClient) SignalWithStartWorkflowExecutionAsync(ctx context.Context, request *shared.SignalWithStartWorkflowExecutionAsyncRequest, opts ...yarpc.CallOption) (*shared.SignalWithStartWorkflowExecutionAsyncResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.SignalWithStartWorkflowExecutionAsync(ctx, request, opts...)
+}
+
+func (c *serviceClient) StartWorkflowExecution(ctx context.Context, request *shared.StartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.StartWorkflowExecution(ctx, request, opts...)
+}
+
+func (c *serviceClient) StartWorkflowExecutionAsync(ctx context.Context, request *shared.StartWorkflowExecutionAsyncRequest, opts ...yarpc.CallOption) (*shared.StartWorkflowExecutionAsyncResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.StartWorkflowExecutionAsync(ctx, request, opts...)
+}
+
+func (c *serviceClient) TerminateWorkflowExecution(ctx context.Context, request *shared.TerminateWorkflowExecutionRequest, opts ...yarpc.CallOption) error {
+	client, release := c.acquire()
+	defer release()
+	return client.TerminateWorkflowExecution(ctx, request, opts...)
+}
+
+func (c *serviceClient) ResetWorkflowExecution(ctx context.Context, request *shared.ResetWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.ResetWorkflowExecutionResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.ResetWorkflowExecution(ctx, request, opts...)
+}
+
+func (c *serviceClient) UpdateDomain(ctx context.Context, request *shared.UpdateDomainRequest, opts ...yarpc.CallOption) (*shared.UpdateDomainResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.UpdateDomain(ctx, request, opts...)
+}
+
+func (c *serviceClient) QueryWorkflow(ctx context.Context, request *shared.QueryWorkflowRequest, opts ...yarpc.CallOption) (*shared.QueryWorkflowResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.QueryWorkflow(ctx, request, opts...)
+}
+
+func (c *serviceClient) ResetStickyTaskList(ctx context.Context, request *shared.ResetStickyTaskListRequest, opts ...yarpc.CallOption) (*shared.ResetStickyTaskListResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.ResetStickyTaskList(ctx, request, opts...)
+}
+
+func (c *serviceClient) DescribeTaskList(ctx context.Context, request *shared.DescribeTaskListRequest, opts ...yarpc.CallOption) (*shared.DescribeTaskListResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.DescribeTaskList(ctx, request, opts...)
+}
+
+func (c *serviceClient) RespondQueryTaskCompleted(ctx context.Context, request *shared.RespondQueryTaskCompletedRequest, opts ...yarpc.CallOption) error {
+	client, release := c.acquire()
+	defer release()
+	return client.RespondQueryTaskCompleted(ctx, request, opts...)
+}
+
+func (c *serviceClient) GetSearchAttributes(ctx context.Context, opts ...yarpc.CallOption) (*shared.GetSearchAttributesResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.GetSearchAttributes(ctx, opts...)
+}
+
+func (c *serviceClient) ListTaskListPartitions(ctx context.Context, request *shared.ListTaskListPartitionsRequest, opts ...yarpc.CallOption) (*shared.ListTaskListPartitionsResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.ListTaskListPartitions(ctx, request, opts...)
+}
+
+func (c *serviceClient) GetClusterInfo(ctx context.Context, opts ...yarpc.CallOption) (*shared.ClusterInfo, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.GetClusterInfo(ctx, opts...)
+}
+
+func (c *serviceClient) GetTaskListsByDomain(ctx context.Context, request *shared.GetTaskListsByDomainRequest, opts ...yarpc.CallOption) (*shared.GetTaskListsByDomainResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.GetTaskListsByDomain(ctx, request, opts...)
+}
+
+func (c *serviceClient) RefreshWorkflowTasks(ctx context.Context, request *shared.RefreshWorkflowTasksRequest, opts ...yarpc.CallOption) error {
+	client, release := c.acquire()
+	defer release()
+	return client.RefreshWorkflowTasks(ctx, request, opts...)
+}
+
+func (c *serviceClient) RestartWorkflowExecution(ctx context.Context, request *shared.RestartWorkflowExecutionRequest, opts ...yarpc.CallOption) (*shared.RestartWorkflowExecutionResponse, error) {
+	client, release := c.acquire()
+	defer release()
+	return client.RestartWorkflowExecution(ctx, request, opts...)
+}
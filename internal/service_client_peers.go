@@ -0,0 +1,197 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+)
+
+// LoadBalancing strategies spread the calls of a ServiceClient across the frontend hosts.
+const (
+	// LoadBalancingRoundRobin sends calls to the available hosts in turn.
+	LoadBalancingRoundRobin LoadBalancing = iota
+	// LoadBalancingLeastPending sends calls to the available host with the fewest calls in flight.
+	LoadBalancingLeastPending
+)
+
+const defaultPeerResolveTimeout = 10 * time.Second
+
+type (
+	// PeerResolver returns the host:port addresses of the Cadence frontend hosts, see WithPeerResolver.
+	PeerResolver interface {
+		Resolve(ctx context.Context) ([]string, error)
+	}
+
+	// LoadBalancing is the strategy a ServiceClient uses to pick the frontend host of each call.
+	LoadBalancing int
+
+	staticPeerResolver []string
+
+	dnsPeerResolver struct {
+		host     string
+		port     string
+		resolver *net.Resolver
+	}
+
+	// peerListUpdater keeps the peers of a yarpc peer list in sync with a PeerResolver.
+	peerListUpdater struct {
+		once            *lifecycle.Once
+		list            peer.List
+		resolver        PeerResolver
+		refreshInterval time.Duration
+		logger          *zap.Logger
+
+		peers   map[string]struct{}
+		closeCh chan struct{}
+		closeWG sync.WaitGroup
+	}
+)
+
+// NewDNSPeerResolver creates a PeerResolver that looks up the addresses of the host of hostPort, e.g.
+// "cadence-frontend.example.com:7933", and returns them along with its port.
+func NewDNSPeerResolver(hostPort string) (PeerResolver, error) {
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return nil, fmt.Errorf("invalid host %q: %w", hostPort, err)
+	}
+	return &dnsPeerResolver{host: host, port: port, resolver: net.DefaultResolver}, nil
+}
+
+func (r *dnsPeerResolver) Resolve(ctx context.Context) ([]string, error) {
+	addrs, err := r.resolver.LookupHost(ctx, r.host)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		hosts = append(hosts, net.JoinHostPort(addr, r.port))
+	}
+	return hosts, nil
+}
+
+func (r staticPeerResolver) Resolve(context.Context) ([]string, error) {
+	return r, nil
+}
+
+func newPeerListUpdater(list peer.List, resolver PeerResolver, refreshInterval time.Duration, logger *zap.Logger) *peerListUpdater {
+	return &peerListUpdater{
+		once:            lifecycle.NewOnce(),
+		list:            list,
+		resolver:        resolver,
+		refreshInterval: refreshInterval,
+		logger:          logger,
+		peers:           make(map[string]struct{}),
+		closeCh:         make(chan struct{}),
+	}
+}
+
+// Start resolves the initial peers, failing if there are none, and then refreshes them every refresh interval.
+func (u *peerListUpdater) Start() error {
+	return u.once.Start(func() error {
+		if err := u.refresh(); err != nil {
+			return err
+		}
+		if u.refreshInterval > 0 {
+			u.closeWG.Add(1)
+			go u.refreshLoop()
+		}
+		return nil
+	})
+}
+
+func (u *peerListUpdater) Stop() error {
+	return u.once.Stop(func() error {
+		close(u.closeCh)
+		u.closeWG.Wait()
+		return nil
+	})
+}
+
+func (u *peerListUpdater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+func (u *peerListUpdater) refreshLoop() {
+	defer u.closeWG.Done()
+	ticker := time.NewTicker(u.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-u.closeCh:
+			return
+		case <-ticker.C:
+		}
+		// keep the current peers if the resolver fails, they may well still be reachable
+		if err := u.refresh(); err != nil {
+			u.logger.Warn("Failed to refresh cadence service hosts.", zap.Error(err))
+		}
+	}
+}
+
+func (u *peerListUpdater) refresh() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultPeerResolveTimeout)
+	defer cancel()
+	hosts, err := u.resolver.Resolve(ctx)
+	if err != nil {
+		return err
+	}
+	if len(hosts) == 0 {
+		return errors.New("no cadence service hosts resolved")
+	}
+
+	resolved := make(map[string]struct{}, len(hosts))
+	var updates peer.ListUpdates
+	for _, host := range hosts {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			return fmt.Errorf("invalid host %q: %w", host, err)
+		}
+		if _, ok := resolved[host]; ok {
+			continue
+		}
+		resolved[host] = struct{}{}
+		if _, ok := u.peers[host]; !ok {
+			updates.Additions = append(updates.Additions, hostport.Identify(host))
+		}
+	}
+	for host := range u.peers {
+		if _, ok := resolved[host]; !ok {
+			updates.Removals = append(updates.Removals, hostport.Identify(host))
+		}
+	}
+	if len(updates.Additions) == 0 && len(updates.Removals) == 0 {
+		return nil
+	}
+	if err := u.list.Update(updates); err != nil {
+		return err
+	}
+	u.peers = resolved
+	return nil
+}
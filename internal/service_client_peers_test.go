@@ -0,0 +1,127 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/zap"
+)
+
+type recordingPeerList struct {
+	sync.Mutex
+	peers map[string]struct{}
+}
+
+func (l *recordingPeerList) Update(updates peer.ListUpdates) error {
+	l.Lock()
+	defer l.Unlock()
+	for _, id := range updates.Additions {
+		if _, ok := l.peers[id.Identifier()]; ok {
+			return errors.New("duplicate peer " + id.Identifier())
+		}
+		l.peers[id.Identifier()] = struct{}{}
+	}
+	for _, id := range updates.Removals {
+		if _, ok := l.peers[id.Identifier()]; !ok {
+			return errors.New("unknown peer " + id.Identifier())
+		}
+		delete(l.peers, id.Identifier())
+	}
+	return nil
+}
+
+func (l *recordingPeerList) hosts() []string {
+	l.Lock()
+	defer l.Unlock()
+	var hosts []string
+	for host := range l.peers {
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+type sequencePeerResolver struct {
+	results [][]string
+	err     error
+}
+
+func (r *sequencePeerResolver) Resolve(context.Context) ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	result := r.results[0]
+	if len(r.results) > 1 {
+		r.results = r.results[1:]
+	}
+	return result, nil
+}
+
+func TestPeerListUpdater(t *testing.T) {
+	list := &recordingPeerList{peers: make(map[string]struct{})}
+	resolver := &sequencePeerResolver{results: [][]string{
+		{"10.0.0.1:7933", "10.0.0.2:7933", "10.0.0.2:7933"},
+		{"10.0.0.2:7933", "10.0.0.3:7933"},
+	}}
+	updater := newPeerListUpdater(list, resolver, 0, zap.NewNop())
+	require.NoError(t, updater.Start())
+	assert.True(t, updater.IsRunning())
+	assert.ElementsMatch(t, []string{"10.0.0.1:7933", "10.0.0.2:7933"}, list.hosts())
+
+	require.NoError(t, updater.refresh())
+	assert.ElementsMatch(t, []string{"10.0.0.2:7933", "10.0.0.3:7933"}, list.hosts())
+
+	// failures keep the current peers
+	resolver.err = errors.New("dns failure")
+	assert.Error(t, updater.refresh())
+	resolver.err = nil
+	resolver.results = [][]string{{}}
+	assert.Error(t, updater.refresh())
+	resolver.results = [][]string{{"10.0.0.4"}}
+	assert.Error(t, updater.refresh())
+	assert.ElementsMatch(t, []string{"10.0.0.2:7933", "10.0.0.3:7933"}, list.hosts())
+
+	require.NoError(t, updater.Stop())
+	assert.False(t, updater.IsRunning())
+}
+
+func TestPeerListUpdater_StartFails(t *testing.T) {
+	list := &recordingPeerList{peers: make(map[string]struct{})}
+	updater := newPeerListUpdater(list, &sequencePeerResolver{err: errors.New("dns failure")}, 0, zap.NewNop())
+	assert.Error(t, updater.Start())
+}
+
+func TestDNSPeerResolver(t *testing.T) {
+	_, err := NewDNSPeerResolver("localhost")
+	assert.Error(t, err)
+
+	resolver, err := NewDNSPeerResolver("127.0.0.1:7933")
+	require.NoError(t, err)
+	hosts, err := resolver.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:7933"}, hosts)
+}
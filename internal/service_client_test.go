@@ -0,0 +1,192 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/yarpcerrors"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common/metrics"
+)
+
+func TestNewServiceClient(t *testing.T) {
+	t.Run("no hosts", func(t *testing.T) {
+		_, err := NewServiceClient(nil)
+		assert.Error(t, err)
+	})
+	t.Run("invalid host", func(t *testing.T) {
+		_, err := NewServiceClient([]string{"127.0.0.1:7933", "localhost"})
+		assert.ErrorContains(t, err, `invalid host "localhost"`)
+	})
+	t.Run("hosts and resolver", func(t *testing.T) {
+		_, err := NewServiceClient([]string{"127.0.0.1:7933"}, WithPeerResolver(staticPeerResolver{"127.0.0.1:7934"}, 0))
+		assert.Error(t, err)
+	})
+	t.Run("unknown load balancing", func(t *testing.T) {
+		_, err := NewServiceClient([]string{"127.0.0.1:7933"}, WithLoadBalancing(LoadBalancing(-1)))
+		assert.Error(t, err)
+	})
+	t.Run("resolver and least pending", func(t *testing.T) {
+		client, err := NewServiceClient(nil,
+			WithPeerResolver(staticPeerResolver{"127.0.0.1:7933", "127.0.0.1:7934"}, time.Minute),
+			WithLoadBalancing(LoadBalancingLeastPending),
+		)
+		require.NoError(t, err)
+		assert.NoError(t, client.Close())
+	})
+	t.Run("start and close", func(t *testing.T) {
+		client, err := NewServiceClient(
+			[]string{"127.0.0.1:7933", "127.0.0.1:7934"},
+			WithServiceName("cadence-frontend-test"),
+			WithCallerName("cadence-client-test"),
+		)
+		require.NoError(t, err)
+		assert.NoError(t, client.Close())
+	})
+}
+
+func TestServiceClient_HealthCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	unreachable := workflowservicetest.NewMockClient(ctrl)
+	unreachable.EXPECT().GetClusterInfo(gomock.Any(), callOptions()...).
+		Return(nil, yarpcerrors.UnavailableErrorf("connection refused")).AnyTimes()
+	reachable := workflowservicetest.NewMockClient(ctrl)
+	reachable.EXPECT().GetClusterInfo(gomock.Any(), callOptions()...).
+		Return(nil, &shared.InternalServiceError{}).AnyTimes()
+
+	var lock sync.Mutex
+	var dials, stops int
+	dial := func() (workflowserviceclient.Interface, func() error, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		dials++
+		stop := func() error {
+			lock.Lock()
+			defer lock.Unlock()
+			stops++
+			return nil
+		}
+		if dials == 1 {
+			return unreachable, stop, nil
+		}
+		return reachable, stop, nil
+	}
+	changes := make(chan bool, 2)
+	scope := tally.NewTestScope("", nil)
+
+	client, err := newServiceClient(serviceClientOptions{
+		metricsScope:                scope,
+		healthCheckInterval:         time.Millisecond,
+		healthCheckFailureThreshold: 2,
+		onConnectivityChange:        func(connected bool) { changes <- connected },
+	}, dial)
+	require.NoError(t, err)
+
+	assert.False(t, <-changes)
+	assert.True(t, <-changes)
+	assert.Equal(t, reachable, client.current())
+	require.NoError(t, client.Close())
+
+	lock.Lock()
+	assert.Equal(t, 2, dials)
+	assert.Equal(t, 2, stops)
+	lock.Unlock()
+	counters := scope.Snapshot().Counters()
+	assert.EqualValues(t, 2, counters[metrics.ServiceHealthCheckFailedCounter+"+"].Value())
+	assert.EqualValues(t, 1, counters[metrics.ServiceConnectivityLostCounter+"+"].Value())
+	assert.EqualValues(t, 1, counters[metrics.ServiceReconnectCounter+"+"].Value())
+}
+
+func TestServiceClient_RedialDrainsInflightCalls(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	old := workflowservicetest.NewMockClient(ctrl)
+	replacement := workflowservicetest.NewMockClient(ctrl)
+	polling := make(chan struct{})
+	respond := make(chan struct{})
+	old.EXPECT().PollForActivityTask(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, *shared.PollForActivityTaskRequest, ...yarpc.CallOption) (*shared.PollForActivityTaskResponse, error) {
+			close(polling)
+			<-respond
+			return &shared.PollForActivityTaskResponse{}, nil
+		})
+	replacement.EXPECT().GetClusterInfo(gomock.Any()).Return(&shared.ClusterInfo{}, nil)
+
+	stopped := make(chan string, 2)
+	clients := []workflowserviceclient.Interface{old, replacement}
+	names := []string{"old", "replacement"}
+	dials := 0
+	dial := func() (workflowserviceclient.Interface, func() error, error) {
+		client, name := clients[dials], names[dials]
+		dials++
+		return client, func() error {
+			stopped <- name
+			return nil
+		}, nil
+	}
+	client, err := newServiceClient(serviceClientOptions{}, dial)
+	require.NoError(t, err)
+
+	pollDone := make(chan error)
+	go func() {
+		_, err := client.PollForActivityTask(context.Background(), &shared.PollForActivityTaskRequest{})
+		pollDone <- err
+	}()
+	<-polling
+	client.redial()
+
+	// new calls use the replacement while the poll is still answered by the old connection
+	_, err = client.GetClusterInfo(context.Background())
+	require.NoError(t, err)
+	select {
+	case name := <-stopped:
+		t.Fatalf("%v connection stopped while a call was in flight", name)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(respond)
+	require.NoError(t, <-pollDone)
+	assert.Equal(t, "old", <-stopped)
+	require.NoError(t, client.Close())
+	assert.Equal(t, "replacement", <-stopped)
+}
+
+func TestIsServiceConnectivityError(t *testing.T) {
+	assert.False(t, isServiceConnectivityError(nil))
+	assert.False(t, isServiceConnectivityError(&shared.BadRequestError{}))
+	assert.False(t, isServiceConnectivityError(errors.New("unexpected")))
+	assert.False(t, isServiceConnectivityError(yarpcerrors.UnimplementedErrorf("unimplemented")))
+	assert.False(t, isServiceConnectivityError(yarpcerrors.UnknownErrorf("unknown")))
+	assert.True(t, isServiceConnectivityError(yarpcerrors.UnavailableErrorf("unavailable")))
+	assert.True(t, isServiceConnectivityError(yarpcerrors.DeadlineExceededErrorf("timeout")))
+}
@@ -35,6 +35,7 @@ import (
 	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
 	"go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common/auth"
+	"go.uber.org/cadence/internal/common/rpcheaders"
 )
 
 type (
@@ -43,6 +44,10 @@ type (
 	// subjected to change in the future.
 	WorkerOptions struct {
 		// Optional: To set the maximum concurrent activity executions this worker can have.
+		// Pollers only poll for a new activity task when an execution slot is available, so tasks do not wait in
+		// the worker's memory for a slot, unless ActivityTaskPriority is set or an activity is registered with
+		// RegisterActivityOptions.MaxConcurrentExecutionSize: polled tasks then wait in a dispatch queue of up to
+		// ActivityTaskDispatchQueueSize tasks.
 		// The zero value of this uses the default value.
 		// default: defaultMaxConcurrentActivityExecutionSize(1k)
 		MaxConcurrentActivityExecutionSize int
@@ -91,6 +96,7 @@ type (
 		MinConcurrentActivityTaskPollers int
 
 		// Optional: To set the maximum concurrent decision task executions this worker can have.
+		// Pollers only poll for a new decision task when an execution slot is available.
 		// The zero value of this uses the default value.
 		// default: defaultMaxConcurrentTaskExecutionSize(1k)
 		MaxConcurrentDecisionTaskExecutionSize int
@@ -128,12 +134,25 @@ type (
 		PollerAutoScalerDryRun bool
 
 		// Optional: Sets an identify that can be used to track this host for debugging.
-		// default: default identity that include hostname, groupName and process ID.
+		// default: default identity of the form taskList@host@pid@libraryVersion@uuid, see SetWorkerIdentityFunc.
 		Identity string
 
 		// Optional: Defines the 'zone' or the failure group that the worker belongs to
 		IsolationGroup string
 
+		// Optional: Headers sent with every decision and activity task poll request, to advertise metadata of the
+		// worker, like its zone or rack, to task routing features of the server. Unlike IsolationGroup, which is sent
+		// with all requests, these are only added to polls.
+		// Header names must not be empty or use the reserved cadence- prefix.
+		// default: no headers
+		PollHeaders map[string]string
+
+		// Optional: Headers attached to every service call made by the worker, including polls and task
+		// responses, e.g. a routing key for proxies in front of cadence, see ClientOptions.Headers.
+		// Header names must not be empty or use the reserved cadence- prefix.
+		// default: no headers
+		Headers map[string]string
+
 		// Optional: Metrics to be reported. Metrics emitted by the cadence client are not prometheus compatible by
 		// default. To ensure metrics are compatible with prometheus make sure to create tally scope with sanitizer
 		// options set.
@@ -163,6 +182,16 @@ type (
 		// default: no metrics.
 		MetricsScope tally.Scope
 
+		// Optional: Prefix added to the names of all metrics emitted by the worker, as a sub-scope of MetricsScope.
+		// default: no prefix.
+		MetricsPrefix string
+
+		// Optional: Tags added to all metrics emitted by the worker, e.g. the environment, service or data center
+		// of the worker, so that a MetricsScope does not need to be tagged by each consumer. The tags set by the
+		// framework, like domain and tasklist, take precedence.
+		// default: no tags.
+		MetricsTags map[string]string
+
 		// Optional: Logger framework can use to log.
 		// default: default logger provided.
 		Logger *zap.Logger
@@ -182,6 +211,49 @@ type (
 		// default: false
 		DisableActivityWorker bool
 
+		// Optional: Disable the local dispatch of activities. By default, activities scheduled by the workflows of a
+		// worker on its own task list are handed to its idle activity pollers along with the decision task
+		// completion, and start without a poll round trip to the server. Local dispatch is also disabled when
+		// TaskListActivitiesPerSecond is set, to not bypass the task list rate limit.
+		// default: false
+		DisableActivityLocalDispatch bool
+
+		// Optional: Additional task lists polled by the activity worker besides the worker's own task list. All task
+		// lists share the activity pollers and the MaxConcurrentActivityExecutionSize execution slots, so low-volume
+		// task lists do not each require a dedicated fleet of pollers. Polls are spread across the task lists by
+		// Weight, relative to a weight of 1 for the worker's own task list; include the worker's own task list to
+		// change its weight.
+		// default: nil
+		AdditionalActivityTaskLists []ActivityTaskListWeight
+
+		// Optional: Enables the dispatch of activity tasks by priority, e.g. to keep bulk backfill jobs from starving
+		// latency-sensitive activities sharing the worker. It is called with each polled activity task, and once all
+		// MaxConcurrentActivityExecutionSize execution slots are in use, the waiting task with the highest returned
+		// priority is executed first when a slot frees up. Tasks of the same priority are executed in poll order.
+		// To have tasks to choose from, the worker polls up to ActivityTaskDispatchQueueSize tasks ahead of the free
+		// execution slots. Note that the StartToClose timeout of a queued activity is already running, and queued
+		// activities are not executed if the worker is stopped, they are retried once they time out.
+		// default: nil, activity tasks are executed in poll order.
+		ActivityTaskPriority func(info ActivityTaskPriorityInfo) int
+
+		// Optional: The maximum number of polled activity tasks waiting for an execution slot, see
+		// ActivityTaskPriority and RegisterActivityOptions.MaxConcurrentExecutionSize.
+		// default: MaxConcurrentActivityTaskPollers
+		ActivityTaskDispatchQueueSize int
+
+		// Optional: The fraction of an activity's heartbeat timeout that its heartbeats are batched for, in (0, 1).
+		// RecordHeartbeat calls only reach the server once per batch, and the batch reports the latest details
+		// recorded in it; they are also reported when the activity fails or is canceled. Lower it to leave more time
+		// to retry failed heartbeats before the activity times out, at the cost of more heartbeat requests.
+		// default: 0.8
+		HeartbeatThrottleRatio float64
+
+		// Optional: The longest time activity heartbeats are batched for, see HeartbeatThrottleRatio. Cap it to have
+		// the progress of activities with long heartbeat timeouts reported sooner, at the cost of more heartbeat
+		// requests.
+		// default: 0, which batches heartbeats for HeartbeatThrottleRatio of the heartbeat timeout
+		MaxHeartbeatThrottleInterval time.Duration
+
 		// Optional: Disable sticky execution.
 		// default: false
 		// Sticky Execution is to run the decision tasks for one workflow execution on same worker host. This is an
@@ -192,11 +264,60 @@ type (
 		// is available for any worker to pick up and resume the progress.
 		DisableStickyExecution bool
 
+		// Optional: Identifier of the build of the worker code. It is sent with the decision task polls and
+		// completions of this worker instead of the binary checksum of the process, see SetBinaryChecksum, and is
+		// recorded in the DecisionTaskCompleted events of the workflows it processes. Build IDs can be used to reset
+		// workflows with the auto-reset feature, and listed with GetWorkflowBuildIDs.
+		// default: the binary checksum of the process
+		BuildID string
+
 		// Optional: Sticky schedule to start timeout.
 		// default: 5s
 		// The resolution is seconds. See details about StickyExecution on the comments for DisableStickyExecution.
 		StickyScheduleToStartTimeout time.Duration
 
+		// Optional: Timeout of the long poll requests for decision and activity tasks. When no task is available,
+		// cadence server completes a poll request after about a minute, so the timeout should be longer than that.
+		// Polls in progress are canceled as soon as the worker is stopped, regardless of this timeout.
+		// default: 150s
+		TaskPollTimeout time.Duration
+
+		// Optional: Size in bytes above which a workflow input or result, or an activity input or result,
+		// is logged as a warning together with the execution it belongs to. Payload sizes are always
+		// emitted as histograms, the threshold only controls logging.
+		// default: 0, which disables the warning
+		LargePayloadWarningThreshold int
+
+		// Optional: Activity options used by all workflows of this worker for the fields the workflow code
+		// leaves unset: task list, timeouts and retry policy. Options set on the workflow context always win,
+		// so platform teams can enforce defaults without changing every workflow.
+		// default: nil, activities are scheduled with the options set on the workflow context only
+		DefaultActivityOptions *ActivityOptions
+
+		// Optional: Soft limits on the history event count and size of the workflows of this worker, checked
+		// on every decision task. Workflows growing past them are reported to a callback and through metrics,
+		// or failed, so they can be fixed with ContinueAsNew before reaching the hard limits of the server.
+		// default: nil, which disables the checks
+		HistorySoftLimits *HistorySoftLimits
+
+		// Optional: Notified of the decision tasks, activities, timers and completions of the workflows of this
+		// worker, see DecisionTaskListener.
+		// default: no listener
+		DecisionTaskListener DecisionTaskListener
+
+		// Optional: Called with the statistics of the workflow coroutine dispatcher every time the workflow code
+		// of this worker ran until it blocked, so that pathological workflow code like tight loops is observable
+		// before it causes decision task timeouts. The statistics are also emitted as metrics, outside of replay.
+		// It must not block, as it runs on the decision task processing path.
+		// default: no handler
+		DispatcherStatsHandler func(info *WorkflowInfo, stats DispatcherStats, isReplay bool)
+
+		// Optional: Logs the history events received with every decision task and the decisions sent back,
+		// pretty-printed and with payloads truncated, to help diagnosing stuck or looping workflows.
+		// It is very verbose and meant to be turned on temporarily.
+		// default: false
+		EnableDecisionTaskDebugLogging bool
+
 		// Optional: sets context for activity. The context can be used to pass any configuration to activity
 		// like common logger for all activities.
 		BackgroundActivityContext context.Context
@@ -206,6 +327,11 @@ type (
 		// default: NonDeterministicWorkflowPolicyBlockWorkflow, which just logs error but reply nothing back to server
 		NonDeterministicWorkflowPolicy NonDeterministicWorkflowPolicy
 
+		// Optional: Sets how decision worker deals with a panic in the workflow code.
+		// default: WorkflowPanicPolicyBlockWorkflow, which fails the decision task so that it is retried
+		// once the workflow code is fixed and the worker is redeployed.
+		WorkflowPanicPolicy WorkflowPanicPolicy
+
 		// Optional: Sets DataConverter to customize serialization/deserialization of arguments in Cadence
 		// default: defaultDataConverter, an combination of thriftEncoder and jsonEncoder
 		DataConverter DataConverter
@@ -230,6 +356,13 @@ type (
 		// default: 1000
 		MaxConcurrentSessionExecutionSize int
 
+		// Optional: Enable an additional activity worker polling the host-specific task list of this worker.
+		// An activity can find that task list through activity.GetHostSpecificTaskList and return it to the
+		// workflow, which can then schedule follow-up activities on the same host via ActivityOptions.TaskList.
+		// Unlike sessions, no resource accounting or session lifecycle is involved.
+		// default: false
+		EnableHostSpecificTaskList bool
+
 		// Optional: Specifies factories used to instantiate workflow interceptor chain
 		// The chain is instantiated per each replay of a workflow execution
 		WorkflowInterceptorChainFactories []WorkflowInterceptorFactory
@@ -266,11 +399,25 @@ type (
 		// default: No provider
 		Authorization auth.AuthorizationProvider
 
+		// Optional: Wraps the service used by the worker, e.g. to log, instrument or inject faults into RPCs.
+		// It applies to polls, responds and heartbeats alike, and is applied closest to the transport, so the
+		// latency and errors it adds are reflected in the worker's RPC metrics.
+		// default: no wrapper
+		ServiceWrapper ServiceWrapper
+
 		// Optional: See WorkerBugPorts for more details
 		//
 		// Deprecated: All bugports are always deprecated and may be removed at any time.
 		WorkerBugPorts WorkerBugPorts
 
+		// Optional: Callback invoked after all underlying workers have started successfully.
+		// default: no callback
+		OnStart func()
+
+		// Optional: Callback invoked after all underlying workers have stopped.
+		// default: no callback
+		OnStop func()
+
 		// Optional: WorkerStats provides a set of methods that can be used to collect
 		// stats on the Worker for debugging purposes.
 		// default: noop implementation provided
@@ -278,6 +425,100 @@ type (
 		WorkerStats debug.WorkerStats
 	}
 
+	// HistorySoftLimits configures the history guardrails of a worker, see WorkerOptions.HistorySoftLimits.
+	HistorySoftLimits struct {
+		// Optional: Number of history events above which a workflow is considered over the limit.
+		// default: 0, which disables the event count check
+		EventCount int64
+		// Optional: Estimated history size in bytes above which a workflow is considered over the limit.
+		// default: 0, which disables the size check
+		SizeBytes int64
+		// Optional: Callback invoked for each decision task of a workflow which is over a limit.
+		// It must not block, as it runs on the decision task processing path.
+		// default: no callback, the violation is only logged and counted
+		OnExceeded func(HistoryLimitExceededInfo)
+		// Optional: Fails workflows which are over a limit with a HistorySoftLimitExceeded custom error,
+		// instead of letting them grow until the server terminates them.
+		// default: false
+		FailWorkflow bool
+	}
+
+	// HistoryLimitExceededInfo describes a workflow which exceeds the HistorySoftLimits of its worker.
+	HistoryLimitExceededInfo struct {
+		WorkflowInfo WorkflowInfo
+		// EventCount is the number of history events of the workflow.
+		EventCount int64
+		// SizeBytes is the estimated history size of the workflow in bytes.
+		SizeBytes int64
+	}
+
+	// WorkerIdentityInfo describes the process a default identity is generated for, see SetWorkerIdentityFunc.
+	WorkerIdentityInfo struct {
+		// TaskList of the worker, empty for clients.
+		TaskList       string
+		HostName       string
+		PID            int
+		LibraryVersion string
+	}
+
+	// ActivityTaskListWeight is a task list polled by an activity worker, see WorkerOptions.AdditionalActivityTaskLists.
+	ActivityTaskListWeight struct {
+		Name string
+		// Weight is the relative share of polls for the task list.
+		// default: 1
+		Weight int
+	}
+
+	// ActivityTaskPriorityInfo describes a polled activity task, see WorkerOptions.ActivityTaskPriority.
+	ActivityTaskPriorityInfo struct {
+		// TaskList is the task list the task was polled from.
+		TaskList     string
+		WorkflowType string
+		ActivityType string
+		// Header is the header of the activity, e.g. with a priority set by a ContextPropagator of the workflow.
+		Header map[string][]byte
+	}
+
+	// DispatcherStats describes a single run of the workflow code of a workflow execution, from the moment it was
+	// unblocked, for example by a new decision task, until all of its coroutines completed or blocked again.
+	DispatcherStats struct {
+		// Coroutines is the number of live coroutines of the workflow after the run.
+		Coroutines int
+		// RunQueueLength is the number of times a coroutine was scheduled to run until all of them blocked.
+		RunQueueLength int
+		// MaxCoroutineRunTime is the longest time a single coroutine ran without yielding.
+		MaxCoroutineRunTime time.Duration
+		// MaxCoroutineName is the name of the coroutine which ran for MaxCoroutineRunTime.
+		MaxCoroutineName string
+	}
+
+	// WorkerHealth reports the health of a worker, for example to back readiness and liveness probes.
+	WorkerHealth struct {
+		// Pollers reports the health of each poller group polling the Cadence service.
+		// Poller groups which are not started, like workers without registered workflows, are not included.
+		Pollers []PollerHealth
+		// Number of workflow types registered on the worker, including the global registry.
+		RegisteredWorkflows int
+		// Number of activity types registered on the worker, including the global registry.
+		RegisteredActivities int
+	}
+
+	// PollerHealth reports the health of a group of pollers polling a single task list.
+	PollerHealth struct {
+		// WorkerType is the type of the worker the pollers belong to, e.g. DecisionWorker or ActivityWorker.
+		WorkerType string
+		// TaskList polled by the pollers.
+		TaskList string
+		// Running is true after the pollers were started and before they are stopped.
+		Running bool
+		// LastSuccessfulPollTime is the time of the last poll call which returned without error,
+		// including empty polls. Zero if no poll succeeded yet. The pollers of the LocallyDispatchedActivityWorker
+		// do not poll the server, their poll only returns once an activity is dispatched to them.
+		LastSuccessfulPollTime time.Time
+		// LastPollError is the error of the last poll call, nil if it succeeded.
+		LastPollError error
+	}
+
 	// WorkerBugPorts allows opt-in enabling of older, possibly buggy behavior, primarily intended to allow temporarily
 	// emulating old behavior until a fix is deployed.
 	// By default, bugs (especially rarely-occurring ones) are fixed and all users are opted into the new behavior.
@@ -317,6 +558,20 @@ const (
 	NonDeterministicWorkflowPolicyFailWorkflow
 )
 
+// WorkflowPanicPolicy is an enum for configuring how client's decision task handler deals with
+// a panic in the workflow code.
+type WorkflowPanicPolicy int
+
+const (
+	// WorkflowPanicPolicyBlockWorkflow is the default policy for handling workflow panics.
+	// It logs the panic and fails the decision task, which the server retries until the workflow code is
+	// fixed or the workflow times out. The workflow execution itself is left open.
+	WorkflowPanicPolicyBlockWorkflow WorkflowPanicPolicy = iota
+	// WorkflowPanicPolicyFailWorkflow logs the panic and replies back with a request to fail the workflow
+	// execution with a PanicError.
+	WorkflowPanicPolicyFailWorkflow
+)
+
 // NewWorker creates an instance of worker for managing workflow and activity executions.
 // service 	- thrift connection to the cadence server.
 // domain - the name of the cadence domain.
@@ -381,11 +636,78 @@ func ReplayPartialWorkflowHistoryFromJSONFile(logger *zap.Logger, jsonfileName s
 	return r.ReplayPartialWorkflowHistoryFromJSONFile(logger, jsonfileName, lastEventID)
 }
 
+// SetWorkerIdentityFunc overrides how default identities are generated for workers and clients that are created
+// without an explicit Identity option, e.g. to include a pod or deployment name. If fn returns an empty string, the
+// default identity is used. It should be called before creating any worker or client.
+func SetWorkerIdentityFunc(fn func(info WorkerIdentityInfo) string) {
+	workerIdentityFunc.Store(fn)
+}
+
 // Validate sanity validation of WorkerOptions
 func (o WorkerOptions) Validate() error {
 	// decision task pollers must be >= 2 or unset if sticky tasklist is enabled https://github.com/uber-go/cadence-client/issues/1369
 	if !o.DisableStickyExecution && (o.MaxConcurrentDecisionTaskPollers == 1 || o.MinConcurrentDecisionTaskPollers == 1) {
 		return fmt.Errorf("DecisionTaskPollers must be >= 2 or use default value")
 	}
+	seen := make(map[string]bool, len(o.AdditionalActivityTaskLists))
+	for _, tl := range o.AdditionalActivityTaskLists {
+		if tl.Name == "" {
+			return fmt.Errorf("AdditionalActivityTaskLists must not contain an empty task list name")
+		}
+		if seen[tl.Name] {
+			return fmt.Errorf("AdditionalActivityTaskLists contains task list %q more than once", tl.Name)
+		}
+		seen[tl.Name] = true
+		if tl.Weight < 0 {
+			return fmt.Errorf("AdditionalActivityTaskLists weight of task list %q must not be negative", tl.Name)
+		}
+	}
+	if o.HeartbeatThrottleRatio < 0 || o.HeartbeatThrottleRatio >= 1 {
+		return fmt.Errorf("HeartbeatThrottleRatio must be in (0, 1) or use default value")
+	}
+	if o.PollerAutoScalerTargetUtilization < 0 || o.PollerAutoScalerTargetUtilization > 1 {
+		return fmt.Errorf("PollerAutoScalerTargetUtilization must be in (0, 1] or use default value")
+	}
+	// zero values are replaced with defaults, negative ones would silently produce a worker that does not work
+	for _, option := range []struct {
+		name  string
+		value float64
+	}{
+		{"MaxConcurrentActivityExecutionSize", float64(o.MaxConcurrentActivityExecutionSize)},
+		{"ActivityTaskDispatchQueueSize", float64(o.ActivityTaskDispatchQueueSize)},
+		{"WorkerActivitiesPerSecond", o.WorkerActivitiesPerSecond},
+		{"MaxConcurrentLocalActivityExecutionSize", float64(o.MaxConcurrentLocalActivityExecutionSize)},
+		{"WorkerLocalActivitiesPerSecond", o.WorkerLocalActivitiesPerSecond},
+		{"TaskListActivitiesPerSecond", o.TaskListActivitiesPerSecond},
+		{"MinConcurrentActivityTaskPollers", float64(o.MinConcurrentActivityTaskPollers)},
+		{"MaxConcurrentDecisionTaskExecutionSize", float64(o.MaxConcurrentDecisionTaskExecutionSize)},
+		{"WorkerDecisionTasksPerSecond", o.WorkerDecisionTasksPerSecond},
+		{"MinConcurrentDecisionTaskPollers", float64(o.MinConcurrentDecisionTaskPollers)},
+		{"MaxConcurrentSessionExecutionSize", float64(o.MaxConcurrentSessionExecutionSize)},
+		{"PollerAutoScalerCooldown", float64(o.PollerAutoScalerCooldown)},
+		{"MaxHeartbeatThrottleInterval", float64(o.MaxHeartbeatThrottleInterval)},
+		{"StickyScheduleToStartTimeout", float64(o.StickyScheduleToStartTimeout)},
+		{"WorkerStopTimeout", float64(o.WorkerStopTimeout)},
+		{"LargePayloadWarningThreshold", float64(o.LargePayloadWarningThreshold)},
+	} {
+		if option.value < 0 {
+			return fmt.Errorf("%v must not be negative, set it to 0 to use the default value", option.name)
+		}
+	}
+	if o.MinConcurrentActivityTaskPollers > 0 && o.MaxConcurrentActivityTaskPollers > 0 &&
+		o.MinConcurrentActivityTaskPollers > o.MaxConcurrentActivityTaskPollers {
+		return fmt.Errorf("MinConcurrentActivityTaskPollers must not be greater than MaxConcurrentActivityTaskPollers")
+	}
+	if o.MinConcurrentDecisionTaskPollers > 0 && o.MaxConcurrentDecisionTaskPollers > 0 &&
+		o.MinConcurrentDecisionTaskPollers > o.MaxConcurrentDecisionTaskPollers {
+		return fmt.Errorf("MinConcurrentDecisionTaskPollers must not be greater than MaxConcurrentDecisionTaskPollers")
+	}
+	// the client's own headers, like its version and feature flags, would be sent twice
+	if err := rpcheaders.Validate(o.Headers); err != nil {
+		return fmt.Errorf("invalid Headers: %w", err)
+	}
+	if err := rpcheaders.Validate(o.PollHeaders); err != nil {
+		return fmt.Errorf("invalid PollHeaders: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,176 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+)
+
+type (
+	// WorkerGroupOptions is used to configure a WorkerGroup.
+	WorkerGroupOptions struct {
+		// Optional: Metrics scope shared by all workers of the group which don't set WorkerOptions.MetricsScope.
+		// default: no metrics.
+		MetricsScope tally.Scope
+
+		// Optional: Logger shared by all workers of the group which don't set WorkerOptions.Logger.
+		// default: default logger provided.
+		Logger *zap.Logger
+	}
+
+	// GroupWorker is a worker of a WorkerGroup, see worker.Worker.
+	// Its lifecycle is managed by the group.
+	GroupWorker interface {
+		RegisterWorkflow(w interface{})
+		RegisterWorkflowWithOptions(w interface{}, options RegisterWorkflowOptions)
+		GetRegisteredWorkflows() []RegistryWorkflowInfo
+		RegisterActivity(a interface{})
+		RegisterActivityWithOptions(a interface{}, options RegisterActivityOptions)
+		GetRegisteredActivities() []RegistryActivityInfo
+		DeregisterWorkflow(w interface{}) error
+		DeregisterActivity(a interface{}) error
+		Start() error
+		Run() error
+		RunWithInterrupt(interruptCh <-chan interface{}) error
+		Stop()
+		Health() WorkerHealth
+	}
+
+	// WorkerGroup manages the lifecycle of several workers hosted in one process,
+	// polling different task lists or domains through one service connection.
+	// A group cannot be started again once it is stopped.
+	WorkerGroup struct {
+		service workflowserviceclient.Interface
+		options WorkerGroupOptions
+
+		sync.Mutex
+		workers []*aggregatedWorker
+		started bool
+		stopped bool
+	}
+)
+
+var _ GroupWorker = (*aggregatedWorker)(nil)
+
+// NewWorkerGroup creates an empty WorkerGroup using the given service connection for all its workers.
+func NewWorkerGroup(service workflowserviceclient.Interface, options WorkerGroupOptions) *WorkerGroup {
+	return &WorkerGroup{
+		service: service,
+		options: options,
+	}
+}
+
+// AddWorker creates a worker for the domain and task list and adds it to the group.
+// Workers can only be added before the group is started.
+func (g *WorkerGroup) AddWorker(domain, taskList string, options WorkerOptions) (GroupWorker, error) {
+	if options.MetricsScope == nil {
+		options.MetricsScope = g.options.MetricsScope
+	}
+	if options.Logger == nil {
+		options.Logger = g.options.Logger
+	}
+
+	g.Lock()
+	defer g.Unlock()
+	if g.started || g.stopped {
+		return nil, fmt.Errorf("cannot add worker for domain %v and task list %v after the worker group is started", domain, taskList)
+	}
+	w, err := newAggregatedWorker(g.service, domain, taskList, options)
+	if err != nil {
+		return nil, err
+	}
+	g.workers = append(g.workers, w)
+	return w, nil
+}
+
+// Start starts all workers of the group. If any of them fails to start, the already started ones are stopped.
+// It returns an error if the group was stopped.
+func (g *WorkerGroup) Start() error {
+	g.Lock()
+	defer g.Unlock()
+	if g.stopped {
+		return errors.New("cannot start a stopped worker group")
+	}
+	if g.started {
+		return nil
+	}
+	for i, w := range g.workers {
+		if err := w.Start(); err != nil {
+			stopWorkers(g.workers[:i])
+			return err
+		}
+	}
+	g.started = true
+	return nil
+}
+
+// RunWithInterrupt starts all workers of the group and stops them once a value is received from
+// interruptCh or the channel is closed. It returns an error only if the group fails to start.
+func (g *WorkerGroup) RunWithInterrupt(interruptCh <-chan interface{}) error {
+	if err := g.Start(); err != nil {
+		return err
+	}
+	<-interruptCh
+	g.Stop()
+	return nil
+}
+
+// Stop stops all workers of the group concurrently and waits for them to finish.
+func (g *WorkerGroup) Stop() {
+	g.Lock()
+	defer g.Unlock()
+	if !g.started {
+		return
+	}
+	stopWorkers(g.workers)
+	g.started = false
+	g.stopped = true
+}
+
+// Health returns the health of each worker of the group, in the order they were added.
+func (g *WorkerGroup) Health() []WorkerHealth {
+	g.Lock()
+	defer g.Unlock()
+	result := make([]WorkerHealth, 0, len(g.workers))
+	for _, w := range g.workers {
+		result = append(result, w.Health())
+	}
+	return result
+}
+
+func stopWorkers(workers []*aggregatedWorker) {
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func(w *aggregatedWorker) {
+			defer wg.Done()
+			w.Stop()
+		}(w)
+	}
+	wg.Wait()
+}
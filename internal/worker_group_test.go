@@ -0,0 +1,81 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+
+	"go.uber.org/cadence/.gen/go/cadence/workflowservicetest"
+	"go.uber.org/cadence/.gen/go/shared"
+	"go.uber.org/cadence/internal/common/testlogger"
+)
+
+func TestWorkerGroup(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	service := workflowservicetest.NewMockClient(mockCtrl)
+	service.EXPECT().DescribeDomain(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.DescribeDomainResponse{}, nil).AnyTimes()
+	service.EXPECT().PollForActivityTask(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.PollForActivityTaskResponse{}, nil).AnyTimes()
+
+	scope := tally.NewTestScope("", nil)
+	group := NewWorkerGroup(service, WorkerGroupOptions{
+		MetricsScope: scope,
+		Logger:       testlogger.NewZap(t),
+	})
+	for _, taskList := range []string{"tl1", "tl2"} {
+		w, err := group.AddWorker("domain", taskList, WorkerOptions{DisableWorkflowWorker: true})
+		require.NoError(t, err)
+		w.RegisterActivityWithOptions(func(ctx context.Context) error { return nil }, RegisterActivityOptions{Name: "activity"})
+	}
+
+	require.NoError(t, group.Start())
+	_, err := group.AddWorker("domain", "tl3", WorkerOptions{})
+	assert.Error(t, err)
+
+	health := group.Health()
+	require.Len(t, health, 2)
+	for i, taskList := range []string{"tl1", "tl2"} {
+		require.NotEmpty(t, health[i].Pollers)
+		assert.Equal(t, taskList, health[i].Pollers[0].TaskList)
+		assert.True(t, health[i].Pollers[0].Running)
+	}
+
+	interruptCh := make(chan interface{})
+	close(interruptCh)
+	require.NoError(t, group.RunWithInterrupt(interruptCh))
+	for _, h := range group.Health() {
+		for _, p := range h.Pollers {
+			assert.False(t, p.Running)
+		}
+	}
+	// workers report to the shared metrics scope
+	assert.NotEmpty(t, scope.Snapshot().Counters())
+
+	// a stopped group cannot be restarted
+	assert.Error(t, group.Start())
+	_, err = group.AddWorker("domain", "tl3", WorkerOptions{})
+	assert.Error(t, err)
+}
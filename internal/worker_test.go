@@ -22,6 +22,7 @@ package internal
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -57,6 +58,84 @@ func Test_NewWorker(t *testing.T) {
 			},
 			expectErr: "DecisionTaskPollers must be >= 2 or use default value",
 		},
+		{
+			name: "happy with additional activity task lists",
+			options: WorkerOptions{
+				AdditionalActivityTaskLists: []ActivityTaskListWeight{{Name: "test-tasklist", Weight: 3}, {Name: "gpu"}},
+			},
+			expectErr: "",
+		},
+		{
+			name: "invalid worker with duplicate additional activity task list",
+			options: WorkerOptions{
+				AdditionalActivityTaskLists: []ActivityTaskListWeight{{Name: "gpu"}, {Name: "gpu", Weight: 2}},
+			},
+			expectErr: `contains task list "gpu" more than once`,
+		},
+		{
+			name: "invalid worker with negative activity task list weight",
+			options: WorkerOptions{
+				AdditionalActivityTaskLists: []ActivityTaskListWeight{{Name: "gpu", Weight: -1}},
+			},
+			expectErr: "must not be negative",
+		},
+		{
+			name: "invalid worker with heartbeat throttle ratio above 1",
+			options: WorkerOptions{
+				HeartbeatThrottleRatio: 1.5,
+			},
+			expectErr: "HeartbeatThrottleRatio must be in (0, 1)",
+		},
+		{
+			name: "invalid worker with heartbeat throttle ratio of 1",
+			options: WorkerOptions{
+				HeartbeatThrottleRatio: 1,
+			},
+			expectErr: "HeartbeatThrottleRatio must be in (0, 1)",
+		},
+		{
+			name: "invalid worker with negative execution size",
+			options: WorkerOptions{
+				MaxConcurrentActivityExecutionSize: -1,
+			},
+			expectErr: "MaxConcurrentActivityExecutionSize must not be negative",
+		},
+		{
+			name: "invalid worker with negative activity task dispatch queue size",
+			options: WorkerOptions{
+				ActivityTaskDispatchQueueSize: -1,
+			},
+			expectErr: "ActivityTaskDispatchQueueSize must not be negative",
+		},
+		{
+			name: "invalid worker with negative timeout",
+			options: WorkerOptions{
+				WorkerStopTimeout: -time.Second,
+			},
+			expectErr: "WorkerStopTimeout must not be negative",
+		},
+		{
+			name: "invalid worker with more min than max pollers",
+			options: WorkerOptions{
+				MinConcurrentActivityTaskPollers: 4,
+				MaxConcurrentActivityTaskPollers: 2,
+			},
+			expectErr: "MinConcurrentActivityTaskPollers must not be greater than MaxConcurrentActivityTaskPollers",
+		},
+		{
+			name: "invalid worker with reserved poll header",
+			options: WorkerOptions{
+				PollHeaders: map[string]string{"Cadence-Client-Name": "other"},
+			},
+			expectErr: "uses the reserved cadence- prefix",
+		},
+		{
+			name: "invalid worker with reserved header",
+			options: WorkerOptions{
+				Headers: map[string]string{"cadence-feature-flags": "{}"},
+			},
+			expectErr: "invalid Headers: header \"cadence-feature-flags\" uses the reserved cadence- prefix",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
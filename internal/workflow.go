@@ -25,9 +25,11 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/robfig/cron"
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 
@@ -179,6 +181,17 @@ type (
 		// Close closes the Channel, and prohibits subsequent sends.
 		// As with a normal Go channel that has been closed, sending to a closed channel will panic.
 		Close()
+
+		// Len returns the number of values buffered in the Channel, and not yet received.
+		// Like for a Go channel, values of blocked senders are not included.
+		//
+		// This is equivalent to `len(aChannel)`.
+		Len() int
+
+		// Cap returns the buffer size of the Channel, zero for unbuffered channels.
+		//
+		// This is equivalent to `cap(aChannel)`.
+		Cap() int
 	}
 
 	// Selector must be used in workflows instead of a native Go select statement.
@@ -233,6 +246,12 @@ type (
 	//
 	// 2. There is no way to remove a case from a Selector, so you must make a new Selector to "remove" them.
 	//
+	// 3. Unlike a Go select statement, which picks a random case when several of them are ready, a Selector is
+	// deterministic, as required for workflow replay. Select checks the cases in the order they were added and invokes
+	// the first ready one. When it has to wait, it invokes the case which becomes ready first. As a consequence, a
+	// case added early which is always ready can starve the cases added after it. Use workflow.NewFairSelector to
+	// check the cases in a round-robin order instead, still deterministically.
+	//
 	// Finally, note that Select will not return until a condition's needs are met, like a Go selector - canceling the
 	// Context used to construct the Selector, or the Context used to Select, will not (directly) unblock a Select call.
 	// Read Select for more details.
@@ -327,6 +346,34 @@ type (
 		Wait(ctx Context)
 	}
 
+	// Mutex must be used instead of native go sync.Mutex by workflow code to
+	// coordinate the coroutines started with workflow.Go. Use
+	// workflow.NewMutex(ctx) method to create a new Mutex instance
+	Mutex interface {
+		// Lock blocks until the mutex is acquired. Coroutines waiting for the mutex acquire it in the order
+		// they called Lock. Returns CanceledError if the ctx is canceled before the mutex is acquired.
+		Lock(ctx Context) error
+		// TryLock acquires the mutex without blocking, returns false if it is already locked.
+		TryLock() bool
+		// Unlock releases the mutex, it panics if the mutex is not locked.
+		Unlock()
+	}
+
+	// Semaphore must be used instead of native go semaphores by workflow code to
+	// limit the concurrency of the coroutines started with workflow.Go. Use
+	// workflow.NewSemaphore(ctx, size) method to create a new Semaphore instance
+	Semaphore interface {
+		// Acquire blocks until n permits are acquired. Coroutines waiting for permits acquire them in the order
+		// they called Acquire. Returns CanceledError if the ctx is canceled before the permits are acquired,
+		// and an error if n is not positive or is larger than the size of the semaphore.
+		Acquire(ctx Context, n int64) error
+		// TryAcquire acquires n permits without blocking, returns false if they are not available.
+		// It panics if n is not positive.
+		TryAcquire(n int64) bool
+		// Release releases n permits, it panics if n is not positive or more permits are released than are held.
+		Release(n int64)
+	}
+
 	// Future represents the result of an asynchronous computation.
 	Future interface {
 		// Get blocks until the future is ready.
@@ -389,10 +436,12 @@ type (
 		SignalChildWorkflow(ctx Context, signalName string, data interface{}) Future
 	}
 
-	// RegistryWorkflowInfo
+	// RegistryWorkflowInfo describes a workflow registered on a worker or replayer.
 	RegistryWorkflowInfo interface {
 		WorkflowType() WorkflowType
 		GetFunction() interface{}
+		// GetOptions returns the options the workflow was registered with.
+		GetOptions() RegisterWorkflowOptions
 	}
 
 	// WorkflowType identifies a workflow type.
@@ -405,6 +454,9 @@ type (
 	WorkflowExecution struct {
 		ID    string
 		RunID string
+		// Deduplicated is set by Client.StartWorkflow when the server reported the execution as already started
+		// by the same StartWorkflowOptions.RequestID, i.e. the call was a retry of a start which had succeeded.
+		Deduplicated bool
 	}
 
 	// WorkflowExecutionAsync Details.
@@ -554,6 +606,25 @@ type RegisterWorkflowOptions struct {
 	// This option has no effect when explicit Name is provided.
 	EnableShortName               bool
 	DisableAlreadyRegisteredCheck bool
+	// Maximum number of decision tasks of this workflow type processed concurrently by the worker, so that a
+	// single workflow type cannot occupy all of WorkerOptions.MaxConcurrentDecisionTaskExecutionSize.
+	// Decision tasks above the limit wait in the worker's dispatch queue, without holding an execution slot,
+	// which is enabled when the worker starts with such a limit registered, see
+	// RegisterActivityOptions.MaxConcurrentExecutionSize. Limits of workflows registered after the worker is started
+	// are not enforced.
+	// Default: 0, no limit other than WorkerOptions.MaxConcurrentDecisionTaskExecutionSize
+	MaxConcurrentExecutionSize int
+	// MinExecutionStartToCloseTimeout and MaxExecutionStartToCloseTimeout bound the ExecutionStartToCloseTimeout
+	// of the workflow type. Zero means no bound.
+	// The bounds are validated by Client.StartWorkflow, Client.ExecuteWorkflow and Client.SignalWithStartWorkflow
+	// when the workflow type is visible to the client, that is registered through workflow.RegisterWithOptions.
+	MinExecutionStartToCloseTimeout time.Duration
+	MaxExecutionStartToCloseTimeout time.Duration
+	// MinDecisionTaskStartToCloseTimeout and MaxDecisionTaskStartToCloseTimeout bound the
+	// DecisionTaskStartToCloseTimeout of the workflow type, the same way as MinExecutionStartToCloseTimeout.
+	// They apply to the default decision task timeout when none is provided.
+	MinDecisionTaskStartToCloseTimeout time.Duration
+	MaxDecisionTaskStartToCloseTimeout time.Duration
 }
 
 // RegisterWorkflow - registers a workflow function with the framework.
@@ -626,11 +697,29 @@ func Await(ctx Context, condition func() bool) error {
 	return nil
 }
 
+// AwaitWithTimeout blocks the calling thread until condition() returns true or the timeout expires.
+// Returns ok set to false if the timeout expired before the condition became true.
+// Returns CanceledError if the ctx is canceled.
+func AwaitWithTimeout(ctx Context, timeout time.Duration, condition func() bool) (ok bool, err error) {
+	timerCtx, cancel := WithCancel(ctx)
+	defer cancel()
+	timer := NewTimer(timerCtx, timeout)
+	err = Await(ctx, func() bool {
+		ok = condition()
+		return ok || timer.IsReady()
+	})
+	if err == nil && !ok && ctx.Err() != nil {
+		// the timer is resolved as soon as ctx is canceled, before Await checks ctx
+		return false, NewCanceledError("AwaitWithTimeout context cancelled")
+	}
+	return ok, err
+}
+
 // NewChannel create new Channel instance
 func NewChannel(ctx Context) Channel {
 	state := getState(ctx)
 	state.dispatcher.channelSequence++
-	return NewNamedChannel(ctx, fmt.Sprintf("chan-%v", state.dispatcher.channelSequence))
+	return NewNamedChannel(ctx, "chan-"+strconv.Itoa(state.dispatcher.channelSequence))
 }
 
 // NewNamedChannel create new Channel instance with a given human readable name.
@@ -657,7 +746,7 @@ func NewNamedBufferedChannel(ctx Context, name string, size int) Channel {
 func NewSelector(ctx Context) Selector {
 	state := getState(ctx)
 	state.dispatcher.selectorSequence++
-	return NewNamedSelector(ctx, fmt.Sprintf("selector-%v", state.dispatcher.selectorSequence))
+	return NewNamedSelector(ctx, "selector-"+strconv.Itoa(state.dispatcher.selectorSequence))
 }
 
 // NewNamedSelector creates a new Selector instance with a given human readable name.
@@ -666,12 +755,31 @@ func NewNamedSelector(ctx Context, name string) Selector {
 	return &selectorImpl{name: name}
 }
 
+// NewFairSelector creates a new Selector instance which checks its cases in a round-robin order: every Select call
+// starts with the case added after the one invoked by the previous Select call, so no ready case is starved.
+// The order only depends on the previously selected cases, so it is deterministic and safe to replay.
+func NewFairSelector(ctx Context) Selector {
+	state := getState(ctx)
+	state.dispatcher.selectorSequence++
+	return &selectorImpl{name: "selector-" + strconv.Itoa(state.dispatcher.selectorSequence), fair: true}
+}
+
 // NewWaitGroup creates a new WaitGroup instance.
 func NewWaitGroup(ctx Context) WaitGroup {
 	f, s := NewFuture(ctx)
 	return &waitGroupImpl{future: f, settable: s}
 }
 
+// NewMutex creates a new Mutex instance.
+func NewMutex(ctx Context) Mutex {
+	return &mutexImpl{semaphore: &semaphoreImpl{size: 1}}
+}
+
+// NewSemaphore creates a new Semaphore instance with size permits.
+func NewSemaphore(ctx Context, size int64) Semaphore {
+	return &semaphoreImpl{size: size}
+}
+
 // Go creates a new coroutine. It has similar semantic to goroutine in a context of the workflow.
 func Go(ctx Context, f func(ctx Context)) {
 	state := getState(ctx)
@@ -762,6 +870,15 @@ func (wc *workflowEnvironmentInterceptor) ExecuteActivity(ctx Context, typeName
 		return future
 	}
 
+	// Route the activity type to its own task list, if configured.
+	if taskList, ok := options.routedTaskList(typeName); ok {
+		oldTaskListName := options.TaskListName
+		options.TaskListName = taskList
+		defer func() {
+			options.TaskListName = oldTaskListName
+		}()
+	}
+
 	// Validate session state.
 	if sessionInfo := getSessionInfo(ctx); sessionInfo != nil {
 		isCreationActivity := isSessionCreationActivity(typeName)
@@ -807,7 +924,7 @@ func (wc *workflowEnvironmentInterceptor) ExecuteActivity(ctx Context, typeName
 
 	if cancellable {
 		cancellationCallback.fn = func(v interface{}, more bool) bool {
-			if ctx.Err() == ErrCanceled {
+			if ctx.Err() != nil {
 				wc.env.RequestCancelActivity(a.activityID)
 			}
 			return false
@@ -942,7 +1059,7 @@ func (wc *workflowEnvironmentInterceptor) scheduleLocalActivity(ctx Context, par
 
 	if cancellable {
 		cancellationCallback.fn = func(v interface{}, more bool) bool {
-			if ctx.Err() == ErrCanceled {
+			if ctx.Err() != nil {
 				getWorkflowEnvironment(ctx).RequestCancelLocalActivity(la.activityID)
 			}
 			return false
@@ -1067,7 +1184,7 @@ func (wc *workflowEnvironmentInterceptor) ExecuteChildWorkflow(ctx Context, chil
 
 	if cancellable {
 		cancellationCallback.fn = func(v interface{}, more bool) bool {
-			if ctx.Err() == ErrCanceled {
+			if ctx.Err() != nil {
 				if childWorkflowExecution != nil && !mainFuture.IsReady() {
 					// child workflow started, and ctx cancelled.  forward cancel to the child.
 					getWorkflowEnvironment(ctx).RequestCancelChildWorkflow(*options.domain, childWorkflowExecution.ID)
@@ -1103,21 +1220,22 @@ func getWorkflowHeader(ctx Context, ctxProps []ContextPropagator) *s.Header {
 	return header
 }
 
-// WorkflowInfo information about currently executing workflow
+// WorkflowInfo information about currently executing workflow.
+// It is populated from the workflow's started event and is safe to use for logging and for branching in workflow code.
 type WorkflowInfo struct {
-	WorkflowExecution                   WorkflowExecution
-	OriginalRunId                       string // The original runID before resetting. Using it instead of current runID can make workflow decision determinstic after reset
+	WorkflowExecution                   WorkflowExecution // The workflow ID and run ID of the current run
+	OriginalRunId                       string            // The original runID before resetting. Using it instead of current runID can make workflow decision determinstic after reset
 	WorkflowType                        WorkflowType
-	TaskListName                        string
+	TaskListName                        string // The task list the workflow's decisions are scheduled on
 	ExecutionStartToCloseTimeoutSeconds int32
 	TaskStartToCloseTimeoutSeconds      int32
 	Domain                              string
 	Attempt                             int32 // Attempt starts from 0 and increased by 1 for every retry if retry policy is specified.
 	lastCompletionResult                []byte
-	CronSchedule                        *string
-	ContinuedExecutionRunID             *string
-	ParentWorkflowDomain                *string
-	ParentWorkflowExecution             *WorkflowExecution
+	CronSchedule                        *string             // The cron schedule of the workflow, nil if it is not a cron workflow
+	ContinuedExecutionRunID             *string             // The run ID this run was continued from (continue-as-new, retry or cron), nil for the first run
+	ParentWorkflowDomain                *string             // The domain of the parent workflow, nil if this is not a child workflow
+	ParentWorkflowExecution             *WorkflowExecution  // The execution of the parent workflow, nil if this is not a child workflow
 	Memo                                *s.Memo             // Value can be decoded using data converter (DefaultDataConverter, or custom one if set).
 	SearchAttributes                    *s.SearchAttributes // Value can be decoded using DefaultDataConverter.
 	BinaryChecksum                      *string             // The identifier(generated by md5sum by default) of worker code that is making the current decision(can be used for auto-reset feature)
@@ -1258,6 +1376,36 @@ func (wc *workflowEnvironmentInterceptor) Sleep(ctx Context, d time.Duration) (e
 	return
 }
 
+// SleepUntil pauses the current workflow until the workflow clock reaches t. A t which is not after workflow.Now(ctx)
+// causes SleepUntil to return immediately. Like Sleep, it returns *CanceledError if the ctx is canceled.
+func SleepUntil(ctx Context, t time.Time) (err error) {
+	return Sleep(ctx, t.Sub(Now(ctx)))
+}
+
+// NextCronTime returns the first time after workflow.Now(ctx) matching the cron schedule, in UTC like the cron
+// schedules of workflows, whatever the time zone of the host. It is deterministic as it is computed from the
+// workflow clock, so it can be used with SleepUntil to run calendar based logic within a workflow:
+//
+//	next, err := workflow.NextCronTime(ctx, "0 9 * * MON-FRI")
+//	if err != nil {
+//		return err
+//	}
+//	err = workflow.SleepUntil(ctx, next)
+//
+// The schedule uses the same format as the CronSchedule of workflows, returns an error if it is invalid.
+func NextCronTime(ctx Context, cronSchedule string) (time.Time, error) {
+	return nextCronTime(cronSchedule, Now(ctx))
+}
+
+func nextCronTime(cronSchedule string, now time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(cronSchedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cron schedule %q: %w", cronSchedule, err)
+	}
+	// the schedule is evaluated in the location of the time it is given
+	return schedule.Next(now.UTC()), nil
+}
+
 // RequestCancelExternalWorkflow can be used to request cancellation of an external workflow.
 // Input workflowID is the workflow ID of target workflow.
 // Input runID indicates the instance of a workflow. Input runID is optional (default is ""). When runID is not specified,
@@ -1403,6 +1551,29 @@ func (wc *workflowEnvironmentInterceptor) UpsertSearchAttributes(ctx Context, at
 	return wc.env.UpsertSearchAttributes(attributes)
 }
 
+// UpsertMemo merges the given fields into the memo the workflow sees in GetInfo(ctx).Memo, for example to keep
+// human-readable progress information as a long running workflow advances:
+//
+//	workflow.UpsertMemo(ctx, map[string]interface{}{
+//		"Stage": "shipping",
+//	})
+//
+// The memo stored by the server is not updated. Cadence server has no decision to change the memo of a running
+// workflow, so DescribeWorkflowExecution, visibility queries and the UI keep showing the memo the run started with.
+// The change is recorded as an "UpsertMemo" marker in the history, so that it survives replay, and the merged memo
+// is passed to the next run when the workflow continues as new, which is when the server sees it.
+// Memo values are encoded with the workflow's data converter.
+func UpsertMemo(ctx Context, memo map[string]interface{}) error {
+	return upsertMemo(ctx, getWorkflowInterceptor(ctx), memo)
+}
+
+func (wc *workflowEnvironmentInterceptor) UpsertMemo(ctx Context, memo map[string]interface{}) error {
+	if len(memo) == 0 {
+		return errors.New("memo is empty")
+	}
+	return wc.env.UpsertMemo(memo)
+}
+
 // WithChildWorkflowOptions adds all workflow options to the context.
 // The current timeout resolution implementation is in seconds and uses math.Ceil(d.Seconds()) as the duration. But is
 // subjected to change in the future.
@@ -1475,6 +1646,14 @@ func WithWorkflowTaskStartToCloseTimeout(ctx Context, d time.Duration) Context {
 	return ctx1
 }
 
+// WithWorkflowRetryPolicy adds a child workflow retry policy to the context.
+// Note this shall not confuse with WithRetryPolicy, which sets the retry policy of activities.
+func WithWorkflowRetryPolicy(ctx Context, retryPolicy RetryPolicy) Context {
+	ctx1 := setWorkflowEnvOptionsIfNotExist(ctx)
+	getWorkflowEnvOptions(ctx1).retryPolicy = convertRetryPolicy(&retryPolicy)
+	return ctx1
+}
+
 // WithDataConverter adds DataConverter to the context.
 func WithDataConverter(ctx Context, dc DataConverter) Context {
 	if dc == nil {
@@ -1612,6 +1791,33 @@ func (wc *workflowEnvironmentInterceptor) MutableSideEffect(ctx Context, id stri
 	return wc.env.MutableSideEffect(id, wrapperFunc, equals)
 }
 
+// RecordMarker records a marker with the given name and details into the workflow history, for example to make
+// business events visible to auditing tools that read the history:
+//
+//	workflow.RecordMarker(ctx, "LoanApproved", approval)
+//
+// It is recorded on the next decision and acts as a no-op during replay. Like any other decision, adding or removing
+// RecordMarker calls in a running workflow is a non-deterministic change that requires workflow.GetVersion.
+// The names used by SideEffect, MutableSideEffect, GetVersion, UpsertMemo and local activities are reserved.
+func RecordMarker(ctx Context, name string, details ...interface{}) error {
+	return recordMarker(ctx, getWorkflowInterceptor(ctx), name, details)
+}
+
+func (wc *workflowEnvironmentInterceptor) RecordMarker(ctx Context, name string, details ...interface{}) error {
+	switch name {
+	case "":
+		return errors.New("marker name is required")
+	case sideEffectMarkerName, versionMarkerName, localActivityMarkerName, mutableSideEffectMarkerName, upsertMemoMarkerName:
+		return fmt.Errorf("marker name %q is reserved for internal use", name)
+	}
+	data, err := encodeArgs(getDataConverterFromWorkflowContext(ctx), details)
+	if err != nil {
+		return fmt.Errorf("encode marker details: %w", err)
+	}
+	wc.env.RecordMarker(name, data)
+	return nil
+}
+
 // DefaultVersion is a version returned by GetVersion for code that wasn't versioned before
 const DefaultVersion Version = -1
 
@@ -1838,6 +2044,26 @@ func WithTaskList(ctx Context, name string) Context {
 	return ctx1
 }
 
+// WithActivityTaskListRoute returns a copy of the context in which the given activity, a function or a registered
+// activity type name, is scheduled on taskList instead of the task list of the activity options. This lets a workflow
+// direct specific activities to dedicated workers, e.g. hosts with GPUs, without wrapping every call site.
+// Routes accumulate across calls. An empty taskList routes the activity to the workflow's own task list.
+// Activities executed within an open session always run on the session's task list.
+func WithActivityTaskListRoute(ctx Context, activity interface{}, taskList string) Context {
+	registry := getRegistryFromWorkflowContext(ctx)
+	activityType := getActivityFunctionName(registry, activity)
+
+	ctx1 := setActivityParametersIfNotExist(ctx)
+	opts := getActivityOptions(ctx1)
+	routes := make(map[string]string, len(opts.TaskListRoutes)+1)
+	for k, v := range opts.TaskListRoutes {
+		routes[k] = v
+	}
+	routes[activityType] = taskList
+	opts.TaskListRoutes = routes
+	return ctx1
+}
+
 // GetActivityTaskList retrieves tasklist info from context
 func GetActivityTaskList(ctx Context) *string {
 	ao := getActivityOptions(ctx)
@@ -1910,8 +2136,18 @@ func convertRetryPolicy(retryPolicy *RetryPolicy) *s.RetryPolicy {
 		MaximumIntervalInSeconds:    common.Int32Ptr(common.Int32Ceil(retryPolicy.MaximumInterval.Seconds())),
 		BackoffCoefficient:          &retryPolicy.BackoffCoefficient,
 		MaximumAttempts:             &retryPolicy.MaximumAttempts,
-		NonRetriableErrorReasons:    retryPolicy.NonRetriableErrorReasons,
+		NonRetriableErrorReasons:    getNonRetriableErrorReasons(retryPolicy.NonRetriableErrorReasons),
 		ExpirationIntervalInSeconds: common.Int32Ptr(common.Int32Ceil(retryPolicy.ExpirationInterval.Seconds())),
 	}
 	return &thriftRetryPolicy
 }
+
+// getNonRetriableErrorReasons adds the reason of NonRetryableError to reasons, so that the server does not retry it.
+func getNonRetriableErrorReasons(reasons []string) []string {
+	for _, reason := range reasons {
+		if reason == errReasonNonRetryable {
+			return reasons
+		}
+	}
+	return append(append(make([]string, 0, len(reasons)+1), reasons...), errReasonNonRetryable)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+
+	s "go.uber.org/cadence/.gen/go/shared"
+)
+
+// GetWorkflowBuildIDs returns the build IDs of the workers which completed the decision tasks of a workflow execution,
+// in the order in which they first processed it. These are the WorkerOptions.BuildID of the workers, or their binary
+// checksum if it is not set. The latest run of the workflow is used if runID is empty.
+func GetWorkflowBuildIDs(ctx context.Context, c Client, workflowID, runID string) ([]string, error) {
+	iter := c.GetWorkflowHistory(ctx, workflowID, runID, false, s.HistoryEventFilterTypeAllEvent)
+	var buildIDs []string
+	seen := make(map[string]struct{})
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		attributes := event.DecisionTaskCompletedEventAttributes
+		if attributes == nil || attributes.GetBinaryChecksum() == "" {
+			continue
+		}
+		if _, ok := seen[attributes.GetBinaryChecksum()]; !ok {
+			seen[attributes.GetBinaryChecksum()] = struct{}{}
+			buildIDs = append(buildIDs, attributes.GetBinaryChecksum())
+		}
+	}
+	return buildIDs, nil
+}
@@ -23,7 +23,6 @@ package internal
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -375,15 +374,14 @@ func extractHistoryFromReader(r io.Reader, lastEventID int64) (*shared.History,
 		return nil, fmt.Errorf("failed to read data: %w", err)
 	}
 
-	var deserializedEvents []*shared.HistoryEvent
-	err = json.Unmarshal(raw, &deserializedEvents)
-
+	history, err := HistoryFromJSON(raw)
 	if err != nil {
-		return nil, fmt.Errorf("invalid json contents: %w", err)
+		return nil, err
 	}
+	deserializedEvents := history.Events
 
 	if lastEventID <= 0 {
-		return &shared.History{Events: deserializedEvents}, nil
+		return history, nil
 	}
 
 	// Caller is potentially asking for subset of history instead of all history events
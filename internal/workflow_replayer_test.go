@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"strconv"
 	"testing"
 	"time"
 
@@ -306,6 +307,79 @@ func (w localActivitiesCallingOptionsWorkflow) Execute(ctx Context, input []byte
 	return []byte("Done"), nil
 }
 
+const benchmarkReplayActivityCount = 10000
+
+func benchmarkReplayManyActivitiesWorkflow(ctx Context) error {
+	ctx = WithActivityOptions(ctx, ActivityOptions{
+		ScheduleToStartTimeout: time.Second,
+		StartToCloseTimeout:    time.Second,
+	})
+	for i := 0; i < benchmarkReplayActivityCount; i++ {
+		if err := ExecuteActivity(ctx, "testActivity").Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getBenchmarkReplayManyActivitiesHistory(t *testing.T) *shared.History {
+	events := []*shared.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &shared.WorkflowExecutionStartedEventAttributes{
+			WorkflowType: &shared.WorkflowType{Name: common.StringPtr("benchmarkReplayManyActivitiesWorkflow")},
+			TaskList:     &shared.TaskList{Name: common.StringPtr(testTaskList)},
+			Input:        testEncodeFunctionArgs(t, getDefaultDataConverter()),
+		}),
+		createTestEventDecisionTaskScheduled(2, &shared.DecisionTaskScheduledEventAttributes{}),
+		createTestEventDecisionTaskStarted(3),
+		createTestEventDecisionTaskCompleted(4, &shared.DecisionTaskCompletedEventAttributes{}),
+	}
+	for i := 0; i < benchmarkReplayActivityCount; i++ {
+		id := int64(len(events)) + 1
+		events = append(events,
+			createTestEventActivityTaskScheduled(id, &shared.ActivityTaskScheduledEventAttributes{
+				ActivityId:   common.StringPtr(strconv.Itoa(i)),
+				ActivityType: &shared.ActivityType{Name: common.StringPtr("testActivity")},
+				TaskList:     &shared.TaskList{Name: &testTaskList},
+			}),
+			createTestEventActivityTaskStarted(id+1, &shared.ActivityTaskStartedEventAttributes{
+				ScheduledEventId: common.Int64Ptr(id),
+			}),
+			createTestEventActivityTaskCompleted(id+2, &shared.ActivityTaskCompletedEventAttributes{
+				ScheduledEventId: common.Int64Ptr(id),
+				StartedEventId:   common.Int64Ptr(id + 1),
+			}),
+			createTestEventDecisionTaskScheduled(id+3, &shared.DecisionTaskScheduledEventAttributes{}),
+			createTestEventDecisionTaskStarted(id+4),
+			createTestEventDecisionTaskCompleted(id+5, &shared.DecisionTaskCompletedEventAttributes{
+				ScheduledEventId: common.Int64Ptr(id + 3),
+				StartedEventId:   common.Int64Ptr(id + 4),
+			}),
+		)
+	}
+	events = append(events, createTestEventWorkflowExecutionCompleted(int64(len(events))+1, &shared.WorkflowExecutionCompletedEventAttributes{
+		DecisionTaskCompletedEventId: common.Int64Ptr(int64(len(events))),
+	}))
+	return &shared.History{Events: events}
+}
+
+func BenchmarkReplayManyActivities(b *testing.B) {
+	history := getBenchmarkReplayManyActivitiesHistory(&testing.T{})
+	replayer := NewWorkflowReplayer()
+	replayer.RegisterWorkflowWithOptions(benchmarkReplayManyActivitiesWorkflow, RegisterWorkflowOptions{Name: "benchmarkReplayManyActivitiesWorkflow"})
+	logger := zap.NewNop()
+	// verbose logging is enabled for the package tests and would dominate the measured allocations
+	EnableVerboseLogging(false)
+	defer EnableVerboseLogging(true)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := replayer.ReplayWorkflowHistory(logger, history); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func getTestReplayWorkflowFullHistory(t *testing.T) *shared.History {
 	return &shared.History{
 		Events: []*shared.HistoryEvent{
@@ -103,6 +103,28 @@ type (
 		// An error will be returned if it's set to be larger than 1 when used to NewWorkflowShadower
 		// default: 1
 		Concurrency int
+
+		// Optional: keeps shadowing after a workflow fails to replay because of non-determinism, instead of
+		// returning the error. The outcome of each replay is reported by WorkflowShadower.Stats, so the
+		// shadower can be run as a canary reporting the non-determinism rate of a new version of the workflow code.
+		// Note: this field only applies to the local WorkflowShadower, the shadow worker always
+		// continues after non-deterministic replays.
+		// default: false
+		ContinueOnNonDeterministicError bool
+	}
+
+	// ShadowStats counts the outcomes of the workflow replays done by a WorkflowShadower.
+	ShadowStats struct {
+		// Succeeded is the number of workflows replayed successfully.
+		Succeeded int
+		// Skipped is the number of workflows which could not be replayed, for example because their history
+		// is too short or passed retention.
+		Skipped int
+		// Failed is the number of workflows which failed to replay because of non-determinism.
+		Failed int
+		// Errored is the number of workflows which failed to replay for another reason, for example because
+		// their workflow type is not registered. They are not taken into account by NonDeterminismRate.
+		Errored int
 	}
 
 	// TimeFilter represents a time range through the min and max timestamp
@@ -141,6 +163,11 @@ type (
 		shutdownWG sync.WaitGroup
 
 		clock clock.Clock
+
+		succeeded int64
+		skipped   int64
+		failed    int64
+		errored   int64
 	}
 )
 
@@ -228,6 +255,26 @@ func (s *WorkflowShadower) Stop() {
 	}
 }
 
+// Stats returns the outcomes of the replays done so far, it is safe to call while the shadower is running.
+func (s *WorkflowShadower) Stats() ShadowStats {
+	return ShadowStats{
+		Succeeded: int(atomic.LoadInt64(&s.succeeded)),
+		Skipped:   int(atomic.LoadInt64(&s.skipped)),
+		Failed:    int(atomic.LoadInt64(&s.failed)),
+		Errored:   int(atomic.LoadInt64(&s.errored)),
+	}
+}
+
+// NonDeterminismRate returns the ratio of failed replays to the replayed workflows, skipped workflows are not
+// taken into account. Returns 0 if no workflow was replayed.
+func (s ShadowStats) NonDeterminismRate() float64 {
+	replayed := s.Succeeded + s.Failed
+	if replayed == 0 {
+		return 0
+	}
+	return float64(s.Failed) / float64(replayed)
+}
+
 // GetRegisteredWorkflows retrieves the list of workflows registered on the worker
 func (s *WorkflowShadower) GetRegisteredWorkflows() []RegistryWorkflowInfo {
 	return s.replayer.GetRegisteredWorkflows()
@@ -280,11 +327,20 @@ func (s *WorkflowShadower) shadowWorker() error {
 					RunID: execution.GetRunId(),
 				},
 			)
-			if err != nil {
+			switch {
+			case err != nil && isNondeterministicErr(err):
+				atomic.AddInt64(&s.failed, 1)
+				if !s.shadowOptions.ContinueOnNonDeterministicError {
+					return err
+				}
+			case err != nil:
+				atomic.AddInt64(&s.errored, 1)
 				return err
-			}
-			if success {
+			case success:
+				atomic.AddInt64(&s.succeeded, 1)
 				replayCount++
+			default:
+				atomic.AddInt64(&s.skipped, 1)
 			}
 
 			if replayCount == maxReplayCount {
@@ -391,6 +391,51 @@ func (s *workflowShadowerSuite) TestShadowWorker_ReplayFailed() {
 	}, nil).Times(1)
 
 	s.Error(s.testShadower.shadowWorker())
+	s.Equal(ShadowStats{Succeeded: successfullyReplayed, Failed: 1}, s.testShadower.Stats())
+}
+
+func (s *workflowShadowerSuite) TestShadowWorker_ReplayErrored() {
+	s.testShadower.shadowOptions.ContinueOnNonDeterministicError = true
+	history := getTestReplayWorkflowFullHistory(s.T())
+	history.Events[0].WorkflowExecutionStartedEventAttributes.WorkflowType.Name = common.StringPtr("unregisteredWorkflow")
+
+	s.mockService.EXPECT().ScanWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.ListWorkflowExecutionsResponse{
+		Executions:    newTestWorkflowExecutions(2),
+		NextPageToken: nil,
+	}, nil).Times(1)
+	s.mockService.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.GetWorkflowExecutionHistoryResponse{
+		History: history,
+	}, nil).Times(1)
+
+	// the error is not a non-deterministic replay: it is returned, and not counted as a failure
+	s.Error(s.testShadower.shadowWorker())
+	stats := s.testShadower.Stats()
+	s.Equal(ShadowStats{Errored: 1}, stats)
+	s.Zero(stats.NonDeterminismRate())
+}
+
+func (s *workflowShadowerSuite) TestShadowWorker_ContinueOnNonDeterministicError() {
+	s.testShadower.shadowOptions.ContinueOnNonDeterministicError = true
+
+	s.mockService.EXPECT().ScanWorkflowExecutions(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.ListWorkflowExecutionsResponse{
+		Executions:    newTestWorkflowExecutions(4),
+		NextPageToken: nil,
+	}, nil).Times(1)
+	gomock.InOrder(
+		s.mockService.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.GetWorkflowExecutionHistoryResponse{
+			History: s.testWorkflowHistory,
+		}, nil).Times(2),
+		s.mockService.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).Return(&shared.GetWorkflowExecutionHistoryResponse{
+			History: getTestReplayWorkflowMismatchHistory(s.T()),
+		}, nil).Times(1),
+		s.mockService.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), callOptions()...).Return(
+			nil, &shared.EntityNotExistsError{Message: "Workflow passed retention date"}).Times(1),
+	)
+
+	s.NoError(s.testShadower.shadowWorker())
+	stats := s.testShadower.Stats()
+	s.Equal(ShadowStats{Succeeded: 2, Skipped: 1, Failed: 1}, stats)
+	s.InDelta(1.0/3, stats.NonDeterminismRate(), 0.0001)
 }
 
 func (s *workflowShadowerSuite) TestShadowWorker_ExpectedReplayError() {
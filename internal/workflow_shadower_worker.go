@@ -31,7 +31,6 @@ import (
 	"go.uber.org/cadence/.gen/go/shadower"
 	"go.uber.org/cadence/.gen/go/shared"
 	"go.uber.org/cadence/internal/common"
-	"go.uber.org/cadence/internal/common/backoff"
 )
 
 type (
@@ -181,7 +180,7 @@ func (sw *shadowWorker) startShadowWorkflow() error {
 		return err
 	}
 
-	return backoff.Retry(ctx, startWorkflowOp, createDynamicServiceRetryPolicy(ctx), isServiceTransientError)
+	return retryWhileTransientError(ctx, startWorkflowOp)
 }
 
 func generateShadowTaskList(domain, taskList string) string {
@@ -0,0 +1,213 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package loadgen provides a harness generating workflow load against a task list, to measure the throughput and
+// latency of workers and Cadence clusters in stress tests.
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/worker"
+	"go.uber.org/cadence/workflow"
+)
+
+const (
+	// WorkflowName is the name the load workflow is registered with.
+	WorkflowName = "cadence-loadgen-workflow"
+	// ActivityName is the name the load activity is registered with.
+	ActivityName = "cadence-loadgen-activity"
+
+	defaultExecutionStartToCloseTimeout = time.Minute
+	defaultReportInterval               = 10 * time.Second
+)
+
+type (
+	// Options of a load generation run.
+	Options struct {
+		// TaskList the workflows are started on. The workers polling it must have registered the load workflow
+		// and activity with Register.
+		TaskList string
+		// WorkflowsPerSecond is the rate at which workflows are started.
+		WorkflowsPerSecond float64
+		// Duration of the period during which workflows are started.
+		Duration time.Duration
+		// ActivityFanOut is the number of activities each workflow executes in parallel.
+		ActivityFanOut int
+		// ActivityDuration is the time each activity runs for.
+		ActivityDuration time.Duration
+		// ExecutionStartToCloseTimeout of the workflows.
+		// default: 1 minute
+		ExecutionStartToCloseTimeout time.Duration
+		// ReportInterval is the interval between two reports of the run statistics.
+		// default: 10 seconds
+		ReportInterval time.Duration
+		// Reporter receives the statistics of every report interval and of the whole run.
+		// default: statistics are only returned by Run
+		Reporter Reporter
+	}
+
+	workflowInput struct {
+		FanOut           int
+		ActivityDuration time.Duration
+	}
+)
+
+// startInterval returns the interval between two workflow starts, at least 1ns since tickers require a positive
+// interval.
+func startInterval(workflowsPerSecond float64) time.Duration {
+	interval := time.Duration(float64(time.Second) / workflowsPerSecond)
+	if interval < time.Nanosecond {
+		return time.Nanosecond
+	}
+	return interval
+}
+
+// Register registers the load workflow and activity on a worker.
+func Register(r worker.Registry) {
+	r.RegisterWorkflowWithOptions(loadWorkflow, workflow.RegisterOptions{Name: WorkflowName})
+	r.RegisterActivityWithOptions(loadActivity, activity.RegisterOptions{Name: ActivityName})
+}
+
+// Run starts workflows at the configured rate for the configured duration, waits for them to close and returns
+// the statistics of the whole run. It stops starting workflows when ctx is done, and then returns ctx.Err().
+func Run(ctx context.Context, c client.Client, options Options) (Stats, error) {
+	if options.TaskList == "" {
+		return Stats{}, errors.New("task list is required")
+	}
+	if !(options.WorkflowsPerSecond > 0) || options.Duration <= 0 { // also rejects NaN
+		return Stats{}, errors.New("workflows per second and duration must be positive")
+	}
+	if options.ExecutionStartToCloseTimeout <= 0 {
+		options.ExecutionStartToCloseTimeout = defaultExecutionStartToCloseTimeout
+	}
+	if options.ReportInterval <= 0 {
+		options.ReportInterval = defaultReportInterval
+	}
+	reporter := options.Reporter
+	if reporter == nil {
+		reporter = nopReporter{}
+	}
+
+	collector := newStatsCollector(time.Now())
+	startTicker := time.NewTicker(startInterval(options.WorkflowsPerSecond))
+	defer startTicker.Stop()
+	reportTicker := time.NewTicker(options.ReportInterval)
+	defer reportTicker.Stop()
+	deadline := time.NewTimer(options.Duration)
+	defer deadline.Stop()
+
+	var wg sync.WaitGroup
+	input := workflowInput{FanOut: options.ActivityFanOut, ActivityDuration: options.ActivityDuration}
+starting:
+	for {
+		select {
+		case <-ctx.Done():
+			break starting
+		case <-deadline.C:
+			break starting
+		case now := <-reportTicker.C:
+			reporter.ReportInterval(collector.interval(now))
+		case <-startTicker.C:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				runWorkflow(ctx, c, options, input, collector)
+			}()
+		}
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(closed)
+	}()
+	for waiting := true; waiting; {
+		select {
+		case <-closed:
+			waiting = false
+		case now := <-reportTicker.C:
+			reporter.ReportInterval(collector.interval(now))
+		}
+	}
+
+	// the last interval ends with the run, so that the intervals add up to the summary
+	now := time.Now()
+	reporter.ReportInterval(collector.interval(now))
+	summary := collector.summary(now)
+	reporter.ReportSummary(summary)
+	return summary, ctx.Err()
+}
+
+func runWorkflow(ctx context.Context, c client.Client, options Options, input workflowInput, collector *statsCollector) {
+	startedAt := time.Now()
+	run, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		TaskList:                        options.TaskList,
+		ExecutionStartToCloseTimeout:    options.ExecutionStartToCloseTimeout,
+		DecisionTaskStartToCloseTimeout: decisionTaskTimeout(options),
+	}, WorkflowName, input)
+	if err != nil {
+		collector.recordStartFailure()
+		return
+	}
+	collector.recordStarted()
+	err = run.Get(ctx, nil)
+	collector.recordClosed(time.Since(startedAt), err)
+}
+
+func decisionTaskTimeout(options Options) time.Duration {
+	if options.ExecutionStartToCloseTimeout < 10*time.Second {
+		return options.ExecutionStartToCloseTimeout
+	}
+	return 10 * time.Second
+}
+
+func loadWorkflow(ctx workflow.Context, input workflowInput) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    input.ActivityDuration + time.Minute,
+	})
+	futures := make([]workflow.Future, input.FanOut)
+	for i := range futures {
+		futures[i] = workflow.ExecuteActivity(ctx, ActivityName, input.ActivityDuration)
+	}
+	for _, future := range futures {
+		if err := future.Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadActivity(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loadgen
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/inmemory"
+	"go.uber.org/cadence/worker"
+)
+
+const (
+	testDomain   = "test-domain"
+	testTaskList = "test-tasklist"
+)
+
+type capturingReporter struct {
+	sync.Mutex
+	intervals []Stats
+	summaries []Stats
+}
+
+func (r *capturingReporter) ReportInterval(stats Stats) {
+	r.Lock()
+	defer r.Unlock()
+	r.intervals = append(r.intervals, stats)
+}
+
+func (r *capturingReporter) ReportSummary(stats Stats) {
+	r.Lock()
+	defer r.Unlock()
+	r.summaries = append(r.summaries, stats)
+}
+
+func (r *capturingReporter) SubScope(string) Reporter {
+	return r
+}
+
+func TestRun(t *testing.T) {
+	service := inmemory.NewWorkflowService()
+	w := worker.New(service, testDomain, testTaskList, worker.Options{Logger: zaptest.NewLogger(t)})
+	Register(w)
+	require.NoError(t, w.Start())
+	defer w.Stop()
+
+	reporter := &capturingReporter{}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	stats, err := Run(ctx, client.NewClient(service, testDomain, nil), Options{
+		TaskList:           testTaskList,
+		WorkflowsPerSecond: 20,
+		Duration:           time.Second,
+		ActivityFanOut:     3,
+		ActivityDuration:   10 * time.Millisecond,
+		ReportInterval:     300 * time.Millisecond,
+		Reporter:           reporter,
+	})
+	require.NoError(t, err)
+
+	assert.InDelta(t, 20, stats.Started, 2)
+	assert.Equal(t, stats.Started, stats.Completed)
+	assert.Zero(t, stats.StartFailures)
+	assert.Zero(t, stats.Failed)
+	assert.Positive(t, stats.Throughput)
+	assert.Positive(t, stats.LatencyP50)
+	assert.LessOrEqual(t, stats.LatencyP50, stats.LatencyP95)
+	assert.LessOrEqual(t, stats.LatencyP95, stats.LatencyP99)
+	assert.LessOrEqual(t, stats.LatencyP99, stats.LatencyMax)
+
+	reporter.Lock()
+	defer reporter.Unlock()
+	assert.Equal(t, []Stats{stats}, reporter.summaries)
+	require.NotEmpty(t, reporter.intervals)
+	var completed int64
+	for _, interval := range reporter.intervals {
+		completed += interval.Completed
+	}
+	assert.Equal(t, stats.Completed, completed)
+}
+
+func TestRun_InvalidOptions(t *testing.T) {
+	c := client.NewClient(inmemory.NewWorkflowService(), testDomain, nil)
+	_, err := Run(context.Background(), c, Options{WorkflowsPerSecond: 1, Duration: time.Second})
+	assert.ErrorContains(t, err, "task list is required")
+	_, err = Run(context.Background(), c, Options{TaskList: testTaskList, Duration: time.Second})
+	assert.ErrorContains(t, err, "must be positive")
+	_, err = Run(context.Background(), c, Options{TaskList: testTaskList, WorkflowsPerSecond: math.NaN(), Duration: time.Second})
+	assert.ErrorContains(t, err, "must be positive")
+}
+
+func TestStartInterval(t *testing.T) {
+	assert.Equal(t, 100*time.Millisecond, startInterval(10))
+	assert.Equal(t, time.Nanosecond, startInterval(1e12))
+	assert.Equal(t, time.Nanosecond, startInterval(math.Inf(1)))
+}
+
+func TestStatsPercentiles(t *testing.T) {
+	c := newStatsCollector(time.Unix(0, 0))
+	for i := 1; i <= 100; i++ {
+		c.recordStarted()
+		c.recordClosed(time.Duration(i)*time.Millisecond, nil)
+	}
+	c.recordStartFailure()
+	c.recordClosed(time.Hour, assert.AnError)
+
+	stats := c.summary(time.Unix(10, 0))
+	assert.Equal(t, Stats{
+		Elapsed:       10 * time.Second,
+		Started:       100,
+		StartFailures: 1,
+		Completed:     100,
+		Failed:        1,
+		Throughput:    10,
+		LatencyP50:    50 * time.Millisecond,
+		LatencyP95:    95 * time.Millisecond,
+		LatencyP99:    99 * time.Millisecond,
+		LatencyMax:    100 * time.Millisecond,
+	}, stats)
+
+	interval := c.interval(time.Unix(5, 0))
+	assert.Equal(t, int64(100), interval.Completed)
+	assert.Equal(t, Stats{Elapsed: time.Second}, c.interval(time.Unix(6, 0)))
+}
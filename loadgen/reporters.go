@@ -0,0 +1,118 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loadgen
+
+import (
+	"github.com/uber-go/tally"
+)
+
+// Metrics recorded by TallyReporter.
+const (
+	MetricWorkflowStarted     = "loadgen-workflow-started"
+	MetricWorkflowStartFailed = "loadgen-workflow-start-failed"
+	MetricWorkflowCompleted   = "loadgen-workflow-completed"
+	MetricWorkflowFailed      = "loadgen-workflow-failed"
+	MetricThroughput          = "loadgen-throughput"
+	MetricLatencyP50          = "loadgen-latency-p50"
+	MetricLatencyP95          = "loadgen-latency-p95"
+	MetricLatencyP99          = "loadgen-latency-p99"
+	MetricLatencyMax          = "loadgen-latency-max"
+)
+
+type (
+	// CompositeReporter is a Reporter forwarding the statistics to multiple reporters, to observe a run live
+	// while recording it to a metrics backend.
+	CompositeReporter struct {
+		reporters []Reporter
+	}
+
+	// TallyReporter is a Reporter recording the statistics of every report interval to a tally scope: the
+	// workflow counts increment counters, the throughput is a gauge and the latency percentiles are timers.
+	// Give it a sub-scope of the worker metrics scope to report the load alongside the framework metrics:
+	//
+	//	reporter := loadgen.NewTallyReporter(workerOptions.MetricsScope.SubScope("loadgen"))
+	//
+	// Its sub-scopes record to the corresponding tally sub-scopes.
+	TallyReporter struct {
+		scope tally.Scope
+	}
+)
+
+var (
+	_ Reporter = (*CompositeReporter)(nil)
+	_ Reporter = (*TallyReporter)(nil)
+)
+
+// NewCompositeReporter creates a Reporter forwarding the statistics to reporters, in order.
+func NewCompositeReporter(reporters ...Reporter) *CompositeReporter {
+	return &CompositeReporter{reporters: reporters}
+}
+
+// ReportInterval forwards the statistics of a report interval to all reporters.
+func (r *CompositeReporter) ReportInterval(stats Stats) {
+	for _, reporter := range r.reporters {
+		reporter.ReportInterval(stats)
+	}
+}
+
+// ReportSummary forwards the statistics of the whole run to all reporters.
+func (r *CompositeReporter) ReportSummary(stats Stats) {
+	for _, reporter := range r.reporters {
+		reporter.ReportSummary(stats)
+	}
+}
+
+// SubScope returns a CompositeReporter forwarding the statistics to the sub-scopes of all reporters.
+func (r *CompositeReporter) SubScope(name string) Reporter {
+	reporters := make([]Reporter, 0, len(r.reporters))
+	for _, reporter := range r.reporters {
+		reporters = append(reporters, reporter.SubScope(name))
+	}
+	return NewCompositeReporter(reporters...)
+}
+
+// NewTallyReporter creates a Reporter recording the statistics to scope.
+func NewTallyReporter(scope tally.Scope) *TallyReporter {
+	return &TallyReporter{scope: scope}
+}
+
+// ReportInterval records the statistics of a report interval.
+func (r *TallyReporter) ReportInterval(stats Stats) {
+	r.scope.Counter(MetricWorkflowStarted).Inc(stats.Started)
+	r.scope.Counter(MetricWorkflowStartFailed).Inc(stats.StartFailures)
+	r.scope.Counter(MetricWorkflowCompleted).Inc(stats.Completed)
+	r.scope.Counter(MetricWorkflowFailed).Inc(stats.Failed)
+	r.scope.Gauge(MetricThroughput).Update(stats.Throughput)
+	if stats.Completed > 0 {
+		r.scope.Timer(MetricLatencyP50).Record(stats.LatencyP50)
+		r.scope.Timer(MetricLatencyP95).Record(stats.LatencyP95)
+		r.scope.Timer(MetricLatencyP99).Record(stats.LatencyP99)
+		r.scope.Timer(MetricLatencyMax).Record(stats.LatencyMax)
+	}
+}
+
+// ReportSummary does nothing: the whole run is covered by the recorded intervals.
+func (r *TallyReporter) ReportSummary(Stats) {}
+
+// SubScope returns a TallyReporter recording to the tally sub-scope name.
+func (r *TallyReporter) SubScope(name string) Reporter {
+	return NewTallyReporter(r.scope.SubScope(name))
+}
@@ -0,0 +1,356 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loadgen
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type (
+	// Stats are the statistics of a load generation run over a period of time.
+	Stats struct {
+		// Elapsed is the length of the period.
+		Elapsed time.Duration
+		// Started is the number of workflows started.
+		Started int64
+		// StartFailures is the number of workflows which failed to start.
+		StartFailures int64
+		// Completed is the number of workflows which completed successfully.
+		Completed int64
+		// Failed is the number of workflows which failed, timed out or were canceled.
+		Failed int64
+		// Throughput is the number of workflows completed per second.
+		Throughput float64
+		// LatencyP50, LatencyP95, LatencyP99 and LatencyMax are the percentiles of the time between the start
+		// request and the completion of the completed workflows.
+		LatencyP50 time.Duration
+		LatencyP95 time.Duration
+		LatencyP99 time.Duration
+		LatencyMax time.Duration
+	}
+
+	// Reporter receives the statistics of a load generation run.
+	Reporter interface {
+		// ReportInterval is called with the statistics of every report interval.
+		ReportInterval(stats Stats)
+		// ReportSummary is called once with the statistics of the whole run.
+		ReportSummary(stats Stats)
+		// SubScope returns a Reporter for the statistics of a named part of the load, e.g. one of several runs
+		// against different task lists, reporting them to the same destination.
+		SubScope(name string) Reporter
+	}
+
+	// OutputFormat is the format in which a SimpleReporter writes statistics to its output.
+	OutputFormat int
+
+	// SimpleReporterOptions configure a SimpleReporter.
+	SimpleReporterOptions struct {
+		// Logger the statistics are logged with when Output is nil, and output errors are logged with.
+		// default: no logging
+		Logger *zap.Logger
+		// Output the statistics are written to, one record per line, instead of being logged.
+		Output io.Writer
+		// Format of the records written to Output.
+		// default: OutputFormatCSV
+		Format OutputFormat
+	}
+
+	// SimpleReporter is a Reporter logging the statistics, or writing them as CSV or JSON lines to an io.Writer.
+	// The record type of the statistics reported by a sub-scope is prefixed with the sub-scope name, e.g.
+	// "checkout.interval".
+	SimpleReporter struct {
+		logger *zap.SugaredLogger
+		output io.Writer
+		format OutputFormat
+		prefix string
+		writer *recordWriter
+	}
+
+	// recordWriter serializes the records written to the output of a SimpleReporter and its sub-scopes.
+	recordWriter struct {
+		sync.Mutex
+		csvWriter     *csv.Writer
+		headerWritten bool
+	}
+
+	// statsRecord is the JSON representation of Stats, with latencies in milliseconds.
+	statsRecord struct {
+		Type          string  `json:"type"`
+		ElapsedMs     float64 `json:"elapsedMs"`
+		Started       int64   `json:"started"`
+		StartFailures int64   `json:"startFailures"`
+		Completed     int64   `json:"completed"`
+		Failed        int64   `json:"failed"`
+		Throughput    float64 `json:"throughput"`
+		LatencyP50Ms  float64 `json:"latencyP50Ms"`
+		LatencyP95Ms  float64 `json:"latencyP95Ms"`
+		LatencyP99Ms  float64 `json:"latencyP99Ms"`
+		LatencyMaxMs  float64 `json:"latencyMaxMs"`
+	}
+
+	nopReporter struct{}
+
+	statsCollector struct {
+		sync.Mutex
+		start    time.Time
+		lastTick time.Time
+		total    counters
+		current  counters
+	}
+
+	counters struct {
+		started       int64
+		startFailures int64
+		completed     int64
+		failed        int64
+		latencies     []time.Duration
+	}
+)
+
+const (
+	// OutputFormatCSV writes a header line followed by one comma separated line per record.
+	OutputFormatCSV OutputFormat = iota
+	// OutputFormatJSON writes one JSON object per line.
+	OutputFormatJSON
+)
+
+const (
+	recordTypeInterval = "interval"
+	recordTypeSummary  = "summary"
+)
+
+var csvHeader = []string{
+	"type", "elapsedMs", "started", "startFailures", "completed", "failed", "throughput",
+	"latencyP50Ms", "latencyP95Ms", "latencyP99Ms", "latencyMaxMs",
+}
+
+var _ Reporter = (*SimpleReporter)(nil)
+
+// NewSimpleReporter creates a Reporter logging the statistics with logger.
+func NewSimpleReporter(logger *zap.Logger) *SimpleReporter {
+	return NewSimpleReporterWithOptions(SimpleReporterOptions{Logger: logger})
+}
+
+// NewSimpleReporterWithOptions creates a SimpleReporter with the provided options.
+func NewSimpleReporterWithOptions(options SimpleReporterOptions) *SimpleReporter {
+	logger := options.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	r := &SimpleReporter{
+		logger: logger.Sugar(),
+		output: options.Output,
+		format: options.Format,
+		writer: &recordWriter{},
+	}
+	if r.output != nil && r.format == OutputFormatCSV {
+		r.writer.csvWriter = csv.NewWriter(r.output)
+	}
+	return r
+}
+
+// ReportInterval reports the statistics of a report interval.
+func (r *SimpleReporter) ReportInterval(stats Stats) {
+	r.report(recordTypeInterval, stats)
+}
+
+// ReportSummary reports the statistics of the whole run.
+func (r *SimpleReporter) ReportSummary(stats Stats) {
+	r.report(recordTypeSummary, stats)
+}
+
+// SubScope returns a SimpleReporter writing to the same output, with record types prefixed with name.
+func (r *SimpleReporter) SubScope(name string) Reporter {
+	sub := *r
+	sub.prefix = r.prefix + name + "."
+	return &sub
+}
+
+func (r *SimpleReporter) report(recordType string, stats Stats) {
+	recordType = r.prefix + recordType
+	if r.output == nil {
+		r.logger.Infof("loadgen %v: %v", recordType, stats)
+		return
+	}
+
+	r.writer.Lock()
+	defer r.writer.Unlock()
+	var err error
+	switch r.format {
+	case OutputFormatJSON:
+		err = json.NewEncoder(r.output).Encode(newStatsRecord(recordType, stats))
+	default:
+		err = r.writeCSV(newStatsRecord(recordType, stats))
+	}
+	if err != nil {
+		r.logger.Errorf("failed to write loadgen %v: %v", recordType, err)
+	}
+}
+
+func (r *SimpleReporter) writeCSV(record statsRecord) error {
+	w := r.writer
+	if !w.headerWritten {
+		if err := w.csvWriter.Write(csvHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	formatFloat := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	err := w.csvWriter.Write([]string{
+		record.Type,
+		formatFloat(record.ElapsedMs),
+		strconv.FormatInt(record.Started, 10),
+		strconv.FormatInt(record.StartFailures, 10),
+		strconv.FormatInt(record.Completed, 10),
+		strconv.FormatInt(record.Failed, 10),
+		formatFloat(record.Throughput),
+		formatFloat(record.LatencyP50Ms),
+		formatFloat(record.LatencyP95Ms),
+		formatFloat(record.LatencyP99Ms),
+		formatFloat(record.LatencyMaxMs),
+	})
+	if err != nil {
+		return err
+	}
+	w.csvWriter.Flush()
+	return w.csvWriter.Error()
+}
+
+func newStatsRecord(recordType string, stats Stats) statsRecord {
+	milliseconds := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	return statsRecord{
+		Type:          recordType,
+		ElapsedMs:     milliseconds(stats.Elapsed),
+		Started:       stats.Started,
+		StartFailures: stats.StartFailures,
+		Completed:     stats.Completed,
+		Failed:        stats.Failed,
+		Throughput:    stats.Throughput,
+		LatencyP50Ms:  milliseconds(stats.LatencyP50),
+		LatencyP95Ms:  milliseconds(stats.LatencyP95),
+		LatencyP99Ms:  milliseconds(stats.LatencyP99),
+		LatencyMaxMs:  milliseconds(stats.LatencyMax),
+	}
+}
+
+func (nopReporter) ReportInterval(Stats) {}
+
+func (nopReporter) ReportSummary(Stats) {}
+
+func (r nopReporter) SubScope(string) Reporter { return r }
+
+// String formats the statistics on a single line.
+func (s Stats) String() string {
+	return fmt.Sprintf(
+		"elapsed=%v started=%d startFailures=%d completed=%d failed=%d throughput=%.2f/s p50=%v p95=%v p99=%v max=%v",
+		s.Elapsed, s.Started, s.StartFailures, s.Completed, s.Failed, s.Throughput,
+		s.LatencyP50, s.LatencyP95, s.LatencyP99, s.LatencyMax,
+	)
+}
+
+func newStatsCollector(now time.Time) *statsCollector {
+	return &statsCollector{start: now, lastTick: now}
+}
+
+func (c *statsCollector) recordStarted() {
+	c.Lock()
+	defer c.Unlock()
+	c.total.started++
+	c.current.started++
+}
+
+func (c *statsCollector) recordStartFailure() {
+	c.Lock()
+	defer c.Unlock()
+	c.total.startFailures++
+	c.current.startFailures++
+}
+
+func (c *statsCollector) recordClosed(latency time.Duration, err error) {
+	c.Lock()
+	defer c.Unlock()
+	if err != nil {
+		c.total.failed++
+		c.current.failed++
+		return
+	}
+	c.total.completed++
+	c.total.latencies = append(c.total.latencies, latency)
+	c.current.completed++
+	c.current.latencies = append(c.current.latencies, latency)
+}
+
+// interval returns the statistics since the previous interval and starts a new one.
+func (c *statsCollector) interval(now time.Time) Stats {
+	c.Lock()
+	defer c.Unlock()
+	stats := c.current.stats(now.Sub(c.lastTick))
+	c.current = counters{}
+	c.lastTick = now
+	return stats
+}
+
+func (c *statsCollector) summary(now time.Time) Stats {
+	c.Lock()
+	defer c.Unlock()
+	return c.total.stats(now.Sub(c.start))
+}
+
+func (c *counters) stats(elapsed time.Duration) Stats {
+	stats := Stats{
+		Elapsed:       elapsed,
+		Started:       c.started,
+		StartFailures: c.startFailures,
+		Completed:     c.completed,
+		Failed:        c.failed,
+	}
+	if elapsed > 0 {
+		stats.Throughput = float64(c.completed) / elapsed.Seconds()
+	}
+	if len(c.latencies) > 0 {
+		latencies := append([]time.Duration(nil), c.latencies...)
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		stats.LatencyP50 = percentile(latencies, 0.50)
+		stats.LatencyP95 = percentile(latencies, 0.95)
+		stats.LatencyP99 = percentile(latencies, 0.99)
+		stats.LatencyMax = latencies[len(latencies)-1]
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies.
+func percentile(latencies []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(latencies)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return latencies[rank]
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loadgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var testStats = Stats{
+	Elapsed:       2 * time.Second,
+	Started:       10,
+	StartFailures: 1,
+	Completed:     8,
+	Failed:        1,
+	Throughput:    4,
+	LatencyP50:    1500 * time.Microsecond,
+	LatencyP95:    20 * time.Millisecond,
+	LatencyP99:    30 * time.Millisecond,
+	LatencyMax:    time.Second,
+}
+
+func TestSimpleReporter_CSV(t *testing.T) {
+	var output bytes.Buffer
+	r := NewSimpleReporterWithOptions(SimpleReporterOptions{Output: &output})
+	r.ReportInterval(testStats)
+	r.ReportSummary(Stats{})
+
+	assert.Equal(t, strings.Join([]string{
+		"type,elapsedMs,started,startFailures,completed,failed,throughput,latencyP50Ms,latencyP95Ms,latencyP99Ms,latencyMaxMs",
+		"interval,2000,10,1,8,1,4,1.5,20,30,1000",
+		"summary,0,0,0,0,0,0,0,0,0,0",
+		"",
+	}, "\n"), output.String())
+}
+
+func TestSimpleReporter_JSON(t *testing.T) {
+	var output bytes.Buffer
+	r := NewSimpleReporterWithOptions(SimpleReporterOptions{Output: &output, Format: OutputFormatJSON})
+	r.ReportInterval(testStats)
+	r.ReportSummary(testStats)
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	var record statsRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, statsRecord{
+		Type:          "interval",
+		ElapsedMs:     2000,
+		Started:       10,
+		StartFailures: 1,
+		Completed:     8,
+		Failed:        1,
+		Throughput:    4,
+		LatencyP50Ms:  1.5,
+		LatencyP95Ms:  20,
+		LatencyP99Ms:  30,
+		LatencyMaxMs:  1000,
+	}, record)
+	assert.Contains(t, lines[1], `"type":"summary"`)
+}
+
+func TestSimpleReporter_Log(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	r := NewSimpleReporter(zap.New(core))
+	r.ReportInterval(testStats)
+	r.ReportSummary(testStats)
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "loadgen interval: "+testStats.String(), entries[0].Message)
+	assert.Equal(t, "loadgen summary: "+testStats.String(), entries[1].Message)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestSimpleReporter_OutputError(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	r := NewSimpleReporterWithOptions(SimpleReporterOptions{
+		Logger: zap.New(core),
+		Output: failingWriter{},
+		Format: OutputFormatJSON,
+	})
+	r.ReportSummary(testStats)
+
+	require.Equal(t, 1, logs.Len())
+	assert.Contains(t, logs.All()[0].Message, "disk full")
+}
+
+func TestCompositeReporter(t *testing.T) {
+	first, second := &capturingReporter{}, &capturingReporter{}
+	r := NewCompositeReporter(first, second)
+	r.ReportInterval(testStats)
+	r.ReportSummary(Stats{Started: 1})
+
+	for _, reporter := range []*capturingReporter{first, second} {
+		assert.Equal(t, []Stats{testStats}, reporter.intervals)
+		assert.Equal(t, []Stats{{Started: 1}}, reporter.summaries)
+	}
+}
+
+// callRecordingReporter records the method calls it receives, prefixed with the names of its sub-scopes.
+type callRecordingReporter struct {
+	prefix string
+	calls  *[]string
+}
+
+func (r callRecordingReporter) ReportInterval(stats Stats) {
+	*r.calls = append(*r.calls, fmt.Sprintf("%vReportInterval(%v)", r.prefix, stats.Started))
+}
+
+func (r callRecordingReporter) ReportSummary(stats Stats) {
+	*r.calls = append(*r.calls, fmt.Sprintf("%vReportSummary(%v)", r.prefix, stats.Started))
+}
+
+func (r callRecordingReporter) SubScope(name string) Reporter {
+	*r.calls = append(*r.calls, fmt.Sprintf("%vSubScope(%v)", r.prefix, name))
+	return callRecordingReporter{prefix: r.prefix + name + ".", calls: r.calls}
+}
+
+func TestCompositeReporter_ForwardsEveryMethod(t *testing.T) {
+	var firstCalls, secondCalls []string
+	r := NewCompositeReporter(callRecordingReporter{calls: &firstCalls}, callRecordingReporter{calls: &secondCalls})
+
+	r.ReportInterval(Stats{Started: 1})
+	r.ReportSummary(Stats{Started: 2})
+	sub := r.SubScope("checkout")
+	sub.ReportInterval(Stats{Started: 3})
+	sub.ReportSummary(Stats{Started: 4})
+
+	expected := []string{
+		"ReportInterval(1)",
+		"ReportSummary(2)",
+		"SubScope(checkout)",
+		"checkout.ReportInterval(3)",
+		"checkout.ReportSummary(4)",
+	}
+	assert.Equal(t, expected, firstCalls)
+	assert.Equal(t, expected, secondCalls)
+}
+
+func TestTallyReporter(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	r := NewTallyReporter(scope)
+	r.ReportInterval(testStats)
+	r.ReportInterval(Stats{Started: 2})
+	r.ReportSummary(testStats)
+
+	snapshot := scope.Snapshot()
+	counters := make(map[string]int64)
+	for _, counter := range snapshot.Counters() {
+		counters[counter.Name()] = counter.Value()
+	}
+	assert.Equal(t, map[string]int64{
+		MetricWorkflowStarted:     12,
+		MetricWorkflowStartFailed: 1,
+		MetricWorkflowCompleted:   8,
+		MetricWorkflowFailed:      1,
+	}, counters)
+	assert.Equal(t, float64(0), snapshot.Gauges()[MetricThroughput+"+"].Value())
+	assert.Equal(t, []time.Duration{20 * time.Millisecond}, snapshot.Timers()[MetricLatencyP95+"+"].Values())
+	assert.Equal(t, []time.Duration{time.Second}, snapshot.Timers()[MetricLatencyMax+"+"].Values())
+}
+
+func TestReporterSubScope(t *testing.T) {
+	var output bytes.Buffer
+	simple := NewSimpleReporterWithOptions(SimpleReporterOptions{Output: &output})
+	scope := tally.NewTestScope("", nil)
+	r := NewCompositeReporter(simple, NewTallyReporter(scope))
+
+	r.ReportInterval(Stats{Started: 1})
+	r.SubScope("checkout").SubScope("eu").ReportInterval(Stats{Started: 2})
+	r.SubScope("checkout").ReportSummary(Stats{Started: 3})
+
+	// sub-scopes share the output of the simple reporter, with prefixed record types
+	assert.Equal(t, strings.Join([]string{
+		"type,elapsedMs,started,startFailures,completed,failed,throughput,latencyP50Ms,latencyP95Ms,latencyP99Ms,latencyMaxMs",
+		"interval,0,1,0,0,0,0,0,0,0,0",
+		"checkout.eu.interval,0,2,0,0,0,0,0,0,0,0",
+		"checkout.summary,0,3,0,0,0,0,0,0,0,0",
+		"",
+	}, "\n"), output.String())
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(1), counters[MetricWorkflowStarted+"+"].Value())
+	assert.Equal(t, int64(2), counters["checkout.eu."+MetricWorkflowStarted+"+"].Value())
+}
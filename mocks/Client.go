@@ -204,6 +204,29 @@ func (_m *Client) GetSearchAttributes(ctx context.Context) (*shared.GetSearchAtt
 	return r0, r1
 }
 
+// GetTaskListBacklog provides a mock function with given fields: ctx, tasklist, options
+func (_m *Client) GetTaskListBacklog(ctx context.Context, tasklist string, options *internal.TaskListBacklogOptions) (*internal.TaskListBacklog, error) {
+	ret := _m.Called(ctx, tasklist, options)
+
+	var r0 *internal.TaskListBacklog
+	if rf, ok := ret.Get(0).(func(context.Context, string, *internal.TaskListBacklogOptions) *internal.TaskListBacklog); ok {
+		r0 = rf(ctx, tasklist, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.TaskListBacklog)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *internal.TaskListBacklogOptions) error); ok {
+		r1 = rf(ctx, tasklist, options)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetWorkflow provides a mock function with given fields: ctx, workflowID, runID
 func (_m *Client) GetWorkflow(ctx context.Context, workflowID string, runID string) internal.WorkflowRun {
 	ret := _m.Called(ctx, workflowID, runID)
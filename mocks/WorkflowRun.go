@@ -26,6 +26,8 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	internal "go.uber.org/cadence/internal"
 )
 
 // WorkflowRun is an autogenerated mock type for the WorkflowRun type
@@ -47,6 +49,20 @@ func (_m *WorkflowRun) Get(ctx context.Context, valuePtr interface{}) error {
 	return r0
 }
 
+// GetWithOptions provides a mock function with given fields: ctx, valuePtr, options
+func (_m *WorkflowRun) GetWithOptions(ctx context.Context, valuePtr interface{}, options internal.WorkflowRunGetOptions) error {
+	ret := _m.Called(ctx, valuePtr, options)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, internal.WorkflowRunGetOptions) error); ok {
+		r0 = rf(ctx, valuePtr, options)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetID provides a mock function with given fields:
 func (_m *WorkflowRun) GetID() string {
 	ret := _m.Called()
@@ -0,0 +1,75 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package typed
+
+import (
+	"context"
+
+	"go.uber.org/cadence/workflow"
+)
+
+type (
+	// Activity is a typed reference to an activity which takes a single argument of type In and returns Out.
+	Activity[In, Out any] struct {
+		// target is the activity function or its registered name, as accepted by workflow.ExecuteActivity.
+		target interface{}
+		// fn is the activity function, required to run the activity as a local activity.
+		fn func(context.Context, In) (Out, error)
+	}
+
+	// Future is a pending activity result decoded into Out.
+	Future[Out any] struct {
+		workflow.Future
+	}
+)
+
+// NewActivity returns a typed reference to the activity function. The function is resolved to its registered
+// name the same way workflow.ExecuteActivity resolves function references.
+func NewActivity[In, Out any](fn func(context.Context, In) (Out, error)) Activity[In, Out] {
+	return Activity[In, Out]{target: fn, fn: fn}
+}
+
+// NewActivityByName returns a typed reference to an activity registered under the given name, for workflows
+// that don't link the activity implementation. Such activities can't be executed as local activities.
+func NewActivityByName[In, Out any](name string) Activity[In, Out] {
+	return Activity[In, Out]{target: name}
+}
+
+// Execute schedules the activity with the activity options set on ctx.
+func (a Activity[In, Out]) Execute(ctx workflow.Context, in In) Future[Out] {
+	return Future[Out]{Future: workflow.ExecuteActivity(ctx, a.target, in)}
+}
+
+// ExecuteLocal runs the activity as a local activity with the local activity options set on ctx.
+// It panics if the reference was created with NewActivityByName.
+func (a Activity[In, Out]) ExecuteLocal(ctx workflow.Context, in In) Future[Out] {
+	if a.fn == nil {
+		panic("typed: local activities require a reference created with NewActivity")
+	}
+	return Future[Out]{Future: workflow.ExecuteLocalActivity(ctx, a.fn, in)}
+}
+
+// Get waits for the activity to complete and returns its result.
+func (f Future[Out]) Get(ctx workflow.Context) (Out, error) {
+	var out Out
+	err := f.Future.Get(ctx, &out)
+	return out, err
+}
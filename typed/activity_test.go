@@ -0,0 +1,96 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package typed
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/testsuite"
+	"go.uber.org/cadence/workflow"
+)
+
+var (
+	upperActivity  = NewActivity(upper)
+	lengthActivity = NewActivityByName[string, int]("length")
+)
+
+func upper(_ context.Context, in string) (string, error) {
+	return strings.ToUpper(in), nil
+}
+
+func length(_ context.Context, in string) (int, error) {
+	return len(in), nil
+}
+
+func activities(ctx workflow.Context, in string) (string, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    time.Minute,
+	})
+	shouted, err := upperActivity.Execute(ctx, in).Get(ctx)
+	if err != nil {
+		return "", err
+	}
+	n, err := lengthActivity.Execute(ctx, shouted).Get(ctx)
+	if err != nil {
+		return "", err
+	}
+	ctx = workflow.WithLocalActivityOptions(ctx, workflow.LocalActivityOptions{ScheduleToCloseTimeout: time.Minute})
+	local, err := upperActivity.ExecuteLocal(ctx, "local").Get(ctx)
+	if err != nil {
+		return "", err
+	}
+	return strings.Repeat(shouted, n/len(shouted)) + " " + local, nil
+}
+
+func TestActivity_Execute(t *testing.T) {
+	var s testsuite.WorkflowTestSuite
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(activities)
+	env.RegisterActivity(upper)
+	env.RegisterActivityWithOptions(length, activity.RegisterOptions{Name: "length"})
+
+	env.ExecuteWorkflow(activities, "cadence")
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var out string
+	require.NoError(t, env.GetWorkflowResult(&out))
+	assert.Equal(t, "CADENCE LOCAL", out)
+}
+
+func TestActivity_ExecuteLocalByNamePanics(t *testing.T) {
+	var s testsuite.WorkflowTestSuite
+	env := s.NewTestWorkflowEnvironment()
+	env.ExecuteWorkflow(func(ctx workflow.Context) error {
+		ctx = workflow.WithLocalActivityOptions(ctx, workflow.LocalActivityOptions{ScheduleToCloseTimeout: time.Minute})
+		_, err := lengthActivity.ExecuteLocal(ctx, "x").Get(ctx)
+		return err
+	})
+	require.Error(t, env.GetWorkflowError())
+	assert.Contains(t, env.GetWorkflowError().Error(), "require a reference created with NewActivity")
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package typed provides generic, strongly typed references to workflows, activities, signals and queries, so that
+// callers use real argument and result types instead of interface{} arguments and stringly-typed names.
+//
+// Declare the references once, next to the workflow implementation, and use them from both the workflow and the
+// client code:
+//
+//	var (
+//		OrderWorkflow = typed.NewWorkflow(orderWorkflow) // func(workflow.Context, Order) (Receipt, error)
+//		CancelSignal  = typed.NewSignal[CancelRequest]("cancel")
+//		StatusQuery   = typed.NewQuery[OrderStatus]("status")
+//	)
+//
+//	run, err := OrderWorkflow.Execute(ctx, c, options, Order{ID: "42"})
+//	err = CancelSignal.Send(ctx, c, run.GetID(), run.GetRunID(), CancelRequest{Reason: "duplicate"})
+//	receipt, err := run.Get(ctx)
+package typed
+
+import (
+	"context"
+
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+)
+
+type (
+	// Workflow is a typed reference to a workflow which takes a single argument of type In and returns Out.
+	Workflow[In, Out any] struct {
+		// target is the workflow function or its registered name, as accepted by client.ExecuteWorkflow.
+		target interface{}
+	}
+
+	// Run is a started workflow execution whose result is decoded into Out.
+	Run[Out any] struct {
+		client.WorkflowRun
+	}
+
+	// ChildFuture is a started child workflow whose result is decoded into Out.
+	ChildFuture[Out any] struct {
+		workflow.ChildWorkflowFuture
+	}
+
+	// Signal is a typed reference to a signal carrying a value of type T.
+	Signal[T any] struct {
+		// Name is the signal name.
+		Name string
+	}
+
+	// Query is a typed reference to a query, without arguments, returning a value of type T.
+	Query[T any] struct {
+		// Name is the query type.
+		Name string
+	}
+)
+
+// NewWorkflow returns a typed reference to the workflow function, which must be registered on the workers
+// polling the task list the workflow is started on.
+func NewWorkflow[In, Out any](fn func(workflow.Context, In) (Out, error)) Workflow[In, Out] {
+	return Workflow[In, Out]{target: fn}
+}
+
+// NewWorkflowByName returns a typed reference to a workflow registered under the given name, for callers that
+// don't link the workflow implementation.
+func NewWorkflowByName[In, Out any](name string) Workflow[In, Out] {
+	return Workflow[In, Out]{target: name}
+}
+
+// Start starts the workflow execution without waiting for its result.
+func (w Workflow[In, Out]) Start(ctx context.Context, c client.Client, options client.StartWorkflowOptions, in In) (*workflow.Execution, error) {
+	return c.StartWorkflow(ctx, options, w.target, in)
+}
+
+// Execute starts the workflow execution and returns a Run to wait for its result.
+func (w Workflow[In, Out]) Execute(ctx context.Context, c client.Client, options client.StartWorkflowOptions, in In) (Run[Out], error) {
+	run, err := c.ExecuteWorkflow(ctx, options, w.target, in)
+	if err != nil {
+		return Run[Out]{}, err
+	}
+	return Run[Out]{WorkflowRun: run}, nil
+}
+
+// GetRun returns a Run for an existing execution of the workflow. If runID is empty, the latest run is used.
+func (w Workflow[In, Out]) GetRun(ctx context.Context, c client.Client, workflowID, runID string) Run[Out] {
+	return Run[Out]{WorkflowRun: c.GetWorkflow(ctx, workflowID, runID)}
+}
+
+// ExecuteChild starts the workflow as a child of the calling workflow, with the child workflow options set on ctx.
+func (w Workflow[In, Out]) ExecuteChild(ctx workflow.Context, in In) ChildFuture[Out] {
+	return ChildFuture[Out]{ChildWorkflowFuture: workflow.ExecuteChildWorkflow(ctx, w.target, in)}
+}
+
+// Get waits for the workflow to complete and returns its result.
+func (r Run[Out]) Get(ctx context.Context) (Out, error) {
+	var out Out
+	err := r.WorkflowRun.Get(ctx, &out)
+	return out, err
+}
+
+// Get waits for the child workflow to complete and returns its result.
+func (f ChildFuture[Out]) Get(ctx workflow.Context) (Out, error) {
+	var out Out
+	err := f.ChildWorkflowFuture.Get(ctx, &out)
+	return out, err
+}
+
+// NewSignal returns a typed reference to the signal with the given name.
+func NewSignal[T any](name string) Signal[T] {
+	return Signal[T]{Name: name}
+}
+
+// Send signals the workflow execution. If runID is empty, the latest run is signaled.
+func (s Signal[T]) Send(ctx context.Context, c client.Client, workflowID, runID string, value T) error {
+	return c.SignalWorkflow(ctx, workflowID, runID, s.Name, value)
+}
+
+// SendExternal signals another workflow execution from workflow code.
+func (s Signal[T]) SendExternal(ctx workflow.Context, workflowID, runID string, value T) workflow.Future {
+	return workflow.SignalExternalWorkflow(ctx, workflowID, runID, s.Name, value)
+}
+
+// Receive blocks until the signal is received by the calling workflow. It returns false if the signal channel
+// was closed.
+func (s Signal[T]) Receive(ctx workflow.Context) (T, bool) {
+	var value T
+	more := workflow.GetSignalChannel(ctx, s.Name).Receive(ctx, &value)
+	return value, more
+}
+
+// ReceiveAsync returns a signal received by the calling workflow without blocking. It returns false if no signal
+// is buffered.
+func (s Signal[T]) ReceiveAsync(ctx workflow.Context) (T, bool) {
+	var value T
+	ok := workflow.GetSignalChannel(ctx, s.Name).ReceiveAsync(&value)
+	return value, ok
+}
+
+// NewQuery returns a typed reference to the query with the given type.
+func NewQuery[T any](name string) Query[T] {
+	return Query[T]{Name: name}
+}
+
+// SetHandler registers the query handler on the calling workflow.
+func (q Query[T]) SetHandler(ctx workflow.Context, handler func() (T, error)) error {
+	return workflow.SetQueryHandler(ctx, q.Name, handler)
+}
+
+// Query queries the workflow execution and decodes the result. If runID is empty, the latest run is queried.
+func (q Query[T]) Query(ctx context.Context, c client.Client, workflowID, runID string) (T, error) {
+	var value T
+	result, err := c.QueryWorkflow(ctx, workflowID, runID, q.Name)
+	if err != nil {
+		return value, err
+	}
+	err = result.Get(&value)
+	return value, err
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package typed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/inmemory"
+	"go.uber.org/cadence/mocks"
+	"go.uber.org/cadence/testsuite"
+	"go.uber.org/cadence/worker"
+	"go.uber.org/cadence/workflow"
+)
+
+const (
+	testDomain   = "test-domain"
+	testTaskList = "test-tasklist"
+)
+
+type (
+	greeting struct {
+		Name string
+	}
+
+	reply struct {
+		Text string
+	}
+)
+
+var (
+	greetWorkflow = NewWorkflow(greet)
+	punctuation   = NewSignal[string]("punctuation")
+	progress      = NewQuery[string]("progress")
+)
+
+func greet(ctx workflow.Context, in greeting) (reply, error) {
+	state := "waiting"
+	if err := progress.SetHandler(ctx, func() (string, error) { return state, nil }); err != nil {
+		return reply{}, err
+	}
+	mark, _ := punctuation.Receive(ctx)
+	state = "done"
+	return reply{Text: "hello " + in.Name + mark}, nil
+}
+
+func parent(ctx workflow.Context, name string) (string, error) {
+	ctx = workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID:                   "child",
+		ExecutionStartToCloseTimeout: time.Minute,
+	})
+	child := greetWorkflow.ExecuteChild(ctx, greeting{Name: name})
+	var execution workflow.Execution
+	if err := child.GetChildWorkflowExecution().Get(ctx, &execution); err != nil {
+		return "", err
+	}
+	if err := punctuation.SendExternal(ctx, execution.ID, "", "?").Get(ctx, nil); err != nil {
+		return "", err
+	}
+	out, err := child.Get(ctx)
+	return out.Text, err
+}
+
+func TestWorkflow_ExecuteAndSignal(t *testing.T) {
+	service := inmemory.NewWorkflowService()
+	w := worker.New(service, testDomain, testTaskList, worker.Options{Logger: zaptest.NewLogger(t)})
+	w.RegisterWorkflow(greet)
+	require.NoError(t, w.Start())
+	defer w.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := client.NewClient(service, testDomain, nil)
+	run, err := greetWorkflow.Execute(ctx, c, client.StartWorkflowOptions{
+		ID:                           "greet",
+		TaskList:                     testTaskList,
+		ExecutionStartToCloseTimeout: time.Minute,
+	}, greeting{Name: "cadence"})
+	require.NoError(t, err)
+	require.NoError(t, punctuation.Send(ctx, c, run.GetID(), "", "!"))
+
+	out, err := greetWorkflow.GetRun(ctx, c, run.GetID(), run.GetRunID()).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, reply{Text: "hello cadence!"}, out)
+}
+
+func TestWorkflow_ExecuteChildAndQuery(t *testing.T) {
+	var s testsuite.WorkflowTestSuite
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(parent)
+	env.RegisterWorkflow(greet)
+
+	env.ExecuteWorkflow(parent, "cadence")
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var out string
+	require.NoError(t, env.GetWorkflowResult(&out))
+	assert.Equal(t, "hello cadence?", out)
+}
+
+func TestSignalAndQuery_Handlers(t *testing.T) {
+	var s testsuite.WorkflowTestSuite
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(greet)
+	env.RegisterDelayedCallback(func() {
+		value, err := env.QueryWorkflow(progress.Name)
+		require.NoError(t, err)
+		var state string
+		require.NoError(t, value.Get(&state))
+		assert.Equal(t, "waiting", state)
+		env.SignalWorkflow(punctuation.Name, ".")
+	}, time.Second)
+
+	env.ExecuteWorkflow(greet, greeting{Name: "cadence"})
+	require.NoError(t, env.GetWorkflowError())
+	var out reply
+	require.NoError(t, env.GetWorkflowResult(&out))
+	assert.Equal(t, "hello cadence.", out.Text)
+
+	env = s.NewTestWorkflowEnvironment()
+	env.ExecuteWorkflow(func(ctx workflow.Context) (bool, error) {
+		_, ok := punctuation.ReceiveAsync(ctx)
+		return ok, nil
+	})
+	var received bool
+	require.NoError(t, env.GetWorkflowResult(&received))
+	assert.False(t, received)
+}
+
+func TestQuery_Query(t *testing.T) {
+	c := &mocks.Client{}
+	c.On("QueryWorkflow", mock.Anything, "wid", "rid", progress.Name).
+		Return(client.NewValue([]byte("\"done\"\n")), nil).Once()
+
+	state, err := progress.Query(context.Background(), c, "wid", "rid")
+	require.NoError(t, err)
+	assert.Equal(t, "done", state)
+	c.AssertExpectations(t)
+}
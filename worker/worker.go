@@ -42,13 +42,37 @@ type (
 	Worker interface {
 		Registry
 
-		// Start starts the worker in a non-blocking fashion
+		// Start starts the worker in a non-blocking fashion.
+		// Workflows and activities may still be registered after the worker is started.
 		Start() error
 		// Run is a blocking start and cleans up resources when killed
 		// returns error only if it fails to start the worker
 		Run() error
+		// RunWithInterrupt is a blocking start which stops the worker once a value is received from
+		// interruptCh or the channel is closed. Use InterruptCh to stop on SIGINT or SIGTERM.
+		// returns error only if it fails to start the worker
+		RunWithInterrupt(interruptCh <-chan interface{}) error
 		// Stop cleans up any resources opened by worker
 		Stop()
+
+		// Health reports the status of the worker's pollers and the number of registered workflows and
+		// activities. It can be used to back readiness and liveness probes of the host process.
+		Health() Health
+
+		// DeregisterWorkflow removes a workflow, identified by its function or registered name, from
+		// this worker. A function is removed under every name it was registered with. It is safe to call while
+		// the worker is running: decision tasks received afterwards for this workflow type fail with a
+		// *cadence.NotRegisteredError. Globally registered workflows are not affected.
+		// Returns a *cadence.NotRegisteredError if the workflow is not registered on this worker.
+		DeregisterWorkflow(w interface{}) error
+
+		// DeregisterActivity removes an activity, identified by its function or registered name, from
+		// this worker. A function is removed under every name it was registered with. It is safe to call while
+		// the worker is running: activity tasks received afterwards for this activity type are failed with a
+		// *cadence.NotRegisteredError, and retried according to the activity retry policy.
+		// Globally registered activities are not affected.
+		// Returns a *cadence.NotRegisteredError if the activity is not registered on this worker.
+		DeregisterActivity(a interface{}) error
 	}
 
 	// Registry exposes registration functions to consumers.
@@ -80,8 +104,9 @@ type (
 		// type name twice. Use workflow.RegisterOptions.DisableAlreadyRegisteredCheck to allow multiple registrations.
 		RegisterWorkflowWithOptions(w interface{}, options workflow.RegisterOptions)
 
-		// GetRegisteredWorkflows returns information on all workflows registered on the worker.
-		// the RegistryInfo interface can be used to read workflow names, paths or retrieve the workflow functions.
+		// GetRegisteredWorkflows returns information on all workflows registered on the worker, sorted by name.
+		// the RegistryInfo interface can be used to read workflow names, paths, registration options or retrieve the
+		// workflow functions, e.g. to verify that a binary registers the workflow types it owns before rollout.
 		// The workflow name is by default the method name. However, if the workflow was registered
 		// with options (see Worker.RegisterWorkflowWithOptions), the workflow may have customized name.
 		// For chained registries, this returns a combined list of all registered workflows from the
@@ -140,8 +165,9 @@ type (
 		// worker.RegisterActivityWithOptions(barActivity, RegisterActivityOptions{DisableAlreadyRegisteredCheck: true})
 		RegisterActivityWithOptions(a interface{}, options activity.RegisterOptions)
 
-		// GetRegisteredActivities returns information on all activities registered on the worker.
-		// the RegistryInfo interface can be used to read activity names, paths or retrieve the activity functions.
+		// GetRegisteredActivities returns information on all activities registered on the worker, sorted by name.
+		// the RegistryInfo interface can be used to read activity names, paths, registration options or retrieve the
+		// activity functions.
 		// The activity name is by default the method name. However, if the workflow was registered
 		// with options (see Worker.RegisterWorkflowWithOptions), the workflow may have customized name.
 		// For chained registries, this returns a combined list of all registered activities from the
@@ -212,15 +238,53 @@ type (
 		WorkflowRegistry
 
 		Run() error
+
+		// Stats returns the outcomes of the replays done so far, it is safe to call while the shadower is running.
+		Stats() ShadowStats
 	}
 
 	// Options is used to configure a worker instance.
 	Options = internal.WorkerOptions
 
+	// Health reports the health of a worker, see Worker.Health.
+	Health = internal.WorkerHealth
+
+	// PollerHealth reports the health of a group of pollers polling a single task list.
+	PollerHealth = internal.PollerHealth
+
+	// ActivityTaskPriorityInfo describes a polled activity task, see Options.ActivityTaskPriority.
+	ActivityTaskPriorityInfo = internal.ActivityTaskPriorityInfo
+
+	// Group manages the lifecycle of several workers hosted in one process, polling different task lists
+	// or domains through one service connection. Use worker.NewGroup(...) to create an instance.
+	Group interface {
+		// AddWorker creates a worker for the domain and task list and adds it to the group.
+		// Workflows and activities are registered on the returned worker.
+		// Workers can only be added before the group is started.
+		AddWorker(domain string, taskList string, options Options) (Worker, error)
+		// Start starts all workers of the group in a non-blocking fashion.
+		// If any worker fails to start, the already started ones are stopped.
+		// A stopped group cannot be started again.
+		Start() error
+		// RunWithInterrupt is a blocking start which stops all workers once a value is received from
+		// interruptCh or the channel is closed.
+		// returns error only if it fails to start the workers
+		RunWithInterrupt(interruptCh <-chan interface{}) error
+		// Stop stops all workers of the group and waits for them to finish.
+		Stop()
+		// Health returns the health of each worker of the group, in the order they were added.
+		Health() []Health
+	}
+
+	// GroupOptions is used to configure a Group.
+	GroupOptions = internal.WorkerGroupOptions
+
 	// ShadowOptions is used to configure a WorkflowShadower.
 	ShadowOptions = internal.ShadowOptions
 	// ShadowMode is an enum for configuring if shadowing should continue after all workflows matches the WorkflowQuery have been replayed.
 	ShadowMode = internal.ShadowMode
+	// ShadowStats counts the outcomes of the workflow replays done by a WorkflowShadower.
+	ShadowStats = internal.ShadowStats
 	// TimeFilter represents a time range through the min and max timestamp
 	TimeFilter = internal.TimeFilter
 	// ShadowExitCondition configures when the workflow shadower should exit.
@@ -234,6 +298,34 @@ type (
 	// mismatched history events (presumably arising from non-deterministic workflow definitions).
 	NonDeterministicWorkflowPolicy = internal.NonDeterministicWorkflowPolicy
 
+	// WorkflowPanicPolicy is an enum for configuring how client's decision task handler deals with
+	// a panic in the workflow code, see Options.WorkflowPanicPolicy.
+	WorkflowPanicPolicy = internal.WorkflowPanicPolicy
+
+	// DecisionTaskListener is notified of the progress of the workflows processed by a worker, see Options.DecisionTaskListener.
+	// Embed DecisionTaskListenerBase in implementations that are not interested in every callback.
+	DecisionTaskListener = internal.DecisionTaskListener
+
+	// DecisionTaskListenerBase is a no-op DecisionTaskListener, to be embedded in listeners which only implement a
+	// subset of the callbacks.
+	DecisionTaskListenerBase = internal.DecisionTaskListenerBase
+
+	// HistorySoftLimits configures the history guardrails of a worker, see Options.HistorySoftLimits.
+	HistorySoftLimits = internal.HistorySoftLimits
+
+	// HistoryLimitExceededInfo describes a workflow which exceeds the HistorySoftLimits of its worker.
+	HistoryLimitExceededInfo = internal.HistoryLimitExceededInfo
+
+	// DispatcherStats describes a single run of the workflow code of a workflow execution until it blocked,
+	// see Options.DispatcherStatsHandler.
+	DispatcherStats = internal.DispatcherStats
+
+	// WorkerIdentityInfo describes the process a default identity is generated for, see SetWorkerIdentityFunc.
+	WorkerIdentityInfo = internal.WorkerIdentityInfo
+
+	// ActivityTaskListWeight is a task list polled by an activity worker, see Options.AdditionalActivityTaskLists.
+	ActivityTaskListWeight = internal.ActivityTaskListWeight
+
 	// AuthorizationProvider is the interface that contains the method to get the auth token
 	AuthorizationProvider = auth.AuthorizationProvider
 
@@ -256,6 +348,16 @@ const (
 	NonDeterministicWorkflowPolicyFailWorkflow = internal.NonDeterministicWorkflowPolicyFailWorkflow
 )
 
+const (
+	// WorkflowPanicPolicyBlockWorkflow is the default policy for handling workflow panics.
+	// It logs the panic and fails the decision task, which the server retries until the workflow code is
+	// fixed or the workflow times out. The workflow execution itself is left open.
+	WorkflowPanicPolicyBlockWorkflow = internal.WorkflowPanicPolicyBlockWorkflow
+	// WorkflowPanicPolicyFailWorkflow logs the panic and replies back with a request to fail the workflow
+	// execution with a PanicError.
+	WorkflowPanicPolicyFailWorkflow = internal.WorkflowPanicPolicyFailWorkflow
+)
+
 const (
 	// ShadowModeNormal is the default mode for workflow shadowing.
 	// Shadowing will complete after all workflows matches WorkflowQuery have been replayed.
@@ -300,6 +402,26 @@ func NewV2(
 	return internal.NewWorker(service, domain, taskList, options)
 }
 
+// NewGroup returns an empty Group of workers sharing the service connection and the
+// metrics scope and logger provided in options.
+func NewGroup(service workflowserviceclient.Interface, options GroupOptions) Group {
+	return &workerGroup{internal.NewWorkerGroup(service, options)}
+}
+
+type workerGroup struct {
+	*internal.WorkerGroup
+}
+
+func (g *workerGroup) AddWorker(domain string, taskList string, options Options) (Worker, error) {
+	w, err := g.WorkerGroup.AddWorker(domain, taskList, options)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+var _ Worker = (internal.GroupWorker)(nil)
+
 // NewWorkflowReplayer creates a WorkflowReplayer instance.
 func NewWorkflowReplayer() WorkflowReplayer {
 	return internal.NewWorkflowReplayer()
@@ -313,6 +435,19 @@ func NewWorkflowReplayerWithOptions(
 	return internal.NewWorkflowReplayerWithOptions(options)
 }
 
+// HistoryToJSON serializes a workflow history to JSON in the format of the histories downloaded with the cadence
+// CLI, which can be replayed with WorkflowReplayer.ReplayWorkflowHistoryFromJSON.
+func HistoryToJSON(history *shared.History) ([]byte, error) {
+	return internal.HistoryToJSON(history)
+}
+
+// HistoryFromJSON deserializes a workflow history from JSON, either an array of events as written by HistoryToJSON
+// and the cadence CLI, or a history object with an "events" field. The result can be replayed with
+// WorkflowReplayer.ReplayWorkflowHistory.
+func HistoryFromJSON(data []byte) (*shared.History, error) {
+	return internal.HistoryFromJSON(data)
+}
+
 // NewWorkflowShadower creates a WorkflowShadower instance.
 func NewWorkflowShadower(
 	service workflowserviceclient.Interface,
@@ -385,6 +520,26 @@ func SetBinaryChecksum(checksum string) {
 	internal.SetBinaryChecksum(checksum)
 }
 
+// SetWorkerIdentityFunc overrides how default identities are generated for workers and clients that are created
+// without an explicit Identity option. By default, identities have the form taskList@host@pid@libraryVersion@uuid,
+// which is what DescribeTaskList reports for pollers. If fn returns an empty string, the default identity is used.
+// It should be called before creating any worker or client.
+func SetWorkerIdentityFunc(fn func(info WorkerIdentityInfo) string) {
+	internal.SetWorkerIdentityFunc(fn)
+}
+
+// InterruptCh returns a channel which receives a value when the process gets SIGINT or SIGTERM.
+// It can be used with Worker.RunWithInterrupt, or shared by several workers hosted in one process.
+func InterruptCh() <-chan interface{} {
+	return internal.InterruptCh()
+}
+
+// GetHostSpecificTaskList returns the task list unique to the current host for the given task list.
+// Workers created with the EnableHostSpecificTaskList option also poll this task list for activities.
+func GetHostSpecificTaskList(taskList string) string {
+	return internal.GetHostSpecificTaskList(taskList)
+}
+
 // NewAdminJwtAuthorizationProvider creates a JwtAuthorizationProvider instance.
 func NewAdminJwtAuthorizationProvider(privateKey []byte) AuthorizationProvider {
 	return internal.NewAdminJwtAuthorizationProvider(privateKey)
@@ -58,6 +58,20 @@ func WithTaskList(ctx Context, name string) Context {
 	return internal.WithTaskList(ctx, name)
 }
 
+// WithActivityTaskListRoute makes a copy of the current context in which the given activity, a function or a
+// registered activity type name, is scheduled on taskList regardless of the task list in the activity options.
+// For example, to run a model inference activity on dedicated GPU workers:
+//
+//	ctx = workflow.WithActivityTaskListRoute(ctx, InferenceActivity, "gpu-workers")
+//	err := workflow.ExecuteActivity(ctx, InferenceActivity, input).Get(ctx, &result)
+//
+// Routes accumulate across calls and are kept when activity options are replaced. An empty taskList routes the
+// activity to the workflow's own task list. Activities executed within an open session always run on the
+// session's task list.
+func WithActivityTaskListRoute(ctx Context, activity interface{}, taskList string) Context {
+	return internal.WithActivityTaskListRoute(ctx, activity, taskList)
+}
+
 // GetActivityTaskList returns tasklist in the Context's current ActivityOptions,
 // or workflow.GetInfo(ctx).TaskListName if not set or empty
 func GetActivityTaskList(ctx Context) string {
@@ -21,6 +21,8 @@
 package workflow
 
 import (
+	"time"
+
 	"go.uber.org/cadence/internal"
 )
 
@@ -69,10 +71,24 @@ func WithValue(parent Context, key interface{}, val interface{}) Context {
 //	err := workflow.ExecuteActivity(ctx, ActivityFoo).Get(ctx, &activityFooResult)
 //	if err != nil && cadence.IsCanceledError(ctx.Err()) {
 //	  // activity failed, and workflow context is canceled
-//	  disconnectedCtx, _ := workflow.newDisconnectedContext(ctx);
+//	  disconnectedCtx, _ := workflow.NewDisconnectedContext(ctx)
 //	  workflow.ExecuteActivity(disconnectedCtx, handleCancellationActivity).Get(disconnectedCtx, nil)
 //	  return err // workflow return CanceledError
 //	}
 func NewDisconnectedContext(parent Context) (ctx Context, cancel CancelFunc) {
 	return internal.NewDisconnectedContext(parent)
 }
+
+// WithTimeout returns a copy of parent which is canceled when timeout elapses, when the returned cancel function is
+// called, or when the parent context is canceled, whichever happens first. The timeout is tracked by a durable workflow
+// timer, and once it elapses the Err method of the returned context returns ErrDeadlineExceeded.
+//
+// Canceling this context cancels its timer, so code should call cancel as soon as the operations running in this
+// Context complete:
+//
+//	ctx, cancel := workflow.WithTimeout(ctx, 10*time.Minute)
+//	defer cancel() // cancels the timer if the activity completes before the timeout
+//	err := workflow.ExecuteActivity(ctx, SlowActivity).Get(ctx, nil)
+func WithTimeout(parent Context, timeout time.Duration) (ctx Context, cancel CancelFunc) {
+	return internal.WithTimeout(parent, timeout)
+}
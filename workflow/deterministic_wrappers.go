@@ -47,6 +47,14 @@ type (
 	// WaitGroup is used to wait for a collection of
 	// coroutines to finish
 	WaitGroup = internal.WaitGroup
+
+	// Mutex is used to coordinate the coroutines started with workflow.Go.
+	// Use workflow.NewMutex(ctx) method to create a Mutex instance.
+	Mutex = internal.Mutex
+
+	// Semaphore is used to limit the concurrency of the coroutines started with workflow.Go.
+	// Use workflow.NewSemaphore(ctx, size) method to create a Semaphore instance.
+	Semaphore = internal.Semaphore
 )
 
 // Await blocks the calling thread until condition() returns true.
@@ -62,6 +70,19 @@ func Await(ctx Context, condition func() bool) error {
 	return internal.Await(ctx, condition)
 }
 
+// AwaitWithTimeout blocks the calling thread until condition() returns true or the timeout expires.
+// Do not mutate values or trigger side effects inside condition.
+// Returns ok set to false if the timeout expired before the condition became true.
+// Returns CanceledError if the ctx is canceled.
+// The following code is going to block until the captured count variable is set to 5, or for up to a minute.
+//
+//	ok, err := workflow.AwaitWithTimeout(ctx, time.Minute, func() bool {
+//	  return count == 5
+//	})
+func AwaitWithTimeout(ctx Context, timeout time.Duration, condition func() bool) (ok bool, err error) {
+	return internal.AwaitWithTimeout(ctx, timeout, condition)
+}
+
 // NewChannel create new Channel instance
 func NewChannel(ctx Context) Channel {
 	return internal.NewChannel(ctx)
@@ -95,11 +116,27 @@ func NewNamedSelector(ctx Context, name string) Selector {
 	return internal.NewNamedSelector(ctx, name)
 }
 
+// NewFairSelector creates a new Selector instance which checks its cases in a round-robin order, starting with the
+// case added after the one invoked by the previous Select call. It is deterministic like any other Selector.
+func NewFairSelector(ctx Context) Selector {
+	return internal.NewFairSelector(ctx)
+}
+
 // NewWaitGroup creates a new WaitGroup instance.
 func NewWaitGroup(ctx Context) WaitGroup {
 	return internal.NewWaitGroup(ctx)
 }
 
+// NewMutex creates a new Mutex instance.
+func NewMutex(ctx Context) Mutex {
+	return internal.NewMutex(ctx)
+}
+
+// NewSemaphore creates a new Semaphore instance with size permits.
+func NewSemaphore(ctx Context, size int64) Semaphore {
+	return internal.NewSemaphore(ctx, size)
+}
+
 // Go creates a new coroutine. It has similar semantic to goroutine in a context of the workflow.
 func Go(ctx Context, f func(ctx Context)) {
 	internal.Go(ctx, f)
@@ -165,3 +202,24 @@ func NewTimer(ctx Context, d time.Duration) Future {
 func Sleep(ctx Context, d time.Duration) (err error) {
 	return internal.Sleep(ctx, d)
 }
+
+// SleepUntil pauses the current workflow until the workflow clock reaches t. A t which is not after workflow.Now(ctx)
+// causes SleepUntil to return immediately. Like Sleep, it returns *CanceledError if the ctx is canceled.
+func SleepUntil(ctx Context, t time.Time) (err error) {
+	return internal.SleepUntil(ctx, t)
+}
+
+// NextCronTime returns the first time after workflow.Now(ctx) matching the cron schedule, in UTC like the cron
+// schedules of workflows, whatever the time zone of the host. It is deterministic as it is computed from the
+// workflow clock, so it can be used with SleepUntil to run calendar based logic within a workflow:
+//
+//	next, err := workflow.NextCronTime(ctx, "0 9 * * MON-FRI")
+//	if err != nil {
+//		return err
+//	}
+//	err = workflow.SleepUntil(ctx, next)
+//
+// The schedule uses the same format as the CronSchedule of workflows, returns an error if it is invalid.
+func NextCronTime(ctx Context, cronSchedule string) (time.Time, error) {
+	return internal.NextCronTime(ctx, cronSchedule)
+}
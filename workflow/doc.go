@@ -226,6 +226,35 @@ And actions like this will be completely unaffected:
     nor stop new ones from being started)
   - workflow.GetVersion, workflow.GetLogger, workflow.GetMetricsScope, workflow.Now, many others
 
+# Cancellation scopes
+
+Contexts derived with workflow.WithCancel form cancellation scopes: canceling one cancels every activity, timer and
+child workflow started with it or with a context derived from it, without affecting the rest of the workflow.
+For example, to race two providers and cancel the one which lost:
+
+	scope, cancel := workflow.WithCancel(ctx)
+	defer cancel()
+	var result string
+	var err error
+	selector := workflow.NewSelector(ctx)
+	for _, provider := range []string{"providerA", "providerB"} {
+		selector.AddFuture(workflow.ExecuteActivity(scope, QuoteActivity, provider), func(f workflow.Future) {
+			err = f.Get(ctx, &result)
+		})
+	}
+	selector.Select(ctx)
+	cancel() // cancels the activity which is still running
+
+Conversely, workflow.NewDisconnectedContext creates a scope which is not canceled with its parent, to run cleanup
+activities after the workflow itself was canceled:
+
+	err := workflow.ExecuteActivity(ctx, ActivityFoo).Get(ctx, nil)
+	if err != nil && cadence.IsCanceledError(ctx.Err()) {
+		disconnectedCtx, _ := workflow.NewDisconnectedContext(ctx)
+		_ = workflow.ExecuteActivity(disconnectedCtx, CleanupActivity).Get(disconnectedCtx, nil)
+		return err
+	}
+
 # Execute Activity
 
 The primary responsibility of the workflow implementation is to schedule activities for execution. The most
@@ -36,7 +36,8 @@ Below are the possible errors that activity or child workflow could return:
 2) *workflow.GenericError:
 	If activity or child workflow implementation returns errors other than from NewCustomError() API,
     workflow code would receive *GenericError.
-	Use err.Error() to get the string representation of the actual error.
+	Use err.Error() to get the string representation of the actual error. If FeatureFlags.WrappedErrorEncodingEnabled
+    is set, wrapped errors (e.g. fmt.Errorf("...: %w", err)) are preserved, so errors.As can find a wrapped cadence error.
 3) *workflow.CanceledError:
 	If activity or child workflow was canceled, workflow code will receive instance of *CanceledError.
     When activity or child workflow finishes cleanup it can indicate it by returning error created through
@@ -85,9 +86,14 @@ if err != nil {
 
 type (
 	// GenericError is returned from activity or child workflow when an implementations return error
-	// other than from workflow.NewCustomError() API.
+	// other than from workflow.NewCustomError() API. It unwraps to the errors wrapped by the returned error, if any
+	// and FeatureFlags.WrappedErrorEncodingEnabled is set.
 	GenericError = internal.GenericError
 
+	// NonRetryableError is returned from activity or child workflow when an implementation returns an error
+	// wrapped by cadence.NewNonRetryableError(), which is not retried by the RetryPolicy.
+	NonRetryableError = internal.NonRetryableError
+
 	// TimeoutError returned when activity or child workflow timed out.
 	TimeoutError = internal.TimeoutError
 
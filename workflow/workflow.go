@@ -217,7 +217,11 @@ func GetUnhandledSignalNames(ctx Context) []string {
 	return internal.GetUnhandledSignalNames(ctx)
 }
 
-// GetMetricsScope returns a metrics scope to be used in workflow's context
+// GetMetricsScope returns a metrics scope to be used in workflow's context. It is the worker's metrics scope tagged
+// with the workflow type. Metrics emitted through it, and through any scope derived from it, are dropped while the
+// workflow is replaying history, so business events are counted once rather than on every replay:
+//
+//	workflow.GetMetricsScope(ctx).Counter("orders-placed").Inc(1)
 func GetMetricsScope(ctx Context) tally.Scope {
 	return internal.GetMetricsScope(ctx)
 }
@@ -328,6 +332,18 @@ func MutableSideEffect(ctx Context, id string, f func(ctx Context) interface{},
 	return internal.MutableSideEffect(ctx, id, f, equals)
 }
 
+// RecordMarker records a marker with the given name and details into the workflow history, for example to make
+// business events visible to auditing tools that read the history:
+//
+//	workflow.RecordMarker(ctx, "LoanApproved", approval)
+//
+// It is recorded on the next decision and acts as a no-op during replay. Like any other decision, adding or removing
+// RecordMarker calls in a running workflow is a non-deterministic change that requires workflow.GetVersion.
+// The names used by SideEffect, MutableSideEffect, GetVersion and local activities are reserved.
+func RecordMarker(ctx Context, name string, details ...interface{}) error {
+	return internal.RecordMarker(ctx, name, details...)
+}
+
 // DefaultVersion is a version returned by GetVersion for code that wasn't versioned before
 const DefaultVersion Version = internal.DefaultVersion
 
@@ -512,3 +528,19 @@ func GetLastCompletionResult(ctx Context, d ...interface{}) error {
 func UpsertSearchAttributes(ctx Context, attributes map[string]interface{}) error {
 	return internal.UpsertSearchAttributes(ctx, attributes)
 }
+
+// UpsertMemo merges the given fields into the memo the workflow sees in GetInfo(ctx).Memo, for example to keep
+// human-readable progress information as a long running workflow advances:
+//
+//	workflow.UpsertMemo(ctx, map[string]interface{}{
+//		"Stage": "shipping",
+//	})
+//
+// The memo stored by the server is not updated. Cadence server has no decision to change the memo of a running
+// workflow, so DescribeWorkflowExecution, visibility queries and the UI keep showing the memo the run started with.
+// The change is recorded as an "UpsertMemo" marker in the history, so that it survives replay, and the merged memo
+// is passed to the next run when the workflow continues as new, which is when the server sees it.
+// Memo values are encoded with the workflow's data converter.
+func UpsertMemo(ctx Context, memo map[string]interface{}) error {
+	return internal.UpsertMemo(ctx, memo)
+}
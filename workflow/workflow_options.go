@@ -67,6 +67,12 @@ func WithWorkflowTaskStartToCloseTimeout(ctx Context, d time.Duration) Context {
 	return internal.WithWorkflowTaskStartToCloseTimeout(ctx, d)
 }
 
+// WithWorkflowRetryPolicy adds a retry policy for child workflows to the context.
+// Use WithRetryPolicy to set the retry policy of activities.
+func WithWorkflowRetryPolicy(ctx Context, retryPolicy RetryPolicy) Context {
+	return internal.WithWorkflowRetryPolicy(ctx, retryPolicy)
+}
+
 // WithDataConverter adds DataConverter to the context.
 func WithDataConverter(ctx Context, dc encoded.DataConverter) Context {
 	return internal.WithDataConverter(ctx, dc)